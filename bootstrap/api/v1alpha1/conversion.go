@@ -58,6 +58,15 @@ func (src *RKE2Config) ConvertTo(dstRaw conversion.Hub) error {
 
 	// ConfigMap does not exist in v1alpha1, and Secret has a changed struct type so it needs to be restored manually
 	dst.Spec.Files = restored.Spec.Files
+	dst.Spec.RegistrationTokenMode = restored.Spec.RegistrationTokenMode
+	dst.Spec.AgentConfig.IgnitionProfile = restored.Spec.AgentConfig.IgnitionProfile
+	dst.Spec.AgentConfig.KubeletConfiguration = restored.Spec.AgentConfig.KubeletConfiguration
+	dst.Spec.BootstrapData = restored.Spec.BootstrapData
+	dst.Spec.TemplateContent = restored.Spec.TemplateContent
+
+	dst.Status.LastDataSecretRefresh = restored.Status.LastDataSecretRefresh
+	dst.Status.RegistrationTokenExpiration = restored.Status.RegistrationTokenExpiration
+	dst.Status.RegistrationTokenID = restored.Status.RegistrationTokenID
 
 	return nil
 }
@@ -124,6 +133,12 @@ func (src *RKE2ConfigTemplate) ConvertTo(dstRaw conversion.Hub) error {
 
 	// ConfigMap does not exist in v1alpha1, and Secret has a changed struct type so it needs to be restored manually
 	dst.Spec.Template.Spec.Files = restored.Spec.Template.Spec.Files
+	dst.Spec.Template.Spec.RegistrationTokenMode = restored.Spec.Template.Spec.RegistrationTokenMode
+	dst.Spec.Template.Spec.AgentConfig.IgnitionProfile = restored.Spec.Template.Spec.AgentConfig.IgnitionProfile
+	dst.Spec.Template.Spec.AgentConfig.KubeletConfiguration = restored.Spec.Template.Spec.AgentConfig.KubeletConfiguration
+	dst.Spec.Template.Spec.BootstrapData = restored.Spec.Template.Spec.BootstrapData
+	dst.Spec.Template.Spec.TemplateContent = restored.Spec.Template.Spec.TemplateContent
+
 	return nil
 }
 
@@ -165,7 +180,7 @@ func Convert_v1alpha1_RKE2AgentConfig_To_v1beta1_RKE2AgentConfig(in *RKE2AgentCo
 }
 
 func Convert_v1beta1_RKE2AgentConfig_To_v1alpha1_RKE2AgentConfig(in *bootstrapv1.RKE2AgentConfig, out *RKE2AgentConfig, s apiconversion.Scope) error {
-	// We have to invoke conversion manually because of the added AirGappedChecksum field.
+	// We have to invoke conversion manually because of the added AirGappedChecksum, IgnitionProfile and KubeletConfiguration fields.
 	return autoConvert_v1beta1_RKE2AgentConfig_To_v1alpha1_RKE2AgentConfig(in, out, s)
 }
 
@@ -184,10 +199,15 @@ func Convert_v1beta1_RKE2ConfigSpec_To_v1alpha1_RKE2ConfigSpec(in *bootstrapv1.R
 		return err
 	}
 
-	// GzipUserData does not exist in v1alpha1, so it's intentionally ignored
+	// GzipUserData, TemplateContent, RegistrationTokenMode and BootstrapData do not exist in v1alpha1, so they are intentionally ignored
 	return nil
 }
 
+func Convert_v1beta1_RKE2ConfigStatus_To_v1alpha1_RKE2ConfigStatus(in *bootstrapv1.RKE2ConfigStatus, out *RKE2ConfigStatus, s apiconversion.Scope) error {
+	// We have to invoke conversion manually because of the added MachinePool and registration token fields.
+	return autoConvert_v1beta1_RKE2ConfigStatus_To_v1alpha1_RKE2ConfigStatus(in, out, s)
+}
+
 func Convert_v1beta1_FileSource_To_v1alpha1_FileSource(in *bootstrapv1.FileSource, out *FileSource, s apiconversion.Scope) error {
 	if err := autoConvert_v1beta1_FileSource_To_v1alpha1_FileSource(in, out, s); err != nil {
 		return err
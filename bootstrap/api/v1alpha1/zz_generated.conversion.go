@@ -112,11 +112,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1beta1.RKE2ConfigStatus)(nil), (*RKE2ConfigStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1beta1_RKE2ConfigStatus_To_v1alpha1_RKE2ConfigStatus(a.(*v1beta1.RKE2ConfigStatus), b.(*RKE2ConfigStatus), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*RKE2ConfigTemplate)(nil), (*v1beta1.RKE2ConfigTemplate)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_RKE2ConfigTemplate_To_v1beta1_RKE2ConfigTemplate(a.(*RKE2ConfigTemplate), b.(*v1beta1.RKE2ConfigTemplate), scope)
 	}); err != nil {
@@ -217,6 +212,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1beta1.RKE2ConfigStatus)(nil), (*RKE2ConfigStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_RKE2ConfigStatus_To_v1alpha1_RKE2ConfigStatus(a.(*v1beta1.RKE2ConfigStatus), b.(*RKE2ConfigStatus), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -418,6 +418,7 @@ func autoConvert_v1beta1_RKE2AgentConfig_To_v1alpha1_RKE2AgentConfig(in *v1beta1
 	out.EnableContainerdSElinux = in.EnableContainerdSElinux
 	out.KubeletPath = in.KubeletPath
 	out.Kubelet = (*ComponentConfig)(unsafe.Pointer(in.Kubelet))
+	// WARNING: in.KubeletConfiguration requires manual conversion: does not exist in peer-type
 	out.KubeProxy = (*ComponentConfig)(unsafe.Pointer(in.KubeProxy))
 	out.RuntimeImage = in.RuntimeImage
 	out.LoadBalancerPort = in.LoadBalancerPort
@@ -427,6 +428,7 @@ func autoConvert_v1beta1_RKE2AgentConfig_To_v1alpha1_RKE2AgentConfig(in *v1beta1
 	if err := Convert_v1beta1_AdditionalUserData_To_v1alpha1_AdditionalUserData(&in.AdditionalUserData, &out.AdditionalUserData, s); err != nil {
 		return err
 	}
+	// WARNING: in.IgnitionProfile requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -548,6 +550,9 @@ func autoConvert_v1beta1_RKE2ConfigSpec_To_v1alpha1_RKE2ConfigSpec(in *v1beta1.R
 		return err
 	}
 	// WARNING: in.GzipUserData requires manual conversion: does not exist in peer-type
+	// WARNING: in.TemplateContent requires manual conversion: does not exist in peer-type
+	// WARNING: in.RegistrationTokenMode requires manual conversion: does not exist in peer-type
+	// WARNING: in.BootstrapData requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -573,14 +578,12 @@ func autoConvert_v1beta1_RKE2ConfigStatus_To_v1alpha1_RKE2ConfigStatus(in *v1bet
 	out.FailureMessage = in.FailureMessage
 	out.ObservedGeneration = in.ObservedGeneration
 	out.Conditions = *(*apiv1beta1.Conditions)(unsafe.Pointer(&in.Conditions))
+	// WARNING: in.LastDataSecretRefresh requires manual conversion: does not exist in peer-type
+	// WARNING: in.RegistrationTokenExpiration requires manual conversion: does not exist in peer-type
+	// WARNING: in.RegistrationTokenID requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_v1beta1_RKE2ConfigStatus_To_v1alpha1_RKE2ConfigStatus is an autogenerated conversion function.
-func Convert_v1beta1_RKE2ConfigStatus_To_v1alpha1_RKE2ConfigStatus(in *v1beta1.RKE2ConfigStatus, out *RKE2ConfigStatus, s conversion.Scope) error {
-	return autoConvert_v1beta1_RKE2ConfigStatus_To_v1alpha1_RKE2ConfigStatus(in, out, s)
-}
-
 func autoConvert_v1alpha1_RKE2ConfigTemplate_To_v1beta1_RKE2ConfigTemplate(in *RKE2ConfigTemplate, out *v1beta1.RKE2ConfigTemplate, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_RKE2ConfigTemplateSpec_To_v1beta1_RKE2ConfigTemplateSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -51,3 +51,13 @@ const (
 	// and user intervention is required to get them fixed.
 	CertificatesGenerationFailedReason string = "CertificateGenerationFailed"
 )
+
+const (
+	// UserDataSizeWithinLimitCondition documents whether the size of the user data is within the limit
+	// accepted by the infrastructure provider.
+	UserDataSizeWithinLimitCondition clusterv1.ConditionType = "UserDataSizeWithinLimit"
+
+	// UserDataSizeExceedsLimitReason (Severity=Warning) documents the user data exceeding the size limit
+	// accepted by the infrastructure provider, in which case the bootstrap data should be fetched by the nodes instead.
+	UserDataSizeExceedsLimitReason string = "UserDataSizeExceedsLimit"
+)
@@ -60,8 +60,65 @@ type RKE2ConfigSpec struct {
 	// GzipUserData specifies if the user data should be gzipped.
 	//+optional
 	GzipUserData *bool `json:"gzipUserData,omitempty"`
+
+	// TemplateContent specifies if the contents of Files, PreRKE2Commands and PostRKE2Commands should be rendered
+	// as Go templates with the variables of the Cluster and Machine being bootstrapped, e.g. "{{ .ClusterName }}".
+	//+optional
+	TemplateContent *bool `json:"templateContent,omitempty"`
+
+	// RegistrationTokenMode is the mode used to issue the token agent nodes register into the cluster with.
+	// "cluster" (the default) uses the token shared by all the nodes of the cluster, while "machine" creates
+	// a short-lived bootstrap token for each machine, revoked once its node has joined the cluster.
+	// It is only supported by agent nodes.
+	//+kubebuilder:validation:Enum=cluster;machine
+	//+optional
+	RegistrationTokenMode RegistrationTokenMode `json:"registrationTokenMode,omitempty"`
+
+	// BootstrapData configures how the bootstrap data is delivered to the nodes.
+	//+optional
+	BootstrapData BootstrapData `json:"bootstrapData,omitempty"`
+}
+
+// BootstrapDataMode is the mode used to deliver the bootstrap data to the nodes.
+type BootstrapDataMode string
+
+const (
+	// InlineBootstrapDataMode makes the user data contain the whole bootstrap data.
+	InlineBootstrapDataMode BootstrapDataMode = "inline"
+
+	// FetchBootstrapDataMode makes the user data only contain a stub fetching the bootstrap data
+	// from the bootstrap data server of the bootstrap provider, and verifying its SHA-256 checksum.
+	FetchBootstrapDataMode BootstrapDataMode = "fetch"
+)
+
+// BootstrapData configures how the bootstrap data is delivered to the nodes.
+type BootstrapData struct {
+	// Mode is the mode used to deliver the bootstrap data to the nodes. "inline" (the default) makes the user
+	// data contain the whole bootstrap data, while "fetch" makes it only contain a stub fetching the bootstrap
+	// data from the bootstrap data server, which must be enabled in the bootstrap provider and reachable from the nodes.
+	//+kubebuilder:validation:Enum=inline;fetch
+	//+optional
+	Mode BootstrapDataMode `json:"mode,omitempty"`
+
+	// SizeLimit is the maximum size in bytes of the user data accepted by the infrastructure provider,
+	// which the size of the user data is reported against. Defaults to 16384, the limit of AWS.
+	//+kubebuilder:validation:Minimum=1024
+	//+optional
+	SizeLimit *int32 `json:"sizeLimit,omitempty"`
 }
 
+// RegistrationTokenMode is the mode used to issue the token agent nodes register into the cluster with.
+type RegistrationTokenMode string
+
+const (
+	// ClusterRegistrationTokenMode makes agent nodes register with the token shared by all the nodes of the cluster.
+	ClusterRegistrationTokenMode RegistrationTokenMode = "cluster"
+
+	// MachineRegistrationTokenMode makes each agent node register with its own short-lived bootstrap token,
+	// revoked once the node has joined the cluster.
+	MachineRegistrationTokenMode RegistrationTokenMode = "machine"
+)
+
 // RKE2AgentConfig describes some attributes that are common to agent and server nodes.
 type RKE2AgentConfig struct {
 	// DataDir Folder to hold state.
@@ -139,9 +196,16 @@ type RKE2AgentConfig struct {
 	KubeletPath string `json:"kubeletPath,omitempty"`
 
 	// KubeletArgs Customized flag for kubelet process.
+	// Its extra arguments take precedence over the kubelet configuration, and remain available as an escape hatch
+	// for the settings KubeletConfiguration does not cover.
 	//+optional
 	Kubelet *ComponentConfig `json:"kubelet,omitempty"`
 
+	// KubeletConfiguration is a typed subset of the kubelet configuration, rendered into a kubelet configuration file
+	// passed with the kubelet `config` argument.
+	//+optional
+	KubeletConfiguration *KubeletConfiguration `json:"kubeletConfiguration,omitempty"`
+
 	// KubeProxyArgs Customized flag for kube-proxy process.
 	//+optional
 	KubeProxy *ComponentConfig `json:"kubeProxy,omitempty"`
@@ -173,12 +237,49 @@ type RKE2AgentConfig struct {
 	// generated cloud-init/ignition script.
 	//+optional
 	AdditionalUserData AdditionalUserData `json:"additionalUserData,omitempty"`
+
+	// IgnitionProfile specifies the base storage and systemd layout of the generated Ignition configuration,
+	// which depends on the OS of the nodes. It is only used with the ignition format, and defaults to slmicro.
+	//+optional
+	IgnitionProfile *IgnitionProfile `json:"ignitionProfile,omitempty"`
+}
+
+// IgnitionProfileName is the name of a base layout of the generated Ignition configuration.
+// +kubebuilder:validation:Enum=slmicro;fcos;flatcar;custom
+type IgnitionProfileName string
+
+const (
+	// SLMicroIgnitionProfile is the layout of SUSE Linux Micro, mounting the /opt btrfs subvolume
+	// and configuring sshd.
+	SLMicroIgnitionProfile IgnitionProfileName = "slmicro"
+
+	// FCOSIgnitionProfile is the layout of Fedora CoreOS, which needs no additional storage or systemd configuration.
+	FCOSIgnitionProfile IgnitionProfileName = "fcos"
+
+	// FlatcarIgnitionProfile is the layout of Flatcar Container Linux, configuring sshd without mounting any
+	// additional storage, and not managing SELinux policies.
+	FlatcarIgnitionProfile IgnitionProfileName = "flatcar"
+
+	// CustomIgnitionProfile is a layout provided as a Butane config in a ConfigMap.
+	CustomIgnitionProfile IgnitionProfileName = "custom"
+)
+
+// IgnitionProfile is the base layout of the generated Ignition configuration.
+type IgnitionProfile struct {
+	// Name is the name of the profile.
+	Name IgnitionProfileName `json:"name"`
+
+	// ConfigMap is a reference to the ConfigMap containing the Butane config of a custom profile,
+	// under the "profile.yaml" key. It is required by, and only allowed for, the custom profile.
+	//+optional
+	ConfigMap *corev1.ObjectReference `json:"configMap,omitempty"`
 }
 
 // AdditionalUserData is a field that allows users to specify additional cloud-init configuration .
 // +kubebuilder:validation:XValidation:rule="!has(self.data) || !has(self.config)", message="Only config or data could be populated at once"
 type AdditionalUserData struct {
 	// In case of using ignition, the data format is documented here: https://kinvolk.io/docs/flatcar-container-linux/latest/provisioning/cl-config/
+	// The Butane config is translated according to the variant and version it declares, as fcos 1.4.0 if it declares none.
 	// NOTE: All fields of the UserData that are managed by the RKE2Config controller will be ignored, this include "write_files", "runcmd", "ntp".
 	// +optional
 	Config string `json:"config,omitempty"`
@@ -228,6 +329,21 @@ type RKE2ConfigStatus struct {
 	// Conditions defines current service state of the RKE2Config.
 	//+optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+
+	// LastDataSecretRefresh is the last time the bootstrap data of a MachinePool was regenerated
+	// with a new registration token.
+	//+optional
+	LastDataSecretRefresh *metav1.Time `json:"lastDataSecretRefresh,omitempty"`
+
+	// RegistrationTokenExpiration is the time the registration token included in the bootstrap data expires,
+	// if it expires.
+	//+optional
+	RegistrationTokenExpiration *metav1.Time `json:"registrationTokenExpiration,omitempty"`
+
+	// RegistrationTokenID is the ID of the bootstrap token created for the machine to register with,
+	// until it is revoked once the node has joined the cluster.
+	//+optional
+	RegistrationTokenID string `json:"registrationTokenID,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -396,6 +512,95 @@ type TLSConfig struct {
 	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
 }
 
+// TopologyManagerPolicy is the policy of the kubelet topology manager.
+// +kubebuilder:validation:Enum=none;best-effort;restricted;single-numa-node
+type TopologyManagerPolicy string
+
+// TopologyManagerScope is the scope of the kubelet topology manager.
+// +kubebuilder:validation:Enum=container;pod
+type TopologyManagerScope string
+
+// CPUManagerPolicy is the policy of the kubelet CPU manager.
+// +kubebuilder:validation:Enum=none;static
+type CPUManagerPolicy string
+
+const (
+	// StaticCPUManagerPolicy grants exclusive CPUs to the containers of Guaranteed pods requesting integer CPUs.
+	StaticCPUManagerPolicy CPUManagerPolicy = "static"
+)
+
+// KubeletConfiguration is a subset of the kubelet.config.k8s.io/v1beta1 KubeletConfiguration.
+// The fields have the same names and semantics as in the kubelet configuration.
+type KubeletConfiguration struct {
+	// MaxPods is the maximum number of pods running on the node.
+	// +kubebuilder:validation:Minimum=1
+	//+optional
+	MaxPods *int32 `json:"maxPods,omitempty"`
+
+	// EvictionHard is a map of signal names to quantities or percentages defining the hard eviction thresholds,
+	// e.g. {"memory.available": "300Mi", "nodefs.available": "10%"}.
+	//+optional
+	EvictionHard map[string]string `json:"evictionHard,omitempty"`
+
+	// EvictionSoft is a map of signal names to quantities or percentages defining the soft eviction thresholds.
+	// Each signal requires a grace period in EvictionSoftGracePeriod.
+	//+optional
+	EvictionSoft map[string]string `json:"evictionSoft,omitempty"`
+
+	// EvictionSoftGracePeriod is a map of signal names to durations defining the grace period of the soft eviction
+	// thresholds, e.g. {"memory.available": "1m30s"}.
+	//+optional
+	EvictionSoftGracePeriod map[string]string `json:"evictionSoftGracePeriod,omitempty"`
+
+	// SystemReserved is a map of resource names to quantities reserved for the system daemons,
+	// e.g. {"cpu": "500m", "memory": "1Gi"}.
+	//+optional
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+
+	// KubeReserved is a map of resource names to quantities reserved for the Kubernetes components.
+	//+optional
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+
+	// ImageGCHighThresholdPercent is the percent of disk usage after which image garbage collection is always run.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	//+optional
+	ImageGCHighThresholdPercent *int32 `json:"imageGCHighThresholdPercent,omitempty"`
+
+	// ImageGCLowThresholdPercent is the percent of disk usage before which image garbage collection is never run.
+	// It must be lower than ImageGCHighThresholdPercent.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	//+optional
+	ImageGCLowThresholdPercent *int32 `json:"imageGCLowThresholdPercent,omitempty"`
+
+	// ImageMinimumGCAge is the minimum age of an unused image before it is garbage collected.
+	//+optional
+	ImageMinimumGCAge *metav1.Duration `json:"imageMinimumGCAge,omitempty"`
+
+	// TopologyManagerPolicy is the policy of the topology manager.
+	//+optional
+	TopologyManagerPolicy TopologyManagerPolicy `json:"topologyManagerPolicy,omitempty"`
+
+	// TopologyManagerScope is the scope of the topology manager.
+	//+optional
+	TopologyManagerScope TopologyManagerScope `json:"topologyManagerScope,omitempty"`
+
+	// CPUManagerPolicy is the policy of the CPU manager. The static policy requires reserved CPUs,
+	// set with SystemReserved or KubeReserved.
+	//+optional
+	CPUManagerPolicy CPUManagerPolicy `json:"cpuManagerPolicy,omitempty"`
+
+	// ShutdownGracePeriod is the total duration the node delays its shutdown by, to terminate its pods.
+	//+optional
+	ShutdownGracePeriod *metav1.Duration `json:"shutdownGracePeriod,omitempty"`
+
+	// ShutdownGracePeriodCriticalPods is the part of ShutdownGracePeriod used to terminate the critical pods.
+	// It must not be longer than ShutdownGracePeriod.
+	//+optional
+	ShutdownGracePeriodCriticalPods *metav1.Duration `json:"shutdownGracePeriodCriticalPods,omitempty"`
+}
+
 // ComponentConfig defines the configuration for a Kubernetes Component.
 type ComponentConfig struct {
 	// ExtraEnv is a map of environment variables to pass on to a Kubernetes Component command.
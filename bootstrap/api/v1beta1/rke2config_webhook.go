@@ -19,12 +19,22 @@ package v1beta1
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
+	"github.com/coreos/butane/config"
 	"github.com/coreos/butane/config/common"
 	fcos "github.com/coreos/butane/config/fcos/v1_4"
+	"github.com/coreos/vcontext/path"
+	"github.com/coreos/vcontext/report"
+	"gopkg.in/yaml.v3"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -158,6 +168,137 @@ func (s *RKE2ConfigSpec) validate(pathPrefix *field.Path) field.ErrorList {
 
 	allErrs = append(allErrs, s.validateIgnition(pathPrefix)...)
 	allErrs = append(allErrs, s.validateRegistries(pathPrefix)...)
+	allErrs = append(allErrs, s.validateTemplateContent(pathPrefix)...)
+	allErrs = append(allErrs, s.validateKubeletConfiguration(pathPrefix)...)
+
+	return allErrs
+}
+
+func (s *RKE2ConfigSpec) validateTemplateContent(pathPrefix *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if s.TemplateContent == nil || !*s.TemplateContent {
+		return nil
+	}
+
+	validate := func(fldPath *field.Path, content string) {
+		if _, err := template.New(fldPath.String()).Parse(content); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath, content, fmt.Sprintf("error parsing template: %v", err)))
+		}
+	}
+
+	for i, file := range s.Files {
+		validate(pathPrefix.Child("files").Index(i).Child("content"), file.Content)
+	}
+
+	for i, command := range s.PreRKE2Commands {
+		validate(pathPrefix.Child("preRKE2Commands").Index(i), command)
+	}
+
+	for i, command := range s.PostRKE2Commands {
+		validate(pathPrefix.Child("postRKE2Commands").Index(i), command)
+	}
+
+	return allErrs
+}
+
+// evictionSignals are the eviction signals supported by the kubelet.
+var evictionSignals = sets.New(
+	"memory.available", "allocatableMemory.available", "pid.available",
+	"nodefs.available", "nodefs.inodesFree", "imagefs.available", "imagefs.inodesFree",
+	"containerfs.available", "containerfs.inodesFree",
+)
+
+// reservedResources are the resources that can be reserved for the system daemons and Kubernetes components,
+// besides the hugepages ones.
+var reservedResources = sets.New("cpu", "memory", "ephemeral-storage", "pid")
+
+func (s *RKE2ConfigSpec) validateKubeletConfiguration(pathPrefix *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	kubelet := s.AgentConfig.KubeletConfiguration
+	if kubelet == nil {
+		return nil
+	}
+
+	kubeletPath := pathPrefix.Child("agentConfig", "kubeletConfiguration")
+
+	validateThresholds := func(fldPath *field.Path, thresholds map[string]string) {
+		for signal, threshold := range thresholds {
+			if !evictionSignals.Has(signal) {
+				allErrs = append(allErrs, field.NotSupported(fldPath, signal, sets.List(evictionSignals)))
+
+				continue
+			}
+
+			if percentage, ok := strings.CutSuffix(threshold, "%"); ok {
+				if value, err := strconv.ParseFloat(percentage, 64); err != nil || value <= 0 || value > 100 {
+					allErrs = append(allErrs, field.Invalid(fldPath.Key(signal), threshold, "must be a percentage between 0 and 100"))
+				}
+			} else if _, err := resource.ParseQuantity(threshold); err != nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Key(signal), threshold, "must be a quantity or a percentage"))
+			}
+		}
+	}
+
+	validateThresholds(kubeletPath.Child("evictionHard"), kubelet.EvictionHard)
+	validateThresholds(kubeletPath.Child("evictionSoft"), kubelet.EvictionSoft)
+
+	for signal, gracePeriod := range kubelet.EvictionSoftGracePeriod {
+		fldPath := kubeletPath.Child("evictionSoftGracePeriod")
+
+		if _, ok := kubelet.EvictionSoft[signal]; !ok {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(signal), gracePeriod, "must match a threshold in evictionSoft"))
+		} else if _, err := time.ParseDuration(gracePeriod); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(signal), gracePeriod, "must be a duration"))
+		}
+	}
+
+	for signal := range kubelet.EvictionSoft {
+		if _, ok := kubelet.EvictionSoftGracePeriod[signal]; !ok {
+			allErrs = append(allErrs, field.Required(kubeletPath.Child("evictionSoftGracePeriod").Key(signal),
+				"is required by the soft eviction threshold"))
+		}
+	}
+
+	validateReserved := func(fldPath *field.Path, reserved map[string]string) {
+		for name, quantity := range reserved {
+			if !reservedResources.Has(name) && !strings.HasPrefix(name, "hugepages-") {
+				allErrs = append(allErrs, field.NotSupported(fldPath, name, sets.List(reservedResources)))
+			} else if _, err := resource.ParseQuantity(quantity); err != nil {
+				allErrs = append(allErrs, field.Invalid(fldPath.Key(name), quantity, "must be a quantity"))
+			}
+		}
+	}
+
+	validateReserved(kubeletPath.Child("systemReserved"), kubelet.SystemReserved)
+	validateReserved(kubeletPath.Child("kubeReserved"), kubelet.KubeReserved)
+
+	if kubelet.ImageGCLowThresholdPercent != nil && kubelet.ImageGCHighThresholdPercent != nil &&
+		*kubelet.ImageGCLowThresholdPercent >= *kubelet.ImageGCHighThresholdPercent {
+		allErrs = append(allErrs, field.Invalid(kubeletPath.Child("imageGCLowThresholdPercent"),
+			*kubelet.ImageGCLowThresholdPercent, "must be lower than imageGCHighThresholdPercent"))
+	}
+
+	if kubelet.CPUManagerPolicy == StaticCPUManagerPolicy && kubelet.SystemReserved["cpu"] == "" && kubelet.KubeReserved["cpu"] == "" {
+		allErrs = append(allErrs, field.Invalid(kubeletPath.Child("cpuManagerPolicy"), kubelet.CPUManagerPolicy,
+			"the static policy requires CPUs to be reserved in systemReserved or kubeReserved"))
+	}
+
+	if critical := kubelet.ShutdownGracePeriodCriticalPods; critical != nil &&
+		(kubelet.ShutdownGracePeriod == nil || critical.Duration > kubelet.ShutdownGracePeriod.Duration) {
+		allErrs = append(allErrs, field.Invalid(kubeletPath.Child("shutdownGracePeriodCriticalPods"), critical.Duration.String(),
+			"must not be longer than shutdownGracePeriod"))
+	}
+
+	if s.AgentConfig.Kubelet != nil {
+		for i, arg := range s.AgentConfig.Kubelet.ExtraArgs {
+			if flag, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "="); flag == "config" {
+				allErrs = append(allErrs, field.Forbidden(pathPrefix.Child("agentConfig", "kubelet", "extraArgs").Index(i),
+					"can not be set with kubeletConfiguration"))
+			}
+		}
+	}
 
 	return allErrs
 }
@@ -184,7 +325,7 @@ func (s *RKE2ConfigSpec) validateIgnition(pathPrefix *field.Path) field.ErrorLis
 	var allErrs field.ErrorList
 
 	if s.AgentConfig.Format == Ignition {
-		_, reports, _ := fcos.ToIgn3_3Bytes([]byte(s.AgentConfig.AdditionalUserData.Config), common.TranslateBytesOptions{})
+		reports := validateButane([]byte(s.AgentConfig.AdditionalUserData.Config))
 		if (len(reports.Entries) > 0 && s.AgentConfig.AdditionalUserData.Strict) || reports.IsFatal() {
 			allErrs = append(
 				allErrs,
@@ -196,6 +337,16 @@ func (s *RKE2ConfigSpec) validateIgnition(pathPrefix *field.Path) field.ErrorLis
 			)
 		}
 
+		if profile := s.AgentConfig.IgnitionProfile; profile != nil {
+			profilePath := pathPrefix.Child("agentConfig", "ignitionProfile", "configMap")
+
+			if profile.Name == CustomIgnitionProfile && profile.ConfigMap == nil {
+				allErrs = append(allErrs, field.Required(profilePath, "is required by the custom profile"))
+			} else if profile.Name != CustomIgnitionProfile && profile.ConfigMap != nil {
+				allErrs = append(allErrs, field.Forbidden(profilePath, "is only allowed for the custom profile"))
+			}
+		}
+
 		for i, file := range s.Files {
 			if file.Encoding == Gzip || file.Encoding == GzipBase64 {
 				allErrs = append(
@@ -207,7 +358,36 @@ func (s *RKE2ConfigSpec) validateIgnition(pathPrefix *field.Path) field.ErrorLis
 				)
 			}
 		}
+	} else if s.AgentConfig.IgnitionProfile != nil {
+		allErrs = append(
+			allErrs,
+			field.Forbidden(
+				pathPrefix.Child("agentConfig", "ignitionProfile"),
+				"can only be used with the ignition format",
+			),
+		)
 	}
 
 	return allErrs
 }
+
+// validateButane translates a Butane config to Ignition as the variant and version it declares, and returns
+// the translation report. Configs not declaring both are translated as fcos 1.4.0 ones.
+func validateButane(data []byte) report.Report {
+	header := struct {
+		Variant string `yaml:"variant"`
+		Version string `yaml:"version"`
+	}{}
+	if err := yaml.Unmarshal(data, &header); err != nil || header.Variant == "" || header.Version == "" {
+		_, reports, _ := fcos.ToIgn3_3Bytes(data, common.TranslateBytesOptions{})
+
+		return reports
+	}
+
+	_, reports, err := config.TranslateBytes(data, common.TranslateBytesOptions{Raw: true})
+	if err != nil && !reports.IsFatal() {
+		reports.AddOnError(path.New("yaml"), err)
+	}
+
+	return reports
+}
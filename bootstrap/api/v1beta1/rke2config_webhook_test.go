@@ -19,9 +19,12 @@ package v1beta1
 import (
 	"context"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 )
 
 func TestRKE2Config_ValidateCreate(t *testing.T) {
@@ -67,6 +70,150 @@ func TestRKE2Config_ValidateCreate(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "ignition profile without ignition format",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					IgnitionProfile: &IgnitionProfile{Name: FCOSIgnitionProfile},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "ignition profile",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					Format:          Ignition,
+					IgnitionProfile: &IgnitionProfile{Name: FlatcarIgnitionProfile},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "custom ignition profile without ConfigMap",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					Format:          Ignition,
+					IgnitionProfile: &IgnitionProfile{Name: CustomIgnitionProfile},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "custom ignition profile",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					Format: Ignition,
+					IgnitionProfile: &IgnitionProfile{
+						Name:      CustomIgnitionProfile,
+						ConfigMap: &v1.ObjectReference{Name: "profile"},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "invalid template content",
+			spec: &RKE2ConfigSpec{
+				TemplateContent:  ptr.To(true),
+				PreRKE2Commands:  []string{"echo {{ .ClusterName }}"},
+				PostRKE2Commands: []string{"echo {{ .ClusterName"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "template content",
+			spec: &RKE2ConfigSpec{
+				TemplateContent: ptr.To(true),
+				Files:           []File{{Path: "/etc/cluster", Content: "{{ .ClusterName }}"}},
+				PreRKE2Commands: []string{"echo {{ .MachineName }}"},
+			},
+			expectErr: false,
+		},
+		{
+			name: "invalid template content without templating",
+			spec: &RKE2ConfigSpec{
+				PostRKE2Commands: []string{"echo {{ .ClusterName"},
+			},
+			expectErr: false,
+		},
+		{
+			name: "kubelet configuration",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					KubeletConfiguration: &KubeletConfiguration{
+						MaxPods:                         ptr.To(int32(250)),
+						EvictionHard:                    map[string]string{"memory.available": "300Mi", "nodefs.available": "10%"},
+						EvictionSoft:                    map[string]string{"memory.available": "500Mi"},
+						EvictionSoftGracePeriod:         map[string]string{"memory.available": "1m30s"},
+						SystemReserved:                  map[string]string{"cpu": "500m", "memory": "1Gi"},
+						ImageGCHighThresholdPercent:     ptr.To(int32(85)),
+						ImageGCLowThresholdPercent:      ptr.To(int32(80)),
+						CPUManagerPolicy:                StaticCPUManagerPolicy,
+						ShutdownGracePeriod:             &metav1.Duration{Duration: time.Minute},
+						ShutdownGracePeriodCriticalPods: &metav1.Duration{Duration: 10 * time.Second},
+					},
+					Kubelet: &ComponentConfig{ExtraArgs: []string{"v=2"}},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			name: "kubelet configuration with invalid eviction threshold",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					KubeletConfiguration: &KubeletConfiguration{
+						EvictionHard: map[string]string{"memory.avail": "300Mi"},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "kubelet configuration with soft eviction threshold without grace period",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					KubeletConfiguration: &KubeletConfiguration{
+						EvictionSoft: map[string]string{"memory.available": "500Mi"},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "kubelet configuration with invalid image garbage collection thresholds",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					KubeletConfiguration: &KubeletConfiguration{
+						ImageGCHighThresholdPercent: ptr.To(int32(80)),
+						ImageGCLowThresholdPercent:  ptr.To(int32(85)),
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "kubelet configuration with static CPU manager policy without reserved CPUs",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					KubeletConfiguration: &KubeletConfiguration{
+						CPUManagerPolicy: StaticCPUManagerPolicy,
+						KubeReserved:     map[string]string{"memory": "1Gi"},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "kubelet configuration with config argument",
+			spec: &RKE2ConfigSpec{
+				AgentConfig: RKE2AgentConfig{
+					KubeletConfiguration: &KubeletConfiguration{MaxPods: ptr.To(int32(250))},
+					Kubelet:              &ComponentConfig{ExtraArgs: []string{"config=/etc/kubelet.yaml"}},
+				},
+			},
+			expectErr: true,
+		},
 	}
 
 	validator := RKE2ConfigCustomValidator{}
@@ -22,6 +22,7 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	apiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
@@ -48,6 +49,26 @@ func (in *AdditionalUserData) DeepCopy() *AdditionalUserData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BootstrapData) DeepCopyInto(out *BootstrapData) {
+	*out = *in
+	if in.SizeLimit != nil {
+		in, out := &in.SizeLimit, &out.SizeLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapData.
+func (in *BootstrapData) DeepCopy() *BootstrapData {
+	if in == nil {
+		return nil
+	}
+	out := new(BootstrapData)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComponentConfig) DeepCopyInto(out *ComponentConfig) {
 	*out = *in
@@ -142,6 +163,106 @@ func (in *FileSourceRef) DeepCopy() *FileSourceRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionProfile) DeepCopyInto(out *IgnitionProfile) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionProfile.
+func (in *IgnitionProfile) DeepCopy() *IgnitionProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletConfiguration) DeepCopyInto(out *KubeletConfiguration) {
+	*out = *in
+	if in.MaxPods != nil {
+		in, out := &in.MaxPods, &out.MaxPods
+		*out = new(int32)
+		**out = **in
+	}
+	if in.EvictionHard != nil {
+		in, out := &in.EvictionHard, &out.EvictionHard
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EvictionSoft != nil {
+		in, out := &in.EvictionSoft, &out.EvictionSoft
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EvictionSoftGracePeriod != nil {
+		in, out := &in.EvictionSoftGracePeriod, &out.EvictionSoftGracePeriod
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SystemReserved != nil {
+		in, out := &in.SystemReserved, &out.SystemReserved
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KubeReserved != nil {
+		in, out := &in.KubeReserved, &out.KubeReserved
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ImageGCHighThresholdPercent != nil {
+		in, out := &in.ImageGCHighThresholdPercent, &out.ImageGCHighThresholdPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ImageGCLowThresholdPercent != nil {
+		in, out := &in.ImageGCLowThresholdPercent, &out.ImageGCLowThresholdPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ImageMinimumGCAge != nil {
+		in, out := &in.ImageMinimumGCAge, &out.ImageMinimumGCAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ShutdownGracePeriod != nil {
+		in, out := &in.ShutdownGracePeriod, &out.ShutdownGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.ShutdownGracePeriodCriticalPods != nil {
+		in, out := &in.ShutdownGracePeriodCriticalPods, &out.ShutdownGracePeriodCriticalPods
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletConfiguration.
+func (in *KubeletConfiguration) DeepCopy() *KubeletConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Mirror) DeepCopyInto(out *Mirror) {
 	*out = *in
@@ -234,12 +355,22 @@ func (in *RKE2AgentConfig) DeepCopyInto(out *RKE2AgentConfig) {
 		*out = new(ComponentConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.KubeletConfiguration != nil {
+		in, out := &in.KubeletConfiguration, &out.KubeletConfiguration
+		*out = new(KubeletConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.KubeProxy != nil {
 		in, out := &in.KubeProxy, &out.KubeProxy
 		*out = new(ComponentConfig)
 		(*in).DeepCopyInto(*out)
 	}
 	in.AdditionalUserData.DeepCopyInto(&out.AdditionalUserData)
+	if in.IgnitionProfile != nil {
+		in, out := &in.IgnitionProfile, &out.IgnitionProfile
+		*out = new(IgnitionProfile)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RKE2AgentConfig.
@@ -338,6 +469,12 @@ func (in *RKE2ConfigSpec) DeepCopyInto(out *RKE2ConfigSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.TemplateContent != nil {
+		in, out := &in.TemplateContent, &out.TemplateContent
+		*out = new(bool)
+		**out = **in
+	}
+	in.BootstrapData.DeepCopyInto(&out.BootstrapData)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RKE2ConfigSpec.
@@ -365,6 +502,14 @@ func (in *RKE2ConfigStatus) DeepCopyInto(out *RKE2ConfigStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastDataSecretRefresh != nil {
+		in, out := &in.LastDataSecretRefresh, &out.LastDataSecretRefresh
+		*out = (*in).DeepCopy()
+	}
+	if in.RegistrationTokenExpiration != nil {
+		in, out := &in.RegistrationTokenExpiration, &out.RegistrationTokenExpiration
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RKE2ConfigStatus.
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	// fetchedUserDataPath is the path the fetched bootstrap data is written to on the node.
+	fetchedUserDataPath = "/etc/cluster-api/bootstrap-data.txt"
+
+	// The boothook runs before the user data is processed, fetches the bootstrap data and verifies its checksum,
+	// so that the include part can then hand it to cloud-init. A failed verification leaves no file to include,
+	// failing the bootstrap instead of running a tampered payload.
+	fetchUserDataTemplate = `Content-Type: multipart/mixed; boundary="MIMEBOUNDARY"
+MIME-Version: 1.0
+
+--MIMEBOUNDARY
+Content-Type: text/cloud-boothook; charset="us-ascii"
+MIME-Version: 1.0
+
+#!/bin/sh
+set -eu
+umask 077
+mkdir -p "$(dirname {{ .Path }})"
+if echo "{{ .Checksum }}  {{ .Path }}" | sha256sum -c - >/dev/null 2>&1; then
+  exit 0
+fi
+{{- if .CACert }}
+cat > {{ .Path }}.ca.crt <<'CACERT'
+{{ .CACert }}
+CACERT
+{{- end }}
+for i in $(seq 1 30); do
+  if curl -fsSL --retry 3{{ if .CACert }} --cacert {{ .Path }}.ca.crt{{ end }} -H "Authorization: Bearer {{ .Token }}" -o {{ .Path }}.tmp '{{ .URL }}'; then
+    break
+  fi
+  sleep 10
+done
+if ! echo "{{ .Checksum }}  {{ .Path }}.tmp" | sha256sum -c -; then
+  rm -f {{ .Path }}.tmp
+  echo "checksum verification of the bootstrap data failed" >&2
+  exit 1
+fi
+mv {{ .Path }}.tmp {{ .Path }}
+
+--MIMEBOUNDARY
+Content-Type: text/x-include-once-url; charset="us-ascii"
+MIME-Version: 1.0
+
+file://{{ .Path }}
+
+--MIMEBOUNDARY--
+`
+)
+
+// NewFetchUserData returns user data fetching the bootstrap data served at the given URL with the given bearer token,
+// verifying it against the given SHA-256 checksum and handing it to cloud-init. If not empty, caCert is the PEM
+// encoded CA certificate the server certificate is verified against.
+func NewFetchUserData(url, token string, checksum []byte, caCert []byte) ([]byte, error) {
+	return generate("FetchUserData", fetchUserDataTemplate, struct {
+		Path     string
+		URL      string
+		Token    string
+		Checksum string
+		CACert   string
+	}{
+		Path:     fetchedUserDataPath,
+		URL:      url,
+		Token:    token,
+		Checksum: fmt.Sprintf("%x", checksum),
+		CACert:   string(bytes.TrimSpace(caCert)),
+	})
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FetchUserData", func() {
+	checksum := sha256.Sum256([]byte("data"))
+
+	It("fetches and verifies the bootstrap data before including it", func() {
+		data, err := NewFetchUserData("https://capi.example.com/bootstrap-data/default/config", "token", checksum[:], nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		userData := string(data)
+		Expect(userData).To(HavePrefix(`Content-Type: multipart/mixed; boundary="MIMEBOUNDARY"`))
+		Expect(userData).To(ContainSubstring(`-H "Authorization: Bearer token" -o /etc/cluster-api/bootstrap-data.txt.tmp 'https://capi.example.com/bootstrap-data/default/config'`))
+		Expect(userData).To(ContainSubstring(fmt.Sprintf(`echo "%x  /etc/cluster-api/bootstrap-data.txt.tmp" | sha256sum -c -`, checksum)))
+		Expect(userData).To(ContainSubstring("Content-Type: text/x-include-once-url"))
+		Expect(userData).To(ContainSubstring("file:///etc/cluster-api/bootstrap-data.txt\n"))
+		Expect(userData).ToNot(ContainSubstring("--cacert"))
+	})
+
+	It("verifies the server against the CA certificate", func() {
+		data, err := NewFetchUserData("https://capi.example.com", "token", checksum[:], []byte("-----BEGIN CERTIFICATE-----\n"))
+		Expect(err).ToNot(HaveOccurred())
+
+		userData := string(data)
+		Expect(userData).To(ContainSubstring("cat > /etc/cluster-api/bootstrap-data.txt.ca.crt <<'CACERT'\n-----BEGIN CERTIFICATE-----\nCACERT\n"))
+		Expect(userData).To(ContainSubstring("--cacert /etc/cluster-api/bootstrap-data.txt.ca.crt"))
+	})
+})
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/dataserver"
+)
+
+func TestStoreBootstrapData(t *testing.T) {
+	newScope := func(bootstrapData bootstrapv1.BootstrapData) *Scope {
+		return &Scope{
+			Config: &bootstrapv1.RKE2Config{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"},
+				Spec: bootstrapv1.RKE2ConfigSpec{
+					AgentConfig:   bootstrapv1.RKE2AgentConfig{Format: bootstrapv1.CloudConfig},
+					BootstrapData: bootstrapData,
+				},
+			},
+			Cluster: &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster"}},
+		}
+	}
+
+	t.Run("inlines the bootstrap data by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().Build()
+		r := &RKE2ConfigReconciler{Client: c}
+		scope := newScope(bootstrapv1.BootstrapData{})
+
+		g.Expect(r.storeBootstrapData(context.Background(), scope, []byte("data"))).To(Succeed())
+
+		secret := &corev1.Secret{}
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "config"}, secret)).To(Succeed())
+		g.Expect(secret.Data).To(HaveKeyWithValue("value", []byte("data")))
+		g.Expect(conditions.IsTrue(scope.Config, bootstrapv1.UserDataSizeWithinLimitCondition)).To(BeTrue())
+	})
+
+	t.Run("reports user data exceeding the size limit", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r := &RKE2ConfigReconciler{Client: fake.NewClientBuilder().Build()}
+		scope := newScope(bootstrapv1.BootstrapData{SizeLimit: ptr.To[int32](1024)})
+
+		g.Expect(r.storeBootstrapData(context.Background(), scope, []byte(strings.Repeat("a", 1025)))).To(Succeed())
+		g.Expect(conditions.IsFalse(scope.Config, bootstrapv1.UserDataSizeWithinLimitCondition)).To(BeTrue())
+		g.Expect(conditions.GetReason(scope.Config, bootstrapv1.UserDataSizeWithinLimitCondition)).
+			To(Equal(bootstrapv1.UserDataSizeExceedsLimitReason))
+	})
+
+	t.Run("stores the bootstrap data to be fetched", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().Build()
+		r := &RKE2ConfigReconciler{Client: c, BootstrapDataServerURL: "https://capi.example.com/"}
+		scope := newScope(bootstrapv1.BootstrapData{Mode: bootstrapv1.FetchBootstrapDataMode})
+
+		data := []byte(strings.Repeat("a", int(DefaultUserDataSizeLimit)))
+		g.Expect(r.storeBootstrapData(context.Background(), scope, data)).To(Succeed())
+
+		fetched := &corev1.Secret{}
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "config-bootstrap-data"}, fetched)).To(Succeed())
+		g.Expect(fetched.Labels).To(HaveKey(dataserver.SecretLabel))
+		g.Expect(fetched.Data).To(HaveKeyWithValue(dataserver.DataKey, data))
+		g.Expect(fetched.Data).To(HaveKey(dataserver.TokenKey))
+
+		token := fetched.Data[dataserver.TokenKey]
+
+		secret := &corev1.Secret{}
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "config"}, secret)).To(Succeed())
+
+		userData := string(secret.Data["value"])
+		g.Expect(userData).To(ContainSubstring("https://capi.example.com/bootstrap-data/default/config"))
+		g.Expect(userData).To(ContainSubstring("Bearer " + string(token)))
+		g.Expect(userData).To(ContainSubstring(fmt.Sprintf("%x", sha256.Sum256(data))))
+		g.Expect(conditions.IsTrue(scope.Config, bootstrapv1.UserDataSizeWithinLimitCondition)).To(BeTrue())
+
+		// The token is kept when the bootstrap data is updated.
+		g.Expect(r.storeBootstrapData(context.Background(), scope, []byte("updated"))).To(Succeed())
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "config-bootstrap-data"}, fetched)).To(Succeed())
+		g.Expect(fetched.Data).To(HaveKeyWithValue(dataserver.DataKey, []byte("updated")))
+		g.Expect(fetched.Data).To(HaveKeyWithValue(dataserver.TokenKey, token))
+	})
+
+	t.Run("fails to store the bootstrap data to be fetched without the server", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r := &RKE2ConfigReconciler{Client: fake.NewClientBuilder().Build()}
+		scope := newScope(bootstrapv1.BootstrapData{Mode: bootstrapv1.FetchBootstrapDataMode})
+
+		g.Expect(r.storeBootstrapData(context.Background(), scope, []byte("data"))).ToNot(Succeed())
+		g.Expect(conditions.IsFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition)).To(BeTrue())
+		g.Expect(scope.Config.Status.Ready).To(BeFalse())
+	})
+}
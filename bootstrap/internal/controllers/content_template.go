@@ -0,0 +1,147 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+)
+
+// contentTemplateVariables are the variables the templated Files, PreRKE2Commands and PostRKE2Commands are rendered with.
+type contentTemplateVariables struct {
+	// ClusterName is the name of the Cluster.
+	ClusterName string
+	// ClusterNamespace is the namespace of the Cluster.
+	ClusterNamespace string
+	// MachineName is the name of the Machine, or of the MachinePool.
+	MachineName string
+	// FailureDomain is the failure domain of the Machine, if any.
+	FailureDomain string
+	// ControlPlaneEndpoint is the control plane endpoint of the Cluster.
+	ControlPlaneEndpoint clusterv1.APIEndpoint
+	// RKE2Version is the RKE2 version being installed.
+	RKE2Version string
+	// NodeIPs are the IP addresses of the Machine, when already known.
+	NodeIPs []string
+}
+
+// newContentTemplateVariables returns the variables of the Cluster and Machine being bootstrapped.
+func newContentTemplateVariables(scope *Scope) contentTemplateVariables {
+	vars := contentTemplateVariables{
+		ClusterName:          scope.Cluster.Name,
+		ClusterNamespace:     scope.Cluster.Namespace,
+		ControlPlaneEndpoint: scope.Cluster.Spec.ControlPlaneEndpoint,
+		RKE2Version:          scope.GetDesiredVersion(),
+	}
+
+	switch {
+	case scope.Machine != nil:
+		vars.MachineName = scope.Machine.Name
+		vars.FailureDomain = ptr.Deref(scope.Machine.Spec.FailureDomain, "")
+
+		for _, address := range scope.Machine.Status.Addresses {
+			if address.Type == clusterv1.MachineInternalIP || address.Type == clusterv1.MachineExternalIP {
+				vars.NodeIPs = append(vars.NodeIPs, address.Address)
+			}
+		}
+	case scope.MachinePool != nil:
+		vars.MachineName = scope.MachinePool.Name
+	}
+
+	return vars
+}
+
+func templateContentEnabled(scope *Scope) bool {
+	return scope.Config.Spec.TemplateContent != nil && *scope.Config.Spec.TemplateContent
+}
+
+// renderFileTemplates renders the contents of the given files as Go templates if the RKE2Config enables it.
+func renderFileTemplates(scope *Scope, files []bootstrapv1.File) ([]bootstrapv1.File, error) {
+	if !templateContentEnabled(scope) {
+		return files, nil
+	}
+
+	vars := newContentTemplateVariables(scope)
+	rendered := make([]bootstrapv1.File, 0, len(files))
+
+	for _, file := range files {
+		content, err := renderContentTemplate("file "+file.Path, file.Content, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		file.Content = content
+		rendered = append(rendered, file)
+	}
+
+	return rendered, nil
+}
+
+// renderCommandTemplates returns the PreRKE2Commands and PostRKE2Commands of the RKE2Config,
+// rendered as Go templates if it enables it.
+func renderCommandTemplates(scope *Scope) (preRKE2Commands []string, postRKE2Commands []string, err error) {
+	if !templateContentEnabled(scope) {
+		return scope.Config.Spec.PreRKE2Commands, scope.Config.Spec.PostRKE2Commands, nil
+	}
+
+	vars := newContentTemplateVariables(scope)
+
+	render := func(field string, commands []string) ([]string, error) {
+		rendered := make([]string, 0, len(commands))
+
+		for i, command := range commands {
+			content, err := renderContentTemplate(fmt.Sprintf("%s[%d]", field, i), command, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			rendered = append(rendered, content)
+		}
+
+		return rendered, nil
+	}
+
+	if preRKE2Commands, err = render("preRKE2Commands", scope.Config.Spec.PreRKE2Commands); err != nil {
+		return nil, nil, err
+	}
+
+	if postRKE2Commands, err = render("postRKE2Commands", scope.Config.Spec.PostRKE2Commands); err != nil {
+		return nil, nil, err
+	}
+
+	return preRKE2Commands, postRKE2Commands, nil
+}
+
+func renderContentTemplate(name, content string, vars contentTemplateVariables) (string, error) {
+	tpl, err := template.New(name).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse template of %s: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tpl.Execute(&out, vars); err != nil {
+		return "", fmt.Errorf("unable to render template of %s: %w", name, err)
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+)
+
+func TestRenderContentTemplates(t *testing.T) {
+	newScope := func(templateContent *bool) *Scope {
+		return &Scope{
+			Config: &bootstrapv1.RKE2Config{
+				Spec: bootstrapv1.RKE2ConfigSpec{
+					TemplateContent:  templateContent,
+					PreRKE2Commands:  []string{"echo {{ .ClusterNamespace }}/{{ .ClusterName }}"},
+					PostRKE2Commands: []string{"echo {{ .MachineName }} {{ .FailureDomain }} {{ range .NodeIPs }}{{ . }} {{ end }}"},
+				},
+			},
+			Cluster: &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster"},
+				Spec: clusterv1.ClusterSpec{
+					ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: "cluster.example.com", Port: 6443},
+				},
+			},
+			Machine: &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine"},
+				Spec: clusterv1.MachineSpec{
+					Version:       ptr.To("v1.31.5+rke2r1"),
+					FailureDomain: ptr.To("zone-a"),
+				},
+				Status: clusterv1.MachineStatus{
+					Addresses: clusterv1.MachineAddresses{
+						{Type: clusterv1.MachineInternalIP, Address: "10.0.0.1"},
+						{Type: clusterv1.MachineHostName, Address: "machine"},
+						{Type: clusterv1.MachineExternalIP, Address: "192.0.2.1"},
+					},
+				},
+			},
+		}
+	}
+
+	files := []bootstrapv1.File{
+		{Path: "/etc/endpoint", Content: "{{ .ControlPlaneEndpoint.Host }}:{{ .ControlPlaneEndpoint.Port }} {{ .RKE2Version }}"},
+	}
+
+	t.Run("renders the content when enabled", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope(ptr.To(true))
+
+		rendered, err := renderFileTemplates(scope, files)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(rendered).To(HaveLen(1))
+		g.Expect(rendered[0].Path).To(Equal("/etc/endpoint"))
+		g.Expect(rendered[0].Content).To(Equal("cluster.example.com:6443 v1.31.5+rke2r1"))
+
+		pre, post, err := renderCommandTemplates(scope)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(pre).To(Equal([]string{"echo default/cluster"}))
+		g.Expect(post).To(Equal([]string{"echo machine zone-a 10.0.0.1 192.0.2.1 "}))
+
+		// The spec itself is left untouched.
+		g.Expect(files[0].Content).To(HavePrefix("{{"))
+		g.Expect(scope.Config.Spec.PreRKE2Commands[0]).To(ContainSubstring("{{"))
+	})
+
+	t.Run("leaves the content unchanged when disabled", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope(nil)
+
+		rendered, err := renderFileTemplates(scope, files)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(rendered).To(Equal(files))
+
+		pre, post, err := renderCommandTemplates(scope)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(pre).To(Equal(scope.Config.Spec.PreRKE2Commands))
+		g.Expect(post).To(Equal(scope.Config.Spec.PostRKE2Commands))
+	})
+
+	t.Run("fails on unknown variables", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope(ptr.To(true))
+		scope.Config.Spec.PreRKE2Commands = []string{"echo {{ .Unknown }}"}
+
+		_, _, err := renderCommandTemplates(scope)
+		g.Expect(err).To(MatchError(ContainSubstring("preRKE2Commands[0]")))
+	})
+}
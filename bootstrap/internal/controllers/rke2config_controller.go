@@ -20,7 +20,11 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -41,6 +45,7 @@ import (
 	kubeyaml "sigs.k8s.io/yaml"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/clustercache"
 	clusterexpv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	"sigs.k8s.io/cluster-api/feature"
 	"sigs.k8s.io/cluster-api/util"
@@ -50,21 +55,26 @@ import (
 
 	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/cloudinit"
+	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/dataserver"
 	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/ignition"
 	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/ignition/butane"
+	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/metrics"
 	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/consts"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/locking"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/secret"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
 	bsutil "github.com/rancher/cluster-api-provider-rke2/pkg/util"
 )
 
 const (
 	filePermissions  string = "0640"
 	registrationPort int    = 9345
-	serverURLFormat  string = "https://%v:%v"
 	tokenPrefix      string = "-token"
+
+	// ignitionProfileKey is the key of the Butane config in the ConfigMap of a custom Ignition profile.
+	ignitionProfileKey string = "profile.yaml"
 )
 
 // RKE2ConfigReconciler reconciles a Rke2Config object.
@@ -72,6 +82,18 @@ type RKE2ConfigReconciler struct {
 	RKE2InitLock RKE2InitLock
 	client.Client
 	Scheme *runtime.Scheme
+
+	// TokenTTL is the TTL of the registration tokens created in the workload cluster.
+	TokenTTL time.Duration
+
+	// BootstrapDataServerURL is the URL the nodes reach the bootstrap data server at, required by the fetch mode.
+	BootstrapDataServerURL string
+
+	// BootstrapDataServerCACert is the PEM encoded CA certificate the nodes verify the bootstrap data server against.
+	BootstrapDataServerCACert []byte
+
+	// ClusterCache provides the clients of the workload clusters the registration tokens are managed in.
+	ClusterCache clustercache.ClusterCache
 }
 
 const (
@@ -81,6 +103,9 @@ const (
 	// DefaultRequeueAfter is the default requeue time.
 	DefaultRequeueAfter time.Duration = 20 * time.Second
 	defaultTokenLength                = 16
+
+	// DefaultUserDataSizeLimit is the default size limit of the user data, which is the limit of AWS.
+	DefaultUserDataSizeLimit int32 = 16384
 )
 
 //+kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=rke2configs;rke2configs/status;rke2configs/finalizers,verbs=get;list;watch;create;update;patch;delete
@@ -91,6 +116,10 @@ const (
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 func (r *RKE2ConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, rerr error) {
+	ctx, span := tracing.Start(ctx, "RKE2Config.Reconcile",
+		tracing.NamespaceKey.String(req.Namespace), tracing.ConfigKey.String(req.Name))
+	defer tracing.End(span, &rerr)
+
 	logger := log.FromContext(ctx)
 	logger.Info("Reconcile RKE2Config")
 
@@ -111,6 +140,12 @@ func (r *RKE2ConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, fmt.Errorf("initializing RKE2Config scope: %w", err)
 	}
 
+	span.SetAttributes(tracing.ClusterKey.String(scope.Cluster.Name))
+
+	if scope.HasMachineOwner() {
+		span.SetAttributes(tracing.MachineKey.String(scope.Machine.Name))
+	}
+
 	if annotations.IsPaused(scope.Cluster, scope.Config) {
 		logger.Info("Reconciliation is paused for this object")
 
@@ -141,6 +176,15 @@ func (r *RKE2ConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}()
 
+	// The workload cluster is not reachable while the cluster cache is not connected to it, wait for it to be.
+	defer func() {
+		if errors.Is(rerr, clustercache.ErrClusterNotConnected) {
+			logger.Info("Requeuing because the connection to the workload cluster is down")
+
+			res, rerr = ctrl.Result{RequeueAfter: DefaultRequeueAfter}, nil
+		}
+	}()
+
 	if !scope.Cluster.Status.InfrastructureReady {
 		logger.Info("Infrastructure machine not yet ready")
 		conditions.MarkFalse(
@@ -172,8 +216,25 @@ func (r *RKE2ConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 	// Status is ready means a config has been generated.
 	if scope.Config.Status.Ready {
-		// In any other case just return as the config is already generated and need not be generated again.
-		return ctrl.Result{}, nil
+		// The registration token of a Machine is not needed anymore once its node has joined the cluster.
+		if scope.HasMachineOwner() && scope.Machine.Status.NodeRef != nil && scope.Config.Status.RegistrationTokenID != "" {
+			if err := r.revokeRegistrationToken(ctx, scope); err != nil {
+				return ctrl.Result{}, err
+			}
+
+			scope.Logger.Info("Revoked the registration token of the Machine")
+		}
+
+		// The bootstrap data of a MachinePool is used to launch instances over its whole lifetime,
+		// it is regenerated before the registration token it contains expires.
+		if refreshIn, ok := r.machinePoolDataSecretRefreshIn(scope); ok && refreshIn > 0 {
+			return ctrl.Result{RequeueAfter: refreshIn}, nil
+		} else if !ok {
+			// In any other case just return as the config is already generated and need not be generated again.
+			return ctrl.Result{}, nil
+		}
+
+		scope.Logger.Info("Refreshing MachinePool bootstrap data with a new registration token")
 	}
 
 	// Note: can't use IsFalse here because we need to handle the absence of the condition as well as false.
@@ -332,6 +393,9 @@ func (r *RKE2ConfigReconciler) ClusterToRKE2Configs(ctx context.Context, o clien
 
 // handleClusterNotInitialized handles the first control plane node.
 func (r *RKE2ConfigReconciler) handleClusterNotInitialized(ctx context.Context, scope *Scope) (res ctrl.Result, reterr error) { //nolint:funlen
+	ctx, span := tracing.Start(ctx, "RKE2Config.handleClusterNotInitialized")
+	defer tracing.End(span, &reterr)
+
 	if !scope.HasControlPlaneOwner() {
 		scope.Logger.Info("Requeuing because this machine is not a Control Plane machine")
 
@@ -352,6 +416,8 @@ func (r *RKE2ConfigReconciler) handleClusterNotInitialized(ctx context.Context,
 		}
 	}()
 
+	start := time.Now()
+
 	certificates := secret.NewCertificatesForInitialControlPlane()
 	if _, found := scope.ControlPlane.Annotations[controlplanev1.LegacyRKE2ControlPlane]; found {
 		certificates = secret.NewCertificatesForLegacyControlPlane()
@@ -406,15 +472,16 @@ func (r *RKE2ConfigReconciler) handleClusterNotInitialized(ctx context.Context,
 
 	configStruct, configFiles, err := rke2.GenerateInitControlPlaneConfig(
 		rke2.ServerConfigOpts{
-			Cluster:              *scope.Cluster,
-			ControlPlaneEndpoint: scope.Cluster.Spec.ControlPlaneEndpoint.Host,
-			Token:                token,
-			ServerURL:            fmt.Sprintf(serverURLFormat, registrationAddress, registrationPort),
-			ServerConfig:         scope.ControlPlane.Spec.ServerConfig,
-			AgentConfig:          scope.Config.Spec.AgentConfig,
-			Ctx:                  ctx,
-			Client:               r.Client,
-			Version:              scope.GetDesiredVersion(),
+			Cluster:                        *scope.Cluster,
+			ControlPlaneEndpoint:           scope.Cluster.Spec.ControlPlaneEndpoint.Host,
+			Token:                          token,
+			ServerURL:                      serverURL(registrationAddress),
+			ServerConfig:                   scope.ControlPlane.Spec.ServerConfig,
+			AgentConfig:                    scope.Config.Spec.AgentConfig,
+			ControlPlaneEndpointManagement: scope.ControlPlane.Spec.ControlPlaneEndpointManagement,
+			Ctx:                            ctx,
+			Client:                         r.Client,
+			Version:                        scope.GetDesiredVersion(),
 		})
 	if err != nil {
 		return ctrl.Result{}, err
@@ -468,13 +535,18 @@ func (r *RKE2ConfigReconciler) handleClusterNotInitialized(ctx context.Context,
 		ntpServers = scope.Config.Spec.AgentConfig.NTP.Servers
 	}
 
+	preRKE2Commands, postRKE2Commands, err := renderCommandTemplates(scope)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	cpinput := &cloudinit.ControlPlaneInput{
 		BaseUserData: cloudinit.BaseUserData{
 			AirGapped:               scope.Config.Spec.AgentConfig.AirGapped,
 			AirGappedChecksum:       scope.Config.Spec.AgentConfig.AirGappedChecksum,
 			CISEnabled:              scope.Config.Spec.AgentConfig.CISProfile != "",
-			PreRKE2Commands:         scope.Config.Spec.PreRKE2Commands,
-			PostRKE2Commands:        scope.Config.Spec.PostRKE2Commands,
+			PreRKE2Commands:         preRKE2Commands,
+			PostRKE2Commands:        postRKE2Commands,
 			ConfigFile:              initConfigFile,
 			RKE2Version:             scope.GetDesiredVersion(),
 			WriteFiles:              files,
@@ -489,9 +561,17 @@ func (r *RKE2ConfigReconciler) handleClusterNotInitialized(ctx context.Context,
 
 	switch scope.Config.Spec.AgentConfig.Format {
 	case bootstrapv1.Ignition:
+		var profile *butane.Profile
+
+		profile, err = r.getIgnitionProfile(ctx, scope)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
 		userData, err = ignition.NewInitControlPlane(&ignition.ControlPlaneInput{
 			ControlPlaneInput:  cpinput,
 			AdditionalIgnition: &scope.Config.Spec.AgentConfig.AdditionalUserData,
+			IgnitionProfile:    profile,
 		})
 	default:
 		userData, err = cloudinit.NewInitControlPlane(cpinput)
@@ -505,6 +585,8 @@ func (r *RKE2ConfigReconciler) handleClusterNotInitialized(ctx context.Context,
 		return ctrl.Result{}, err
 	}
 
+	metrics.ObserveBootstrapDataGeneration(scope.Config.Spec.AgentConfig.Format, metrics.RoleInitControlPlane, time.Since(start))
+
 	return ctrl.Result{}, nil
 }
 
@@ -594,6 +676,11 @@ func (r *RKE2ConfigReconciler) generateFileListIncludingRegistries(
 		additionalFiles = append(additionalFiles, file)
 	}
 
+	additionalFiles, err = renderFileTemplates(scope, additionalFiles)
+	if err != nil {
+		return nil, err
+	}
+
 	files := configFiles
 	files = append(files, registryFiles...)
 	files = append(files, initRegistriesFile)
@@ -612,6 +699,11 @@ type RKE2InitLock interface {
 // joinControlPlane implements the part of the Reconciler which bootstraps a secondary
 // Control Plane machine joining a cluster that is already initialized.
 func (r *RKE2ConfigReconciler) joinControlplane(ctx context.Context, scope *Scope) (res ctrl.Result, rerr error) {
+	ctx, span := tracing.Start(ctx, "RKE2Config.joinControlplane")
+	defer tracing.End(span, &rerr)
+
+	start := time.Now()
+
 	tokenSecret := &corev1.Secret{}
 
 	secretKey := types.NamespacedName{
@@ -640,15 +732,16 @@ func (r *RKE2ConfigReconciler) joinControlplane(ctx context.Context, scope *Scop
 
 	configStruct, configFiles, err := rke2.GenerateJoinControlPlaneConfig(
 		rke2.ServerConfigOpts{
-			Cluster:              *scope.Cluster,
-			Token:                token,
-			ControlPlaneEndpoint: scope.Cluster.Spec.ControlPlaneEndpoint.Host,
-			ServerURL:            fmt.Sprintf(serverURLFormat, scope.ControlPlane.Status.AvailableServerIPs[0], registrationPort),
-			ServerConfig:         scope.ControlPlane.Spec.ServerConfig,
-			AgentConfig:          scope.Config.Spec.AgentConfig,
-			Ctx:                  ctx,
-			Client:               r.Client,
-			Version:              scope.GetDesiredVersion(),
+			Cluster:                        *scope.Cluster,
+			Token:                          token,
+			ControlPlaneEndpoint:           scope.Cluster.Spec.ControlPlaneEndpoint.Host,
+			ServerURL:                      serverURL(scope.ControlPlane.Status.AvailableServerIPs[0]),
+			ServerConfig:                   scope.ControlPlane.Spec.ServerConfig,
+			AgentConfig:                    scope.Config.Spec.AgentConfig,
+			ControlPlaneEndpointManagement: scope.ControlPlane.Spec.ControlPlaneEndpointManagement,
+			Ctx:                            ctx,
+			Client:                         r.Client,
+			Version:                        scope.GetDesiredVersion(),
 		},
 	)
 	if err != nil {
@@ -705,13 +798,18 @@ func (r *RKE2ConfigReconciler) joinControlplane(ctx context.Context, scope *Scop
 		ntpServers = scope.Config.Spec.AgentConfig.NTP.Servers
 	}
 
+	preRKE2Commands, postRKE2Commands, err := renderCommandTemplates(scope)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	cpinput := &cloudinit.ControlPlaneInput{
 		BaseUserData: cloudinit.BaseUserData{
 			AirGapped:               scope.Config.Spec.AgentConfig.AirGapped,
 			AirGappedChecksum:       scope.Config.Spec.AgentConfig.AirGappedChecksum,
 			CISEnabled:              scope.Config.Spec.AgentConfig.CISProfile != "",
-			PreRKE2Commands:         scope.Config.Spec.PreRKE2Commands,
-			PostRKE2Commands:        scope.Config.Spec.PostRKE2Commands,
+			PreRKE2Commands:         preRKE2Commands,
+			PostRKE2Commands:        postRKE2Commands,
 			ConfigFile:              initConfigFile,
 			RKE2Version:             scope.GetDesiredVersion(),
 			WriteFiles:              files,
@@ -725,9 +823,17 @@ func (r *RKE2ConfigReconciler) joinControlplane(ctx context.Context, scope *Scop
 
 	switch scope.Config.Spec.AgentConfig.Format {
 	case bootstrapv1.Ignition:
+		var profile *butane.Profile
+
+		profile, err = r.getIgnitionProfile(ctx, scope)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
 		userData, err = ignition.NewJoinControlPlane(&ignition.ControlPlaneInput{
 			ControlPlaneInput:  cpinput,
 			AdditionalIgnition: &scope.Config.Spec.AgentConfig.AdditionalUserData,
+			IgnitionProfile:    profile,
 		})
 	default:
 		userData, err = cloudinit.NewJoinControlPlane(cpinput)
@@ -741,12 +847,19 @@ func (r *RKE2ConfigReconciler) joinControlplane(ctx context.Context, scope *Scop
 		return ctrl.Result{}, err
 	}
 
+	metrics.ObserveBootstrapDataGeneration(scope.Config.Spec.AgentConfig.Format, metrics.RoleJoinControlPlane, time.Since(start))
+
 	return ctrl.Result{}, nil
 }
 
 // joinWorker implements the part of the Reconciler which bootstraps a worker node
 // after the cluster has been initialized.
 func (r *RKE2ConfigReconciler) joinWorker(ctx context.Context, scope *Scope) (res ctrl.Result, rerr error) {
+	ctx, span := tracing.Start(ctx, "RKE2Config.joinWorker")
+	defer tracing.End(span, &rerr)
+
+	start := time.Now()
+
 	tokenSecret := &corev1.Secret{}
 
 	secretKey := types.NamespacedName{
@@ -768,6 +881,35 @@ func (r *RKE2ConfigReconciler) joinWorker(ctx context.Context, scope *Scope) (re
 
 	scope.Logger.Info("RKE2 server token found in Secret!")
 
+	switch {
+	// MachinePools join with a registration token expiring after the configured TTL instead,
+	// their bootstrap data being refreshed periodically.
+	case scope.HasMachinePoolOwner():
+		registrationToken, err := r.createRegistrationToken(ctx, scope,
+			fmt.Sprintf("Registration token for MachinePool %s/%s", scope.MachinePool.Namespace, scope.MachinePool.Name))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		token = registrationToken
+		scope.Config.Status.LastDataSecretRefresh = ptr.To(metav1.Now())
+	// Machines can join with their own registration token, revoked once their node has joined the cluster.
+	case scope.Config.Spec.RegistrationTokenMode == bootstrapv1.MachineRegistrationTokenMode:
+		// A token created by a previous attempt to generate the bootstrap data is not used anymore.
+		if err := r.revokeRegistrationToken(ctx, scope); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		registrationToken, err := r.createRegistrationToken(ctx, scope,
+			fmt.Sprintf("Registration token for Machine %s/%s", scope.Machine.Namespace, scope.Machine.Name))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		token = registrationToken
+		scope.Config.Status.RegistrationTokenID, _, _ = strings.Cut(registrationToken, ".")
+	}
+
 	if len(scope.ControlPlane.Status.AvailableServerIPs) == 0 {
 		scope.Logger.V(1).Info("No ControlPlane IP Address found for node registration")
 
@@ -776,7 +918,7 @@ func (r *RKE2ConfigReconciler) joinWorker(ctx context.Context, scope *Scope) (re
 
 	configStruct, configFiles, err := rke2.GenerateWorkerConfig(
 		rke2.AgentConfigOpts{
-			ServerURL:              fmt.Sprintf(serverURLFormat, scope.ControlPlane.Status.AvailableServerIPs[0], registrationPort),
+			ServerURL:              serverURL(scope.ControlPlane.Status.AvailableServerIPs[0]),
 			Token:                  token,
 			AgentConfig:            scope.Config.Spec.AgentConfig,
 			Ctx:                    ctx,
@@ -819,12 +961,17 @@ func (r *RKE2ConfigReconciler) joinWorker(ctx context.Context, scope *Scope) (re
 		ntpServers = scope.Config.Spec.AgentConfig.NTP.Servers
 	}
 
+	preRKE2Commands, postRKE2Commands, err := renderCommandTemplates(scope)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	wkInput := &cloudinit.BaseUserData{
-		PreRKE2Commands:         scope.Config.Spec.PreRKE2Commands,
+		PreRKE2Commands:         preRKE2Commands,
 		AirGapped:               scope.Config.Spec.AgentConfig.AirGapped,
 		AirGappedChecksum:       scope.Config.Spec.AgentConfig.AirGappedChecksum,
 		CISEnabled:              scope.Config.Spec.AgentConfig.CISProfile != "",
-		PostRKE2Commands:        scope.Config.Spec.PostRKE2Commands,
+		PostRKE2Commands:        postRKE2Commands,
 		ConfigFile:              wkJoinConfigFile,
 		RKE2Version:             scope.GetDesiredVersion(),
 		WriteFiles:              files,
@@ -837,9 +984,17 @@ func (r *RKE2ConfigReconciler) joinWorker(ctx context.Context, scope *Scope) (re
 
 	switch scope.Config.Spec.AgentConfig.Format {
 	case bootstrapv1.Ignition:
+		var profile *butane.Profile
+
+		profile, err = r.getIgnitionProfile(ctx, scope)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
 		userData, err = ignition.NewJoinWorker(&ignition.JoinWorkerInput{
 			BaseUserData:       wkInput,
 			AdditionalIgnition: &scope.Config.Spec.AgentConfig.AdditionalUserData,
+			IgnitionProfile:    profile,
 		})
 	default:
 		userData, err = cloudinit.NewJoinWorker(wkInput)
@@ -853,9 +1008,137 @@ func (r *RKE2ConfigReconciler) joinWorker(ctx context.Context, scope *Scope) (re
 		return ctrl.Result{}, err
 	}
 
+	metrics.ObserveBootstrapDataGeneration(scope.Config.Spec.AgentConfig.Format, metrics.RoleWorker, time.Since(start))
+
+	if refreshIn, ok := r.machinePoolDataSecretRefreshIn(scope); ok {
+		return ctrl.Result{RequeueAfter: refreshIn}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// machinePoolDataSecretRefreshIn returns how long until the bootstrap data of a MachinePool needs to be refreshed,
+// and false if it is not refreshed, e.g. when the bootstrap data is provided by the MachinePool.
+// The bootstrap data is refreshed when half of the TTL of its registration token has elapsed, so that
+// instances launched from it always have time to join.
+func (r *RKE2ConfigReconciler) machinePoolDataSecretRefreshIn(scope *Scope) (time.Duration, bool) {
+	if !scope.HasMachinePoolOwner() || scope.Config.Status.DataSecretName == nil ||
+		*scope.Config.Status.DataSecretName != scope.Config.Name {
+		return 0, false
+	}
+
+	if scope.Config.Status.LastDataSecretRefresh == nil {
+		return 0, true
+	}
+
+	refreshIn := time.Until(scope.Config.Status.LastDataSecretRefresh.Add(r.tokenTTL() / 2)) //nolint:mnd
+	if refreshIn < 0 {
+		return 0, true
+	}
+
+	return refreshIn, true
+}
+
+// getIgnitionProfile returns the profile to render the Ignition bootstrap data with, reading the Butane config
+// of a custom profile from its ConfigMap.
+func (r *RKE2ConfigReconciler) getIgnitionProfile(ctx context.Context, scope *Scope) (*butane.Profile, error) {
+	ignitionProfile := scope.Config.Spec.AgentConfig.IgnitionProfile
+	if ignitionProfile == nil {
+		return nil, nil
+	}
+
+	profile := &butane.Profile{Name: ignitionProfile.Name}
+
+	if ignitionProfile.Name != bootstrapv1.CustomIgnitionProfile || ignitionProfile.ConfigMap == nil {
+		return profile, nil
+	}
+
+	namespace := ignitionProfile.ConfigMap.Namespace
+	if namespace == "" {
+		namespace = scope.Config.Namespace
+	}
+
+	profileConfigMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ignitionProfile.ConfigMap.Name}, profileConfigMap); err != nil {
+		return nil, fmt.Errorf("failed to get ignition profile config map: %w", err)
+	}
+
+	config, ok := profileConfigMap.Data[ignitionProfileKey]
+	if !ok {
+		return nil, fmt.Errorf("ignition profile config map is missing %s", ignitionProfileKey)
+	}
+
+	profile.Config = config
+
+	return profile, nil
+}
+
+// createRegistrationToken creates a registration token in the workload cluster and records its expiration.
+func (r *RKE2ConfigReconciler) createRegistrationToken(ctx context.Context, scope *Scope, description string) (string, error) {
+	remoteClient, err := r.getRemoteClient(ctx, scope)
+	if err != nil {
+		return "", err
+	}
+
+	token, expiration, err := createToken(ctx, remoteClient, r.tokenTTL(), description)
+	if err != nil {
+		return "", err
+	}
+
+	metrics.RecordToken(metrics.TokenRegistration, metrics.TokenCreated)
+
+	scope.Config.Status.RegistrationTokenExpiration = ptr.To(metav1.NewTime(expiration))
+
+	return token, nil
+}
+
+// revokeRegistrationToken deletes the registration token created for a machine from the workload cluster.
+func (r *RKE2ConfigReconciler) revokeRegistrationToken(ctx context.Context, scope *Scope) error {
+	if scope.Config.Status.RegistrationTokenID == "" {
+		return nil
+	}
+
+	remoteClient, err := r.getRemoteClient(ctx, scope)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteToken(ctx, remoteClient, scope.Config.Status.RegistrationTokenID); err != nil {
+		return err
+	}
+
+	metrics.RecordToken(metrics.TokenRegistration, metrics.TokenRevoked)
+
+	scope.Config.Status.RegistrationTokenID = ""
+	scope.Config.Status.RegistrationTokenExpiration = nil
+
+	return nil
+}
+
+// serverURL returns the URL of the registration endpoint of the server at the given address, which may be
+// a hostname or an IPv4 or IPv6 address.
+func serverURL(address string) string {
+	return "https://" + net.JoinHostPort(strings.Trim(address, "[]"), strconv.Itoa(registrationPort))
+}
+
+func (r *RKE2ConfigReconciler) tokenTTL() time.Duration {
+	if r.TokenTTL == 0 {
+		return DefaultTokenTTL
+	}
+
+	return r.TokenTTL
+}
+
+// getRemoteClient returns the client of the workload cluster from the cluster cache.
+func (r *RKE2ConfigReconciler) getRemoteClient(ctx context.Context, scope *Scope) (client.Client, error) {
+	remoteClient, err := r.ClusterCache.GetClient(ctx, util.ObjectKey(scope.Cluster))
+	if err != nil {
+		return nil, fmt.Errorf("getting workload cluster client: %w", err)
+	}
+
+	return tracing.WrapClient(remoteClient, "workload"), nil
+}
+
 // getRegistrationTokenFromSecretValue retrieves the registration token from an existing secret's value.
 func (r *RKE2ConfigReconciler) getRegistrationTokenFromSecretValue(ctx context.Context, name, namespace string) (string, error) {
 	tokenSecret := &corev1.Secret{}
@@ -908,12 +1191,29 @@ func (r *RKE2ConfigReconciler) generateAndStoreToken(ctx context.Context, scope
 		return "", err
 	}
 
+	metrics.RecordToken(metrics.TokenServer, metrics.TokenCreated)
+
 	return token, nil
 }
 
 // storeBootstrapData creates a new secret with the data passed in as input,
 // sets the reference in the configuration status and ready to true.
-func (r *RKE2ConfigReconciler) storeBootstrapData(ctx context.Context, scope *Scope, data []byte) error {
+func (r *RKE2ConfigReconciler) storeBootstrapData(ctx context.Context, scope *Scope, data []byte) (reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2Config.storeBootstrapData")
+	defer tracing.End(span, &reterr)
+
+	if scope.Config.Spec.BootstrapData.Mode == bootstrapv1.FetchBootstrapDataMode {
+		stub, err := r.storeFetchedBootstrapData(ctx, scope, data)
+		if err != nil {
+			conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition,
+				bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+
+			return err
+		}
+
+		data = stub
+	}
+
 	if scope.Config.Spec.GzipUserData != nil && *scope.Config.Spec.GzipUserData {
 		var buf bytes.Buffer
 		gz := gzip.NewWriter(&buf)
@@ -970,10 +1270,95 @@ func (r *RKE2ConfigReconciler) storeBootstrapData(ctx context.Context, scope *Sc
 	scope.Config.Status.Ready = true
 
 	conditions.MarkTrue(scope.Config, bootstrapv1.DataSecretAvailableCondition)
+	reconcileUserDataSize(scope, len(data))
+	metrics.ObserveBootstrapDataSize(scope.Config.Spec.AgentConfig.Format, len(data))
 
 	return nil
 }
 
+// storeFetchedBootstrapData stores the bootstrap data in a Secret served by the bootstrap data server,
+// and returns the user data fetching it.
+func (r *RKE2ConfigReconciler) storeFetchedBootstrapData(ctx context.Context, scope *Scope, data []byte) ([]byte, error) {
+	if r.BootstrapDataServerURL == "" {
+		return nil, errors.New("the fetch mode requires the bootstrap data server, which is not enabled in the bootstrap provider")
+	}
+
+	secret := &corev1.Secret{}
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: scope.Config.Namespace, Name: dataserver.SecretName(scope.Config.Name)}, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, "failed to get bootstrap data secret")
+	}
+
+	// The token is kept across updates of the bootstrap data, as it is embedded in the user data of the existing nodes.
+	token := secret.Data[dataserver.TokenKey]
+	if len(token) == 0 {
+		generated, err := bsutil.Random(defaultTokenLength)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to generate bootstrap data token")
+		}
+
+		token = []byte(generated)
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dataserver.SecretName(scope.Config.Name),
+			Namespace: scope.Config.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel: scope.Cluster.Name,
+				dataserver.SecretLabel:     "",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: scope.Config.APIVersion,
+					Kind:       scope.Config.Kind,
+					Name:       scope.Config.Name,
+					UID:        scope.Config.UID,
+					Controller: ptr.To(true),
+				},
+			},
+		},
+		Data: map[string][]byte{
+			dataserver.DataKey:  data,
+			dataserver.TokenKey: token,
+		},
+		Type: clusterv1.ClusterSecretType,
+	}
+
+	if err := r.createOrUpdateSecretFromObject(ctx, *secret, scope.Logger, "fetched bootstrap data", *scope.Config); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s%s%s/%s", strings.TrimSuffix(r.BootstrapDataServerURL, "/"),
+		dataserver.PathPrefix, scope.Config.Namespace, scope.Config.Name)
+	checksum := sha256.Sum256(data)
+
+	if scope.Config.Spec.AgentConfig.Format == bootstrapv1.Ignition {
+		return butane.FetchConfig(url, string(token), checksum[:], r.BootstrapDataServerCACert)
+	}
+
+	return cloudinit.NewFetchUserData(url, string(token), checksum[:], r.BootstrapDataServerCACert)
+}
+
+// reconcileUserDataSize reports the size of the stored user data against the size limit of the infrastructure provider.
+func reconcileUserDataSize(scope *Scope, size int) {
+	limit := DefaultUserDataSizeLimit
+	if scope.Config.Spec.BootstrapData.SizeLimit != nil {
+		limit = *scope.Config.Spec.BootstrapData.SizeLimit
+	}
+
+	if size > int(limit) {
+		conditions.MarkFalse(scope.Config, bootstrapv1.UserDataSizeWithinLimitCondition,
+			bootstrapv1.UserDataSizeExceedsLimitReason, clusterv1.ConditionSeverityWarning,
+			"User data is %d bytes, exceeding the limit of %d bytes: enable gzipUserData or set bootstrapData.mode to fetch", size, limit)
+
+		return
+	}
+
+	conditions.MarkTrue(scope.Config, bootstrapv1.UserDataSizeWithinLimitCondition)
+}
+
 // createSecretFromObject tries to create the given secret in the API, if that secret exists it will return an error.
 func (r *RKE2ConfigReconciler) createSecretFromObject(
 	ctx context.Context,
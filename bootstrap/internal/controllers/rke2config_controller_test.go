@@ -0,0 +1,60 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestServerURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		expected string
+	}{
+		{
+			name:     "hostname",
+			address:  "cp.example.com",
+			expected: "https://cp.example.com:9345",
+		},
+		{
+			name:     "IPv4 address",
+			address:  "10.0.0.1",
+			expected: "https://10.0.0.1:9345",
+		},
+		{
+			name:     "IPv6 address",
+			address:  "fd00::1",
+			expected: "https://[fd00::1]:9345",
+		},
+		{
+			name:     "bracketed IPv6 address",
+			address:  "[fd00::1]",
+			expected: "https://[fd00::1]:9345",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(serverURL(tt.address)).To(Equal(tt.expected))
+		})
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	bootstraputil "k8s.io/cluster-bootstrap/token/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultTokenTTL is the default TTL of the registration tokens created in the workload cluster.
+	DefaultTokenTTL = 24 * time.Hour
+
+	// rke2BootstrapTokenGroup is the group RKE2 expects bootstrap tokens to authenticate agents with,
+	// the same as the tokens created with `rke2 token create`.
+	rke2BootstrapTokenGroup = "system:bootstrappers:k3s:default-node-token"
+)
+
+// createToken creates a bootstrap token in the workload cluster, which RKE2 agents can join with until it expires.
+func createToken(ctx context.Context, c client.Client, ttl time.Duration, description string) (string, time.Time, error) {
+	token, err := bootstraputil.GenerateBootstrapToken()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("generating bootstrap token: %w", err)
+	}
+
+	substrs := bootstraputil.BootstrapTokenRegexp.FindStringSubmatch(token)
+	if len(substrs) != 3 { //nolint:mnd
+		return "", time.Time{}, fmt.Errorf("the bootstrap token %q was not of the form %q", token, bootstrapapi.BootstrapTokenPattern)
+	}
+
+	tokenID, tokenSecret := substrs[1], substrs[2]
+	expiration := time.Now().UTC().Add(ttl).Truncate(time.Second)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bootstraputil.BootstrapTokenSecretName(tokenID),
+			Namespace: metav1.NamespaceSystem,
+		},
+		Type: bootstrapapi.SecretTypeBootstrapToken,
+		Data: map[string][]byte{
+			bootstrapapi.BootstrapTokenIDKey:               []byte(tokenID),
+			bootstrapapi.BootstrapTokenSecretKey:           []byte(tokenSecret),
+			bootstrapapi.BootstrapTokenExpirationKey:       []byte(expiration.Format(time.RFC3339)),
+			bootstrapapi.BootstrapTokenUsageAuthentication: []byte("true"),
+			bootstrapapi.BootstrapTokenExtraGroupsKey:      []byte(rke2BootstrapTokenGroup),
+			bootstrapapi.BootstrapTokenDescriptionKey:      []byte(description),
+		},
+	}
+
+	if err := c.Create(ctx, secret); err != nil {
+		return "", time.Time{}, fmt.Errorf("creating bootstrap token secret %s: %w", secret.Name, err)
+	}
+
+	return token, expiration, nil
+}
+
+// deleteToken deletes a bootstrap token from the workload cluster, revoking it.
+func deleteToken(ctx context.Context, c client.Client, tokenID string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bootstraputil.BootstrapTokenSecretName(tokenID),
+			Namespace: metav1.NamespaceSystem,
+		},
+	}
+
+	if err := c.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting bootstrap token secret %s: %w", secret.Name, err)
+	}
+
+	return nil
+}
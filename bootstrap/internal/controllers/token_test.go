@@ -0,0 +1,157 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/clustercache"
+	clusterexpv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+)
+
+// fakeClusterCache returns the same client for every workload cluster.
+type fakeClusterCache struct {
+	clustercache.ClusterCache
+
+	client client.Client
+}
+
+func (f *fakeClusterCache) GetClient(context.Context, client.ObjectKey) (client.Client, error) {
+	return f.client, nil
+}
+
+func TestCreateToken(t *testing.T) {
+	g := NewWithT(t)
+
+	c := fake.NewClientBuilder().Build()
+
+	token, expiration, err := createToken(context.Background(), c, time.Hour, "test")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(expiration).To(BeTemporally("~", time.Now().Add(time.Hour), time.Minute))
+
+	tokenID, tokenSecret, found := strings.Cut(token, ".")
+	g.Expect(found).To(BeTrue())
+
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{
+		Namespace: metav1.NamespaceSystem,
+		Name:      bootstrapapi.BootstrapTokenSecretPrefix + tokenID,
+	}, secret)).To(Succeed())
+	g.Expect(secret.Type).To(Equal(corev1.SecretType(bootstrapapi.SecretTypeBootstrapToken)))
+	g.Expect(secret.Data).To(HaveKeyWithValue(bootstrapapi.BootstrapTokenSecretKey, []byte(tokenSecret)))
+	g.Expect(secret.Data).To(HaveKeyWithValue(bootstrapapi.BootstrapTokenExtraGroupsKey, []byte(rke2BootstrapTokenGroup)))
+	g.Expect(secret.Data).To(HaveKeyWithValue(bootstrapapi.BootstrapTokenExpirationKey, []byte(expiration.Format(time.RFC3339))))
+}
+
+func TestRevokeRegistrationToken(t *testing.T) {
+	g := NewWithT(t)
+
+	c := fake.NewClientBuilder().Build()
+	r := &RKE2ConfigReconciler{
+		ClusterCache: &fakeClusterCache{client: c},
+	}
+
+	token, expiration, err := createToken(context.Background(), c, time.Hour, "test")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	tokenID, _, _ := strings.Cut(token, ".")
+	scope := &Scope{
+		Config: &bootstrapv1.RKE2Config{
+			Status: bootstrapv1.RKE2ConfigStatus{
+				RegistrationTokenID:         tokenID,
+				RegistrationTokenExpiration: ptr.To(metav1.NewTime(expiration)),
+			},
+		},
+		Cluster: &clusterv1.Cluster{},
+	}
+
+	g.Expect(r.revokeRegistrationToken(context.Background(), scope)).To(Succeed())
+	g.Expect(scope.Config.Status.RegistrationTokenID).To(BeEmpty())
+	g.Expect(scope.Config.Status.RegistrationTokenExpiration).To(BeNil())
+
+	secrets := &corev1.SecretList{}
+	g.Expect(c.List(context.Background(), secrets)).To(Succeed())
+	g.Expect(secrets.Items).To(BeEmpty())
+
+	// Revoking a token already deleted, e.g. by the token cleaner once expired, succeeds.
+	g.Expect(deleteToken(context.Background(), c, tokenID)).To(Succeed())
+}
+
+func TestMachinePoolDataSecretRefreshIn(t *testing.T) {
+	r := &RKE2ConfigReconciler{TokenTTL: 2 * time.Hour}
+
+	newScope := func(lastRefresh *metav1.Time) *Scope {
+		return &Scope{
+			Config: &bootstrapv1.RKE2Config{
+				ObjectMeta: metav1.ObjectMeta{Name: "config"},
+				Status: bootstrapv1.RKE2ConfigStatus{
+					DataSecretName:        ptr.To("config"),
+					LastDataSecretRefresh: lastRefresh,
+				},
+			},
+			MachinePool: &clusterexpv1.MachinePool{},
+		}
+	}
+
+	t.Run("is not refreshed without MachinePool", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope(nil)
+		scope.MachinePool = nil
+
+		_, ok := r.machinePoolDataSecretRefreshIn(scope)
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("is not refreshed when provided by the MachinePool", func(t *testing.T) {
+		g := NewWithT(t)
+		scope := newScope(nil)
+		scope.Config.Status.DataSecretName = ptr.To("user-provided")
+
+		_, ok := r.machinePoolDataSecretRefreshIn(scope)
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("is refreshed after half of the TTL", func(t *testing.T) {
+		g := NewWithT(t)
+
+		refreshIn, ok := r.machinePoolDataSecretRefreshIn(newScope(ptr.To(metav1.NewTime(time.Now().Add(-30 * time.Minute)))))
+		g.Expect(ok).To(BeTrue())
+		g.Expect(refreshIn).To(BeNumerically("~", 30*time.Minute, time.Minute))
+
+		refreshIn, ok = r.machinePoolDataSecretRefreshIn(newScope(ptr.To(metav1.NewTime(time.Now().Add(-90 * time.Minute)))))
+		g.Expect(ok).To(BeTrue())
+		g.Expect(refreshIn).To(BeZero())
+
+		refreshIn, ok = r.machinePoolDataSecretRefreshIn(newScope(nil))
+		g.Expect(ok).To(BeTrue())
+		g.Expect(refreshIn).To(BeZero())
+	})
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dataserver implements the server the nodes fetch their bootstrap data from when it is not inlined
+// in their user data.
+package dataserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// PathPrefix is the path prefix the bootstrap data is served under, followed by the namespace and name of its RKE2Config.
+	PathPrefix = "/bootstrap-data/"
+
+	// DataKey is the key of the bootstrap data in its Secret.
+	DataKey = "value"
+
+	// TokenKey is the key of the token authenticating the nodes in the Secret of the bootstrap data.
+	TokenKey = "token"
+
+	// SecretLabel is the label marking the Secrets whose bootstrap data can be served.
+	SecretLabel = "bootstrap.cluster.x-k8s.io/bootstrap-data"
+
+	secretSuffix = "-bootstrap-data"
+
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+var (
+	errCertDirRequired     = errors.New("the bootstrap data server requires a certificate directory unless it is insecure")
+	errInsecureWithCertDir = errors.New("the bootstrap data server can not be insecure with a certificate directory")
+)
+
+// SecretName returns the name of the Secret holding the bootstrap data served for the given RKE2Config.
+func SecretName(configName string) string {
+	return configName + secretSuffix
+}
+
+// secretCache is a cache started by the manager on all the replicas, as they all serve the bootstrap data.
+type secretCache struct {
+	cache.Cache
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (c *secretCache) NeedLeaderElection() bool {
+	return false
+}
+
+// NewSecretCache returns a cache of the Secrets of the bootstrap data, to be added to the manager and read by the
+// server, so that the requests, authenticated or not, are served from memory instead of reaching the API server.
+func NewSecretCache(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+	labeled, err := labels.NewRequirement(SecretLabel, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.ByObject = map[client.Object]cache.ByObject{
+		&corev1.Secret{}: {Label: labels.NewSelector().Add(*labeled)},
+	}
+
+	c, err := cache.New(config, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secretCache{Cache: c}, nil
+}
+
+// Server serves the bootstrap data stored in Secrets to the nodes, authenticating them with the token stored alongside.
+type Server struct {
+	// Client is used to read the Secrets of the bootstrap data, usually the cache returned by NewSecretCache.
+	Client client.Reader
+
+	// BindAddress is the address the server binds to.
+	BindAddress string
+
+	// CertDir is the directory containing the tls.crt and tls.key files of the server. It is required unless
+	// Insecure is set.
+	CertDir string
+
+	// Insecure serves plain HTTP instead of HTTPS, sending the tokens and the bootstrap data in clear text.
+	// It is only suitable for trusted networks, and can not be set along with CertDir.
+	Insecure bool
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that all the replicas serve the bootstrap data.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable, serving the bootstrap data until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx).WithName("bootstrap-data-server")
+
+	switch {
+	case s.CertDir == "" && !s.Insecure:
+		return errCertDirRequired
+	case s.CertDir != "" && s.Insecure:
+		return errInsecureWithCertDir
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(PathPrefix, s)
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	listener, err := net.Listen("tcp", s.BindAddress)
+	if err != nil {
+		return err
+	}
+
+	if !s.Insecure {
+		watcher, err := certwatcher.New(filepath.Join(s.CertDir, "tls.crt"), filepath.Join(s.CertDir, "tls.key"))
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			if err := watcher.Start(ctx); err != nil {
+				log.Error(err, "Failed to watch the certificate of the bootstrap data server")
+			}
+		}()
+
+		listener = tls.NewListener(listener, &tls.Config{
+			GetCertificate: watcher.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		})
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error(err, "Failed to shut down the bootstrap data server")
+		}
+	}()
+
+	log.Info("Serving bootstrap data", "address", s.BindAddress, "tls", !s.Insecure)
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// ServeHTTP serves the bootstrap data of the RKE2Config whose namespace and name follow the path prefix,
+// if the request carries the token stored alongside it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	namespace, name, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, PathPrefix), "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	log := ctrl.LoggerFrom(r.Context()).WithValues("namespace", namespace, "RKE2Config", name)
+
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(r.Context(), types.NamespacedName{Namespace: namespace, Name: SecretName(name)}, secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			log.Error(err, "Failed to get the bootstrap data")
+		}
+
+		// Not found and unauthorized requests are not told apart, so that the existence of configs is not disclosed.
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	expected := secret.Data[TokenKey]
+	if _, ok := secret.Labels[SecretLabel]; !ok || len(expected) == 0 ||
+		subtle.ConstantTimeCompare(expected, []byte(token)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if _, err := w.Write(secret.Data[DataKey]); err != nil {
+		log.Error(err, "Failed to serve the bootstrap data")
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestServeHTTP(t *testing.T) {
+	s := &Server{
+		Client: fake.NewClientBuilder().WithObjects(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      SecretName("config"),
+					Labels:    map[string]string{SecretLabel: ""},
+				},
+				Data: map[string][]byte{DataKey: []byte("data"), TokenKey: []byte("token")},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+					Name:      SecretName("unlabeled"),
+				},
+				Data: map[string][]byte{DataKey: []byte("data"), TokenKey: []byte("token")},
+			},
+		).Build(),
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		token        string
+		expectedCode int
+	}{
+		{
+			name:         "serves the bootstrap data with a valid token",
+			method:       http.MethodGet,
+			path:         PathPrefix + "default/config",
+			token:        "token",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "rejects an invalid token",
+			method:       http.MethodGet,
+			path:         PathPrefix + "default/config",
+			token:        "invalid",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "rejects a missing token",
+			method:       http.MethodGet,
+			path:         PathPrefix + "default/config",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "rejects a missing config like an invalid token",
+			method:       http.MethodGet,
+			path:         PathPrefix + "default/missing",
+			token:        "token",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "rejects a Secret without the label",
+			method:       http.MethodGet,
+			path:         PathPrefix + "default/unlabeled",
+			token:        "token",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "rejects an invalid path",
+			method:       http.MethodGet,
+			path:         PathPrefix + "default/config/other",
+			token:        "token",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "rejects other methods",
+			method:       http.MethodPost,
+			path:         PathPrefix + "default/config",
+			token:        "token",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			g.Expect(rec.Code).To(Equal(tt.expectedCode))
+
+			if tt.expectedCode == http.StatusOK {
+				g.Expect(rec.Body.String()).To(Equal("data"))
+			}
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name        string
+		certDir     string
+		insecure    bool
+		expectedErr error
+	}{
+		{
+			name:        "refuses to start without a certificate directory",
+			expectedErr: errCertDirRequired,
+		},
+		{
+			name:        "refuses to start insecure with a certificate directory",
+			certDir:     "/tmp/certs",
+			insecure:    true,
+			expectedErr: errInsecureWithCertDir,
+		},
+		{
+			name:     "serves plain HTTP when insecure",
+			insecure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			s := &Server{
+				Client:      fake.NewClientBuilder().Build(),
+				BindAddress: "127.0.0.1:0",
+				CertDir:     tt.certDir,
+				Insecure:    tt.insecure,
+			}
+
+			err := s.Start(ctx)
+			if tt.expectedErr != nil {
+				g.Expect(err).To(MatchError(tt.expectedErr))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
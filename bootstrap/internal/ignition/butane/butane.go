@@ -20,15 +20,13 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"text/template"
 
-	"github.com/coreos/butane/config/common"
-	fcos "github.com/coreos/butane/config/fcos/v1_4"
-	ignition "github.com/coreos/ignition/v2/config/v3_3"
-	ignitionTypes "github.com/coreos/ignition/v2/config/v3_3/types"
-	"github.com/pkg/errors"
+	ignitionv33types "github.com/coreos/ignition/v2/config/v3_3/types"
+	ignitionv35 "github.com/coreos/ignition/v2/config/v3_5"
 
 	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/cloudinit"
@@ -41,6 +39,7 @@ import (
 // The chronyd.service unit is enabled only if NTP servers are specified.
 // The second section defines storage files for the system. It creates a file at /etc/rke2-install.sh.
 // If NTP servers are specified, it creates an NTP configuration file at /etc/chrony.conf.
+// The storage and systemd layout specific to the OS of the node is provided by the profile the template is merged with.
 const (
 	butaneTemplate = `
 variant: fcos
@@ -67,26 +66,7 @@ systemd:
       enabled: true
     {{- end }}
 storage:
-  filesystems:
-    - path: /opt
-      device: "/dev/disk/by-partlabel/p.lxroot"
-      format: btrfs
-      wipe_filesystem: false
-      mount_options:
-       - "subvol=/@/opt"
   files:
-    - path: /etc/ssh/sshd_config.d/010-rke2.conf
-      mode: 0600
-      overwrite: true
-      contents:
-        inline: |
-          # Use most defaults for sshd configuration.
-          Subsystem sftp internal-sftp
-          ClientAliveInterval 180
-          UseDNS no
-          UsePAM yes
-          PrintLastLog no # handled by PAM
-          PrintMotd no # handled by PAM
     {{- range .WriteFiles }}
     - path: {{ .Path }}
       {{- $owner := ParseOwner .Owner }}
@@ -147,9 +127,100 @@ storage:
           include /etc/chrony.d/*.conf
           sourcedir /run/chrony-dhcp
     {{- end }}
+`
+
+	// sshdFiles configures sshd, it is the files section of the storage of the profiles configuring sshd.
+	sshdFiles = `
+  files:
+    - path: /etc/ssh/sshd_config.d/010-rke2.conf
+      mode: 0600
+      overwrite: true
+      contents:
+        inline: |
+          # Use most defaults for sshd configuration.
+          Subsystem sftp internal-sftp
+          ClientAliveInterval 180
+          UseDNS no
+          UsePAM yes
+          PrintLastLog no # handled by PAM
+          PrintMotd no # handled by PAM
+`
+
+	// slMicroProfile mounts the /opt btrfs subvolume RKE2 is installed to, and configures sshd.
+	slMicroProfile = `
+variant: fcos
+version: 1.4.0
+storage:
+  filesystems:
+    - path: /opt
+      device: "/dev/disk/by-partlabel/p.lxroot"
+      format: btrfs
+      wipe_filesystem: false
+      mount_options:
+       - "subvol=/@/opt"` + sshdFiles
+
+	// flatcarProfile configures sshd, Flatcar has no /opt subvolume to mount.
+	flatcarProfile = `
+variant: fcos
+version: 1.4.0
+storage:` + sshdFiles
+
+	// emptyProfile is the profile of the OSes needing no additional storage or systemd configuration.
+	emptyProfile = `
+variant: fcos
+version: 1.4.0
 `
 )
 
+// profiles are the Butane configs of the built-in profiles.
+var profiles = map[bootstrapv1.IgnitionProfileName]string{
+	bootstrapv1.SLMicroIgnitionProfile: slMicroProfile,
+	bootstrapv1.FCOSIgnitionProfile:    emptyProfile,
+	bootstrapv1.FlatcarIgnitionProfile: flatcarProfile,
+}
+
+// Profile is the base storage and systemd layout of the Ignition config, depending on the OS of the node.
+type Profile struct {
+	// Name is the name of the profile.
+	Name bootstrapv1.IgnitionProfileName
+
+	// Config is the Butane config of a custom profile.
+	Config string
+}
+
+// ResolveProfile returns the profile to render the Ignition config with. When no profile is set, it defaults
+// to slmicro, or to flatcar when the additional config is a Flatcar Butane config.
+func ResolveProfile(profile *Profile, butaneCfg *bootstrapv1.AdditionalUserData) Profile {
+	if profile != nil && profile.Name != "" {
+		return *profile
+	}
+
+	if butaneCfg != nil && strings.Contains(butaneCfg.Config, "variant: flatcar") {
+		return Profile{Name: bootstrapv1.FlatcarIgnitionProfile}
+	}
+
+	return Profile{Name: bootstrapv1.SLMicroIgnitionProfile}
+}
+
+// profileToIgnition converts the Butane config of a profile to an Ignition config.
+func profileToIgnition(profile Profile) ([]byte, error) {
+	if profile.Name == bootstrapv1.CustomIgnitionProfile {
+		if profile.Config == "" {
+			return nil, errors.New("custom profile has no Butane config")
+		}
+
+		return butaneToIgnition([]byte(profile.Config), false)
+	}
+
+	config, ok := profiles[profile.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", profile.Name)
+	}
+
+	// the built-in profiles are static, so treat them as strict
+	return butaneToIgnition([]byte(config), true)
+}
+
 func defaultTemplateFuncMap() template.FuncMap {
 	return template.FuncMap{
 		"Indent":         templateYAMLIndent,
@@ -210,73 +281,77 @@ func renderButane(input *cloudinit.BaseUserData) ([]byte, error) {
 
 	var out bytes.Buffer
 	if err := t.Execute(&out, input); err != nil {
-		return nil, errors.Wrapf(err, "failed to render template")
+		return nil, fmt.Errorf("failed to render template: %w", err)
 	}
 
 	return out.Bytes(), nil
 }
 
-// butaneToIgnition converts butane bytes to an ignition v3.3 `Config`.
-func butaneToIgnition(data []byte, strict bool) (ignitionTypes.Config, error) {
-	// converting butane config to ignition config (bytes)
-	// NB: it could be simpler to Translate directly to a Config struct
-	//     but it doesn't seems to be supported (at least without an over-complicated implementation)
-	ignBytes, reports, err := fcos.ToIgn3_3Bytes(data, common.TranslateBytesOptions{})
+// butaneToIgnition converts butane bytes to Ignition config bytes, of the spec version matching the variant
+// and version of the Butane config.
+func butaneToIgnition(data []byte, strict bool) ([]byte, error) {
+	ignBytes, reports, err := translateButane(data)
 	if err != nil {
-		return ignitionTypes.Config{}, fmt.Errorf("error converting to Ignition: %w", err)
+		return nil, fmt.Errorf("error converting to Ignition: %w", err)
 	}
 
 	if (len(reports.Entries) > 0 && strict) || reports.IsFatal() {
-		return ignitionTypes.Config{}, fmt.Errorf("error converting to Ignition: %s", reports.String())
+		return nil, fmt.Errorf("error converting to Ignition: %s", reports.String())
 	}
-	// parse the ignition config bytes to have a Config struct
-	cfg, parseReport, err := ignition.Parse(ignBytes)
+	// parse the ignition config bytes to validate them
+	_, parseReport, err := ignitionv35.ParseCompatibleVersion(ignBytes)
 	if err != nil {
-		return ignitionTypes.Config{}, fmt.Errorf("error parsing resulting Ignition: %w", err)
+		return nil, fmt.Errorf("error parsing resulting Ignition: %w", err)
 	}
 
 	if (len(parseReport.Entries) > 0 && strict) || parseReport.IsFatal() {
-		return ignitionTypes.Config{}, fmt.Errorf("error parsing resulting Ignition: %v", parseReport.String())
+		return nil, fmt.Errorf("error parsing resulting Ignition: %v", parseReport.String())
 	}
 
-	reports.Merge(parseReport)
-
-	return cfg, nil
+	return ignBytes, nil
 }
 
-// Render renders the provided user data and additional butane config into an Ignition config.
-func Render(input *cloudinit.BaseUserData, butaneCfg *bootstrapv1.AdditionalUserData) ([]byte, error) {
+// Render renders the provided user data and additional butane config into an Ignition config,
+// on top of the layout of the given profile. The Ignition config is of the newest spec version
+// among the generated and additional configs.
+func Render(input *cloudinit.BaseUserData, profile *Profile, butaneCfg *bootstrapv1.AdditionalUserData) ([]byte, error) {
 	if input == nil {
 		return nil, errors.New("empty base user data")
 	}
 
+	profileCfg, err := profileToIgnition(ResolveProfile(profile, butaneCfg))
+	if err != nil {
+		return nil, fmt.Errorf("converting profile config to Ignition: %w", err)
+	}
+
 	butaneBytes, err := renderButane(input)
 	if err != nil {
 		return nil, err
 	}
 	// the base config is derived from the static template above, so treat it as strict
-	cfg, err := butaneToIgnition(butaneBytes, true)
+	baseCfg, err := butaneToIgnition(butaneBytes, true)
 	if err != nil {
-		return nil, errors.Wrap(err, "converting base config to Ignition")
+		return nil, fmt.Errorf("converting base config to Ignition: %w", err)
 	}
 
+	configs := [][]byte{profileCfg, baseCfg}
+
 	if butaneCfg != nil && butaneCfg.Config != "" {
 		addCfg, err := butaneToIgnition([]byte(butaneCfg.Config), butaneCfg.Strict)
 		if err != nil {
-			return nil, errors.Wrap(err, "converting additional config to Ignition")
+			return nil, fmt.Errorf("converting additional config to Ignition: %w", err)
 		}
 
-		if strings.Contains(butaneCfg.Config, "variant: flatcar") {
-			// this is flatcar drop /opt filesystem from default config
-			cfg.Storage.Filesystems = []ignitionTypes.Filesystem{}
+		if err := checkConflicts(addCfg, profileCfg, baseCfg); err != nil {
+			return nil, err
 		}
 
-		cfg = ignition.Merge(cfg, addCfg)
+		configs = append(configs, addCfg)
 	}
 
-	userData, err := json.Marshal(cfg)
+	userData, err := mergeIgnition(configs...)
 	if err != nil {
-		return nil, errors.Wrap(err, "marshaling Ignition config into JSON")
+		return nil, fmt.Errorf("merging additional config with the generated config: %w", err)
 	}
 
 	return userData, nil
@@ -287,11 +362,11 @@ func EncapsulateGzippedConfig(gzippedConfig []byte) ([]byte, error) {
 	comp := "gzip"
 	dataSource := "data:text/plain;base64," + base64.StdEncoding.EncodeToString(gzippedConfig)
 
-	encapCfg := ignitionTypes.Config{
-		Ignition: ignitionTypes.Ignition{
+	encapCfg := ignitionv33types.Config{
+		Ignition: ignitionv33types.Ignition{
 			Version: "3.3.0",
-			Config: ignitionTypes.IgnitionConfig{
-				Replace: ignitionTypes.Resource{
+			Config: ignitionv33types.IgnitionConfig{
+				Replace: ignitionv33types.Resource{
 					Compression: &comp,
 					Source:      &dataSource,
 				},
@@ -301,7 +376,46 @@ func EncapsulateGzippedConfig(gzippedConfig []byte) ([]byte, error) {
 
 	cfg, err := json.Marshal(encapCfg)
 	if err != nil {
-		return nil, errors.Wrap(err, "marshaling Ignition config into JSON")
+		return nil, fmt.Errorf("marshaling Ignition config into JSON: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// FetchConfig returns an Ignition config replacing itself with the config served at the given URL, which is
+// fetched with the given bearer token and verified against the given SHA-256 checksum. If not empty, caCert is
+// the PEM encoded CA certificate the server certificate is verified against.
+func FetchConfig(url, token string, checksum []byte, caCert []byte) ([]byte, error) {
+	authorization := "Bearer " + token
+	hash := fmt.Sprintf("sha256-%x", checksum)
+
+	fetchCfg := ignitionv33types.Config{
+		Ignition: ignitionv33types.Ignition{
+			Version: "3.3.0",
+			Config: ignitionv33types.IgnitionConfig{
+				Replace: ignitionv33types.Resource{
+					Source: &url,
+					HTTPHeaders: ignitionv33types.HTTPHeaders{
+						{Name: "Authorization", Value: &authorization},
+					},
+					Verification: ignitionv33types.Verification{
+						Hash: &hash,
+					},
+				},
+			},
+		},
+	}
+
+	if len(caCert) > 0 {
+		caSource := "data:text/plain;base64," + base64.StdEncoding.EncodeToString(caCert)
+		fetchCfg.Ignition.Security.TLS.CertificateAuthorities = []ignitionv33types.Resource{
+			{Source: &caSource},
+		}
+	}
+
+	cfg, err := json.Marshal(fetchCfg)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling Ignition config into JSON: %w", err)
 	}
 
 	return cfg, nil
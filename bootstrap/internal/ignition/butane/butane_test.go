@@ -18,6 +18,8 @@ limitations under the License.
 package butane
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -25,6 +27,8 @@ import (
 	"k8s.io/utils/ptr"
 
 	ignition "github.com/coreos/ignition/v2/config/v3_3"
+	ignitionv34 "github.com/coreos/ignition/v2/config/v3_4"
+	ignitionv34types "github.com/coreos/ignition/v2/config/v3_4/types"
 
 	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/cloudinit"
@@ -95,7 +99,7 @@ var _ = Describe("Render", func() {
 	})
 
 	It("should render a valid ignition config", func() {
-		ignitionJson, err := Render(input, additionalConfig)
+		ignitionJson, err := Render(input, nil, additionalConfig)
 		Expect(err).ToNot(HaveOccurred())
 
 		ign, reports, err := ignition.Parse(ignitionJson)
@@ -131,12 +135,12 @@ var _ = Describe("Render", func() {
 
 	It("accepts empty additional config", func() {
 		additionalConfig = nil
-		_, err := Render(input, additionalConfig)
+		_, err := Render(input, nil, additionalConfig)
 		Expect(err).ToNot(HaveOccurred())
 	})
 
 	It("should return error if input is nil", func() {
-		_, err := Render(nil, additionalConfig)
+		_, err := Render(nil, nil, additionalConfig)
 		Expect(err).To(HaveOccurred())
 	})
 
@@ -155,7 +159,7 @@ storage:
 			Strict: true,
 		}
 
-		_, err := Render(input, additionalConfig)
+		_, err := Render(input, nil, additionalConfig)
 		Expect(err).To(HaveOccurred())
 	})
 	It("handles flatcar specifics", func() {
@@ -167,7 +171,7 @@ version: 1.0.0
 			Config: flatCarIgnition,
 			Strict: true,
 		}
-		ignitionJson, err := Render(input, additionalConfig)
+		ignitionJson, err := Render(input, nil, additionalConfig)
 		Expect(err).ToNot(HaveOccurred())
 		ign, reports, err := ignition.Parse(ignitionJson)
 		Expect(err).ToNot(HaveOccurred())
@@ -178,4 +182,174 @@ version: 1.0.0
 		Expect(ign.Storage.Filesystems).To(HaveLen(0))
 
 	})
+	It("renders the layout of the slmicro profile by default", func() {
+		ignitionJson, err := Render(input, &Profile{Name: bootstrapv1.SLMicroIgnitionProfile}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		ign, _, err := ignition.Parse(ignitionJson)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ign.Storage.Filesystems).To(HaveLen(1))
+		Expect(ign.Storage.Filesystems[0].Path).To(Equal(ptr.To("/opt")))
+		Expect(ign.Storage.Files[0].Path).To(Equal("/etc/ssh/sshd_config.d/010-rke2.conf"))
+	})
+	It("renders the layout of the fcos profile", func() {
+		ignitionJson, err := Render(input, &Profile{Name: bootstrapv1.FCOSIgnitionProfile}, additionalConfig)
+		Expect(err).ToNot(HaveOccurred())
+		ign, _, err := ignition.Parse(ignitionJson)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ign.Storage.Filesystems).To(BeEmpty())
+		Expect(ign.Storage.Files).To(HaveLen(4))
+		Expect(ign.Storage.Files[0].Path).To(Equal("/test/file"))
+		Expect(ign.Systemd.Units).To(HaveLen(3))
+	})
+	It("renders the layout of the flatcar profile, also when detected from the additional config", func() {
+		for _, profile := range []*Profile{{Name: bootstrapv1.FlatcarIgnitionProfile}, nil} {
+			ignitionJson, err := Render(input, profile, &bootstrapv1.AdditionalUserData{Config: "variant: flatcar\nversion: 1.0.0\n"})
+			Expect(err).ToNot(HaveOccurred())
+			ign, _, err := ignition.Parse(ignitionJson)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ign.Storage.Filesystems).To(BeEmpty())
+			Expect(ign.Storage.Files[0].Path).To(Equal("/etc/ssh/sshd_config.d/010-rke2.conf"))
+			Expect(ign.Storage.Files).To(HaveLen(5))
+		}
+	})
+	It("renders the layout of a custom profile", func() {
+		customProfile := `
+variant: fcos
+version: 1.4.0
+systemd:
+  units:
+    - name: custom.service
+      enabled: true
+storage:
+  filesystems:
+    - path: /var/lib/rancher
+      device: /dev/vdb
+      format: xfs
+      with_mount_unit: true
+`
+		ignitionJson, err := Render(input, &Profile{Name: bootstrapv1.CustomIgnitionProfile, Config: customProfile}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		ign, _, err := ignition.Parse(ignitionJson)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ign.Storage.Filesystems).To(HaveLen(1))
+		Expect(ign.Storage.Filesystems[0].Path).To(Equal(ptr.To("/var/lib/rancher")))
+		Expect(ign.Systemd.Units).To(ContainElement(HaveField("Name", "custom.service")))
+		Expect(ign.Systemd.Units).To(ContainElement(HaveField("Name", "rke2-install.service")))
+	})
+	It("renders the Ignition spec version of the additional config", func() {
+		additionalConfig = &bootstrapv1.AdditionalUserData{
+			Config: `
+variant: fcos
+version: 1.5.0
+kernel_arguments:
+  should_exist:
+    - mitigations=auto
+storage:
+  luks:
+    - name: data
+      device: /dev/vdb
+      clevis:
+        tpm2: true
+`,
+			Strict: true,
+		}
+
+		ignitionJson, err := Render(input, nil, additionalConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		ign, _, err := ignitionv34.Parse(ignitionJson)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ign.Ignition.Version).To(Equal("3.4.0"))
+		Expect(ign.KernelArguments.ShouldExist).To(ConsistOf(ignitionv34types.KernelArgument("mitigations=auto")))
+		Expect(ign.Storage.Luks).To(HaveLen(1))
+		Expect(ign.Storage.Filesystems).To(HaveLen(1))
+		Expect(ign.Systemd.Units[0].Name).To(Equal("rke2-install.service"))
+	})
+	It("renders the Ignition spec version of a flatcar additional config", func() {
+		additionalConfig = &bootstrapv1.AdditionalUserData{
+			Config: `
+variant: flatcar
+version: 1.1.0
+`,
+		}
+
+		ignitionJson, err := Render(input, nil, additionalConfig)
+		Expect(err).ToNot(HaveOccurred())
+
+		ign, _, err := ignitionv34.Parse(ignitionJson)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ign.Storage.Filesystems).To(BeEmpty())
+	})
+	It("should return error if the additional config does not merge cleanly", func() {
+		additionalConfig = &bootstrapv1.AdditionalUserData{
+			Config: `
+variant: fcos
+version: 1.5.0
+storage:
+  directories:
+    - path: /etc/rke2-install.sh
+`,
+		}
+
+		_, err := Render(input, nil, additionalConfig)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should return error if the additional config replaces a file of the profile", func() {
+		additionalConfig = &bootstrapv1.AdditionalUserData{
+			Config: `
+variant: fcos
+version: 1.4.0
+storage:
+  files:
+    - path: /etc/ssh/sshd_config.d/010-rke2.conf
+      overwrite: true
+      contents:
+        inline: PermitRootLogin yes
+`,
+		}
+
+		_, err := Render(input, nil, additionalConfig)
+		Expect(err).To(MatchError(ContainSubstring("/etc/ssh/sshd_config.d/010-rke2.conf")))
+	})
+	It("should return error for an unknown variant", func() {
+		additionalConfig = &bootstrapv1.AdditionalUserData{
+			Config: `
+variant: unknown
+version: 1.0.0
+`,
+		}
+
+		_, err := Render(input, nil, additionalConfig)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should return error if the custom profile has no config", func() {
+		_, err := Render(input, &Profile{Name: bootstrapv1.CustomIgnitionProfile}, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("FetchConfig", func() {
+	It("replaces the config with the fetched and verified one", func() {
+		checksum := sha256.Sum256([]byte("data"))
+
+		data, err := FetchConfig("https://capi.example.com/bootstrap-data/default/config", "token", checksum[:], []byte("ca"))
+		Expect(err).ToNot(HaveOccurred())
+
+		cfg, reports, err := ignition.Parse(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports.IsFatal()).To(BeFalse())
+
+		replace := cfg.Ignition.Config.Replace
+		Expect(replace.Source).To(Equal(ptr.To("https://capi.example.com/bootstrap-data/default/config")))
+		Expect(replace.HTTPHeaders).To(HaveLen(1))
+		Expect(replace.HTTPHeaders[0].Name).To(Equal("Authorization"))
+		Expect(replace.HTTPHeaders[0].Value).To(Equal(ptr.To("Bearer token")))
+		Expect(replace.Verification.Hash).To(Equal(ptr.To(fmt.Sprintf("sha256-%x", checksum))))
+		Expect(cfg.Ignition.Security.TLS.CertificateAuthorities).To(HaveLen(1))
+		Expect(*cfg.Ignition.Security.TLS.CertificateAuthorities[0].Source).To(Equal("data:text/plain;base64,Y2E="))
+	})
 })
@@ -0,0 +1,193 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package butane
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/butane/config"
+	"github.com/coreos/butane/config/common"
+	fcos "github.com/coreos/butane/config/fcos/v1_4"
+	"github.com/coreos/go-semver/semver"
+	"github.com/coreos/ignition/v2/config/merge"
+	"github.com/coreos/ignition/v2/config/util"
+	ignitionv33 "github.com/coreos/ignition/v2/config/v3_3"
+	ignitionv34 "github.com/coreos/ignition/v2/config/v3_4"
+	ignitionv35 "github.com/coreos/ignition/v2/config/v3_5"
+	"github.com/coreos/vcontext/report"
+	"gopkg.in/yaml.v3"
+)
+
+// ignitionSpecs parse Ignition configs into the types of the supported spec versions,
+// translating the configs of older versions.
+var ignitionSpecs = map[string]func(raw []byte) (any, report.Report, error){
+	"3.3.0": parseCompatibleVersion(ignitionv33.ParseCompatibleVersion),
+	"3.4.0": parseCompatibleVersion(ignitionv34.ParseCompatibleVersion),
+	"3.5.0": parseCompatibleVersion(ignitionv35.ParseCompatibleVersion),
+}
+
+func parseCompatibleVersion[T any](parse func([]byte) (T, report.Report, error)) func([]byte) (any, report.Report, error) {
+	return func(raw []byte) (any, report.Report, error) {
+		cfg, rpt, err := parse(raw)
+
+		return cfg, rpt, err
+	}
+}
+
+// translateButane translates a Butane config to an Ignition config of the spec version matching its variant and version.
+// Butane configs not declaring both their variant and version are translated as fcos 1.4.0 ones, to Ignition v3.3.
+func translateButane(data []byte) ([]byte, report.Report, error) {
+	header := struct {
+		Variant string `yaml:"variant"`
+		Version string `yaml:"version"`
+	}{}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, report.Report{}, fmt.Errorf("unmarshaling Butane config: %w", err)
+	}
+
+	if header.Variant == "" || header.Version == "" {
+		return fcos.ToIgn3_3Bytes(data, common.TranslateBytesOptions{})
+	}
+
+	// Raw makes the openshift variant produce an Ignition config instead of a MachineConfig.
+	return config.TranslateBytes(data, common.TranslateBytesOptions{Raw: true})
+}
+
+// checkConflicts returns an error if the additional Ignition config replaces the files, directories, links
+// or systemd unit contents of the generated Ignition configs, the profile and base configs the bootstrap
+// of the node relies on.
+func checkConflicts(additional []byte, generated ...[]byte) error {
+	additionalCfg, _, err := ignitionv35.ParseCompatibleVersion(additional)
+	if err != nil {
+		return fmt.Errorf("parsing additional Ignition config: %w", err)
+	}
+
+	nodes := map[string]bool{}
+	units := map[string]bool{}
+
+	for _, raw := range generated {
+		generatedCfg, _, err := ignitionv35.ParseCompatibleVersion(raw)
+		if err != nil {
+			return fmt.Errorf("parsing generated Ignition config: %w", err)
+		}
+
+		for _, file := range generatedCfg.Storage.Files {
+			nodes[file.Path] = true
+		}
+
+		for _, directory := range generatedCfg.Storage.Directories {
+			nodes[directory.Path] = true
+		}
+
+		for _, link := range generatedCfg.Storage.Links {
+			nodes[link.Path] = true
+		}
+
+		for _, unit := range generatedCfg.Systemd.Units {
+			units[unit.Name] = units[unit.Name] || unit.Contents != nil
+		}
+	}
+
+	conflicts := []string{}
+
+	for _, file := range additionalCfg.Storage.Files {
+		if nodes[file.Path] {
+			conflicts = append(conflicts, file.Path)
+		}
+	}
+
+	for _, directory := range additionalCfg.Storage.Directories {
+		if nodes[directory.Path] {
+			conflicts = append(conflicts, directory.Path)
+		}
+	}
+
+	for _, link := range additionalCfg.Storage.Links {
+		if nodes[link.Path] {
+			conflicts = append(conflicts, link.Path)
+		}
+	}
+
+	for _, unit := range additionalCfg.Systemd.Units {
+		if units[unit.Name] && unit.Contents != nil {
+			conflicts = append(conflicts, unit.Name)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("additional config replaces generated %s", strings.Join(conflicts, ", "))
+	}
+
+	return nil
+}
+
+// mergeIgnition merges Ignition configs, later configs taking precedence, into a config of the newest
+// spec version among them. The merged config is validated, so that configs not merging cleanly are reported.
+func mergeIgnition(configs ...[]byte) ([]byte, error) {
+	var version *semver.Version
+
+	for _, raw := range configs {
+		configVersion, _, err := util.GetConfigVersion(raw)
+		if err != nil {
+			return nil, fmt.Errorf("getting Ignition config version: %w", err)
+		}
+
+		if version == nil || version.LessThan(configVersion) {
+			version = &configVersion
+		}
+	}
+
+	if version == nil {
+		return nil, errors.New("no Ignition config to merge")
+	}
+
+	parse, ok := ignitionSpecs[version.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported Ignition spec version %s", version)
+	}
+
+	var merged any
+
+	for _, raw := range configs {
+		cfg, rpt, err := parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing Ignition config: %w: %s", err, rpt.String())
+		}
+
+		if merged == nil {
+			merged = cfg
+
+			continue
+		}
+
+		merged, _ = merge.MergeStructTranscribe(merged, cfg)
+	}
+
+	mergedBytes, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling Ignition config into JSON: %w", err)
+	}
+
+	if _, rpt, err := parse(mergedBytes); err != nil {
+		return nil, fmt.Errorf("merged Ignition config is invalid: %w: %s", err, rpt.String())
+	}
+
+	return mergedBytes, nil
+}
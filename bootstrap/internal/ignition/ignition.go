@@ -59,6 +59,7 @@ type JoinWorkerInput struct {
 	*cloudinit.BaseUserData
 
 	AdditionalIgnition *bootstrapv1.AdditionalUserData
+	IgnitionProfile    *butane.Profile
 }
 
 // ControlPlaneInput defines the context to generate a controlplane instance user data.
@@ -66,6 +67,7 @@ type ControlPlaneInput struct {
 	*cloudinit.ControlPlaneInput
 
 	AdditionalIgnition *bootstrapv1.AdditionalUserData
+	IgnitionProfile    *butane.Profile
 }
 
 // NewJoinWorker returns Ignition configuration for new worker node joining the cluster.
@@ -86,7 +88,7 @@ func NewJoinWorker(input *JoinWorkerInput) ([]byte, error) {
 	input.DeployRKE2Commands = deployRKE2Command
 	input.WriteFiles = append(input.WriteFiles, input.ConfigFile)
 
-	return render(input.BaseUserData, input.AdditionalIgnition)
+	return render(input.BaseUserData, input.IgnitionProfile, input.AdditionalIgnition)
 }
 
 // NewJoinControlPlane returns Ignition configuration for new controlplane node joining the cluster.
@@ -96,7 +98,7 @@ func NewJoinControlPlane(input *ControlPlaneInput) ([]byte, error) {
 		return nil, fmt.Errorf("failed to process controlplane input: %w", err)
 	}
 
-	return render(&processedInput.BaseUserData, processedInput.AdditionalIgnition)
+	return render(&processedInput.BaseUserData, processedInput.IgnitionProfile, processedInput.AdditionalIgnition)
 }
 
 func removeSemanageCmd(cmds []string) []string {
@@ -119,7 +121,7 @@ func NewInitControlPlane(input *ControlPlaneInput) ([]byte, error) {
 		return nil, fmt.Errorf("failed to process controlplane input: %w", err)
 	}
 
-	return render(&processedInput.BaseUserData, processedInput.AdditionalIgnition)
+	return render(&processedInput.BaseUserData, processedInput.IgnitionProfile, processedInput.AdditionalIgnition)
 }
 
 func controlPlaneConfigInput(input *ControlPlaneInput) (*ControlPlaneInput, error) {
@@ -143,17 +145,17 @@ func controlPlaneConfigInput(input *ControlPlaneInput) (*ControlPlaneInput, erro
 	return input, nil
 }
 
-func render(input *cloudinit.BaseUserData, ignitionConfig *bootstrapv1.AdditionalUserData) ([]byte, error) {
+func render(input *cloudinit.BaseUserData, profile *butane.Profile, ignitionConfig *bootstrapv1.AdditionalUserData) ([]byte, error) {
 	additionalButaneConfig := &bootstrapv1.AdditionalUserData{}
 	if ignitionConfig != nil && ignitionConfig.Config != "" {
 		additionalButaneConfig = ignitionConfig
 	}
 
-	if strings.Contains(ignitionConfig.Config, "variant: flatcar") {
+	if butane.ResolveProfile(profile, additionalButaneConfig).Name == bootstrapv1.FlatcarIgnitionProfile {
 		input.DeployRKE2Commands = removeSemanageCmd(input.DeployRKE2Commands)
 	}
 
-	return butane.Render(input, additionalButaneConfig)
+	return butane.Render(input, profile, additionalButaneConfig)
 }
 
 func getControlPlaneRKE2Commands(baseUserData *cloudinit.BaseUserData) ([]string, error) {
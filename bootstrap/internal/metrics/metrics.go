@@ -0,0 +1,101 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics implements the Prometheus metrics of the RKE2 bootstrap controller.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+)
+
+const (
+	// RoleInitControlPlane is the role of the bootstrap data of the first control plane machine.
+	RoleInitControlPlane = "init-control-plane"
+
+	// RoleJoinControlPlane is the role of the bootstrap data of the control plane machines joining the cluster.
+	RoleJoinControlPlane = "join-control-plane"
+
+	// RoleWorker is the role of the bootstrap data of the worker machines.
+	RoleWorker = "worker"
+
+	// TokenServer is the type of the token shared by the servers of a cluster.
+	TokenServer = "server"
+
+	// TokenRegistration is the type of the per-machine registration tokens of the workers.
+	TokenRegistration = "registration"
+
+	// TokenCreated is the operation of a token being created.
+	TokenCreated = "created"
+
+	// TokenRevoked is the operation of a token being revoked.
+	TokenRevoked = "revoked"
+)
+
+var (
+	bootstrapDataGenerationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "caprke2_bootstrap_data_generation_duration_seconds",
+		Help:    "Duration of the generation of the bootstrap data of a machine.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"format", "role"})
+
+	bootstrapDataSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "caprke2_bootstrap_data_size_bytes",
+		Help:    "Size of the bootstrap data stored for a machine, after compression.",
+		Buckets: prometheus.ExponentialBuckets(1024, 2, 11),
+	}, []string{"format"})
+
+	tokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "caprke2_bootstrap_tokens_total",
+		Help: "Total number of tokens created or revoked by the bootstrap controller.",
+	}, []string{"type", "operation"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(
+		bootstrapDataGenerationDuration,
+		bootstrapDataSize,
+		tokens,
+	)
+}
+
+// ObserveBootstrapDataGeneration records the duration of the generation of the bootstrap data of a machine.
+func ObserveBootstrapDataGeneration(format bootstrapv1.Format, role string, duration time.Duration) {
+	bootstrapDataGenerationDuration.WithLabelValues(formatLabel(format), role).Observe(duration.Seconds())
+}
+
+// ObserveBootstrapDataSize records the size of the bootstrap data stored for a machine.
+func ObserveBootstrapDataSize(format bootstrapv1.Format, size int) {
+	bootstrapDataSize.WithLabelValues(formatLabel(format)).Observe(float64(size))
+}
+
+// RecordToken records a token being created or revoked.
+func RecordToken(tokenType, operation string) {
+	tokens.WithLabelValues(tokenType, operation).Inc()
+}
+
+// formatLabel returns the format of the bootstrap data, cloud-config being the default one.
+func formatLabel(format bootstrapv1.Format) string {
+	if format == "" {
+		return string(bootstrapv1.CloudConfig)
+	}
+
+	return string(format)
+}
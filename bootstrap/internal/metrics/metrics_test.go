@@ -0,0 +1,67 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+)
+
+func TestBootstrapDataMetrics(t *testing.T) {
+	g := NewWithT(t)
+
+	ObserveBootstrapDataGeneration("", RoleWorker, 2*time.Second)
+	ObserveBootstrapDataGeneration(bootstrapv1.Ignition, RoleInitControlPlane, time.Second)
+	ObserveBootstrapDataSize(bootstrapv1.Ignition, 4096)
+
+	g.Expect(testutil.CollectAndCount(bootstrapDataGenerationDuration)).To(Equal(2))
+
+	// The format defaults to cloud-config.
+	worker := histogram(g, bootstrapDataGenerationDuration.WithLabelValues(string(bootstrapv1.CloudConfig), RoleWorker))
+	g.Expect(worker.GetSampleCount()).To(Equal(uint64(1)))
+	g.Expect(worker.GetSampleSum()).To(Equal(2.0))
+
+	size := histogram(g, bootstrapDataSize.WithLabelValues(string(bootstrapv1.Ignition)))
+	g.Expect(size.GetSampleCount()).To(Equal(uint64(1)))
+	g.Expect(size.GetSampleSum()).To(Equal(4096.0))
+}
+
+func TestRecordToken(t *testing.T) {
+	g := NewWithT(t)
+
+	RecordToken(TokenRegistration, TokenCreated)
+	RecordToken(TokenRegistration, TokenCreated)
+	RecordToken(TokenRegistration, TokenRevoked)
+
+	g.Expect(testutil.ToFloat64(tokens.WithLabelValues(TokenRegistration, TokenCreated))).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(tokens.WithLabelValues(TokenRegistration, TokenRevoked))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(tokens.WithLabelValues(TokenServer, TokenCreated))).To(BeZero())
+}
+
+func histogram(g *WithT, observer prometheus.Observer) *dto.Histogram {
+	metric := &dto.Metric{}
+	g.Expect(observer.(prometheus.Metric).Write(metric)).To(Succeed())
+
+	return metric.GetHistogram()
+}
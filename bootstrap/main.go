@@ -17,9 +17,11 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -35,9 +37,12 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/clustercache"
+	"sigs.k8s.io/cluster-api/controllers/remote"
 	clusterexpv1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	"sigs.k8s.io/cluster-api/feature"
 	"sigs.k8s.io/cluster-api/util/flags"
@@ -45,9 +50,11 @@ import (
 	bootstrapv1alpha1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1alpha1"
 	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/controllers"
+	"github.com/rancher/cluster-api-provider-rke2/bootstrap/internal/dataserver"
 	controlplanev1alpha1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1alpha1"
 	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/consts"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
 	"github.com/rancher/cluster-api-provider-rke2/version"
 )
 
@@ -56,19 +63,28 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 
 	// flags.
-	enableLeaderElection        bool
-	leaderElectionLeaseDuration time.Duration
-	leaderElectionRenewDeadline time.Duration
-	leaderElectionRetryPeriod   time.Duration
-	watchFilterValue            string
-	profilerAddress             string
-	concurrencyNumber           int
-	syncPeriod                  time.Duration
-	watchNamespace              string
-	webhookPort                 int
-	webhookCertDir              string
-	healthAddr                  string
-	managerOptions              = flags.ManagerOptions{}
+	enableLeaderElection           bool
+	leaderElectionLeaseDuration    time.Duration
+	leaderElectionRenewDeadline    time.Duration
+	leaderElectionRetryPeriod      time.Duration
+	watchFilterValue               string
+	profilerAddress                string
+	concurrencyNumber              int
+	syncPeriod                     time.Duration
+	watchNamespace                 string
+	webhookPort                    int
+	webhookCertDir                 string
+	healthAddr                     string
+	tokenTTL                       time.Duration
+	clusterCacheTrackerClientQPS   float32
+	clusterCacheTrackerClientBurst int
+	clusterCacheConcurrencyNumber  int
+	bootstrapDataServerAddr        string
+	bootstrapDataServerURL         string
+	bootstrapDataServerCertDir     string
+	bootstrapDataServerInsecure    bool
+	tracingOptions                 = tracing.Options{}
+	managerOptions                 = flags.ManagerOptions{}
 )
 
 func init() {
@@ -121,6 +137,33 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&healthAddr, "health-addr", ":9440",
 		"The address the health endpoint binds to.")
 
+	fs.DurationVar(&tokenTTL, "bootstrap-token-ttl", controllers.DefaultTokenTTL,
+		"The TTL of the registration tokens created in the workload cluster, the bootstrap data of MachinePools is refreshed after half of it (e.g. 24h)")
+
+	fs.StringVar(&bootstrapDataServerAddr, "bootstrap-data-server-addr", "",
+		"The address the bootstrap data server binds to, serving the bootstrap data fetched by the nodes (e.g. :9445). If unspecified, the server is disabled.")
+
+	fs.StringVar(&bootstrapDataServerURL, "bootstrap-data-server-url", "",
+		"The URL the nodes reach the bootstrap data server at (e.g. https://capi.example.com:9445)")
+
+	fs.StringVar(&bootstrapDataServerCertDir, "bootstrap-data-server-cert-dir", "",
+		"The directory containing the tls.crt, tls.key and optional ca.crt files of the bootstrap data server. It is required unless --bootstrap-data-server-insecure is set.") //nolint:lll
+
+	fs.BoolVar(&bootstrapDataServerInsecure, "bootstrap-data-server-insecure", false,
+		"Serve the bootstrap data over plain HTTP without --bootstrap-data-server-cert-dir, sending the tokens and bootstrap data in clear text. Only suitable for trusted networks.") //nolint:lll
+
+	fs.Float32Var(&clusterCacheTrackerClientQPS, "clustercachetracker-client-qps", 20,
+		"Maximum queries per second from the cluster cache tracker clients to the Kubernetes API server of workload clusters.")
+
+	fs.IntVar(&clusterCacheTrackerClientBurst, "clustercachetracker-client-burst", 30,
+		"Maximum number of queries allowed in one burst from cluster cache tracker clients "+
+			"to the Kubernetes API server of workload clusters.")
+
+	fs.IntVar(&clusterCacheConcurrencyNumber, "clustercache-concurrency", consts.DefaultClusterCacheConcurrency,
+		"Number of clusters to process simultaneously")
+
+	tracing.AddFlags(fs, &tracingOptions)
+
 	flags.AddManagerOptions(fs, &managerOptions)
 
 	feature.MutableGates.AddFlag(fs)
@@ -194,13 +237,26 @@ func main() {
 	// Setup the context that's going to be used in controllers and for the manager.
 	ctx := ctrl.SetupSignalHandler()
 
+	shutdownTracing, err := tracing.Setup(ctx, "rke2-bootstrap-controller", tracingOptions)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	setupChecks(mgr)
-	setupReconcilers(mgr)
+	setupReconcilers(ctx, mgr)
 	setupWebhooks(mgr)
+	setupBootstrapDataServer(mgr, watchNamespaces)
 
 	setupLog.Info("Starting manager", "version", version.Get().String(), "concurrency", concurrencyNumber)
 
-	if err := mgr.Start(ctx); err != nil {
+	err = mgr.Start(ctx)
+
+	if tracingErr := shutdownTracing(); tracingErr != nil {
+		setupLog.Error(tracingErr, "unable to flush traces")
+	}
+
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -218,10 +274,66 @@ func setupChecks(mgr ctrl.Manager) {
 	}
 }
 
-func setupReconcilers(mgr ctrl.Manager) {
+func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
+	secretCachingClient, err := client.New(mgr.GetConfig(), client.Options{
+		HTTPClient: mgr.GetHTTPClient(),
+		Cache: &client.CacheOptions{
+			Reader: mgr.GetCache(),
+		},
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create secret caching client")
+		os.Exit(1)
+	}
+
+	// Set up a clusterCache to provide the clients of the workload clusters the registration tokens are managed in.
+	clusterCache, err := clustercache.SetupWithManager(ctx, mgr, clustercache.Options{
+		SecretClient: secretCachingClient,
+		Client: clustercache.ClientOptions{
+			QPS:       clusterCacheTrackerClientQPS,
+			Burst:     clusterCacheTrackerClientBurst,
+			UserAgent: remote.DefaultClusterAPIUserAgent("rke2-bootstrap-controller"),
+			Cache: clustercache.ClientCacheOptions{
+				DisableFor: []client.Object{
+					// Don't cache ConfigMaps & Secrets.
+					&corev1.ConfigMap{},
+					&corev1.Secret{},
+				},
+			},
+		},
+	}, controller.Options{
+		MaxConcurrentReconciles: clusterCacheConcurrencyNumber,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create cluster cache")
+		os.Exit(1)
+	}
+
+	var (
+		serverURL string
+		caCert    []byte
+	)
+
+	// The bootstrap data can only be fetched by the nodes if the bootstrap data server is enabled.
+	if bootstrapDataServerAddr != "" {
+		serverURL = bootstrapDataServerURL
+	}
+
+	if serverURL != "" && bootstrapDataServerCertDir != "" {
+		caCert, err = os.ReadFile(filepath.Join(bootstrapDataServerCertDir, "ca.crt"))
+		if err != nil && !os.IsNotExist(err) {
+			setupLog.Error(err, "unable to read the CA certificate of the bootstrap data server")
+			os.Exit(1)
+		}
+	}
+
 	if err := (&controllers.RKE2ConfigReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                    mgr.GetClient(),
+		Scheme:                    mgr.GetScheme(),
+		TokenTTL:                  tokenTTL,
+		BootstrapDataServerURL:    serverURL,
+		BootstrapDataServerCACert: caCert,
+		ClusterCache:              clusterCache,
 	}).SetupWithManager(mgr, concurrencyNumber); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Rke2Config")
 		os.Exit(1)
@@ -239,3 +351,49 @@ func setupWebhooks(mgr ctrl.Manager) {
 		os.Exit(1)
 	}
 }
+
+func setupBootstrapDataServer(mgr ctrl.Manager, watchNamespaces map[string]cache.Config) {
+	if bootstrapDataServerAddr == "" {
+		return
+	}
+
+	if bootstrapDataServerURL == "" {
+		setupLog.Error(nil, "the bootstrap data server requires --bootstrap-data-server-url")
+		os.Exit(1)
+	}
+
+	switch {
+	case bootstrapDataServerCertDir == "" && !bootstrapDataServerInsecure:
+		setupLog.Error(nil, "the bootstrap data server requires --bootstrap-data-server-cert-dir, unless --bootstrap-data-server-insecure is set")
+		os.Exit(1)
+	case bootstrapDataServerCertDir != "" && bootstrapDataServerInsecure:
+		setupLog.Error(nil, "--bootstrap-data-server-insecure can not be set along with --bootstrap-data-server-cert-dir")
+		os.Exit(1)
+	}
+
+	secretCache, err := dataserver.NewSecretCache(mgr.GetConfig(), cache.Options{
+		HTTPClient:        mgr.GetHTTPClient(),
+		Scheme:            mgr.GetScheme(),
+		Mapper:            mgr.GetRESTMapper(),
+		DefaultNamespaces: watchNamespaces,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create bootstrap data cache")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(secretCache); err != nil {
+		setupLog.Error(err, "unable to add bootstrap data cache")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&dataserver.Server{
+		Client:      secretCache,
+		BindAddress: bootstrapDataServerAddr,
+		CertDir:     bootstrapDataServerCertDir,
+		Insecure:    bootstrapDataServerInsecure,
+	}); err != nil {
+		setupLog.Error(err, "unable to create bootstrap data server")
+		os.Exit(1)
+	}
+}
@@ -70,10 +70,52 @@ func (src *RKE2ControlPlane) ConvertTo(dstRaw conversion.Hub) error {
 		dst.Spec.ServerConfig.ExternalDatastoreSecret = restored.Spec.ServerConfig.ExternalDatastoreSecret
 	}
 
+	dst.Spec.ServerConfig.AuditLog = restored.Spec.ServerConfig.AuditLog
+	dst.Spec.ServerConfig.AdmissionConfiguration = restored.Spec.ServerConfig.AdmissionConfiguration
+	dst.Spec.ServerConfig.SecretsEncryption = restored.Spec.ServerConfig.SecretsEncryption
+	dst.Spec.ServerConfig.Etcd.Maintenance = restored.Spec.ServerConfig.Etcd.Maintenance
+	dst.Spec.ServerConfig.Etcd.MemberHealth = restored.Spec.ServerConfig.Etcd.MemberHealth
+
+	if restored.Spec.Restore != nil {
+		dst.Spec.Restore = restored.Spec.Restore
+	}
+
+	if restored.Spec.CertificateRotation != nil {
+		dst.Spec.CertificateRotation = restored.Spec.CertificateRotation
+	}
+
+	if restored.Spec.SecretsEncryptionRotation != nil {
+		dst.Spec.SecretsEncryptionRotation = restored.Spec.SecretsEncryptionRotation
+	}
+
+	if restored.Spec.RolloutAfter != nil {
+		dst.Spec.RolloutAfter = restored.Spec.RolloutAfter
+	}
+
+	if restored.Spec.RolloutBefore != nil {
+		dst.Spec.RolloutBefore = restored.Spec.RolloutBefore
+	}
+
+	dst.Spec.ControlPlaneEndpoint = restored.Spec.ControlPlaneEndpoint
+	dst.Spec.ControlPlaneEndpointManagement = restored.Spec.ControlPlaneEndpointManagement
+
+	if restored.Spec.RolloutStrategy != nil && dst.Spec.RolloutStrategy != nil {
+		dst.Spec.RolloutStrategy.PreUpgradeSnapshot = restored.Spec.RolloutStrategy.PreUpgradeSnapshot
+		dst.Spec.RolloutStrategy.InPlace = restored.Spec.RolloutStrategy.InPlace
+	}
+
 	dst.Spec.ServerConfig.EmbeddedRegistry = restored.Spec.ServerConfig.EmbeddedRegistry
 	dst.Spec.MachineTemplate = restored.Spec.MachineTemplate
 	dst.Status = restored.Status
 	dst.Spec.Files = restored.Spec.Files
+	dst.Spec.RegistrationTokenMode = restored.Spec.RegistrationTokenMode
+	dst.Spec.RegistrationAddressFamily = restored.Spec.RegistrationAddressFamily
+	dst.Spec.RegistrationServiceRef = restored.Spec.RegistrationServiceRef
+	dst.Spec.RegistrationWebhook = restored.Spec.RegistrationWebhook
+	dst.Spec.AgentConfig.IgnitionProfile = restored.Spec.AgentConfig.IgnitionProfile
+	dst.Spec.AgentConfig.KubeletConfiguration = restored.Spec.AgentConfig.KubeletConfiguration
+	dst.Spec.BootstrapData = restored.Spec.BootstrapData
+	dst.Spec.TemplateContent = restored.Spec.TemplateContent
 
 	return nil
 }
@@ -241,6 +283,17 @@ func Convert_v1alpha1_RKE2ConfigSpec_To_v1beta1_RKE2ConfigSpec(in *bootstrapv1al
 	return bootstrapv1alpha1.Convert_v1alpha1_RKE2ConfigSpec_To_v1beta1_RKE2ConfigSpec(in, out, s)
 }
 
+func Convert_v1beta1_RolloutStrategy_To_v1alpha1_RolloutStrategy(in *controlplanev1.RolloutStrategy, out *RolloutStrategy, s apiconversion.Scope) error {
+	// PreUpgradeSnapshot was added in v1beta1.
+	return autoConvert_v1beta1_RolloutStrategy_To_v1alpha1_RolloutStrategy(in, out, s)
+}
+
+func Convert_v1beta1_EtcdConfig_To_v1alpha1_EtcdConfig(in *controlplanev1.EtcdConfig, out *EtcdConfig, s apiconversion.Scope) error {
+	// Maintenance was added in v1beta1.
+	// MemberHealth was added in v1beta1.
+	return autoConvert_v1beta1_EtcdConfig_To_v1alpha1_EtcdConfig(in, out, s)
+}
+
 func Convert_v1beta1_RKE2ServerConfig_To_v1alpha1_RKE2ServerConfig(in *controlplanev1.RKE2ServerConfig, out *RKE2ServerConfig, s apiconversion.Scope) error {
 	return autoConvert_v1beta1_RKE2ServerConfig_To_v1alpha1_RKE2ServerConfig(in, out, s)
 }
@@ -66,11 +66,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1beta1.EtcdConfig)(nil), (*EtcdConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1beta1_EtcdConfig_To_v1alpha1_EtcdConfig(a.(*v1beta1.EtcdConfig), b.(*EtcdConfig), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddGeneratedConversionFunc((*EtcdS3)(nil), (*v1beta1.EtcdS3)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_EtcdS3_To_v1beta1_EtcdS3(a.(*EtcdS3), b.(*v1beta1.EtcdS3), scope)
 	}); err != nil {
@@ -146,11 +141,6 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
-	if err := s.AddGeneratedConversionFunc((*v1beta1.RolloutStrategy)(nil), (*RolloutStrategy)(nil), func(a, b interface{}, scope conversion.Scope) error {
-		return Convert_v1beta1_RolloutStrategy_To_v1alpha1_RolloutStrategy(a.(*v1beta1.RolloutStrategy), b.(*RolloutStrategy), scope)
-	}); err != nil {
-		return err
-	}
 	if err := s.AddConversionFunc((*apiv1alpha1.RKE2ConfigSpec)(nil), (*apiv1beta1.RKE2ConfigSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_RKE2ConfigSpec_To_v1beta1_RKE2ConfigSpec(a.(*apiv1alpha1.RKE2ConfigSpec), b.(*apiv1beta1.RKE2ConfigSpec), scope)
 	}); err != nil {
@@ -171,6 +161,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1beta1.EtcdConfig)(nil), (*EtcdConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_EtcdConfig_To_v1alpha1_EtcdConfig(a.(*v1beta1.EtcdConfig), b.(*EtcdConfig), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddConversionFunc((*apiv1beta1.RKE2ConfigSpec)(nil), (*apiv1alpha1.RKE2ConfigSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_RKE2ConfigSpec_To_v1alpha1_RKE2ConfigSpec(a.(*apiv1beta1.RKE2ConfigSpec), b.(*apiv1alpha1.RKE2ConfigSpec), scope)
 	}); err != nil {
@@ -201,6 +196,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddConversionFunc((*v1beta1.RolloutStrategy)(nil), (*RolloutStrategy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_RolloutStrategy_To_v1alpha1_RolloutStrategy(a.(*v1beta1.RolloutStrategy), b.(*RolloutStrategy), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -276,14 +276,11 @@ func autoConvert_v1beta1_EtcdConfig_To_v1alpha1_EtcdConfig(in *v1beta1.EtcdConfi
 		return err
 	}
 	out.CustomConfig = (*apiv1alpha1.ComponentConfig)(unsafe.Pointer(in.CustomConfig))
+	// WARNING: in.Maintenance requires manual conversion: does not exist in peer-type
+	// WARNING: in.MemberHealth requires manual conversion: does not exist in peer-type
 	return nil
 }
 
-// Convert_v1beta1_EtcdConfig_To_v1alpha1_EtcdConfig is an autogenerated conversion function.
-func Convert_v1beta1_EtcdConfig_To_v1alpha1_EtcdConfig(in *v1beta1.EtcdConfig, out *EtcdConfig, s conversion.Scope) error {
-	return autoConvert_v1beta1_EtcdConfig_To_v1alpha1_EtcdConfig(in, out, s)
-}
-
 func autoConvert_v1alpha1_EtcdS3_To_v1beta1_EtcdS3(in *EtcdS3, out *v1beta1.EtcdS3, s conversion.Scope) error {
 	out.Endpoint = in.Endpoint
 	out.EndpointCASecret = (*v1.ObjectReference)(unsafe.Pointer(in.EndpointCASecret))
@@ -403,7 +400,15 @@ func autoConvert_v1alpha1_RKE2ControlPlaneSpec_To_v1beta1_RKE2ControlPlaneSpec(i
 	out.NodeDrainTimeout = (*metav1.Duration)(unsafe.Pointer(in.NodeDrainTimeout))
 	out.RegistrationMethod = v1beta1.RegistrationMethod(in.RegistrationMethod)
 	out.RegistrationAddress = in.RegistrationAddress
-	out.RolloutStrategy = (*v1beta1.RolloutStrategy)(unsafe.Pointer(in.RolloutStrategy))
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(v1beta1.RolloutStrategy)
+		if err := Convert_v1alpha1_RolloutStrategy_To_v1beta1_RolloutStrategy(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RolloutStrategy = nil
+	}
 	return nil
 }
 
@@ -427,8 +432,26 @@ func autoConvert_v1beta1_RKE2ControlPlaneSpec_To_v1alpha1_RKE2ControlPlaneSpec(i
 	out.NodeDrainTimeout = (*metav1.Duration)(unsafe.Pointer(in.NodeDrainTimeout))
 	out.RegistrationMethod = RegistrationMethod(in.RegistrationMethod)
 	out.RegistrationAddress = in.RegistrationAddress
-	out.RolloutStrategy = (*RolloutStrategy)(unsafe.Pointer(in.RolloutStrategy))
+	// WARNING: in.RegistrationAddressFamily requires manual conversion: does not exist in peer-type
+	// WARNING: in.RegistrationServiceRef requires manual conversion: does not exist in peer-type
+	// WARNING: in.RegistrationWebhook requires manual conversion: does not exist in peer-type
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(RolloutStrategy)
+		if err := Convert_v1beta1_RolloutStrategy_To_v1alpha1_RolloutStrategy(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RolloutStrategy = nil
+	}
 	// WARNING: in.RemediationStrategy requires manual conversion: does not exist in peer-type
+	// WARNING: in.Restore requires manual conversion: does not exist in peer-type
+	// WARNING: in.CertificateRotation requires manual conversion: does not exist in peer-type
+	// WARNING: in.SecretsEncryptionRotation requires manual conversion: does not exist in peer-type
+	// WARNING: in.RolloutAfter requires manual conversion: does not exist in peer-type
+	// WARNING: in.RolloutBefore requires manual conversion: does not exist in peer-type
+	// WARNING: in.ControlPlaneEndpoint requires manual conversion: does not exist in peer-type
+	// WARNING: in.ControlPlaneEndpointManagement requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -463,6 +486,14 @@ func autoConvert_v1beta1_RKE2ControlPlaneStatus_To_v1alpha1_RKE2ControlPlaneStat
 	out.UnavailableReplicas = in.UnavailableReplicas
 	out.AvailableServerIPs = *(*[]string)(unsafe.Pointer(&in.AvailableServerIPs))
 	// WARNING: in.LastRemediation requires manual conversion: does not exist in peer-type
+	// WARNING: in.Restore requires manual conversion: does not exist in peer-type
+	// WARNING: in.EtcdSnapshots requires manual conversion: does not exist in peer-type
+	// WARNING: in.EtcdSnapshotsRefreshTime requires manual conversion: does not exist in peer-type
+	// WARNING: in.PreUpgradeSnapshot requires manual conversion: does not exist in peer-type
+	// WARNING: in.CertificateRotation requires manual conversion: does not exist in peer-type
+	// WARNING: in.SecretsEncryptionRotation requires manual conversion: does not exist in peer-type
+	// WARNING: in.CertificatesExpiry requires manual conversion: does not exist in peer-type
+	// WARNING: in.Etcd requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -582,6 +613,9 @@ func Convert_v1alpha1_RKE2ServerConfig_To_v1beta1_RKE2ServerConfig(in *RKE2Serve
 
 func autoConvert_v1beta1_RKE2ServerConfig_To_v1alpha1_RKE2ServerConfig(in *v1beta1.RKE2ServerConfig, out *RKE2ServerConfig, s conversion.Scope) error {
 	out.AuditPolicySecret = (*v1.ObjectReference)(unsafe.Pointer(in.AuditPolicySecret))
+	// WARNING: in.AuditLog requires manual conversion: does not exist in peer-type
+	// WARNING: in.AdmissionConfiguration requires manual conversion: does not exist in peer-type
+	// WARNING: in.SecretsEncryption requires manual conversion: does not exist in peer-type
 	out.BindAddress = in.BindAddress
 	out.AdvertiseAddress = in.AdvertiseAddress
 	out.TLSSan = *(*[]string)(unsafe.Pointer(&in.TLSSan))
@@ -642,10 +676,7 @@ func Convert_v1alpha1_RolloutStrategy_To_v1beta1_RolloutStrategy(in *RolloutStra
 func autoConvert_v1beta1_RolloutStrategy_To_v1alpha1_RolloutStrategy(in *v1beta1.RolloutStrategy, out *RolloutStrategy, s conversion.Scope) error {
 	out.Type = RolloutStrategyType(in.Type)
 	out.RollingUpdate = (*RollingUpdate)(unsafe.Pointer(in.RollingUpdate))
+	// WARNING: in.PreUpgradeSnapshot requires manual conversion: does not exist in peer-type
+	// WARNING: in.InPlace requires manual conversion: does not exist in peer-type
 	return nil
 }
-
-// Convert_v1beta1_RolloutStrategy_To_v1alpha1_RolloutStrategy is an autogenerated conversion function.
-func Convert_v1beta1_RolloutStrategy_To_v1alpha1_RolloutStrategy(in *v1beta1.RolloutStrategy, out *RolloutStrategy, s conversion.Scope) error {
-	return autoConvert_v1beta1_RolloutStrategy_To_v1alpha1_RolloutStrategy(in, out, s)
-}
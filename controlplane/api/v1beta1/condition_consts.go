@@ -77,6 +77,13 @@ const (
 	// RollingUpdateInProgressReason (Severity=Warning) documents a RKE2ControlPlane object executing a
 	// rolling upgrade for aligning the machines spec to the desired state.
 	RollingUpdateInProgressReason = "RollingUpdateInProgress"
+
+	// InPlaceUpgradeInProgressReason (Severity=Warning) documents a RKE2ControlPlane object upgrading
+	// the version of the machines in place, one at a time.
+	InPlaceUpgradeInProgressReason = "InPlaceUpgradeInProgress"
+
+	// InPlaceUpgradeFailedReason (Severity=Error) documents a failure to upgrade a machine in place.
+	InPlaceUpgradeFailedReason = "InPlaceUpgradeFailed"
 )
 
 const (
@@ -93,6 +100,15 @@ const (
 	// EtcdMemberInspectionFailedReason documents a failure in inspecting the etcd member status.
 	EtcdMemberInspectionFailedReason = "MemberInspectionFailed"
 
+	// EtcdMemberUnhealthyReason documents an etcd member reporting errors, being a learner, or exceeding
+	// the member health thresholds of the RKE2ControlPlane.
+	EtcdMemberUnhealthyReason = "EtcdMemberUnhealthy"
+
+	// EtcdMemberNoSpaceReason (Severity=Warning) documents an etcd member whose only issue is a NOSPACE alarm. The
+	// member still takes part in the quorum, and the alarm is disarmed by the etcd maintenance once the member is
+	// defragmented.
+	EtcdMemberNoSpaceReason = "EtcdMemberNoSpace"
+
 	// ResizedCondition documents a RKE2ControlPlane that is resizing the set of controlled machines.
 	ResizedCondition clusterv1.ConditionType = "Resized"
 
@@ -110,3 +126,112 @@ const (
 	// CertificatesGenerationFailedReason documents a failure in generating the certificates.
 	CertificatesGenerationFailedReason string = "CertificateGenerationFailed"
 )
+
+const (
+	// EtcdRestoredCondition documents the status of an etcd snapshot restore requested on the RKE2ControlPlane.
+	EtcdRestoredCondition clusterv1.ConditionType = "EtcdRestored"
+
+	// EtcdRestoreScalingDownReason (Severity=Info) documents a RKE2ControlPlane scaling down to a single
+	// machine before restoring etcd from a snapshot.
+	EtcdRestoreScalingDownReason = "EtcdRestoreScalingDown"
+
+	// EtcdRestoreResettingReason (Severity=Info) documents a RKE2ControlPlane waiting for its last machine to reset
+	// the etcd cluster from a snapshot.
+	EtcdRestoreResettingReason = "EtcdRestoreResetting"
+
+	// EtcdRestoreFailedReason (Severity=Error) documents a failure while restoring etcd from a snapshot.
+	EtcdRestoreFailedReason = "EtcdRestoreFailed"
+)
+
+const (
+	// PreUpgradeSnapshotTakenCondition documents the etcd snapshot taken before rolling out a new version
+	// of the control plane, when enabled in the rollout strategy.
+	PreUpgradeSnapshotTakenCondition clusterv1.ConditionType = "PreUpgradeSnapshotTaken"
+
+	// PreUpgradeSnapshotInProgressReason (Severity=Info) documents a RKE2ControlPlane waiting for the etcd
+	// snapshot to be taken before rolling out a new version.
+	PreUpgradeSnapshotInProgressReason = "PreUpgradeSnapshotInProgress"
+
+	// PreUpgradeSnapshotFailedReason (Severity=Error) documents a failure to take the etcd snapshot
+	// before rolling out a new version.
+	PreUpgradeSnapshotFailedReason = "PreUpgradeSnapshotFailed"
+)
+
+const (
+	// CertificateAuthoritiesValidCondition documents that the certificate authorities of the cluster are not
+	// about to expire.
+	CertificateAuthoritiesValidCondition clusterv1.ConditionType = "CertificateAuthoritiesValid"
+
+	// CertificateAuthoritiesExpiringSoonReason (Severity=Warning) documents a certificate authority of the cluster
+	// expiring soon, it should be rotated.
+	CertificateAuthoritiesExpiringSoonReason = "CertificateAuthoritiesExpiringSoon"
+
+	// CertificateAuthoritiesExpiredReason (Severity=Error) documents an expired certificate authority of the cluster.
+	CertificateAuthoritiesExpiredReason = "CertificateAuthoritiesExpired"
+
+	// CertificatesRotatedCondition documents that the certificate rotations requested in the RKE2ControlPlane
+	// have completed.
+	CertificatesRotatedCondition clusterv1.ConditionType = "CertificatesRotated"
+
+	// CertificateRotationInProgressReason (Severity=Info) documents a RKE2ControlPlane rotating certificates.
+	CertificateRotationInProgressReason = "CertificateRotationInProgress"
+
+	// CertificateRotationFailedReason (Severity=Error) documents a failure while rotating certificates.
+	CertificateRotationFailedReason = "CertificateRotationFailed"
+)
+
+const (
+	// SecretsEncryptionKeysRotatedCondition documents that the secrets encryption keys rotation requested in the
+	// RKE2ControlPlane has completed.
+	SecretsEncryptionKeysRotatedCondition clusterv1.ConditionType = "SecretsEncryptionKeysRotated"
+
+	// SecretsEncryptionKeysRotationInProgressReason (Severity=Info) documents a RKE2ControlPlane rotating its
+	// secrets encryption keys.
+	SecretsEncryptionKeysRotationInProgressReason = "SecretsEncryptionKeysRotationInProgress"
+
+	// SecretsEncryptionKeysRotationFailedReason (Severity=Error) documents a failure while rotating the secrets
+	// encryption keys.
+	SecretsEncryptionKeysRotationFailedReason = "SecretsEncryptionKeysRotationFailed"
+)
+
+const (
+	// CertificatesExpiringSoonCondition documents that serving certificates of the control plane Nodes are about
+	// to expire. Unlike most conditions, it is true when attention is needed.
+	CertificatesExpiringSoonCondition clusterv1.ConditionType = "CertificatesExpiringSoon"
+
+	// MachineCertificatesExpiringSoonReason (Severity=Warning) documents control plane Machines whose Node
+	// serving certificates are about to expire.
+	MachineCertificatesExpiringSoonReason = "MachineCertificatesExpiringSoon"
+)
+
+const (
+	// ConfigDriftedCondition documents that the configuration running on the Node of a control plane Machine differs
+	// from the configuration the Machine was created with, e.g. after a manual edit on the Node. It is set on the
+	// Machines and aggregated on the RKE2ControlPlane. Unlike most conditions, it is true when attention is needed.
+	ConfigDriftedCondition clusterv1.ConditionType = "ConfigDrifted"
+
+	// MachineConfigDriftedReason (Severity=Warning) documents control plane Machines whose Node configuration has
+	// drifted from their specification.
+	MachineConfigDriftedReason = "MachineConfigDrifted"
+
+	// ConfigDriftInspectionFailedReason documents a failure to read the configuration running on a Node.
+	ConfigDriftInspectionFailedReason = "ConfigDriftInspectionFailed"
+)
+
+const (
+	// EtcdMaintenanceSucceededCondition documents that the automated etcd maintenance requested in the RKE2ControlPlane
+	// has not failed. It only exists when etcd maintenance is enabled.
+	EtcdMaintenanceSucceededCondition clusterv1.ConditionType = "EtcdMaintenanceSucceeded"
+
+	// EtcdDefragmentationInProgressReason (Severity=Info) documents a RKE2ControlPlane defragmenting its etcd members.
+	EtcdDefragmentationInProgressReason = "EtcdDefragmentationInProgress"
+
+	// EtcdDefragmentationFailedReason (Severity=Warning) documents a failure to defragment an etcd member.
+	EtcdDefragmentationFailedReason = "EtcdDefragmentationFailed"
+
+	// EtcdAlarmDisarmFailedReason (Severity=Warning) documents a failure to disarm the NOSPACE alarm of an etcd member.
+	EtcdAlarmDisarmFailedReason = "EtcdAlarmDisarmFailed"
+
+	// EtcdMaintenanceInspectionFailedReason (Severity=Warning) documents a failure to read the status of an etcd member.
+	EtcdMaintenanceInspectionFailedReason = "EtcdMaintenanceInspectionFailed"
+)
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -64,6 +65,47 @@ const (
 	// of the `node.kubernetes.io/exclude-from-external-load-balancers` label on control plane Nodes during Machine deletion.
 	// This label can be consumed by load balancers to stop advertising a Node.
 	LoadBalancerExclusionAnnotation = "rke2.controlplane.cluster.x-k8s.io/load-balancer-exclusion"
+
+	// InPlaceUpgradedVersionAnnotation is set on control plane Machines whose Node has been upgraded in place.
+	// It stores the RKE2 version the Node runs, which takes precedence over the version of the Machine spec.
+	InPlaceUpgradedVersionAnnotation = "controlplane.cluster.x-k8s.io/in-place-upgraded-version"
+
+	// LeafCertificatesRotationAnnotation is set on control plane Machines once the leaf certificates of their Node
+	// have been rotated. It stores the value of the leaf certificates rotation requested in the RKE2ControlPlane.
+	LeafCertificatesRotationAnnotation = "controlplane.cluster.x-k8s.io/leaf-certificates-rotation"
+
+	// CertificateAuthoritiesRotationAnnotation is set on control plane Machines running with the rotated
+	// certificate authorities. It stores the value of the certificate authorities rotation requested in the RKE2ControlPlane.
+	CertificateAuthoritiesRotationAnnotation = "controlplane.cluster.x-k8s.io/certificate-authorities-rotation"
+
+	// SecretsEncryptionRotationAnnotation is set on control plane Machines once their Node has gone through a stage
+	// of a secrets encryption keys rotation. It stores the value of the rotation requested in the RKE2ControlPlane
+	// along with the stage.
+	SecretsEncryptionRotationAnnotation = "controlplane.cluster.x-k8s.io/secrets-encryption-rotation"
+
+	// APIServerCertificateExpiryAnnotation is set on control plane Machines with the expiry date, in RFC3339 format,
+	// of the kube-apiserver serving certificate of their Node.
+	APIServerCertificateExpiryAnnotation = "controlplane.cluster.x-k8s.io/kube-apiserver-certificate-expiry"
+
+	// EtcdCertificateExpiryAnnotation is set on control plane Machines with the expiry date, in RFC3339 format,
+	// of the etcd serving certificate of their Node.
+	EtcdCertificateExpiryAnnotation = "controlplane.cluster.x-k8s.io/etcd-certificate-expiry"
+
+	// KubeletCertificateExpiryAnnotation is set on control plane Machines with the expiry date, in RFC3339 format,
+	// of the kubelet serving certificate of their Node.
+	KubeletCertificateExpiryAnnotation = "controlplane.cluster.x-k8s.io/kubelet-certificate-expiry"
+
+	// EtcdDBSizeAnnotation is set on control plane Machines with the size in bytes of the etcd database of their Node,
+	// when etcd maintenance is enabled.
+	EtcdDBSizeAnnotation = "controlplane.cluster.x-k8s.io/etcd-db-size"
+
+	// EtcdDBSizeInUseAnnotation is set on control plane Machines with the size in bytes of the etcd database of their
+	// Node actually in use, when etcd maintenance is enabled. The difference with the database size is fragmentation.
+	EtcdDBSizeInUseAnnotation = "controlplane.cluster.x-k8s.io/etcd-db-size-in-use"
+
+	// EtcdDefragmentedAnnotation is set on control plane Machines with the date, in RFC3339 format, the etcd member
+	// of their Node was last defragmented.
+	EtcdDefragmentedAnnotation = "controlplane.cluster.x-k8s.io/etcd-defragmented"
 )
 
 // RKE2ControlPlaneSpec defines the desired state of RKE2ControlPlane.
@@ -111,21 +153,78 @@ type RKE2ControlPlaneSpec struct {
 	NodeDrainTimeout *metav1.Duration `json:"nodeDrainTimeout,omitempty"`
 
 	// RegistrationMethod is the method to use for registering nodes into the RKE2 cluster.
-	// +kubebuilder:validation:Enum=internal-first;internal-only-ips;external-only-ips;address;control-plane-endpoint;""
+	// +kubebuilder:validation:Enum=internal-first;internal-only-ips;external-only-ips;address;control-plane-endpoint;dns;service;webhook;""
 	// +optional
 	RegistrationMethod RegistrationMethod `json:"registrationMethod,omitempty"`
 
 	// RegistrationAddress is an explicit address to use when registering a node. This is required if
 	// the registration type is "address". Its for scenarios where a load-balancer or VIP is used.
+	// With the "dns" registration type, this is the DNS name the addresses are resolved from.
 	// +optional
 	RegistrationAddress string `json:"registrationAddress,omitempty"`
 
+	// RegistrationAddressFamily is the IP family of the addresses preferred for registering nodes, for dual-stack
+	// clusters. Addresses of the other family are only used for machines without an address of this family.
+	// It applies to the "internal-first", "internal-only-ips", "external-only-ips", "dns", "service" and "webhook"
+	// registration types.
+	// +kubebuilder:validation:Enum=IPv4;IPv6
+	// +optional
+	RegistrationAddressFamily corev1.IPFamily `json:"registrationAddressFamily,omitempty"`
+
+	// RegistrationServiceRef references the Service of the management cluster the registration addresses are read
+	// from. This is required if the registration type is "service". The namespace defaults to the namespace of the
+	// RKE2ControlPlane.
+	// +optional
+	RegistrationServiceRef *corev1.ObjectReference `json:"registrationServiceRef,omitempty"`
+
+	// RegistrationWebhook is the webhook the registration addresses are requested from. This is required if the
+	// registration type is "webhook".
+	// +optional
+	RegistrationWebhook *RegistrationWebhook `json:"registrationWebhook,omitempty"`
+
 	// The RolloutStrategy to use to replace control plane machines with new ones.
 	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy"`
 
 	// remediationStrategy is the RemediationStrategy that controls how control plane machine remediation happens.
 	// +optional
 	RemediationStrategy *RemediationStrategy `json:"remediationStrategy,omitempty"`
+
+	// Restore defines an etcd snapshot the control plane should be restored from.
+	// When a new snapshot name is set, the control plane is scaled down to its oldest Machine, which is retained
+	// and resets the etcd cluster from the snapshot in place with `rke2 server --cluster-reset`. The remaining
+	// replicas rejoin the cluster once the reset has completed.
+	// +optional
+	Restore *EtcdRestore `json:"restore,omitempty"`
+
+	// CertificateRotation requests the rotation of the certificates of the control plane.
+	// +optional
+	CertificateRotation *CertificateRotation `json:"certificateRotation,omitempty"`
+
+	// SecretsEncryptionRotation requests the rotation of the keys encrypting the Secrets of the workload cluster.
+	// +optional
+	SecretsEncryptionRotation *SecretsEncryptionRotation `json:"secretsEncryptionRotation,omitempty"`
+
+	// RolloutAfter is a field to indicate a rollout should be performed
+	// after the specified time even if no changes have been made to the
+	// RKE2ControlPlane. Machines created before this time are replaced.
+	// +optional
+	RolloutAfter *metav1.Time `json:"rolloutAfter,omitempty"`
+
+	// RolloutBefore is a field to indicate a rollout should be performed
+	// if the specified criteria is met.
+	// +optional
+	RolloutBefore *RolloutBefore `json:"rolloutBefore,omitempty"`
+
+	// ControlPlaneEndpoint is the endpoint used to communicate with the control plane. It is copied to the
+	// Cluster by Cluster API when the Cluster does not define one, and defaults to the virtual IP address when
+	// the control plane endpoint is managed.
+	// +optional
+	ControlPlaneEndpoint clusterv1.APIEndpoint `json:"controlPlaneEndpoint,omitempty"`
+
+	// ControlPlaneEndpointManagement configures kube-vip to announce a virtual IP address as the control plane
+	// endpoint from the control plane nodes, for clusters without an external load balancer.
+	// +optional
+	ControlPlaneEndpointManagement *ControlPlaneEndpointManagement `json:"controlPlaneEndpointManagement,omitempty"`
 }
 
 // RKE2ControlPlaneMachineTemplate defines the template for Machines
@@ -164,6 +263,20 @@ type RKE2ServerConfig struct {
 	//+optional
 	AuditPolicySecret *corev1.ObjectReference `json:"auditPolicySecret,omitempty"`
 
+	// AuditLog configures the audit log backends of the Kube API Server.
+	//+optional
+	AuditLog *AuditLogConfig `json:"auditLog,omitempty"`
+
+	// AdmissionConfiguration configures the admission plugins of the Kube API Server. It replaces the
+	// Pod Security Admission configuration generated by RKE2, and can not be used with agentConfig.podSecurityAdmissionConfigFile.
+	//+optional
+	AdmissionConfiguration *AdmissionConfiguration `json:"admissionConfiguration,omitempty"`
+
+	// SecretsEncryption configures the encryption at rest of the Secrets of the workload cluster.
+	// RKE2 encrypts them with the aescbc provider if not set.
+	// +optional
+	SecretsEncryption *SecretsEncryption `json:"secretsEncryption,omitempty"`
+
 	// BindAddress describes the rke2 bind address (default: 0.0.0.0).
 	//+optional
 	BindAddress string `json:"bindAddress,omitempty"`
@@ -302,6 +415,39 @@ type RKE2ControlPlaneStatus struct {
 	// lastRemediation stores info about last remediation performed.
 	// +optional
 	LastRemediation *LastRemediationStatus `json:"lastRemediation,omitempty"`
+
+	// Restore reports the progress of the last etcd snapshot restore.
+	// +optional
+	Restore *EtcdRestoreStatus `json:"restore,omitempty"`
+
+	// EtcdSnapshots is the list of the most recent etcd snapshots taken by RKE2 on the control plane nodes,
+	// most recent first. It is refreshed every few minutes, and only reports up to 20 snapshots.
+	// +optional
+	EtcdSnapshots []EtcdSnapshotFile `json:"etcdSnapshots,omitempty"`
+
+	// EtcdSnapshotsRefreshTime is the last time the etcd snapshots were listed.
+	// +optional
+	EtcdSnapshotsRefreshTime *metav1.Time `json:"etcdSnapshotsRefreshTime,omitempty"`
+
+	// PreUpgradeSnapshot reports the etcd snapshot taken before the last version upgrade of the control plane.
+	// +optional
+	PreUpgradeSnapshot *PreUpgradeSnapshotStatus `json:"preUpgradeSnapshot,omitempty"`
+
+	// CertificateRotation reports the progress of the certificate rotations requested in the spec.
+	// +optional
+	CertificateRotation *CertificateRotationStatus `json:"certificateRotation,omitempty"`
+
+	// SecretsEncryptionRotation reports the progress of the secrets encryption keys rotation requested in the spec.
+	// +optional
+	SecretsEncryptionRotation *SecretsEncryptionRotationStatus `json:"secretsEncryptionRotation,omitempty"`
+
+	// CertificatesExpiry is the expiry date of each certificate authority managed for the cluster.
+	// +optional
+	CertificatesExpiry []CertificateExpiry `json:"certificatesExpiry,omitempty"`
+
+	// Etcd reports the status of the embedded etcd members of the control plane.
+	// +optional
+	Etcd *EtcdStatus `json:"etcd,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -340,6 +486,119 @@ type EtcdConfig struct {
 
 	// CustomConfig defines the custom settings for ETCD.
 	CustomConfig *bootstrapv1.ComponentConfig `json:"customConfig,omitempty"`
+
+	// Maintenance enables the automated maintenance of the etcd members: defragmentation and NOSPACE alarm disarm.
+	// +optional
+	Maintenance *EtcdMaintenance `json:"maintenance,omitempty"`
+
+	// MemberHealth defines the thresholds from which an etcd member is reported unhealthy, in addition to the member
+	// being unreachable, reporting errors or being a learner.
+	// +optional
+	MemberHealth *EtcdMemberHealth `json:"memberHealth,omitempty"`
+}
+
+// EtcdMemberHealth defines the thresholds from which an etcd member is reported unhealthy. A threshold which is
+// not set is not checked.
+type EtcdMemberHealth struct {
+	// MaxRaftIndexLag is the number of raft entries an etcd member can be behind the leader.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxRaftIndexLag *int64 `json:"maxRaftIndexLag,omitempty"`
+
+	// MaxRoundTripTime is the time the status of an etcd member can take to be read, e.g. 500ms.
+	// +optional
+	MaxRoundTripTime *metav1.Duration `json:"maxRoundTripTime,omitempty"`
+}
+
+// EtcdMaintenance defines when the etcd members are defragmented. The members are defragmented one at a time,
+// the leader last, when the control plane is stable, and a member raising a NOSPACE alarm is always defragmented.
+type EtcdMaintenance struct {
+	// DefragmentationInterval is the interval at which every etcd member is defragmented, e.g. 168h.
+	// +optional
+	DefragmentationInterval *metav1.Duration `json:"defragmentationInterval,omitempty"`
+
+	// FragmentationThresholdPercent defragments an etcd member when the share of its database not in use
+	// reaches this percentage.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	FragmentationThresholdPercent *int32 `json:"fragmentationThresholdPercent,omitempty"`
+
+	// MinDBSize is the database size under which an etcd member is not defragmented for reaching the fragmentation
+	// threshold, small databases being fragmented is not an issue. Defaults to 100Mi.
+	// +optional
+	MinDBSize *resource.Quantity `json:"minDBSize,omitempty"`
+
+	// DisarmNoSpaceAlarm disarms the NOSPACE alarm of an etcd member once it has been defragmented, which
+	// makes the etcd cluster writable again, when the database is back under its quota.
+	// +optional
+	DisarmNoSpaceAlarm bool `json:"disarmNoSpaceAlarm,omitempty"`
+}
+
+// EtcdStatus reports the status of the embedded etcd members of the control plane.
+type EtcdStatus struct {
+	// LastUpdateTime is the time the status of the members was last refreshed. It is refreshed at most every minute,
+	// or when the control plane Machines change.
+	// +optional
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+
+	// Members is the status of the etcd member of each control plane Machine, sorted by Machine name.
+	// +optional
+	Members []EtcdMemberStatus `json:"members,omitempty"`
+}
+
+// EtcdMemberStatus reports the status of the etcd member of a control plane Machine.
+type EtcdMemberStatus struct {
+	// MachineName is the name of the Machine running the member.
+	MachineName string `json:"machineName"`
+
+	// NodeName is the name of the Node running the member.
+	NodeName string `json:"nodeName"`
+
+	// ID is the hexadecimal ID of the member.
+	// +optional
+	ID string `json:"id,omitempty"`
+
+	// LeaderID is the hexadecimal ID of the leader of the etcd cluster, as seen by the member.
+	// +optional
+	LeaderID string `json:"leaderID,omitempty"`
+
+	// IsLeader is true when the member is the leader of the etcd cluster.
+	// +optional
+	IsLeader bool `json:"isLeader,omitempty"`
+
+	// IsLearner is true when the member is a learner, which does not vote yet.
+	// +optional
+	IsLearner bool `json:"isLearner,omitempty"`
+
+	// DBSize is the size in bytes of the database of the member.
+	// +optional
+	DBSize int64 `json:"dbSize,omitempty"`
+
+	// DBSizeInUse is the size in bytes of the database of the member actually in use.
+	// +optional
+	DBSizeInUse int64 `json:"dbSizeInUse,omitempty"`
+
+	// RaftIndex is the raft index of the member.
+	// +optional
+	RaftIndex int64 `json:"raftIndex,omitempty"`
+
+	// RaftIndexLag is the number of raft entries the member is behind the leader. It is only reported when the
+	// status of the leader could be read.
+	// +optional
+	RaftIndexLag *int64 `json:"raftIndexLag,omitempty"`
+
+	// RoundTripTime is the time the status of the member took to be read.
+	// +optional
+	RoundTripTime metav1.Duration `json:"roundTripTime,omitempty"`
+
+	// Errors are the errors reported by the member, without the alarms of the cluster.
+	// +optional
+	Errors []string `json:"errors,omitempty"`
+
+	// Alarms are the alarms raised by the member, like NOSPACE or CORRUPT.
+	// +optional
+	Alarms []string `json:"alarms,omitempty"`
 }
 
 // EtcdBackupConfig describes the backup configuration for ETCD.
@@ -370,6 +629,230 @@ type EtcdBackupConfig struct {
 	S3 *EtcdS3 `json:"s3,omitempty"`
 }
 
+// EtcdRestore defines the etcd snapshot to restore the control plane from.
+type EtcdRestore struct {
+	// SnapshotName is the name of the snapshot to restore.
+	// If S3 is configured in the etcd backup configuration, the snapshot is downloaded from the S3 bucket,
+	// otherwise it must be the path to a snapshot file available on the oldest control plane Machine,
+	// which is the one resetting etcd.
+	// +kubebuilder:validation:MinLength=1
+	SnapshotName string `json:"snapshotName"`
+
+	// Image is the container image used to run the Job resetting etcd on the node. It needs to provide `sh`
+	// and `chroot` binaries. If not set, a default image is used.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// EtcdRestorePhase describes the progress of an etcd snapshot restore.
+type EtcdRestorePhase string
+
+const (
+	// EtcdRestorePhaseScalingDown means the control plane is being scaled down to a single Machine.
+	EtcdRestorePhaseScalingDown EtcdRestorePhase = "ScalingDown"
+
+	// EtcdRestorePhaseResetting means the last remaining Machine is resetting the etcd cluster from the snapshot.
+	EtcdRestorePhaseResetting EtcdRestorePhase = "Resetting"
+
+	// EtcdRestorePhaseCompleted means the etcd cluster has been restored, and the remaining replicas can rejoin.
+	EtcdRestorePhaseCompleted EtcdRestorePhase = "Completed"
+
+	// EtcdRestorePhaseFailed means the etcd cluster could not be reset from the snapshot. The restore is retried
+	// by removing it from the spec, or by setting a different snapshot.
+	EtcdRestorePhaseFailed EtcdRestorePhase = "Failed"
+)
+
+// EtcdRestoreStatus reports the progress of an etcd snapshot restore.
+type EtcdRestoreStatus struct {
+	// SnapshotName is the name of the snapshot being restored.
+	SnapshotName string `json:"snapshotName"`
+
+	// Phase is the current phase of the restore.
+	// +optional
+	Phase EtcdRestorePhase `json:"phase,omitempty"`
+
+	// Machine is the name of the Machine resetting the etcd cluster from the snapshot.
+	// +optional
+	Machine string `json:"machine,omitempty"`
+
+	// StartTime is the time the restore was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the etcd cluster was restored from the snapshot.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// PreUpgradeSnapshotStatus reports the etcd snapshot taken before a version upgrade of the control plane.
+type PreUpgradeSnapshotStatus struct {
+	// Version is the version of the control plane the snapshot was taken for.
+	Version string `json:"version"`
+
+	// Name is the name of the RKE2EtcdSnapshot taking the snapshot.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// SnapshotName is the name of the snapshot, as reported by RKE2.
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// Attempts is the number of RKE2EtcdSnapshots created for the version. A failed snapshot is taken again
+	// up to 3 attempts, then each deletion of the failed RKE2EtcdSnapshot gives it a new attempt.
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+}
+
+// CertificateRotation requests the rotation of the certificates of the control plane.
+// Each rotation is triggered by setting its field to a new value, e.g. a timestamp.
+type CertificateRotation struct {
+	// LeafCertificates rotates the RKE2 leaf certificates of the control plane nodes when set to a new value.
+	// The nodes are rotated one at a time by running `rke2 certificate rotate` and restarting RKE2.
+	// +optional
+	LeafCertificates string `json:"leafCertificates,omitempty"`
+
+	// CertificateAuthorities rotates the certificate authorities of the cluster when set to a new value.
+	// New certificate authorities, cross-signed by the current ones, are installed with `rke2 certificate rotate-ca`,
+	// then the control plane machines are rolled out so that they run with the new certificate authorities.
+	// It is not supported for legacy control planes, nor with an external datastore.
+	// +optional
+	CertificateAuthorities string `json:"certificateAuthorities,omitempty"`
+
+	// Image is the container image used to run the rotation Jobs on the nodes. It needs to provide `sh`,
+	// `cp` and `chroot` binaries. If not set, a default image is used.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// CertificateAuthoritiesRotationPhase describes the progress of a certificate authorities rotation.
+type CertificateAuthoritiesRotationPhase string
+
+const (
+	// CertificateAuthoritiesRotationPhasePreparing means the new certificate authorities are being generated.
+	CertificateAuthoritiesRotationPhasePreparing CertificateAuthoritiesRotationPhase = "Preparing"
+
+	// CertificateAuthoritiesRotationPhaseRotating means the new certificate authorities are being installed
+	// in the workload cluster with `rke2 certificate rotate-ca`.
+	CertificateAuthoritiesRotationPhaseRotating CertificateAuthoritiesRotationPhase = "Rotating"
+
+	// CertificateAuthoritiesRotationPhaseRollingOut means the control plane machines are being replaced
+	// by machines running with the new certificate authorities.
+	CertificateAuthoritiesRotationPhaseRollingOut CertificateAuthoritiesRotationPhase = "RollingOut"
+)
+
+// CertificateRotationStatus reports the progress of the certificate rotations.
+type CertificateRotationStatus struct {
+	// LeafCertificates is the value of the last leaf certificates rotation completed on all the control plane nodes.
+	// +optional
+	LeafCertificates string `json:"leafCertificates,omitempty"`
+
+	// CertificateAuthorities is the value of the last certificate authorities rotation completed.
+	// +optional
+	CertificateAuthorities string `json:"certificateAuthorities,omitempty"`
+
+	// CertificateAuthoritiesPhase is the phase of the certificate authorities rotation in progress, if any.
+	// +optional
+	CertificateAuthoritiesPhase CertificateAuthoritiesRotationPhase `json:"certificateAuthoritiesPhase,omitempty"`
+}
+
+// SecretsEncryptionProvider is the provider encrypting the Secrets of the workload cluster.
+type SecretsEncryptionProvider string
+
+const (
+	// SecretsEncryptionProviderAESCBC encrypts the Secrets with AES-CBC, with keys managed by RKE2.
+	SecretsEncryptionProviderAESCBC SecretsEncryptionProvider = "aescbc"
+	// SecretsEncryptionProviderSecretbox encrypts the Secrets with XSalsa20 and Poly1305, with keys managed by RKE2.
+	// It requires RKE2 v1.30 or newer.
+	SecretsEncryptionProviderSecretbox SecretsEncryptionProvider = "secretbox"
+	// SecretsEncryptionProviderKMS encrypts the Secrets with a KMS v2 plugin running on the control plane nodes.
+	SecretsEncryptionProviderKMS SecretsEncryptionProvider = "kms"
+)
+
+// SecretsEncryption configures the encryption at rest of the Secrets of the workload cluster.
+type SecretsEncryption struct {
+	// Provider is the provider encrypting the Secrets. With the kms provider, RKE2 secrets encryption is disabled
+	// in favor of an encryption configuration using the KMS plugin, and the keys are rotated by the KMS.
+	// +kubebuilder:validation:Enum=aescbc;secretbox;kms
+	// +kubebuilder:default=aescbc
+	// +optional
+	Provider SecretsEncryptionProvider `json:"provider,omitempty"`
+
+	// KMS configures the KMS v2 plugin encrypting the Secrets, it is required with the kms provider.
+	// +optional
+	KMS *KMSPlugin `json:"kms,omitempty"`
+}
+
+// KMSPlugin configures a KMS v2 plugin of the Kube API Server.
+type KMSPlugin struct {
+	// Name is the name of the KMS plugin.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// SocketPath is the path of the unix socket the KMS plugin listens on, on the control plane nodes.
+	// Its directory is mounted in the Kube API Server.
+	// +kubebuilder:validation:Pattern=`^/`
+	SocketPath string `json:"socketPath"`
+
+	// Timeout is the timeout of the calls to the KMS plugin, 3 seconds if not set.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// SecretsEncryptionRotation requests the rotation of the keys encrypting the Secrets of the workload cluster.
+type SecretsEncryptionRotation struct {
+	// Keys rotates the secrets encryption keys when set to a new value, e.g. a timestamp. A new key is
+	// prepared, made the primary key and all the Secrets are reencrypted with it, with `rke2 secrets-encrypt`.
+	// The control plane nodes are restarted one at a time after each stage. It is not supported with the kms provider.
+	// If a stage fails, setting a new value runs the failed stage again on all the nodes, the stages completed
+	// before are not run again.
+	// +optional
+	Keys string `json:"keys,omitempty"`
+
+	// Image is the container image used to run the rotation Jobs on the nodes. It needs to provide `sh`
+	// and `chroot` binaries. If not set, a default image is used.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// SecretsEncryptionRotationStage describes the progress of a secrets encryption keys rotation.
+type SecretsEncryptionRotationStage string
+
+const (
+	// SecretsEncryptionRotationStagePreparing means a new encryption key is being added to the nodes.
+	SecretsEncryptionRotationStagePreparing SecretsEncryptionRotationStage = "Preparing"
+
+	// SecretsEncryptionRotationStageRotating means the new encryption key is being made the primary key.
+	SecretsEncryptionRotationStageRotating SecretsEncryptionRotationStage = "Rotating"
+
+	// SecretsEncryptionRotationStageReencrypting means the Secrets are being reencrypted with the new encryption key,
+	// and the previous key is being removed.
+	SecretsEncryptionRotationStageReencrypting SecretsEncryptionRotationStage = "Reencrypting"
+)
+
+// SecretsEncryptionRotationStatus reports the progress of the secrets encryption keys rotation.
+type SecretsEncryptionRotationStatus struct {
+	// Keys is the value of the last secrets encryption keys rotation completed.
+	// +optional
+	Keys string `json:"keys,omitempty"`
+
+	// Stage is the stage of the secrets encryption keys rotation in progress, if any.
+	// +optional
+	Stage SecretsEncryptionRotationStage `json:"stage,omitempty"`
+
+	// LastRotationTime is the time the last secrets encryption keys rotation completed.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+}
+
+// CertificateExpiry reports the expiry date of a certificate authority managed for the cluster.
+type CertificateExpiry struct {
+	// Purpose is the purpose of the certificate authority, which is also the suffix of the Secret storing it.
+	Purpose string `json:"purpose"`
+
+	// NotAfter is the time the certificate authority expires.
+	NotAfter metav1.Time `json:"notAfter"`
+}
+
 // EtcdS3 defines the S3 configuration for ETCD snapshots.
 type EtcdS3 struct {
 	// Endpoint S3 endpoint url (default: "s3.amazonaws.com").
@@ -402,6 +885,174 @@ type EtcdS3 struct {
 	Folder string `json:"folder,omitempty"`
 }
 
+// AuditLogConfig configures the audit log backends of the Kube API Server.
+type AuditLogConfig struct {
+	// Path is the path of the audit log file on the control plane nodes, "-" meaning the standard output
+	// (default: "/var/lib/rancher/rke2/server/logs/audit.log").
+	//+optional
+	Path string `json:"path,omitempty"`
+
+	// MaxAge is the maximum number of days to retain the rotated audit log files (default: 30).
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	MaxAge *int32 `json:"maxAge,omitempty"`
+
+	// MaxBackups is the maximum number of rotated audit log files to retain (default: 10).
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	MaxBackups *int32 `json:"maxBackups,omitempty"`
+
+	// MaxSize is the maximum size in megabytes of the audit log file before it gets rotated (default: 100).
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	MaxSize *int32 `json:"maxSize,omitempty"`
+
+	// Webhook configures the webhook audit backend, sending the audit events to a remote API.
+	//+optional
+	Webhook *AuditWebhookConfig `json:"webhook,omitempty"`
+}
+
+// AuditWebhookMode is the strategy the webhook audit backend sends the audit events with.
+type AuditWebhookMode string
+
+const (
+	// AuditWebhookModeBatch buffers the audit events and sends them asynchronously.
+	AuditWebhookModeBatch AuditWebhookMode = "batch"
+	// AuditWebhookModeBlocking blocks the API server responses on sending each audit event.
+	AuditWebhookModeBlocking AuditWebhookMode = "blocking"
+	// AuditWebhookModeBlockingStrict is the same as blocking, but fails the requests whose audit events could not be sent.
+	AuditWebhookModeBlockingStrict AuditWebhookMode = "blocking-strict"
+)
+
+// AuditWebhookConfig configures the webhook audit backend of the Kube API Server.
+type AuditWebhookConfig struct {
+	// ConfigSecret is a reference to a Secret containing the kubeconfig of the remote API under the "webhook-config.yaml" key.
+	ConfigSecret corev1.ObjectReference `json:"configSecret"`
+
+	// Mode is the strategy the audit events are sent with, one of batch, blocking, blocking-strict (default: "batch").
+	//+kubebuilder:validation:Enum=batch;blocking;blocking-strict
+	//+optional
+	Mode AuditWebhookMode `json:"mode,omitempty"`
+
+	// InitialBackoff is the time to wait before retrying a failed request to the remote API (default: 10s).
+	//+optional
+	InitialBackoff *metav1.Duration `json:"initialBackoff,omitempty"`
+}
+
+// AdmissionConfiguration configures the admission plugins of the Kube API Server.
+type AdmissionConfiguration struct {
+	// PodSecurity configures the PodSecurity admission plugin. If not set, all the pods are privileged.
+	//+optional
+	PodSecurity *PodSecurityAdmission `json:"podSecurity,omitempty"`
+
+	// EventRateLimit enables and configures the EventRateLimit admission plugin.
+	//+optional
+	EventRateLimit *EventRateLimitAdmission `json:"eventRateLimit,omitempty"`
+
+	// Plugins lists additional admission plugins to enable, with their optional configuration.
+	//+optional
+	Plugins []AdmissionPlugin `json:"plugins,omitempty"`
+}
+
+// PodSecurityLevel is a Pod Security Standards level.
+type PodSecurityLevel string
+
+const (
+	// PodSecurityLevelPrivileged is the unrestricted Pod Security Standards level.
+	PodSecurityLevelPrivileged PodSecurityLevel = "privileged"
+	// PodSecurityLevelBaseline is the Pod Security Standards level preventing known privilege escalations.
+	PodSecurityLevelBaseline PodSecurityLevel = "baseline"
+	// PodSecurityLevelRestricted is the Pod Security Standards level following the pod hardening best practices.
+	PodSecurityLevelRestricted PodSecurityLevel = "restricted"
+)
+
+// PodSecurityAdmission configures the cluster-wide defaults and exemptions of the PodSecurity admission plugin.
+type PodSecurityAdmission struct {
+	// Enforce is the level enforced on the pods of the namespaces without the pod-security.kubernetes.io/enforce label (default: "privileged").
+	//+kubebuilder:validation:Enum=privileged;baseline;restricted
+	//+optional
+	Enforce PodSecurityLevel `json:"enforce,omitempty"`
+
+	// Audit is the level the violations of are audited in the namespaces without the pod-security.kubernetes.io/audit label (default: "privileged").
+	//+kubebuilder:validation:Enum=privileged;baseline;restricted
+	//+optional
+	Audit PodSecurityLevel `json:"audit,omitempty"`
+
+	// Warn is the level the violations of are warned about in the namespaces without the pod-security.kubernetes.io/warn label (default: "privileged").
+	//+kubebuilder:validation:Enum=privileged;baseline;restricted
+	//+optional
+	Warn PodSecurityLevel `json:"warn,omitempty"`
+
+	// Version is the Kubernetes version of the levels, e.g. "v1.31" (default: "latest").
+	//+optional
+	Version string `json:"version,omitempty"`
+
+	// ExemptUsernames lists the usernames whose requests are exempted from the checks.
+	//+optional
+	ExemptUsernames []string `json:"exemptUsernames,omitempty"`
+
+	// ExemptRuntimeClasses lists the runtime classes whose pods are exempted from the checks.
+	//+optional
+	ExemptRuntimeClasses []string `json:"exemptRuntimeClasses,omitempty"`
+
+	// ExemptNamespaces lists the namespaces exempted from the checks.
+	//+optional
+	ExemptNamespaces []string `json:"exemptNamespaces,omitempty"`
+}
+
+// EventRateLimitType is the bucket the events are rate limited by.
+type EventRateLimitType string
+
+const (
+	// EventRateLimitTypeServer limits the events received by the API server.
+	EventRateLimitTypeServer EventRateLimitType = "Server"
+	// EventRateLimitTypeNamespace limits the events received for each namespace.
+	EventRateLimitTypeNamespace EventRateLimitType = "Namespace"
+	// EventRateLimitTypeUser limits the events received from each user.
+	EventRateLimitTypeUser EventRateLimitType = "User"
+	// EventRateLimitTypeSourceAndObject limits the events received for each source and involved object.
+	EventRateLimitTypeSourceAndObject EventRateLimitType = "SourceAndObject"
+)
+
+// EventRateLimitAdmission configures the EventRateLimit admission plugin.
+type EventRateLimitAdmission struct {
+	// Limits are the rate limits applied to the events.
+	//+kubebuilder:validation:MinItems=1
+	Limits []EventRateLimit `json:"limits"`
+}
+
+// EventRateLimit is a rate limit applied to the events.
+type EventRateLimit struct {
+	// Type is the bucket the events are rate limited by, one of Server, Namespace, User, SourceAndObject.
+	//+kubebuilder:validation:Enum=Server;Namespace;User;SourceAndObject
+	Type EventRateLimitType `json:"type"`
+
+	// QPS is the number of events per second allowed.
+	//+kubebuilder:validation:Minimum=1
+	QPS int32 `json:"qps"`
+
+	// Burst is the number of events allowed above the QPS.
+	//+kubebuilder:validation:Minimum=1
+	Burst int32 `json:"burst"`
+
+	// CacheSize is the number of buckets kept for the Namespace, User and SourceAndObject types.
+	//+kubebuilder:validation:Minimum=0
+	//+optional
+	CacheSize int32 `json:"cacheSize,omitempty"`
+}
+
+// AdmissionPlugin is an admission plugin to enable.
+type AdmissionPlugin struct {
+	// Name is the name of the admission plugin, e.g. AlwaysPullImages.
+	//+kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ConfigMap is a reference to a ConfigMap containing the configuration of the admission plugin
+	// under the "configuration.yaml" key.
+	//+optional
+	ConfigMap *corev1.ObjectReference `json:"configMap,omitempty"`
+}
+
 // CNI defines the Cni options for deploying RKE2.
 type CNI string
 
@@ -524,17 +1175,140 @@ type LastRemediationStatus struct {
 	RetryCount int `json:"retryCount"`
 }
 
+// RegistrationWebhook is an HTTPS endpoint returning the registration addresses of a control plane, for the
+// "webhook" registration type.
+type RegistrationWebhook struct {
+	// URL is the HTTPS URL the registration addresses are requested from, with a POST request carrying the
+	// control plane and the addresses of its ready machines.
+	// +kubebuilder:validation:Pattern=`^https://`
+	URL string `json:"url"`
+
+	// CABundle is the PEM encoded CA bundle the certificate of the webhook is verified against. The system
+	// certificate authorities are used if not set.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
 // RolloutStrategy describes how to replace existing machines
 // with new ones.
 type RolloutStrategy struct {
-	// Type of rollout. Currently the only supported strategy is "RollingUpdate".
+	// Type of rollout. Supported strategies are "RollingUpdate" and "InPlace".
 	// Default is RollingUpdate.
+	// +kubebuilder:validation:Enum=RollingUpdate;InPlace
 	// +optional
 	Type RolloutStrategyType `json:"type,omitempty"`
 
 	// Rolling update config params. Present only if RolloutStrategyType = RollingUpdate.
 	// +optional
 	RollingUpdate *RollingUpdate `json:"rollingUpdate,omitempty"`
+
+	// PreUpgradeSnapshot takes an etcd snapshot before rolling out a new version of the control plane,
+	// and blocks the rollout until the snapshot succeeds. It is ignored when using an external datastore.
+	// +optional
+	PreUpgradeSnapshot bool `json:"preUpgradeSnapshot,omitempty"`
+
+	// In-place upgrade config params. Used only if RolloutStrategyType = InPlace.
+	// +optional
+	InPlace *InPlaceUpgrade `json:"inPlace,omitempty"`
+}
+
+// RolloutBefore describes when a rollout should be performed on the control plane machines.
+type RolloutBefore struct {
+	// CertificatesExpiryDays indicates a rollout needs to be performed if the
+	// serving certificates of the control plane Nodes will expire within the
+	// specified days.
+	// +kubebuilder:validation:Minimum=7
+	// +optional
+	CertificatesExpiryDays *int32 `json:"certificatesExpiryDays,omitempty"`
+
+	// MaxAge indicates a rollout needs to be performed on the machines
+	// older than the specified duration, e.g. to pick up a new base image
+	// behind the same infrastructure template. It must be at least 24h.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// ConfigDrifted indicates a rollout needs to be performed on the machines
+	// whose Node configuration drifted from the configuration they were
+	// created with, as reported by their ConfigDrifted condition.
+	// +optional
+	ConfigDrifted bool `json:"configDrifted,omitempty"`
+}
+
+// KubeVIPMode is the way kube-vip announces the control plane endpoint address.
+// +kubebuilder:validation:Enum=arp;bgp
+type KubeVIPMode string
+
+const (
+	// KubeVIPModeARP announces the address from the leader node with ARP, the nodes must share a layer 2 network.
+	KubeVIPModeARP KubeVIPMode = "arp"
+
+	// KubeVIPModeBGP announces the address from all the nodes to BGP peers.
+	KubeVIPModeBGP KubeVIPMode = "bgp"
+)
+
+// ControlPlaneEndpointManagement configures kube-vip to manage the control plane endpoint. kube-vip is deployed
+// as a DaemonSet from the manifests directory of the control plane nodes.
+type ControlPlaneEndpointManagement struct {
+	// Address is the virtual IP address of the control plane endpoint.
+	Address string `json:"address"`
+
+	// Mode is the way the address is announced, one of arp or bgp.
+	// +kubebuilder:default=arp
+	// +optional
+	Mode KubeVIPMode `json:"mode,omitempty"`
+
+	// Interface is the network interface the address is announced on. It is detected by kube-vip when not set
+	// in arp mode, and required in bgp mode, where the router ID is the address of the interface.
+	// +optional
+	Interface string `json:"interface,omitempty"`
+
+	// BGP configures the BGP peering, it is required in bgp mode.
+	// +optional
+	BGP *KubeVIPBGP `json:"bgp,omitempty"`
+
+	// Image is the kube-vip image. Defaults to ghcr.io/kube-vip/kube-vip:v0.8.9.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// KubeVIPBGP configures the BGP peering of kube-vip.
+type KubeVIPBGP struct {
+	// AS is the autonomous system number of the control plane nodes.
+	// +kubebuilder:validation:Minimum=1
+	AS uint32 `json:"as"`
+
+	// Peers are the BGP peers the address is announced to.
+	// +kubebuilder:validation:MinItems=1
+	Peers []KubeVIPBGPPeer `json:"peers"`
+}
+
+// KubeVIPBGPPeer is a BGP peer of kube-vip.
+type KubeVIPBGPPeer struct {
+	// Address is the IP address of the peer.
+	Address string `json:"address"`
+
+	// AS is the autonomous system number of the peer.
+	// +kubebuilder:validation:Minimum=1
+	AS uint32 `json:"as"`
+}
+
+// InPlaceUpgrade configures the system-upgrade-controller Plan used to upgrade control plane nodes in place.
+// The system-upgrade-controller must be installed in the workload cluster.
+type InPlaceUpgrade struct {
+	// Namespace is the namespace of the workload cluster the system-upgrade-controller runs in.
+	// Defaults to "system-upgrade".
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// ServiceAccountName is the service account the upgrade Jobs run with.
+	// Defaults to "system-upgrade".
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Image is the image used to upgrade RKE2 on the nodes, its tag is the version being upgraded to.
+	// Defaults to "rancher/rke2-upgrade".
+	// +optional
+	Image string `json:"image,omitempty"`
 }
 
 // RollingUpdate is used to control the desired behavior of rolling update.
@@ -545,6 +1319,9 @@ type RollingUpdate struct {
 	// Defaults to 1.
 	// Example: when this is set to 1, the control plane can be scaled
 	// up immediately when the rolling update starts.
+	// When this is set to 0, an outdated machine is deleted before its replacement
+	// is created, one at a time, as long as etcd can keep quorum. This requires
+	// at least 3 replicas.
 	// +optional
 	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
 }
@@ -557,6 +1334,10 @@ const (
 	// i.e. gradually scale up or down the old control planes and scale up or down the new one.
 	RollingUpdateStrategyType RolloutStrategyType = "RollingUpdate"
 
+	// InPlaceUpgradeStrategyType upgrades the RKE2 version of the existing control plane nodes one at a time,
+	// using the system-upgrade-controller. Changes other than the version are still rolled out by replacing machines.
+	InPlaceUpgradeStrategyType RolloutStrategyType = "InPlace"
+
 	// PreTerminateHookCleanupAnnotation is the annotation RKE2 sets on Machines to ensure it can later remove the
 	// etcd member right before Machine termination (i.e. before InfraMachine deletion).
 	// For RKE2 we need wait for all other pre-terminate hooks to finish to
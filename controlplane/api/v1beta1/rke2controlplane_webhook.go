@@ -19,13 +19,19 @@ package v1beta1
 import (
 	"context"
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -38,6 +44,14 @@ const (
 	defaultNodeDeletionTimeout     = 10 * time.Second
 	defaultNodeDrainTimeout        = 120 * time.Second
 	defaultNodeVolumeDetachTimeout = 300 * time.Second
+
+	minCertificatesExpiryDays = 7
+	maxCertificatesExpiryDays = 365
+	minRolloutMaxAge          = 24 * time.Hour
+
+	minEtcdDefragmentationInterval = time.Hour
+
+	defaultControlPlaneEndpointPort = 6443
 )
 
 // rke2ControlPlaneLogger is the RKE2ControlPlane webhook logger.
@@ -111,6 +125,14 @@ func (rd *RKE2ControlPlaneCustomDefaulter) Default(_ context.Context, obj runtim
 		rcp.Spec.MachineTemplate.NodeDeletionTimeout = &metav1.Duration{Duration: defaultNodeDeletionTimeout}
 	}
 
+	// Defaults the control plane endpoint to the managed address, Cluster API copies it to the Cluster
+	if rcp.Spec.ControlPlaneEndpointManagement != nil && rcp.Spec.ControlPlaneEndpoint.Host == "" {
+		rcp.Spec.ControlPlaneEndpoint = clusterv1.APIEndpoint{
+			Host: rcp.Spec.ControlPlaneEndpointManagement.Address,
+			Port: defaultControlPlaneEndpointPort,
+		}
+	}
+
 	// Set replicas to 1 if not set
 	if rcp.Spec.Replicas == nil {
 		replicas := int32(1)
@@ -140,6 +162,12 @@ func (rv *RKE2ControlPlaneCustomValidator) ValidateCreate(_ context.Context, obj
 	allErrs = append(allErrs, rcp.validateRegistrationMethod()...)
 	allErrs = append(allErrs, rcp.validateMachineTemplate()...)
 	allErrs = append(allErrs, rcp.validateSpec()...)
+	allErrs = append(allErrs, rcp.validateRestore()...)
+	allErrs = append(allErrs, rcp.validateRolloutStrategy(nil)...)
+	allErrs = append(allErrs, rcp.validateCertificateRotation()...)
+	allErrs = append(allErrs, rcp.validateRolloutBefore()...)
+	allErrs = append(allErrs, rcp.validateServerConfig()...)
+	allErrs = append(allErrs, validateControlPlaneEndpointManagement(&rcp.Spec, field.NewPath("spec"))...)
 
 	if len(allErrs) == 0 {
 		return nil, nil
@@ -168,6 +196,12 @@ func (rv *RKE2ControlPlaneCustomValidator) ValidateUpdate(_ context.Context, old
 	allErrs = append(allErrs, newControlplane.validateCNI()...)
 	allErrs = append(allErrs, newControlplane.validateMachineTemplate()...)
 	allErrs = append(allErrs, newControlplane.validateSpec()...)
+	allErrs = append(allErrs, newControlplane.validateRestore()...)
+	allErrs = append(allErrs, newControlplane.validateRolloutStrategy(oldControlplane)...)
+	allErrs = append(allErrs, newControlplane.validateCertificateRotation()...)
+	allErrs = append(allErrs, newControlplane.validateRolloutBefore()...)
+	allErrs = append(allErrs, newControlplane.validateServerConfig()...)
+	allErrs = append(allErrs, validateControlPlaneEndpointManagement(&newControlplane.Spec, field.NewPath("spec"))...)
 
 	oldSet := oldControlplane.Spec.RegistrationMethod != ""
 	if oldSet && newControlplane.Spec.RegistrationMethod != oldControlplane.Spec.RegistrationMethod {
@@ -246,6 +280,30 @@ func (r *RKE2ControlPlane) validateRegistrationMethod() field.ErrorList {
 		}
 	}
 
+	if r.Spec.RegistrationMethod == RegistrationMethodDNS {
+		if r.Spec.RegistrationAddress == "" {
+			allErrs = append(allErrs,
+				field.Invalid(field.NewPath("spec.registrationAddress"),
+					r.Spec.RegistrationAddress, "registrationAddress must be supplied when using registration method 'dns'"))
+		}
+	}
+
+	if r.Spec.RegistrationMethod == RegistrationMethodService {
+		if r.Spec.RegistrationServiceRef == nil || r.Spec.RegistrationServiceRef.Name == "" {
+			allErrs = append(allErrs,
+				field.Required(field.NewPath("spec.registrationServiceRef"),
+					"registrationServiceRef must be supplied when using registration method 'service'"))
+		}
+	}
+
+	if r.Spec.RegistrationMethod == RegistrationMethodWebhook {
+		if r.Spec.RegistrationWebhook == nil || r.Spec.RegistrationWebhook.URL == "" {
+			allErrs = append(allErrs,
+				field.Required(field.NewPath("spec.registrationWebhook"),
+					"registrationWebhook must be supplied when using registration method 'webhook'"))
+		}
+	}
+
 	return allErrs
 }
 
@@ -303,5 +361,314 @@ func (r *RKE2ControlPlane) validateSpec() field.ErrorList {
 		)
 	}
 
+	if r.Spec.RegistrationTokenMode == bootstrapv1.MachineRegistrationTokenMode {
+		allErrs = append(allErrs,
+			field.Forbidden(field.NewPath("spec", "registrationTokenMode"),
+				"control plane nodes can only register with the cluster token"))
+	}
+
+	return allErrs
+}
+
+func (r *RKE2ControlPlane) validateServerConfig() field.ErrorList {
+	allErrs := validateKubeAPIServerConfig(&r.Spec.ServerConfig, &r.Spec.AgentConfig, field.NewPath("spec"))
+
+	allErrs = append(allErrs, validateEtcdMaintenance(&r.Spec.ServerConfig, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateEtcdMemberHealth(&r.Spec.ServerConfig, field.NewPath("spec"))...)
+
+	return append(allErrs, validateSecretsEncryption(&r.Spec, field.NewPath("spec"))...)
+}
+
+// validateEtcdMemberHealth validates the etcd member health thresholds.
+func validateEtcdMemberHealth(serverConfig *RKE2ServerConfig, pathPrefix *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	health := serverConfig.Etcd.MemberHealth
+	if health == nil {
+		return allErrs
+	}
+
+	if maxRoundTripTime := health.MaxRoundTripTime; maxRoundTripTime != nil && maxRoundTripTime.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(
+			pathPrefix.Child("serverConfig", "etcd", "memberHealth", "maxRoundTripTime"),
+			maxRoundTripTime.Duration.String(), "must be positive"))
+	}
+
+	return allErrs
+}
+
+// validateEtcdMaintenance validates the etcd maintenance policy, which must not defragment the etcd members
+// more often than the controller allows.
+func validateEtcdMaintenance(serverConfig *RKE2ServerConfig, pathPrefix *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	maintenance := serverConfig.Etcd.Maintenance
+	if maintenance == nil {
+		return allErrs
+	}
+
+	maintenancePath := pathPrefix.Child("serverConfig", "etcd", "maintenance")
+
+	if interval := maintenance.DefragmentationInterval; interval != nil && interval.Duration < minEtcdDefragmentationInterval {
+		allErrs = append(allErrs, field.Invalid(maintenancePath.Child("defragmentationInterval"), interval.Duration.String(),
+			fmt.Sprintf("must be at least %s", minEtcdDefragmentationInterval)))
+	}
+
+	if minDBSize := maintenance.MinDBSize; minDBSize != nil && minDBSize.Sign() < 0 {
+		allErrs = append(allErrs, field.Invalid(maintenancePath.Child("minDBSize"), minDBSize.String(), "must not be negative"))
+	}
+
+	return allErrs
+}
+
+// validateSecretsEncryption validates the secrets encryption configuration and the rotation of its keys.
+func validateSecretsEncryption(spec *RKE2ControlPlaneSpec, pathPrefix *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	encryption := spec.ServerConfig.SecretsEncryption
+	if encryption == nil || encryption.Provider != SecretsEncryptionProviderKMS {
+		if encryption != nil && encryption.KMS != nil {
+			allErrs = append(allErrs, field.Forbidden(pathPrefix.Child("serverConfig", "secretsEncryption", "kms"),
+				"can only be set with the kms provider"))
+		}
+
+		return allErrs
+	}
+
+	if encryption.KMS == nil {
+		allErrs = append(allErrs, field.Required(pathPrefix.Child("serverConfig", "secretsEncryption", "kms"),
+			"is required with the kms provider"))
+	}
+
+	if spec.SecretsEncryptionRotation != nil && spec.SecretsEncryptionRotation.Keys != "" {
+		allErrs = append(allErrs, field.Forbidden(pathPrefix.Child("secretsEncryptionRotation", "keys"),
+			"the keys of the kms provider are rotated by the KMS"))
+	}
+
+	if spec.ServerConfig.KubeAPIServer != nil {
+		for i, arg := range spec.ServerConfig.KubeAPIServer.ExtraArgs {
+			if flag, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "="); flag == "encryption-provider-config" {
+				allErrs = append(allErrs, field.Forbidden(pathPrefix.Child("serverConfig", "kubeAPIServer", "extraArgs").Index(i),
+					"can not be set with the kms provider"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateKubeAPIServerConfig validates the audit log and admission configuration of the Kube API Server,
+// which must not conflict with its extra arguments and the Pod Security Admission configuration file.
+func validateKubeAPIServerConfig(
+	serverConfig *RKE2ServerConfig,
+	agentConfig *bootstrapv1.RKE2AgentConfig,
+	pathPrefix *field.Path,
+) field.ErrorList {
+	var allErrs field.ErrorList
+
+	serverConfigPath := pathPrefix.Child("serverConfig")
+
+	var extraArgs []string
+	if serverConfig.KubeAPIServer != nil {
+		extraArgs = serverConfig.KubeAPIServer.ExtraArgs
+	}
+
+	for i, arg := range extraArgs {
+		flag, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		argPath := serverConfigPath.Child("kubeAPIServer", "extraArgs").Index(i)
+
+		if serverConfig.AuditLog != nil && (strings.HasPrefix(flag, "audit-log-") || strings.HasPrefix(flag, "audit-webhook-")) {
+			allErrs = append(allErrs, field.Forbidden(argPath, "audit log arguments can not be set when auditLog is used"))
+		}
+
+		if serverConfig.AdmissionConfiguration != nil && (flag == "admission-control-config-file" || flag == "enable-admission-plugins") {
+			allErrs = append(allErrs, field.Forbidden(argPath, "admission arguments can not be set when admissionConfiguration is used"))
+		}
+	}
+
+	if serverConfig.AuditLog != nil && serverConfig.AuditLog.Webhook != nil && serverConfig.AuditLog.Webhook.ConfigSecret.Name == "" {
+		allErrs = append(allErrs, field.Required(serverConfigPath.Child("auditLog", "webhook", "configSecret", "name"), "is required"))
+	}
+
+	admission := serverConfig.AdmissionConfiguration
+	if admission == nil {
+		return allErrs
+	}
+
+	if agentConfig.PodSecurityAdmissionConfigFile != "" {
+		allErrs = append(allErrs, field.Forbidden(pathPrefix.Child("agentConfig", "podSecurityAdmissionConfigFile"),
+			"can not be set when serverConfig.admissionConfiguration is used"))
+	}
+
+	// PodSecurity and EventRateLimit are configured by their own fields, and NodeRestriction is always enabled by RKE2.
+	names := sets.New("PodSecurity", "EventRateLimit", "NodeRestriction")
+
+	for i, plugin := range admission.Plugins {
+		if names.Has(plugin.Name) {
+			allErrs = append(allErrs, field.Duplicate(serverConfigPath.Child("admissionConfiguration", "plugins").Index(i).Child("name"), plugin.Name))
+		}
+
+		names.Insert(plugin.Name)
+	}
+
+	return allErrs
+}
+
+func (r *RKE2ControlPlane) validateRestore() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.Restore == nil {
+		return allErrs
+	}
+
+	if r.Spec.Restore.SnapshotName == "" {
+		allErrs = append(allErrs,
+			field.Required(field.NewPath("spec", "restore", "snapshotName"), "is required"))
+	}
+
+	if r.Spec.ServerConfig.ExternalDatastoreSecret != nil {
+		allErrs = append(allErrs,
+			field.Forbidden(field.NewPath("spec", "restore"),
+				"etcd can not be restored from a snapshot when an external datastore is used"))
+	}
+
+	return allErrs
+}
+
+func (r *RKE2ControlPlane) validateCertificateRotation() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.CertificateRotation == nil || r.Spec.CertificateRotation.CertificateAuthorities == "" {
+		return allErrs
+	}
+
+	caPath := field.NewPath("spec", "certificateRotation", "certificateAuthorities")
+
+	// Legacy control planes do not manage the etcd certificate authorities, and RKE2 only rotates
+	// the certificate authorities of its embedded etcd.
+	if _, legacy := r.Annotations[LegacyRKE2ControlPlane]; legacy {
+		allErrs = append(allErrs, field.Forbidden(caPath,
+			"certificate authorities can not be rotated for a legacy control plane"))
+	}
+
+	if r.Spec.ServerConfig.ExternalDatastoreSecret != nil {
+		allErrs = append(allErrs, field.Forbidden(caPath,
+			"certificate authorities can not be rotated when an external datastore is used"))
+	}
+
+	return allErrs
+}
+
+func (r *RKE2ControlPlane) validateRolloutBefore() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Spec.RolloutBefore == nil {
+		return allErrs
+	}
+
+	rolloutBeforePath := field.NewPath("spec", "rolloutBefore")
+
+	// RKE2 issues leaf certificates valid for a year, a longer expiry threshold would roll out machines endlessly.
+	if days := r.Spec.RolloutBefore.CertificatesExpiryDays; days != nil && (*days < minCertificatesExpiryDays || *days >= maxCertificatesExpiryDays) {
+		allErrs = append(allErrs, field.Invalid(rolloutBeforePath.Child("certificatesExpiryDays"), *days,
+			fmt.Sprintf("must be at least %d and less than %d", minCertificatesExpiryDays, maxCertificatesExpiryDays)))
+	}
+
+	if maxAge := r.Spec.RolloutBefore.MaxAge; maxAge != nil && maxAge.Duration < minRolloutMaxAge {
+		allErrs = append(allErrs, field.Invalid(rolloutBeforePath.Child("maxAge"), maxAge.Duration.String(),
+			fmt.Sprintf("must be at least %s", minRolloutMaxAge)))
+	}
+
+	return allErrs
+}
+
+// validateControlPlaneEndpointManagement validates the virtual IP address announced by kube-vip, which must be the
+// control plane endpoint when the latter is set.
+func validateControlPlaneEndpointManagement(spec *RKE2ControlPlaneSpec, pathPrefix *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	management := spec.ControlPlaneEndpointManagement
+	if management == nil {
+		return allErrs
+	}
+
+	managementPath := pathPrefix.Child("controlPlaneEndpointManagement")
+
+	if net.ParseIP(management.Address) == nil {
+		allErrs = append(allErrs, field.Invalid(managementPath.Child("address"), management.Address, "must be an IP address"))
+	}
+
+	if host := spec.ControlPlaneEndpoint.Host; host != "" && host != management.Address {
+		allErrs = append(allErrs, field.Invalid(pathPrefix.Child("controlPlaneEndpoint", "host"), host,
+			"must be the address of controlPlaneEndpointManagement"))
+	}
+
+	if management.Mode != KubeVIPModeBGP {
+		if management.BGP != nil {
+			allErrs = append(allErrs, field.Forbidden(managementPath.Child("bgp"), "can only be set in bgp mode"))
+		}
+
+		return allErrs
+	}
+
+	if management.BGP == nil {
+		allErrs = append(allErrs, field.Required(managementPath.Child("bgp"), "is required in bgp mode"))
+	}
+
+	if management.Interface == "" {
+		allErrs = append(allErrs, field.Required(managementPath.Child("interface"), "is required in bgp mode"))
+	}
+
+	if management.BGP != nil {
+		for i, peer := range management.BGP.Peers {
+			if net.ParseIP(peer.Address) == nil {
+				allErrs = append(allErrs, field.Invalid(managementPath.Child("bgp", "peers").Index(i).Child("address"),
+					peer.Address, "must be an IP address"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func (r *RKE2ControlPlane) validateRolloutStrategy(old *RKE2ControlPlane) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// The upgrade image of the system-upgrade-controller is tagged with RKE2 versions only.
+	if r.Spec.RolloutStrategy != nil && r.Spec.RolloutStrategy.Type == InPlaceUpgradeStrategyType &&
+		!strings.Contains(r.Spec.Version, "+rke2r") {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "version"), r.Spec.Version,
+			"must be a RKE2 version, e.g. v1.31.2+rke2r1, when using the InPlace rollout strategy"))
+	}
+
+	if r.Spec.RolloutStrategy == nil || r.Spec.RolloutStrategy.RollingUpdate == nil ||
+		r.Spec.RolloutStrategy.RollingUpdate.MaxSurge == nil {
+		return allErrs
+	}
+
+	maxSurgePath := field.NewPath("spec", "rolloutStrategy", "rollingUpdate", "maxSurge")
+	maxSurge := r.Spec.RolloutStrategy.RollingUpdate.MaxSurge
+
+	// The surge is only validated when set or changed, so that control planes created before it was validated
+	// can still be updated.
+	if old != nil && old.Spec.RolloutStrategy != nil && old.Spec.RolloutStrategy.RollingUpdate != nil &&
+		ptr.Equal(old.Spec.RolloutStrategy.RollingUpdate.MaxSurge, maxSurge) &&
+		ptr.Equal(old.Spec.Replicas, r.Spec.Replicas) {
+		return allErrs
+	}
+
+	if maxSurge.Type != intstr.Int || (maxSurge.IntValue() != 0 && maxSurge.IntValue() != 1) {
+		allErrs = append(allErrs, field.Invalid(maxSurgePath, maxSurge.String(), "must be either 0 or 1"))
+
+		return allErrs
+	}
+
+	// Without surge, etcd must keep quorum while an outdated machine is deleted before its replacement is created.
+	if maxSurge.IntValue() == 0 && r.Spec.ServerConfig.ExternalDatastoreSecret == nil &&
+		r.Spec.Replicas != nil && *r.Spec.Replicas < 3 {
+		allErrs = append(allErrs, field.Forbidden(maxSurgePath,
+			"rolling out without surge requires at least 3 replicas"))
+	}
+
 	return allErrs
 }
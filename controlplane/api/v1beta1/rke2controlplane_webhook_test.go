@@ -18,12 +18,16 @@ package v1beta1
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
 )
 
 var _ = Describe("RKE2ControlPlane webhook", func() {
@@ -74,4 +78,138 @@ var _ = Describe("RKE2ControlPlane webhook", func() {
 		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
 		Expect(err).ShouldNot(HaveOccurred())
 	})
+	It("Should validate the etcd restore configuration", func() {
+		rcp.Spec.Replicas = ptr.To(int32(1))
+		rcp.Spec.Restore = &EtcdRestore{}
+		_, err := validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.Restore.SnapshotName = "etcd-snapshot"
+		_, err = validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+		rcp.Spec.ServerConfig.ExternalDatastoreSecret = &v1.ObjectReference{Name: "datastore"}
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).Should(HaveOccurred())
+	})
+	It("Should validate the rollout strategy max surge", func() {
+		rcp.Spec.Replicas = ptr.To(int32(1))
+		rcp.Spec.RolloutStrategy = &RolloutStrategy{
+			Type:          RollingUpdateStrategyType,
+			RollingUpdate: &RollingUpdate{MaxSurge: ptr.To(intstr.FromInt32(2))},
+		}
+		_, err := validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge = ptr.To(intstr.FromInt32(0))
+		_, err = validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.Replicas = ptr.To(int32(3))
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("Should only validate the rollout strategy max surge when it changes", func() {
+		oldRcp.Spec.Replicas = ptr.To(int32(1))
+		oldRcp.Spec.RolloutStrategy = &RolloutStrategy{
+			Type:          RollingUpdateStrategyType,
+			RollingUpdate: &RollingUpdate{MaxSurge: ptr.To(intstr.FromString("100%"))},
+		}
+		rcp.Spec.Replicas = ptr.To(int32(1))
+		rcp.Spec.RolloutStrategy = oldRcp.Spec.RolloutStrategy.DeepCopy()
+		_, err := validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+		rcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge = ptr.To(intstr.FromInt32(2))
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge = ptr.To(intstr.FromInt32(0))
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.Replicas = ptr.To(int32(3))
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("Should require a RKE2 version for in-place upgrades", func() {
+		rcp.Spec.Replicas = ptr.To(int32(1))
+		rcp.Spec.Version = "v1.31.2"
+		rcp.Spec.RolloutStrategy = &RolloutStrategy{Type: InPlaceUpgradeStrategyType}
+		_, err := validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.Version = "v1.31.2+rke2r1"
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("Should forbid rotating certificate authorities with an external datastore", func() {
+		rcp.Spec.Replicas = ptr.To(int32(1))
+		rcp.Spec.CertificateRotation = &CertificateRotation{CertificateAuthorities: "2024-10"}
+		_, err := validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+		rcp.Spec.ServerConfig.ExternalDatastoreSecret = &v1.ObjectReference{Name: "datastore"}
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.CertificateRotation.CertificateAuthorities = ""
+		rcp.Spec.CertificateRotation.LeafCertificates = "2024-10"
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("Should validate the rollout before criteria", func() {
+		rcp.Spec.Replicas = ptr.To(int32(1))
+		rcp.Spec.RolloutBefore = &RolloutBefore{CertificatesExpiryDays: ptr.To(int32(365))}
+		_, err := validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.RolloutBefore.CertificatesExpiryDays = ptr.To(int32(30))
+		_, err = validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+		rcp.Spec.RolloutBefore.MaxAge = &metav1.Duration{Duration: time.Hour}
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.RolloutBefore.MaxAge = &metav1.Duration{Duration: 30 * 24 * time.Hour}
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("Should forbid per-machine registration tokens", func() {
+		rcp.Spec.Replicas = ptr.To(int32(1))
+		rcp.Spec.RegistrationTokenMode = bootstrapv1.MachineRegistrationTokenMode
+		_, err := validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.RegistrationTokenMode = bootstrapv1.ClusterRegistrationTokenMode
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+	It("Should validate the audit log and admission configuration", func() {
+		rcp.Spec.Replicas = ptr.To(int32(1))
+		rcp.Spec.ServerConfig.AuditLog = &AuditLogConfig{Path: "/var/log/audit.log"}
+		rcp.Spec.ServerConfig.KubeAPIServer = &bootstrapv1.ComponentConfig{ExtraArgs: []string{"audit-log-maxage=30"}}
+		_, err := validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.ServerConfig.KubeAPIServer = nil
+		rcp.Spec.ServerConfig.AdmissionConfiguration = &AdmissionConfiguration{
+			PodSecurity: &PodSecurityAdmission{Enforce: PodSecurityLevelRestricted},
+			Plugins:     []AdmissionPlugin{{Name: "AlwaysPullImages"}},
+		}
+		_, err = validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+		rcp.Spec.AgentConfig.PodSecurityAdmissionConfigFile = "/etc/rancher/rke2/psa.yaml"
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.AgentConfig.PodSecurityAdmissionConfigFile = ""
+		rcp.Spec.ServerConfig.AdmissionConfiguration.Plugins = append(rcp.Spec.ServerConfig.AdmissionConfiguration.Plugins,
+			AdmissionPlugin{Name: "PodSecurity"})
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).Should(HaveOccurred())
+	})
+	It("Should validate the secrets encryption configuration", func() {
+		rcp.Spec.Replicas = ptr.To(int32(1))
+		rcp.Spec.ServerConfig.SecretsEncryption = &SecretsEncryption{Provider: SecretsEncryptionProviderKMS}
+		_, err := validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.ServerConfig.SecretsEncryption.KMS = &KMSPlugin{Name: "vault", SocketPath: "/var/run/kmsplugin/socket.sock"}
+		_, err = validator.ValidateCreate(context.TODO(), rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+		rcp.Spec.SecretsEncryptionRotation = &SecretsEncryptionRotation{Keys: "2024-10-18"}
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.ServerConfig.SecretsEncryption.Provider = SecretsEncryptionProviderSecretbox
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).Should(HaveOccurred())
+		rcp.Spec.ServerConfig.SecretsEncryption.KMS = nil
+		_, err = validator.ValidateUpdate(context.TODO(), oldRcp, rcp)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
 })
@@ -95,6 +95,7 @@ func (r *RKE2ControlPlaneTemplateCustomValidator) ValidateCreate(_ context.Conte
 	allErrs = append(allErrs, bootstrapv1.ValidateRKE2ConfigSpec(rcpt.Name, &rcpt.Spec.Template.Spec.RKE2ConfigSpec)...)
 	allErrs = append(allErrs, rcpt.validateCNI()...)
 	allErrs = append(allErrs, rcpt.validateRegistrationMethod()...)
+	allErrs = append(allErrs, rcpt.validateServerConfig()...)
 
 	if len(allErrs) == 0 {
 		return nil, nil
@@ -126,6 +127,7 @@ func (r *RKE2ControlPlaneTemplateCustomValidator) ValidateUpdate(_ context.Conte
 
 	allErrs = append(allErrs, bootstrapv1.ValidateRKE2ConfigSpec(newControlplane.Name, &newControlplane.Spec.Template.Spec.RKE2ConfigSpec)...)
 	allErrs = append(allErrs, newControlplane.validateCNI()...)
+	allErrs = append(allErrs, newControlplane.validateServerConfig()...)
 
 	oldSet := oldControlplane.Spec.Template.Spec.RegistrationMethod != ""
 	if oldSet && newControlplane.Spec.Template.Spec.RegistrationMethod != oldControlplane.Spec.Template.Spec.RegistrationMethod {
@@ -184,5 +186,41 @@ func (rcpt *RKE2ControlPlaneTemplate) validateRegistrationMethod() field.ErrorLi
 		}
 	}
 
+	if spec.RegistrationMethod == RegistrationMethodDNS {
+		if spec.RegistrationAddress == "" {
+			allErrs = append(allErrs,
+				field.Invalid(field.NewPath("spec.registrationAddress"),
+					spec.RegistrationAddress, "registrationAddress must be supplied when using registration method 'dns'"))
+		}
+	}
+
+	if spec.RegistrationMethod == RegistrationMethodService {
+		if spec.RegistrationServiceRef == nil || spec.RegistrationServiceRef.Name == "" {
+			allErrs = append(allErrs,
+				field.Required(field.NewPath("spec.registrationServiceRef"),
+					"registrationServiceRef must be supplied when using registration method 'service'"))
+		}
+	}
+
+	if spec.RegistrationMethod == RegistrationMethodWebhook {
+		if spec.RegistrationWebhook == nil || spec.RegistrationWebhook.URL == "" {
+			allErrs = append(allErrs,
+				field.Required(field.NewPath("spec.registrationWebhook"),
+					"registrationWebhook must be supplied when using registration method 'webhook'"))
+		}
+	}
+
 	return allErrs
 }
+
+func (rcpt *RKE2ControlPlaneTemplate) validateServerConfig() field.ErrorList {
+	spec := rcpt.Spec.Template.Spec
+
+	allErrs := validateKubeAPIServerConfig(&spec.ServerConfig, &spec.AgentConfig, field.NewPath("spec", "template", "spec"))
+
+	allErrs = append(allErrs, validateSecretsEncryption(&spec, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateEtcdMaintenance(&spec.ServerConfig, field.NewPath("spec", "template", "spec"))...)
+	allErrs = append(allErrs, validateEtcdMemberHealth(&spec.ServerConfig, field.NewPath("spec", "template", "spec"))...)
+
+	return append(allErrs, validateControlPlaneEndpointManagement(&spec, field.NewPath("spec", "template", "spec"))...)
+}
@@ -18,6 +18,8 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
 )
 
 func TestRKE2ControlPlaneTemplateValidateCreate(t *testing.T) {
@@ -44,6 +46,124 @@ func TestRKE2ControlPlaneTemplateValidateCreate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "don't allow RKE2ControlPlaneTemplate with admission arguments and configuration",
+			inputTemplate: &RKE2ControlPlaneTemplate{
+				Spec: RKE2ControlPlaneTemplateSpec{
+					Template: RKE2ControlPlaneTemplateResource{
+						Spec: RKE2ControlPlaneSpec{
+							ServerConfig: RKE2ServerConfig{
+								KubeAPIServer:          &bootstrapv1.ComponentConfig{ExtraArgs: []string{"enable-admission-plugins=AlwaysPullImages"}},
+								AdmissionConfiguration: &AdmissionConfiguration{},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "don't allow RKE2ControlPlaneTemplate with kms provider without kms plugin",
+			inputTemplate: &RKE2ControlPlaneTemplate{
+				Spec: RKE2ControlPlaneTemplateSpec{
+					Template: RKE2ControlPlaneTemplateResource{
+						Spec: RKE2ControlPlaneSpec{
+							ServerConfig: RKE2ServerConfig{
+								SecretsEncryption: &SecretsEncryption{Provider: SecretsEncryptionProviderKMS},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "don't allow RKE2ControlPlaneTemplate with kube-vip bgp mode without bgp configuration",
+			inputTemplate: &RKE2ControlPlaneTemplate{
+				Spec: RKE2ControlPlaneTemplateSpec{
+					Template: RKE2ControlPlaneTemplateResource{
+						Spec: RKE2ControlPlaneSpec{
+							ControlPlaneEndpointManagement: &ControlPlaneEndpointManagement{
+								Address:   "192.168.1.100",
+								Mode:      KubeVIPModeBGP,
+								Interface: "eth0",
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "don't allow RKE2ControlPlaneTemplate with a kube-vip address that is not an IP address",
+			inputTemplate: &RKE2ControlPlaneTemplate{
+				Spec: RKE2ControlPlaneTemplateSpec{
+					Template: RKE2ControlPlaneTemplateResource{
+						Spec: RKE2ControlPlaneSpec{
+							ControlPlaneEndpointManagement: &ControlPlaneEndpointManagement{
+								Address: "api.example.com",
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "don't allow RKE2ControlPlaneTemplate with dns registration method without registration address",
+			inputTemplate: &RKE2ControlPlaneTemplate{
+				Spec: RKE2ControlPlaneTemplateSpec{
+					Template: RKE2ControlPlaneTemplateResource{
+						Spec: RKE2ControlPlaneSpec{
+							RegistrationMethod: RegistrationMethodDNS,
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "don't allow RKE2ControlPlaneTemplate with service registration method without service reference",
+			inputTemplate: &RKE2ControlPlaneTemplate{
+				Spec: RKE2ControlPlaneTemplateSpec{
+					Template: RKE2ControlPlaneTemplateResource{
+						Spec: RKE2ControlPlaneSpec{
+							RegistrationMethod: RegistrationMethodService,
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "don't allow RKE2ControlPlaneTemplate with webhook registration method without webhook",
+			inputTemplate: &RKE2ControlPlaneTemplate{
+				Spec: RKE2ControlPlaneTemplateSpec{
+					Template: RKE2ControlPlaneTemplateResource{
+						Spec: RKE2ControlPlaneSpec{
+							RegistrationMethod: RegistrationMethodWebhook,
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "allow RKE2ControlPlaneTemplate with kube-vip arp mode",
+			inputTemplate: &RKE2ControlPlaneTemplate{
+				Spec: RKE2ControlPlaneTemplateSpec{
+					Template: RKE2ControlPlaneTemplateResource{
+						Spec: RKE2ControlPlaneSpec{
+							ControlPlaneEndpointManagement: &ControlPlaneEndpointManagement{
+								Address: "192.168.1.100",
+								Mode:    KubeVIPModeARP,
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	validator := RKE2ControlPlaneTemplateCustomValidator{}
 	for _, test := range tests {
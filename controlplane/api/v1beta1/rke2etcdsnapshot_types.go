@@ -0,0 +1,172 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RKE2EtcdSnapshotSpec defines the desired state of RKE2EtcdSnapshot.
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type RKE2EtcdSnapshotSpec struct {
+	// ClusterName is the name of the Cluster the snapshot is taken for.
+	// +kubebuilder:validation:MinLength=1
+	ClusterName string `json:"clusterName"`
+
+	// MachineName is the name of the control plane Machine the snapshot is taken on.
+	// If not set, the snapshot is taken on the oldest ready control plane Machine.
+	// +optional
+	MachineName string `json:"machineName,omitempty"`
+
+	// SnapshotName is the name given to the snapshot. RKE2 suffixes it with the node name and a timestamp.
+	// If not set, the name of the RKE2EtcdSnapshot is used.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=128
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// Image is the container image used to run the snapshot Job on the node. It needs to provide a `chroot` binary.
+	// If not set, a default image is used.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// EtcdSnapshotPhase describes the state of an on-demand etcd snapshot.
+type EtcdSnapshotPhase string
+
+const (
+	// EtcdSnapshotPhasePending is the phase while the snapshot has not been started yet.
+	EtcdSnapshotPhasePending EtcdSnapshotPhase = "Pending"
+
+	// EtcdSnapshotPhaseRunning is the phase while the snapshot is being taken.
+	EtcdSnapshotPhaseRunning EtcdSnapshotPhase = "Running"
+
+	// EtcdSnapshotPhaseSucceeded is the phase once the snapshot has been taken.
+	EtcdSnapshotPhaseSucceeded EtcdSnapshotPhase = "Succeeded"
+
+	// EtcdSnapshotPhaseFailed is the phase when the snapshot could not be taken.
+	EtcdSnapshotPhaseFailed EtcdSnapshotPhase = "Failed"
+)
+
+// RKE2EtcdSnapshotStatus defines the observed state of RKE2EtcdSnapshot.
+type RKE2EtcdSnapshotStatus struct {
+	// Phase is the current phase of the snapshot.
+	// +optional
+	Phase EtcdSnapshotPhase `json:"phase,omitempty"`
+
+	// MachineName is the name of the Machine the snapshot is taken on.
+	// +optional
+	MachineName string `json:"machineName,omitempty"`
+
+	// NodeName is the name of the Node the snapshot is taken on.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// SnapshotName is the full name of the snapshot, as reported by RKE2.
+	// This is the name to use when restoring etcd from this snapshot.
+	// +optional
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// Size is the size of the snapshot.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// Location is the location of the snapshot on the node.
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// S3Location is the location of the snapshot in S3, if S3 uploads are configured.
+	// +optional
+	S3Location string `json:"s3Location,omitempty"`
+
+	// CompletionTime is the time the snapshot was taken.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// FailureMessage describes why the snapshot could not be taken.
+	// +optional
+	FailureMessage string `json:"failureMessage,omitempty"`
+}
+
+// EtcdSnapshotFile describes an etcd snapshot file discovered from the ETCDSnapshotFile objects created by RKE2.
+type EtcdSnapshotFile struct {
+	// Name is the name of the snapshot.
+	Name string `json:"name"`
+
+	// NodeName is the name of the Node the snapshot was taken on.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// Location is the location of the snapshot, either a file:// or a s3:// URI.
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// Size is the size of the snapshot.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// CreationTime is the time the snapshot was taken.
+	// +optional
+	CreationTime *metav1.Time `json:"creationTime,omitempty"`
+
+	// ReadyToUse is true if the snapshot can be used to restore etcd.
+	// +optional
+	ReadyToUse bool `json:"readyToUse,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:resource:path=rke2etcdsnapshots,scope=Namespaced,categories=cluster-api,shortName=rke2es
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterName",description="Cluster"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Snapshot phase"
+// +kubebuilder:printcolumn:name="Snapshot",type="string",JSONPath=".status.snapshotName",description="Snapshot name"
+// +kubebuilder:printcolumn:name="Node",type="string",JSONPath=".status.nodeName",description="Node the snapshot was taken on"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// RKE2EtcdSnapshot is the Schema for the rke2etcdsnapshots API.
+// It triggers a one-off etcd snapshot on a control plane Machine of a Cluster.
+type RKE2EtcdSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RKE2EtcdSnapshotSpec   `json:"spec,omitempty"`
+	Status RKE2EtcdSnapshotStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RKE2EtcdSnapshotList contains a list of RKE2EtcdSnapshot.
+type RKE2EtcdSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RKE2EtcdSnapshot `json:"items"`
+}
+
+func init() { //nolint:gochecknoinits
+	objectTypes = append(objectTypes, &RKE2EtcdSnapshot{}, &RKE2EtcdSnapshotList{})
+}
+
+// GetSnapshotName returns the name given to the snapshot, defaulting to the name of the RKE2EtcdSnapshot.
+func (s *RKE2EtcdSnapshot) GetSnapshotName() string {
+	if s.Spec.SnapshotName != "" {
+		return s.Spec.SnapshotName
+	}
+
+	return s.Name
+}
@@ -36,4 +36,14 @@ var (
 	// RegistrationMethodControlPlaneEndpoint is a registration method where the control plane endpoint from the
 	// Cluster is used for registration.
 	RegistrationMethodControlPlaneEndpoint = RegistrationMethod("control-plane-endpoint")
+	// RegistrationMethodDNS is a registration method where the addresses are resolved from the DNS name supplied
+	// at cluster creation time. The name is looked up as an SRV record when it starts with an underscore,
+	// and as A and AAAA records otherwise.
+	RegistrationMethodDNS = RegistrationMethod("dns")
+	// RegistrationMethodService is a registration method where the addresses are read from a Service of the
+	// management cluster, from its load balancer ingress or, when it has none, from its ready endpoints.
+	RegistrationMethodService = RegistrationMethod("service")
+	// RegistrationMethodWebhook is a registration method where the addresses are requested from a webhook,
+	// allowing external address providers to supply them.
+	RegistrationMethodWebhook = RegistrationMethod("webhook")
 )
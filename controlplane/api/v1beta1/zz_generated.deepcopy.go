@@ -29,6 +29,180 @@ import (
 	cluster_apiapiv1beta1 "sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionConfiguration) DeepCopyInto(out *AdmissionConfiguration) {
+	*out = *in
+	if in.PodSecurity != nil {
+		in, out := &in.PodSecurity, &out.PodSecurity
+		*out = new(PodSecurityAdmission)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EventRateLimit != nil {
+		in, out := &in.EventRateLimit, &out.EventRateLimit
+		*out = new(EventRateLimitAdmission)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]AdmissionPlugin, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionConfiguration.
+func (in *AdmissionConfiguration) DeepCopy() *AdmissionConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdmissionPlugin) DeepCopyInto(out *AdmissionPlugin) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdmissionPlugin.
+func (in *AdmissionPlugin) DeepCopy() *AdmissionPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(AdmissionPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogConfig) DeepCopyInto(out *AuditLogConfig) {
+	*out = *in
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxBackups != nil {
+		in, out := &in.MaxBackups, &out.MaxBackups
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(AuditWebhookConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogConfig.
+func (in *AuditLogConfig) DeepCopy() *AuditLogConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditWebhookConfig) DeepCopyInto(out *AuditWebhookConfig) {
+	*out = *in
+	out.ConfigSecret = in.ConfigSecret
+	if in.InitialBackoff != nil {
+		in, out := &in.InitialBackoff, &out.InitialBackoff
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditWebhookConfig.
+func (in *AuditWebhookConfig) DeepCopy() *AuditWebhookConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditWebhookConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateExpiry) DeepCopyInto(out *CertificateExpiry) {
+	*out = *in
+	in.NotAfter.DeepCopyInto(&out.NotAfter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateExpiry.
+func (in *CertificateExpiry) DeepCopy() *CertificateExpiry {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateExpiry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRotation) DeepCopyInto(out *CertificateRotation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRotation.
+func (in *CertificateRotation) DeepCopy() *CertificateRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRotationStatus) DeepCopyInto(out *CertificateRotationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRotationStatus.
+func (in *CertificateRotationStatus) DeepCopy() *CertificateRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneEndpointManagement) DeepCopyInto(out *ControlPlaneEndpointManagement) {
+	*out = *in
+	if in.BGP != nil {
+		in, out := &in.BGP, &out.BGP
+		*out = new(KubeVIPBGP)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneEndpointManagement.
+func (in *ControlPlaneEndpointManagement) DeepCopy() *ControlPlaneEndpointManagement {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneEndpointManagement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DisableComponents) DeepCopyInto(out *DisableComponents) {
 	*out = *in
@@ -88,6 +262,16 @@ func (in *EtcdConfig) DeepCopyInto(out *EtcdConfig) {
 		*out = new(apiv1beta1.ComponentConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(EtcdMaintenance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MemberHealth != nil {
+		in, out := &in.MemberHealth, &out.MemberHealth
+		*out = new(EtcdMemberHealth)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdConfig.
@@ -100,6 +284,130 @@ func (in *EtcdConfig) DeepCopy() *EtcdConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdMaintenance) DeepCopyInto(out *EtcdMaintenance) {
+	*out = *in
+	if in.DefragmentationInterval != nil {
+		in, out := &in.DefragmentationInterval, &out.DefragmentationInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.FragmentationThresholdPercent != nil {
+		in, out := &in.FragmentationThresholdPercent, &out.FragmentationThresholdPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinDBSize != nil {
+		in, out := &in.MinDBSize, &out.MinDBSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdMaintenance.
+func (in *EtcdMaintenance) DeepCopy() *EtcdMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdMemberHealth) DeepCopyInto(out *EtcdMemberHealth) {
+	*out = *in
+	if in.MaxRaftIndexLag != nil {
+		in, out := &in.MaxRaftIndexLag, &out.MaxRaftIndexLag
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxRoundTripTime != nil {
+		in, out := &in.MaxRoundTripTime, &out.MaxRoundTripTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdMemberHealth.
+func (in *EtcdMemberHealth) DeepCopy() *EtcdMemberHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdMemberHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdMemberStatus) DeepCopyInto(out *EtcdMemberStatus) {
+	*out = *in
+	if in.RaftIndexLag != nil {
+		in, out := &in.RaftIndexLag, &out.RaftIndexLag
+		*out = new(int64)
+		**out = **in
+	}
+	out.RoundTripTime = in.RoundTripTime
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Alarms != nil {
+		in, out := &in.Alarms, &out.Alarms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdMemberStatus.
+func (in *EtcdMemberStatus) DeepCopy() *EtcdMemberStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdMemberStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRestore) DeepCopyInto(out *EtcdRestore) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestore.
+func (in *EtcdRestore) DeepCopy() *EtcdRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdRestoreStatus) DeepCopyInto(out *EtcdRestoreStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdRestoreStatus.
+func (in *EtcdRestoreStatus) DeepCopy() *EtcdRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EtcdS3) DeepCopyInto(out *EtcdS3) {
 	*out = *in
@@ -125,6 +433,161 @@ func (in *EtcdS3) DeepCopy() *EtcdS3 {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdSnapshotFile) DeepCopyInto(out *EtcdSnapshotFile) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.CreationTime != nil {
+		in, out := &in.CreationTime, &out.CreationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdSnapshotFile.
+func (in *EtcdSnapshotFile) DeepCopy() *EtcdSnapshotFile {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdSnapshotFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdStatus) DeepCopyInto(out *EtcdStatus) {
+	*out = *in
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]EtcdMemberStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdStatus.
+func (in *EtcdStatus) DeepCopy() *EtcdStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventRateLimit) DeepCopyInto(out *EventRateLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventRateLimit.
+func (in *EventRateLimit) DeepCopy() *EventRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(EventRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventRateLimitAdmission) DeepCopyInto(out *EventRateLimitAdmission) {
+	*out = *in
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make([]EventRateLimit, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventRateLimitAdmission.
+func (in *EventRateLimitAdmission) DeepCopy() *EventRateLimitAdmission {
+	if in == nil {
+		return nil
+	}
+	out := new(EventRateLimitAdmission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InPlaceUpgrade) DeepCopyInto(out *InPlaceUpgrade) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InPlaceUpgrade.
+func (in *InPlaceUpgrade) DeepCopy() *InPlaceUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(InPlaceUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KMSPlugin) DeepCopyInto(out *KMSPlugin) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KMSPlugin.
+func (in *KMSPlugin) DeepCopy() *KMSPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(KMSPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVIPBGP) DeepCopyInto(out *KubeVIPBGP) {
+	*out = *in
+	if in.Peers != nil {
+		in, out := &in.Peers, &out.Peers
+		*out = make([]KubeVIPBGPPeer, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVIPBGP.
+func (in *KubeVIPBGP) DeepCopy() *KubeVIPBGP {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVIPBGP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeVIPBGPPeer) DeepCopyInto(out *KubeVIPBGPPeer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeVIPBGPPeer.
+func (in *KubeVIPBGPPeer) DeepCopy() *KubeVIPBGPPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeVIPBGPPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LastRemediationStatus) DeepCopyInto(out *LastRemediationStatus) {
 	*out = *in
@@ -141,6 +604,51 @@ func (in *LastRemediationStatus) DeepCopy() *LastRemediationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSecurityAdmission) DeepCopyInto(out *PodSecurityAdmission) {
+	*out = *in
+	if in.ExemptUsernames != nil {
+		in, out := &in.ExemptUsernames, &out.ExemptUsernames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExemptRuntimeClasses != nil {
+		in, out := &in.ExemptRuntimeClasses, &out.ExemptRuntimeClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExemptNamespaces != nil {
+		in, out := &in.ExemptNamespaces, &out.ExemptNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSecurityAdmission.
+func (in *PodSecurityAdmission) DeepCopy() *PodSecurityAdmission {
+	if in == nil {
+		return nil
+	}
+	out := new(PodSecurityAdmission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreUpgradeSnapshotStatus) DeepCopyInto(out *PreUpgradeSnapshotStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreUpgradeSnapshotStatus.
+func (in *PreUpgradeSnapshotStatus) DeepCopy() *PreUpgradeSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreUpgradeSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RKE2ControlPlane) DeepCopyInto(out *RKE2ControlPlane) {
 	*out = *in
@@ -250,6 +758,16 @@ func (in *RKE2ControlPlaneSpec) DeepCopyInto(out *RKE2ControlPlaneSpec) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.RegistrationServiceRef != nil {
+		in, out := &in.RegistrationServiceRef, &out.RegistrationServiceRef
+		*out = new(corev1.ObjectReference)
+		**out = **in
+	}
+	if in.RegistrationWebhook != nil {
+		in, out := &in.RegistrationWebhook, &out.RegistrationWebhook
+		*out = new(RegistrationWebhook)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.RolloutStrategy != nil {
 		in, out := &in.RolloutStrategy, &out.RolloutStrategy
 		*out = new(RolloutStrategy)
@@ -260,6 +778,36 @@ func (in *RKE2ControlPlaneSpec) DeepCopyInto(out *RKE2ControlPlaneSpec) {
 		*out = new(RemediationStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(EtcdRestore)
+		**out = **in
+	}
+	if in.CertificateRotation != nil {
+		in, out := &in.CertificateRotation, &out.CertificateRotation
+		*out = new(CertificateRotation)
+		**out = **in
+	}
+	if in.SecretsEncryptionRotation != nil {
+		in, out := &in.SecretsEncryptionRotation, &out.SecretsEncryptionRotation
+		*out = new(SecretsEncryptionRotation)
+		**out = **in
+	}
+	if in.RolloutAfter != nil {
+		in, out := &in.RolloutAfter, &out.RolloutAfter
+		*out = (*in).DeepCopy()
+	}
+	if in.RolloutBefore != nil {
+		in, out := &in.RolloutBefore, &out.RolloutBefore
+		*out = new(RolloutBefore)
+		(*in).DeepCopyInto(*out)
+	}
+	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
+	if in.ControlPlaneEndpointManagement != nil {
+		in, out := &in.ControlPlaneEndpointManagement, &out.ControlPlaneEndpointManagement
+		*out = new(ControlPlaneEndpointManagement)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RKE2ControlPlaneSpec.
@@ -302,6 +850,49 @@ func (in *RKE2ControlPlaneStatus) DeepCopyInto(out *RKE2ControlPlaneStatus) {
 		*out = new(LastRemediationStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(EtcdRestoreStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EtcdSnapshots != nil {
+		in, out := &in.EtcdSnapshots, &out.EtcdSnapshots
+		*out = make([]EtcdSnapshotFile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EtcdSnapshotsRefreshTime != nil {
+		in, out := &in.EtcdSnapshotsRefreshTime, &out.EtcdSnapshotsRefreshTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PreUpgradeSnapshot != nil {
+		in, out := &in.PreUpgradeSnapshot, &out.PreUpgradeSnapshot
+		*out = new(PreUpgradeSnapshotStatus)
+		**out = **in
+	}
+	if in.CertificateRotation != nil {
+		in, out := &in.CertificateRotation, &out.CertificateRotation
+		*out = new(CertificateRotationStatus)
+		**out = **in
+	}
+	if in.SecretsEncryptionRotation != nil {
+		in, out := &in.SecretsEncryptionRotation, &out.SecretsEncryptionRotation
+		*out = new(SecretsEncryptionRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CertificatesExpiry != nil {
+		in, out := &in.CertificatesExpiry, &out.CertificatesExpiry
+		*out = make([]CertificateExpiry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Etcd != nil {
+		in, out := &in.Etcd, &out.Etcd
+		*out = new(EtcdStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RKE2ControlPlaneStatus.
@@ -405,6 +996,104 @@ func (in *RKE2ControlPlaneTemplateSpec) DeepCopy() *RKE2ControlPlaneTemplateSpec
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RKE2EtcdSnapshot) DeepCopyInto(out *RKE2EtcdSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RKE2EtcdSnapshot.
+func (in *RKE2EtcdSnapshot) DeepCopy() *RKE2EtcdSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(RKE2EtcdSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RKE2EtcdSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RKE2EtcdSnapshotList) DeepCopyInto(out *RKE2EtcdSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RKE2EtcdSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RKE2EtcdSnapshotList.
+func (in *RKE2EtcdSnapshotList) DeepCopy() *RKE2EtcdSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(RKE2EtcdSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RKE2EtcdSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RKE2EtcdSnapshotSpec) DeepCopyInto(out *RKE2EtcdSnapshotSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RKE2EtcdSnapshotSpec.
+func (in *RKE2EtcdSnapshotSpec) DeepCopy() *RKE2EtcdSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RKE2EtcdSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RKE2EtcdSnapshotStatus) DeepCopyInto(out *RKE2EtcdSnapshotStatus) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RKE2EtcdSnapshotStatus.
+func (in *RKE2EtcdSnapshotStatus) DeepCopy() *RKE2EtcdSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RKE2EtcdSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RKE2ServerConfig) DeepCopyInto(out *RKE2ServerConfig) {
 	*out = *in
@@ -413,6 +1102,21 @@ func (in *RKE2ServerConfig) DeepCopyInto(out *RKE2ServerConfig) {
 		*out = new(corev1.ObjectReference)
 		**out = **in
 	}
+	if in.AuditLog != nil {
+		in, out := &in.AuditLog, &out.AuditLog
+		*out = new(AuditLogConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdmissionConfiguration != nil {
+		in, out := &in.AdmissionConfiguration, &out.AdmissionConfiguration
+		*out = new(AdmissionConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretsEncryption != nil {
+		in, out := &in.SecretsEncryption, &out.SecretsEncryption
+		*out = new(SecretsEncryption)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TLSSan != nil {
 		in, out := &in.TLSSan, &out.TLSSan
 		*out = make([]string, len(*in))
@@ -462,6 +1166,26 @@ func (in *RKE2ServerConfig) DeepCopy() *RKE2ServerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistrationWebhook) DeepCopyInto(out *RegistrationWebhook) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistrationWebhook.
+func (in *RegistrationWebhook) DeepCopy() *RegistrationWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistrationWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemediationStrategy) DeepCopyInto(out *RemediationStrategy) {
 	*out = *in
@@ -508,6 +1232,31 @@ func (in *RollingUpdate) DeepCopy() *RollingUpdate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutBefore) DeepCopyInto(out *RolloutBefore) {
+	*out = *in
+	if in.CertificatesExpiryDays != nil {
+		in, out := &in.CertificatesExpiryDays, &out.CertificatesExpiryDays
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutBefore.
+func (in *RolloutBefore) DeepCopy() *RolloutBefore {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutBefore)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
 	*out = *in
@@ -516,6 +1265,11 @@ func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
 		*out = new(RollingUpdate)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.InPlace != nil {
+		in, out := &in.InPlace, &out.InPlace
+		*out = new(InPlaceUpgrade)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategy.
@@ -527,3 +1281,57 @@ func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretsEncryption) DeepCopyInto(out *SecretsEncryption) {
+	*out = *in
+	if in.KMS != nil {
+		in, out := &in.KMS, &out.KMS
+		*out = new(KMSPlugin)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretsEncryption.
+func (in *SecretsEncryption) DeepCopy() *SecretsEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretsEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretsEncryptionRotation) DeepCopyInto(out *SecretsEncryptionRotation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretsEncryptionRotation.
+func (in *SecretsEncryptionRotation) DeepCopy() *SecretsEncryptionRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretsEncryptionRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretsEncryptionRotationStatus) DeepCopyInto(out *SecretsEncryptionRotationStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretsEncryptionRotationStatus.
+func (in *SecretsEncryptionRotationStatus) DeepCopy() *SecretsEncryptionRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretsEncryptionRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,723 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/controlplane/internal/util/hash"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/kubeconfig"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/secret"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
+)
+
+const (
+	// certificateAuthoritiesExpiryWarning is how long before their expiry the certificate authorities are reported
+	// as expiring soon.
+	certificateAuthoritiesExpiryWarning = 90 * 24 * time.Hour
+
+	// defaultMachineCertificatesExpiryWarning is how long before their expiry the serving certificates of the
+	// control plane Nodes are reported as expiring soon, unless rolloutBefore.certificatesExpiryDays is set.
+	defaultMachineCertificatesExpiryWarning = 30 * 24 * time.Hour
+
+	// crossSignedCrtDataName is the key used to store the cross-signed certificate of a rotated certificate authority.
+	crossSignedCrtDataName = "cross-signed.crt"
+)
+
+// reconcileCertificatesExpiry reports the expiry date of the certificate authorities managed for the cluster,
+// and warns when one of them is about to expire.
+func (r *RKE2ControlPlaneReconciler) reconcileCertificatesExpiry(
+	ctx context.Context,
+	rcp *controlplanev1.RKE2ControlPlane,
+	certificates secret.Certificates,
+) {
+	logger := ctrl.LoggerFrom(ctx)
+
+	expiries := make([]controlplanev1.CertificateExpiry, 0, len(certificates))
+	expiring := []string{}
+	expired := []string{}
+	now := time.Now()
+
+	for _, certificate := range certificates {
+		notAfter, err := secret.NotAfter(certificate.GetKeyPair())
+		if err != nil {
+			logger.Error(err, "Failed to read certificate expiry", "purpose", certificate.GetPurpose())
+
+			continue
+		}
+
+		purpose := string(certificate.GetPurpose())
+		expiries = append(expiries, controlplanev1.CertificateExpiry{
+			Purpose:  purpose,
+			NotAfter: metav1.NewTime(notAfter),
+		})
+
+		switch {
+		case notAfter.Before(now):
+			expired = append(expired, purpose)
+		case notAfter.Before(now.Add(certificateAuthoritiesExpiryWarning)):
+			expiring = append(expiring, purpose)
+		}
+	}
+
+	rcp.Status.CertificatesExpiry = expiries
+
+	switch {
+	case len(expired) > 0:
+		conditions.MarkFalse(rcp, controlplanev1.CertificateAuthoritiesValidCondition,
+			controlplanev1.CertificateAuthoritiesExpiredReason, clusterv1.ConditionSeverityError,
+			"Certificate authorities %s have expired", strings.Join(expired, ", "))
+	case len(expiring) > 0:
+		conditions.MarkFalse(rcp, controlplanev1.CertificateAuthoritiesValidCondition,
+			controlplanev1.CertificateAuthoritiesExpiringSoonReason, clusterv1.ConditionSeverityWarning,
+			"Certificate authorities %s expire in less than %d days, they should be rotated",
+			strings.Join(expiring, ", "), int(certificateAuthoritiesExpiryWarning.Hours()/24))
+	default:
+		conditions.MarkTrue(rcp, controlplanev1.CertificateAuthoritiesValidCondition)
+	}
+}
+
+// certificatesExpiryAnnotations are the annotations recording the expiry of the serving certificates of the Node
+// of a control plane Machine.
+var certificatesExpiryAnnotations = []string{
+	controlplanev1.APIServerCertificateExpiryAnnotation,
+	controlplanev1.EtcdCertificateExpiryAnnotation,
+	controlplanev1.KubeletCertificateExpiryAnnotation,
+}
+
+// reconcileMachineCertificatesExpiry records the expiry date of the serving certificates of the control plane Nodes
+// on their Machines, and reports the Machines whose certificates are about to expire. Failing to read the
+// certificates of a Node does not block the reconciliation.
+func (r *RKE2ControlPlaneReconciler) reconcileMachineCertificatesExpiry(ctx context.Context, controlPlane *rke2.ControlPlane) {
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+
+	if !rcp.Status.Initialized {
+		return
+	}
+
+	expiryWarning := defaultMachineCertificatesExpiryWarning
+	if rcp.Spec.RolloutBefore != nil && rcp.Spec.RolloutBefore.CertificatesExpiryDays != nil {
+		expiryWarning = time.Duration(*rcp.Spec.RolloutBefore.CertificatesExpiryDays) * 24 * time.Hour
+	}
+
+	deadline := time.Now().Add(expiryWarning)
+	expiring := []string{}
+
+	for _, machine := range controlPlane.Machines.Filter(collections.ActiveMachines, collections.HasNode()).SortedByCreationTimestamp() {
+		expiry := rke2.MachineCertificatesExpiry(machine)
+
+		// RKE2 renews the certificates about to expire when it restarts, they are read again until they are renewed.
+		_, recorded := machine.Annotations[controlplanev1.APIServerCertificateExpiryAnnotation]
+		if !recorded || (expiry != nil && expiry.Before(deadline)) {
+			if err := r.recordMachineCertificatesExpiry(ctx, controlPlane, machine); err != nil {
+				logger.Error(err, "Failed to record serving certificates expiry", "machine", machine.Name)
+			}
+
+			expiry = rke2.MachineCertificatesExpiry(machine)
+		}
+
+		if expiry != nil && expiry.Before(deadline) {
+			expiring = append(expiring, machine.Name)
+		}
+	}
+
+	if len(expiring) == 0 {
+		conditions.MarkFalseWithNegativePolarity(rcp, controlplanev1.CertificatesExpiringSoonCondition)
+
+		return
+	}
+
+	conditions.MarkTrueWithNegativePolarity(rcp, controlplanev1.CertificatesExpiringSoonCondition,
+		controlplanev1.MachineCertificatesExpiringSoonReason, clusterv1.ConditionSeverityWarning,
+		"Serving certificates of machines %s expire in less than %d days",
+		strings.Join(expiring, ", "), int(expiryWarning.Hours()/24))
+}
+
+// recordMachineCertificatesExpiry reads the expiry date of the serving certificates of the Node of a Machine,
+// and records them in the Machine annotations.
+func (r *RKE2ControlPlaneReconciler) recordMachineCertificatesExpiry(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+	machine *clusterv1.Machine,
+) error {
+	workloadCluster, err := controlPlane.GetWorkloadCluster(ctx)
+	if err != nil {
+		return fmt.Errorf("getting workload cluster: %w", err)
+	}
+
+	expiries, err := workloadCluster.CertificatesExpiry(ctx, machine, controlPlane.UsesEmbeddedEtcd())
+	if err != nil {
+		return err
+	}
+
+	annotations := map[string]string{}
+	for annotation, expiry := range expiries {
+		annotations[annotation] = expiry.UTC().Format(time.RFC3339)
+	}
+
+	return r.patchMachineAnnotations(ctx, machine, annotations)
+}
+
+// reconcileCertificateRotation rotates the certificates requested in the RKE2ControlPlane spec, if any.
+// A certificate authorities rotation takes precedence over a leaf certificates rotation, as it replaces all
+// the machines, which comes with new leaf certificates.
+func (r *RKE2ControlPlaneReconciler) reconcileCertificateRotation(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileCertificateRotation")
+	defer tracing.End(span, &reterr)
+
+	rcp := controlPlane.RCP
+	rotation := rcp.Spec.CertificateRotation
+
+	if rotation == nil {
+		return ctrl.Result{}, nil
+	}
+
+	if rcp.Status.CertificateRotation == nil {
+		rcp.Status.CertificateRotation = &controlplanev1.CertificateRotationStatus{}
+	}
+
+	status := rcp.Status.CertificateRotation
+
+	// The certificates of a cluster being initialized are brand new, there is nothing to rotate.
+	if !rcp.Status.Initialized {
+		status.LeafCertificates = rotation.LeafCertificates
+		status.CertificateAuthorities = rotation.CertificateAuthorities
+
+		return ctrl.Result{}, nil
+	}
+
+	if rotation.CertificateAuthorities != "" && rotation.CertificateAuthorities != status.CertificateAuthorities {
+		return r.rotateCertificateAuthorities(ctx, controlPlane)
+	}
+
+	if rotation.LeafCertificates != "" && rotation.LeafCertificates != status.LeafCertificates {
+		return r.rotateLeafCertificates(ctx, controlPlane)
+	}
+
+	if conditions.Has(rcp, controlplanev1.CertificatesRotatedCondition) {
+		conditions.MarkTrue(rcp, controlplanev1.CertificatesRotatedCondition)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// rotateLeafCertificates rotates the leaf certificates of the control plane nodes, one at a time.
+func (r *RKE2ControlPlaneReconciler) rotateLeafCertificates(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (ctrl.Result, error) {
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+	rotation := rcp.Spec.CertificateRotation
+
+	// Machines being rolled out come with new leaf certificates, wait for the rollout to complete.
+	if controlPlane.MachinesNeedingRollout(ctx).Len() > 0 {
+		return ctrl.Result{}, nil
+	}
+
+	pendingMachines := controlPlane.Machines.Filter(
+		collections.Not(collections.HasDeletionTimestamp),
+		func(machine *clusterv1.Machine) bool {
+			return machine.Annotations[controlplanev1.LeafCertificatesRotationAnnotation] != rotation.LeafCertificates
+		},
+	)
+
+	if pendingMachines.Len() == 0 {
+		rcp.Status.CertificateRotation.LeafCertificates = rotation.LeafCertificates
+		conditions.MarkTrue(rcp, controlplanev1.CertificatesRotatedCondition)
+		r.recorder.Eventf(rcp, corev1.EventTypeNormal, "LeafCertificatesRotated",
+			"Rotated the leaf certificates of all the control plane machines")
+
+		return ctrl.Result{}, nil
+	}
+
+	conditions.MarkFalse(rcp, controlplanev1.CertificatesRotatedCondition, controlplanev1.CertificateRotationInProgressReason,
+		clusterv1.ConditionSeverityInfo, "Rotating the leaf certificates of %d machines", pendingMachines.Len())
+
+	// The oldest pending machine remains the one being rotated until it is done, so that RKE2 is restarted
+	// on a single node at a time.
+	machine := pendingMachines.Oldest()
+
+	if result := r.preflightChecks(ctx, controlPlane, machine); !result.IsZero() {
+		return result, nil
+	}
+
+	workloadCluster, err := controlPlane.GetWorkloadCluster(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting workload cluster: %w", err)
+	}
+
+	jobName, err := rotationJobName("rke2-leaf-rotation", rotation.LeafCertificates, machine.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	done, err := workloadCluster.RotateCertificates(ctx, machine, jobName, rotation.Image)
+	if errors.Is(err, rke2.ErrCertificateRotationFailed) {
+		conditions.MarkFalse(rcp, controlplanev1.CertificatesRotatedCondition, controlplanev1.CertificateRotationFailedReason,
+			clusterv1.ConditionSeverityError, "Failed to rotate the leaf certificates of machine %s, set a new value to retry: %s",
+			machine.Name, err.Error())
+		r.recorder.Eventf(rcp, corev1.EventTypeWarning, "LeafCertificatesRotationFailed",
+			"Failed to rotate the leaf certificates of machine %s: %v", machine.Name, err)
+
+		return ctrl.Result{RequeueAfter: preflightFailedRequeueAfter}, nil
+	}
+
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("rotating leaf certificates of machine %s: %w", machine.Name, err)
+	}
+
+	if !done {
+		logger.Info("Waiting for the leaf certificates to be rotated", "machine", machine.Name)
+
+		return ctrl.Result{RequeueAfter: certificateRotationRequeueAfter}, nil
+	}
+
+	if err := workloadCluster.CleanupCertificateRotation(ctx, jobName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// RKE2 has been restarted with new certificates, their expiry is read again.
+	if err := r.patchMachineAnnotations(ctx, machine,
+		map[string]string{controlplanev1.LeafCertificatesRotationAnnotation: rotation.LeafCertificates},
+		certificatesExpiryAnnotations...); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Rotated leaf certificates", "machine", machine.Name)
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// rotateCertificateAuthorities rotates the certificate authorities of the cluster, in three phases:
+// new certificate authorities cross-signed by the current ones are generated, they are installed in the
+// workload cluster with `rke2 certificate rotate-ca`, and the control plane machines are rolled out.
+// While the machines are rolled out, the management cluster trusts both the current and the new certificate
+// authorities. Once the rollout has completed, it switches to the new ones.
+func (r *RKE2ControlPlaneReconciler) rotateCertificateAuthorities(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (ctrl.Result, error) {
+	rcp := controlPlane.RCP
+	status := rcp.Status.CertificateRotation
+
+	if _, legacy := rcp.Annotations[controlplanev1.LegacyRKE2ControlPlane]; legacy || !controlPlane.UsesEmbeddedEtcd() {
+		conditions.MarkFalse(rcp, controlplanev1.CertificatesRotatedCondition, controlplanev1.CertificateRotationFailedReason,
+			clusterv1.ConditionSeverityError,
+			"Rotating certificate authorities is not supported for legacy control planes or with an external datastore")
+
+		return ctrl.Result{}, nil
+	}
+
+	if status.CertificateAuthoritiesPhase == "" {
+		controlPlane.Logger().Info("Starting certificate authorities rotation")
+		r.recorder.Eventf(rcp, corev1.EventTypeNormal, "CertificateAuthoritiesRotationStarted",
+			"Rotating the certificate authorities of the cluster")
+
+		status.CertificateAuthoritiesPhase = controlplanev1.CertificateAuthoritiesRotationPhasePreparing
+	}
+
+	conditions.MarkFalse(rcp, controlplanev1.CertificatesRotatedCondition, controlplanev1.CertificateRotationInProgressReason,
+		clusterv1.ConditionSeverityInfo, "Rotating the certificate authorities (%s)", status.CertificateAuthoritiesPhase)
+
+	switch status.CertificateAuthoritiesPhase {
+	case controlplanev1.CertificateAuthoritiesRotationPhasePreparing:
+		return r.prepareCertificateAuthoritiesRotation(ctx, controlPlane)
+	case controlplanev1.CertificateAuthoritiesRotationPhaseRotating:
+		return r.installRotatedCertificateAuthorities(ctx, controlPlane)
+	case controlplanev1.CertificateAuthoritiesRotationPhaseRollingOut:
+		return r.completeCertificateAuthoritiesRotation(ctx, controlPlane)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// prepareCertificateAuthoritiesRotation generates the new certificate authorities, cross-signed by the current ones.
+func (r *RKE2ControlPlaneReconciler) prepareCertificateAuthoritiesRotation(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (ctrl.Result, error) {
+	rcp := controlPlane.RCP
+	clusterKey := util.ObjectKey(controlPlane.Cluster)
+
+	certificates := secret.NewCertificatesForInitialControlPlane()
+	if err := certificates.Lookup(ctx, r.Client, clusterKey); err != nil {
+		return ctrl.Result{}, fmt.Errorf("looking up certificate authorities: %w", err)
+	}
+
+	for _, certificate := range certificates {
+		rotated := &secret.ManagedCertificate{Purpose: rotatedPurpose(certificate.GetPurpose())}
+
+		existing, err := rotated.Lookup(ctx, r.Client, clusterKey)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("looking up rotated certificate authority %s: %w", rotated.Purpose, err)
+		}
+
+		if existing != nil {
+			continue
+		}
+
+		if certificate.GetKeyPair() == nil {
+			return ctrl.Result{}, fmt.Errorf("certificate authority %s not found", certificate.GetPurpose())
+		}
+
+		keyPair, crossSigned, err := secret.NewCrossSignedCertificateAuthority(certificate.GetKeyPair())
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("generating rotated certificate authority %s: %w", rotated.Purpose, err)
+		}
+
+		rotated.KeyPair = keyPair
+		rotated.Generated = true
+
+		s := rotated.AsSecret(clusterKey, *metav1.NewControllerRef(rcp, controlplanev1.GroupVersion.WithKind(rke2ControlPlaneKind)))
+		s.Data[crossSignedCrtDataName] = crossSigned
+
+		if err := r.Create(ctx, s); err != nil && !apierrors.IsAlreadyExists(err) {
+			return ctrl.Result{}, fmt.Errorf("creating rotated certificate authority %s: %w", rotated.Purpose, err)
+		}
+	}
+
+	rcp.Status.CertificateRotation.CertificateAuthoritiesPhase = controlplanev1.CertificateAuthoritiesRotationPhaseRotating
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// installRotatedCertificateAuthorities installs the new certificate authorities in the workload cluster,
+// then lets the management cluster trust both the current and the new certificate authorities.
+func (r *RKE2ControlPlaneReconciler) installRotatedCertificateAuthorities(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (ctrl.Result, error) {
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+	rotation := rcp.Spec.CertificateRotation
+
+	machine := controlPlane.Machines.Filter(collections.ActiveMachines, collections.HasNode()).Oldest()
+	if machine == nil {
+		logger.Info("Waiting for a control plane machine to rotate the certificate authorities")
+
+		return ctrl.Result{RequeueAfter: certificateRotationRequeueAfter}, nil
+	}
+
+	certificates, rotatedSecrets, err := r.lookupRotatedCertificateAuthorities(ctx, controlPlane)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The new certificate authorities are installed along with their cross-signed certificates and the current
+	// certificate authorities, so that the nodes trust certificates issued by both during the rollout.
+	files := map[string][]byte{}
+
+	for _, certificate := range certificates {
+		managed, ok := certificate.(*secret.ManagedCertificate)
+		if !ok {
+			continue
+		}
+
+		rotated := rotatedSecrets[certificate.GetPurpose()]
+
+		certPath, err := filepath.Rel(secret.DefaultCertificatesDir, managed.CertFile)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		keyPath, err := filepath.Rel(secret.DefaultCertificatesDir, managed.KeyFile)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		files[certPath] = bytes.Join([][]byte{
+			rotated.Data[secret.TLSCrtDataName],
+			rotated.Data[crossSignedCrtDataName],
+			managed.KeyPair.Cert,
+		}, nil)
+		files[keyPath] = rotated.Data[secret.TLSKeyDataName]
+	}
+
+	workloadCluster, err := controlPlane.GetWorkloadCluster(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting workload cluster: %w", err)
+	}
+
+	jobName, err := rotationJobName("rke2-ca-rotation", rotation.CertificateAuthorities)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	done, err := workloadCluster.RotateCertificateAuthorities(ctx, machine, jobName, rotation.Image, files)
+	if errors.Is(err, rke2.ErrCertificateRotationFailed) {
+		conditions.MarkFalse(rcp, controlplanev1.CertificatesRotatedCondition, controlplanev1.CertificateRotationFailedReason,
+			clusterv1.ConditionSeverityError, "Failed to rotate the certificate authorities, set a new value to retry: %s", err.Error())
+		r.recorder.Eventf(rcp, corev1.EventTypeWarning, "CertificateAuthoritiesRotationFailed",
+			"Failed to rotate the certificate authorities: %v", err)
+
+		return ctrl.Result{RequeueAfter: preflightFailedRequeueAfter}, nil
+	}
+
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("rotating certificate authorities: %w", err)
+	}
+
+	if !done {
+		logger.Info("Waiting for the certificate authorities to be rotated", "machine", machine.Name)
+
+		return ctrl.Result{RequeueAfter: certificateRotationRequeueAfter}, nil
+	}
+
+	if err := workloadCluster.CleanupCertificateRotation(ctx, jobName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The current certificate authorities remain first in the bundles, as they keep issuing the certificates
+	// of the management cluster until the rollout has completed.
+	for _, certificate := range certificates {
+		rotated := rotatedSecrets[certificate.GetPurpose()]
+
+		bundle := bytes.Join([][]byte{certificate.GetKeyPair().Cert, rotated.Data[secret.TLSCrtDataName]}, nil)
+		if err := r.updateCertificateAuthority(ctx, controlPlane, certificate.GetPurpose(), bundle, certificate.GetKeyPair().Key); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.regenerateKubeconfig(ctx, controlPlane); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	rcp.Status.CertificateRotation.CertificateAuthoritiesPhase = controlplanev1.CertificateAuthoritiesRotationPhaseRollingOut
+	r.recorder.Eventf(rcp, corev1.EventTypeNormal, "CertificateAuthoritiesInstalled",
+		"Installed the rotated certificate authorities, rolling out the control plane machines")
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// completeCertificateAuthoritiesRotation waits for the control plane machines to be rolled out, then switches the
+// management cluster to the new certificate authorities.
+func (r *RKE2ControlPlaneReconciler) completeCertificateAuthoritiesRotation(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (ctrl.Result, error) {
+	rcp := controlPlane.RCP
+	rotation := rcp.Spec.CertificateRotation
+
+	// The machines not running with the new certificate authorities are rolled out by the regular rollout.
+	outdatedMachines := controlPlane.Machines.Filter(func(machine *clusterv1.Machine) bool {
+		return machine.Annotations[controlplanev1.CertificateAuthoritiesRotationAnnotation] != rotation.CertificateAuthorities
+	})
+	if outdatedMachines.Len() > 0 {
+		return ctrl.Result{}, nil
+	}
+
+	certificates, rotatedSecrets, err := r.lookupRotatedCertificateAuthorities(ctx, controlPlane)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for _, certificate := range certificates {
+		rotated := rotatedSecrets[certificate.GetPurpose()]
+
+		if err := r.updateCertificateAuthority(ctx, controlPlane, certificate.GetPurpose(),
+			rotated.Data[secret.TLSCrtDataName], rotated.Data[secret.TLSKeyDataName]); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if err := r.regenerateKubeconfig(ctx, controlPlane); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for _, rotated := range rotatedSecrets {
+		if err := r.Delete(ctx, rotated); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("deleting rotated certificate authority %s: %w", rotated.Name, err)
+		}
+	}
+
+	rcp.Status.CertificateRotation.CertificateAuthorities = rotation.CertificateAuthorities
+	rcp.Status.CertificateRotation.CertificateAuthoritiesPhase = ""
+
+	conditions.MarkTrue(rcp, controlplanev1.CertificatesRotatedCondition)
+	r.recorder.Eventf(rcp, corev1.EventTypeNormal, "CertificateAuthoritiesRotated",
+		"Rotated the certificate authorities of the cluster")
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// lookupRotatedCertificateAuthorities returns the current certificate authorities, along with the Secrets
+// storing the new ones by purpose.
+func (r *RKE2ControlPlaneReconciler) lookupRotatedCertificateAuthorities(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (secret.Certificates, map[secret.Purpose]*corev1.Secret, error) {
+	clusterKey := util.ObjectKey(controlPlane.Cluster)
+
+	certificates := secret.NewCertificatesForInitialControlPlane()
+	if err := certificates.Lookup(ctx, r.Client, clusterKey); err != nil {
+		return nil, nil, fmt.Errorf("looking up certificate authorities: %w", err)
+	}
+
+	rotatedSecrets := map[secret.Purpose]*corev1.Secret{}
+
+	for _, certificate := range certificates {
+		if certificate.GetKeyPair() == nil {
+			return nil, nil, fmt.Errorf("certificate authority %s not found", certificate.GetPurpose())
+		}
+
+		rotated, err := secret.GetFromNamespacedName(ctx, r.Client, clusterKey, rotatedPurpose(certificate.GetPurpose()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting rotated certificate authority %s: %w", certificate.GetPurpose(), err)
+		}
+
+		rotatedSecrets[certificate.GetPurpose()] = rotated
+	}
+
+	return certificates, rotatedSecrets, nil
+}
+
+// updateCertificateAuthority updates the certificate and key of a certificate authority managed for the cluster.
+func (r *RKE2ControlPlaneReconciler) updateCertificateAuthority(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+	purpose secret.Purpose,
+	cert, key []byte,
+) error {
+	s, err := secret.GetFromNamespacedName(ctx, r.Client, util.ObjectKey(controlPlane.Cluster), purpose)
+	if err != nil {
+		return fmt.Errorf("getting certificate authority %s: %w", purpose, err)
+	}
+
+	if bytes.Equal(s.Data[secret.TLSCrtDataName], cert) && bytes.Equal(s.Data[secret.TLSKeyDataName], key) {
+		return nil
+	}
+
+	s.Data[secret.TLSCrtDataName] = cert
+	s.Data[secret.TLSKeyDataName] = key
+
+	if err := r.Update(ctx, s); err != nil {
+		return fmt.Errorf("updating certificate authority %s: %w", purpose, err)
+	}
+
+	return nil
+}
+
+// regenerateKubeconfig regenerates the kubeconfig of the cluster with the current certificate authorities.
+func (r *RKE2ControlPlaneReconciler) regenerateKubeconfig(ctx context.Context, controlPlane *rke2.ControlPlane) error {
+	clusterKey := util.ObjectKey(controlPlane.Cluster)
+
+	configSecret, err := secret.GetFromNamespacedName(ctx, r.Client, clusterKey, secret.Kubeconfig)
+	if err != nil {
+		return fmt.Errorf("getting kubeconfig secret: %w", err)
+	}
+
+	if err := kubeconfig.UpdateSecret(ctx, r.Client, clusterKey,
+		controlPlane.Cluster.Spec.ControlPlaneEndpoint.String(), configSecret); err != nil {
+		return fmt.Errorf("regenerating kubeconfig: %w", err)
+	}
+
+	return nil
+}
+
+// patchMachineAnnotations sets and removes annotations of a control plane Machine.
+func (r *RKE2ControlPlaneReconciler) patchMachineAnnotations(
+	ctx context.Context,
+	machine *clusterv1.Machine,
+	set map[string]string,
+	remove ...string,
+) error {
+	patchHelper, err := patch.NewHelper(machine, r.Client)
+	if err != nil {
+		return fmt.Errorf("creating patch helper for machine %s: %w", machine.Name, err)
+	}
+
+	if machine.Annotations == nil {
+		machine.Annotations = map[string]string{}
+	}
+
+	for key, value := range set {
+		machine.Annotations[key] = value
+	}
+
+	for _, key := range remove {
+		delete(machine.Annotations, key)
+	}
+
+	if err := patchHelper.Patch(ctx, machine); err != nil {
+		return fmt.Errorf("patching machine %s: %w", machine.Name, err)
+	}
+
+	return nil
+}
+
+// certificateRotationAnnotations returns the certificate rotation annotations of a new control plane Machine.
+func certificateRotationAnnotations(rcp *controlplanev1.RKE2ControlPlane) map[string]string {
+	annotations := map[string]string{}
+
+	rotation := rcp.Spec.CertificateRotation
+	if rotation == nil {
+		return annotations
+	}
+
+	if rotation.LeafCertificates != "" {
+		annotations[controlplanev1.LeafCertificatesRotationAnnotation] = rotation.LeafCertificates
+	}
+
+	// The certificate authorities are only rotated in the workload cluster once the rollout starts.
+	status := rcp.Status.CertificateRotation
+	if rotation.CertificateAuthorities != "" && status != nil &&
+		(status.CertificateAuthorities == rotation.CertificateAuthorities ||
+			status.CertificateAuthoritiesPhase == controlplanev1.CertificateAuthoritiesRotationPhaseRollingOut) {
+		annotations[controlplanev1.CertificateAuthoritiesRotationAnnotation] = rotation.CertificateAuthorities
+	}
+
+	return annotations
+}
+
+// rotationJobName returns the name of a Job run on the workload cluster, with the given prefix and unique for the given values.
+func rotationJobName(prefix string, values ...string) (string, error) {
+	h, err := hash.Compute(values)
+	if err != nil {
+		return "", fmt.Errorf("computing rotation job name: %w", err)
+	}
+
+	return fmt.Sprintf("%s-%d", prefix, h), nil
+}
+
+// rotatedPurpose returns the purpose of the Secret storing the new certificate authority while it is rotated.
+func rotatedPurpose(purpose secret.Purpose) secret.Purpose {
+	return secret.Purpose(fmt.Sprintf("%s-rotation", purpose))
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/kubeconfig"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/secret"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Certificate rotation", func() {
+	var (
+		cluster  *clusterv1.Cluster
+		rcp      *controlplanev1.RKE2ControlPlane
+		workload *fakeWorkloadCluster
+		m        *fakeManagementCluster
+		r        *RKE2ControlPlaneReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "rotation"},
+			Spec: clusterv1.ClusterSpec{
+				ControlPlaneEndpoint: clusterv1.APIEndpoint{Host: "example.com", Port: 6443},
+			},
+		}
+		rcp = &controlplanev1.RKE2ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "rotation", UID: "rcp-uid"},
+			Spec: controlplanev1.RKE2ControlPlaneSpec{
+				Version:             "v1.31.2+rke2r1",
+				CertificateRotation: &controlplanev1.CertificateRotation{Image: "registry.example.com/busybox"},
+			},
+			Status: controlplanev1.RKE2ControlPlaneStatus{Initialized: true},
+		}
+
+		objects := []client.Object{}
+		for i := range 2 {
+			machine := newTestMachine(fmt.Sprintf("machine-%d", i), "rotation", time.Now().Add(time.Duration(i)*time.Minute))
+			machine.Spec.Version = ptr.To("v1.31.2+rke2r1")
+			conditions.MarkTrue(machine, controlplanev1.MachineAgentHealthyCondition)
+			conditions.MarkTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition)
+			machine.Annotations = map[string]string{
+				controlplanev1.APIServerCertificateExpiryAnnotation: time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			}
+			objects = append(objects, machine)
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).WithStatusSubresource(objects...).Build()
+
+		certificates := secret.NewCertificatesForInitialControlPlane()
+		Expect(certificates.Generate()).To(Succeed())
+		Expect(certificates.SaveGenerated(ctx, c, client.ObjectKeyFromObject(cluster), metav1.OwnerReference{})).To(Succeed())
+		Expect(kubeconfig.CreateSecret(ctx, c, cluster)).To(Succeed())
+
+		workload = &fakeWorkloadCluster{}
+		m = &fakeManagementCluster{Client: c, workload: workload}
+		r = &RKE2ControlPlaneReconciler{Client: c, recorder: record.NewFakeRecorder(32)}
+	})
+
+	reconcile := func() (ctrl.Result, error) {
+		return r.reconcileCertificateRotation(ctx, newTestControlPlane(m, cluster, rcp))
+	}
+
+	getSecret := func(purpose secret.Purpose) *corev1.Secret {
+		s, err := secret.GetFromNamespacedName(ctx, m, client.ObjectKeyFromObject(cluster), purpose)
+		Expect(err).ToNot(HaveOccurred())
+
+		return s
+	}
+
+	// kubeconfigCA returns the certificate authorities trusted by the kubeconfig of the cluster.
+	kubeconfigCA := func() []byte {
+		config, err := clientcmd.Load(getSecret(secret.Kubeconfig).Data[secret.KubeconfigDataName])
+		Expect(err).ToNot(HaveOccurred())
+
+		return config.Clusters["test"].CertificateAuthorityData
+	}
+
+	jobNames := func() []string {
+		names := []string{}
+		for name := range workload.certificateRotationJobs {
+			names = append(names, name)
+		}
+
+		return names
+	}
+
+	Context("of the certificate authorities", func() {
+		BeforeEach(func() {
+			rcp.Spec.CertificateRotation.CertificateAuthorities = "1"
+		})
+
+		It("should prepare, install and roll out the new certificate authorities", func() {
+			currentCA := getSecret(secret.ClusterCA)
+
+			result, err := reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+			Expect(rcp.Status.CertificateRotation.CertificateAuthoritiesPhase).To(
+				Equal(controlplanev1.CertificateAuthoritiesRotationPhaseRotating))
+
+			rotatedCA := getSecret(rotatedPurpose(secret.ClusterCA))
+			Expect(rotatedCA.Data[secret.TLSCrtDataName]).ToNot(Equal(currentCA.Data[secret.TLSCrtDataName]))
+			Expect(rotatedCA.Data[crossSignedCrtDataName]).ToNot(BeEmpty())
+			for _, purpose := range []secret.Purpose{secret.ClientClusterCA, secret.EtcdCA, secret.EtcdServerCA} {
+				getSecret(rotatedPurpose(purpose))
+			}
+
+			// The new certificate authorities are installed from the oldest machine.
+			result, err = reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(certificateRotationRequeueAfter))
+			Expect(workload.certificateRotationJobs).To(HaveLen(1))
+			Expect(workload.certificateRotationJobs).To(ContainElement("machine-0"))
+			Expect(workload.certificateRotationFiles).To(HaveKey("etcd/peer-ca.crt"))
+			Expect(workload.certificateRotationFiles["server-ca.crt"]).To(Equal(bytes.Join([][]byte{
+				rotatedCA.Data[secret.TLSCrtDataName],
+				rotatedCA.Data[crossSignedCrtDataName],
+				currentCA.Data[secret.TLSCrtDataName],
+			}, nil)))
+			Expect(workload.certificateRotationFiles["server-ca.key"]).To(Equal(rotatedCA.Data[secret.TLSKeyDataName]))
+			Expect(getSecret(secret.ClusterCA).Data).To(Equal(currentCA.Data))
+
+			// The management cluster trusts both certificate authorities during the rollout.
+			workload.certificateRotationDone = true
+			result, err = reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+			Expect(workload.cleanedUpJobNames).To(ConsistOf(jobNames()))
+			Expect(rcp.Status.CertificateRotation.CertificateAuthoritiesPhase).To(
+				Equal(controlplanev1.CertificateAuthoritiesRotationPhaseRollingOut))
+
+			bundle := bytes.Join([][]byte{currentCA.Data[secret.TLSCrtDataName], rotatedCA.Data[secret.TLSCrtDataName]}, nil)
+			Expect(getSecret(secret.ClusterCA).Data[secret.TLSCrtDataName]).To(Equal(bundle))
+			Expect(getSecret(secret.ClusterCA).Data[secret.TLSKeyDataName]).To(Equal(currentCA.Data[secret.TLSKeyDataName]))
+			Expect(kubeconfigCA()).To(Equal(bundle))
+
+			// The rotation waits for the machines to be rolled out.
+			result, err = reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.IsZero()).To(BeTrue())
+			Expect(conditions.GetReason(rcp, controlplanev1.CertificatesRotatedCondition)).To(
+				Equal(controlplanev1.CertificateRotationInProgressReason))
+
+			machines := &clusterv1.MachineList{}
+			Expect(m.List(ctx, machines)).To(Succeed())
+			for i := range machines.Items {
+				machines.Items[i].Annotations[controlplanev1.CertificateAuthoritiesRotationAnnotation] = "1"
+				Expect(m.Update(ctx, &machines.Items[i])).To(Succeed())
+			}
+
+			// The management cluster switches to the new certificate authorities once the rollout has completed.
+			result, err = reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+			Expect(rcp.Status.CertificateRotation.CertificateAuthorities).To(Equal("1"))
+			Expect(rcp.Status.CertificateRotation.CertificateAuthoritiesPhase).To(BeEmpty())
+			Expect(conditions.IsTrue(rcp, controlplanev1.CertificatesRotatedCondition)).To(BeTrue())
+
+			Expect(getSecret(secret.ClusterCA).Data[secret.TLSCrtDataName]).To(Equal(rotatedCA.Data[secret.TLSCrtDataName]))
+			Expect(getSecret(secret.ClusterCA).Data[secret.TLSKeyDataName]).To(Equal(rotatedCA.Data[secret.TLSKeyDataName]))
+			Expect(kubeconfigCA()).To(Equal(rotatedCA.Data[secret.TLSCrtDataName]))
+
+			err = m.Get(ctx, client.ObjectKey{Namespace: "rotation", Name: secret.Name("test", rotatedPurpose(secret.ClusterCA))}, &corev1.Secret{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should report a failed rotation until a new value is set", func() {
+			currentCA := getSecret(secret.ClusterCA)
+
+			_, err := reconcile()
+			Expect(err).ToNot(HaveOccurred())
+
+			workload.certificateRotationErr = fmt.Errorf("%w: job failed", rke2.ErrCertificateRotationFailed)
+			result, err := reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(preflightFailedRequeueAfter))
+			Expect(conditions.GetReason(rcp, controlplanev1.CertificatesRotatedCondition)).To(
+				Equal(controlplanev1.CertificateRotationFailedReason))
+			Expect(conditions.GetMessage(rcp, controlplanev1.CertificatesRotatedCondition)).To(ContainSubstring("set a new value to retry"))
+			Expect(rcp.Status.CertificateRotation.CertificateAuthoritiesPhase).To(
+				Equal(controlplanev1.CertificateAuthoritiesRotationPhaseRotating))
+			Expect(workload.cleanedUpJobNames).To(BeEmpty())
+			Expect(getSecret(secret.ClusterCA).Data).To(Equal(currentCA.Data))
+
+			// The failed Job is kept, the same Job is checked again.
+			_, err = reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(workload.certificateRotationJobs).To(HaveLen(1))
+			failedJob := jobNames()[0]
+
+			// A new value runs a new Job, from the phase that failed.
+			rcp.Spec.CertificateRotation.CertificateAuthorities = "2"
+			workload.certificateRotationErr = nil
+			workload.certificateRotationDone = true
+			result, err = reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+			Expect(workload.certificateRotationJobs).To(HaveLen(2))
+			Expect(workload.cleanedUpJobNames).To(HaveLen(1))
+			Expect(workload.cleanedUpJobNames).ToNot(ContainElement(failedJob))
+			Expect(rcp.Status.CertificateRotation.CertificateAuthoritiesPhase).To(
+				Equal(controlplanev1.CertificateAuthoritiesRotationPhaseRollingOut))
+		})
+
+		It("should not rotate the certificate authorities with an external datastore", func() {
+			rcp.Spec.ServerConfig.ExternalDatastoreSecret = &corev1.ObjectReference{Name: "datastore"}
+
+			result, err := reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.IsZero()).To(BeTrue())
+			Expect(conditions.GetReason(rcp, controlplanev1.CertificatesRotatedCondition)).To(
+				Equal(controlplanev1.CertificateRotationFailedReason))
+			Expect(rcp.Status.CertificateRotation.CertificateAuthoritiesPhase).To(BeEmpty())
+
+			err = m.Get(ctx, client.ObjectKey{Namespace: "rotation", Name: secret.Name("test", rotatedPurpose(secret.ClusterCA))}, &corev1.Secret{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("of the leaf certificates", func() {
+		BeforeEach(func() {
+			rcp.Spec.CertificateRotation.LeafCertificates = "1"
+		})
+
+		It("should rotate the leaf certificates one machine at a time", func() {
+			for _, name := range []string{"machine-0", "machine-1"} {
+				result, err := reconcile()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(certificateRotationRequeueAfter))
+				Expect(workload.certificateRotationJobs).To(HaveLen(1))
+				Expect(workload.certificateRotationJobs).To(ContainElement(name))
+				Expect(conditions.GetReason(rcp, controlplanev1.CertificatesRotatedCondition)).To(
+					Equal(controlplanev1.CertificateRotationInProgressReason))
+
+				workload.certificateRotationDone = true
+				result, err = reconcile()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.Requeue).To(BeTrue())
+				Expect(workload.cleanedUpJobNames).To(ConsistOf(jobNames()))
+
+				machine := &clusterv1.Machine{}
+				Expect(m.Get(ctx, client.ObjectKey{Namespace: "rotation", Name: name}, machine)).To(Succeed())
+				Expect(machine.Annotations).To(HaveKeyWithValue(controlplanev1.LeafCertificatesRotationAnnotation, "1"))
+				Expect(machine.Annotations).ToNot(HaveKey(controlplanev1.APIServerCertificateExpiryAnnotation))
+
+				workload.certificateRotationJobs = nil
+				workload.certificateRotationDone = false
+				workload.cleanedUpJobNames = nil
+			}
+
+			result, err := reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.IsZero()).To(BeTrue())
+			Expect(workload.certificateRotationJobs).To(BeEmpty())
+			Expect(rcp.Status.CertificateRotation.LeafCertificates).To(Equal("1"))
+			Expect(conditions.IsTrue(rcp, controlplanev1.CertificatesRotatedCondition)).To(BeTrue())
+		})
+
+		It("should report a failed rotation until a new value is set", func() {
+			workload.certificateRotationErr = fmt.Errorf("%w: job failed", rke2.ErrCertificateRotationFailed)
+
+			result, err := reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(preflightFailedRequeueAfter))
+			Expect(conditions.GetReason(rcp, controlplanev1.CertificatesRotatedCondition)).To(
+				Equal(controlplanev1.CertificateRotationFailedReason))
+			Expect(workload.cleanedUpJobNames).To(BeEmpty())
+			failedJob := jobNames()[0]
+
+			rcp.Spec.CertificateRotation.LeafCertificates = "2"
+			workload.certificateRotationErr = nil
+			result, err = reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(certificateRotationRequeueAfter))
+			Expect(workload.certificateRotationJobs).To(HaveLen(2))
+			Expect(workload.certificateRotationJobs).To(HaveKey(failedJob))
+			Expect(workload.certificateRotationJobs).To(HaveEach("machine-0"))
+		})
+
+		It("should wait for the machines to be healthy", func() {
+			machine := &clusterv1.Machine{}
+			Expect(m.Get(ctx, client.ObjectKey{Namespace: "rotation", Name: "machine-1"}, machine)).To(Succeed())
+			conditions.MarkFalse(machine, controlplanev1.MachineAgentHealthyCondition, "Unhealthy", clusterv1.ConditionSeverityError, "")
+			Expect(m.Status().Update(ctx, machine)).To(Succeed())
+
+			result, err := reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(preflightFailedRequeueAfter))
+			Expect(workload.certificateRotationJobs).To(BeEmpty())
+		})
+	})
+})
@@ -26,4 +26,24 @@ const (
 	// preflightFailedRequeueAfter is how long to wait before trying to scale
 	// up/down if some preflight check for those operation has failed.
 	preflightFailedRequeueAfter = 15 * time.Second
+
+	// etcdSnapshotRequeueAfter is how long to wait before checking again
+	// the progress of an etcd snapshot.
+	etcdSnapshotRequeueAfter = 10 * time.Second
+
+	// inPlaceUpgradeRequeueAfter is how long to wait before checking again
+	// the progress of the in-place upgrade of a machine.
+	inPlaceUpgradeRequeueAfter = 20 * time.Second
+
+	// certificateRotationRequeueAfter is how long to wait before checking again
+	// the progress of a certificate rotation.
+	certificateRotationRequeueAfter = 20 * time.Second
+
+	// secretsEncryptionRotationRequeueAfter is how long to wait before checking again
+	// the progress of a secrets encryption keys rotation.
+	secretsEncryptionRotationRequeueAfter = 20 * time.Second
+
+	// etcdRestoreRequeueAfter is how long to wait before checking again
+	// the progress of an etcd restore.
+	etcdRestoreRequeueAfter = 20 * time.Second
 )
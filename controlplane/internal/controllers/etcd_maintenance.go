@@ -0,0 +1,244 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/etcd"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
+)
+
+const (
+	// etcdMaintenanceRequeueAfter is how long to wait after defragmenting an etcd member before defragmenting
+	// the next one, to let it catch up with the cluster.
+	etcdMaintenanceRequeueAfter = 30 * time.Second
+
+	// minEtcdDefragmentationInterval is the minimum interval between two defragmentations of an etcd member,
+	// whatever triggers them, so that a member whose database does not shrink is not defragmented endlessly.
+	minEtcdDefragmentationInterval = time.Hour
+
+	// etcdDBSizeRecordingThreshold is the change of the database size of an etcd member from which the size recorded
+	// on its Machine is updated, so that the Machine is not patched on every write to etcd.
+	etcdDBSizeRecordingThreshold = 1 << 20
+)
+
+// defaultEtcdMaintenanceMinDBSize is the database size under which an etcd member is not defragmented for reaching
+// the fragmentation threshold, unless etcd.maintenance.minDBSize is set.
+var defaultEtcdMaintenanceMinDBSize = resource.MustParse("100Mi")
+
+// reconcileEtcdMaintenance records the database size of the etcd members on their Machines, and defragments
+// the members requiring it according to the etcd maintenance policy, one at a time and the leader last.
+// It only runs when the control plane is stable, and failures do not block the reconciliation.
+func (r *RKE2ControlPlaneReconciler) reconcileEtcdMaintenance(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileEtcdMaintenance")
+	defer tracing.End(span, &reterr)
+
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+	maintenance := rcp.Spec.ServerConfig.Etcd.Maintenance
+
+	if maintenance == nil || !controlPlane.UsesEmbeddedEtcd() {
+		conditions.Delete(rcp, controlplanev1.EtcdMaintenanceSucceededCondition)
+
+		return ctrl.Result{}, nil
+	}
+
+	if !rcp.Status.Initialized || controlPlane.HasDeletingMachine() {
+		return ctrl.Result{}, nil
+	}
+
+	for _, machine := range controlPlane.Machines {
+		if machine.Status.NodeRef == nil || !etcdMemberHoldsQuorum(machine) {
+			logger.V(5).Info("Waiting for the etcd members to be healthy before maintenance")
+
+			return ctrl.Result{}, nil
+		}
+	}
+
+	workloadCluster, err := controlPlane.GetWorkloadCluster(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	candidates := []*clusterv1.Machine{}
+	statuses := map[string]*rke2.EtcdDatabaseStatus{}
+
+	var leader *clusterv1.Machine
+
+	for _, machine := range controlPlane.Machines.SortedByCreationTimestamp() {
+		status, err := workloadCluster.EtcdDatabaseStatus(ctx, machine)
+		if err != nil {
+			logger.Error(err, "Failed to read etcd member status", "machine", machine.Name)
+			conditions.MarkFalse(rcp, controlplanev1.EtcdMaintenanceSucceededCondition,
+				controlplanev1.EtcdMaintenanceInspectionFailedReason, clusterv1.ConditionSeverityWarning,
+				"Failed to read the etcd member status of machine %s: %v", machine.Name, err)
+
+			return ctrl.Result{}, nil
+		}
+
+		statuses[machine.Name] = status
+
+		if etcdDBSizeChanged(machine, controlplanev1.EtcdDBSizeAnnotation, status.DBSize) ||
+			etcdDBSizeChanged(machine, controlplanev1.EtcdDBSizeInUseAnnotation, status.DBSizeInUse) {
+			if err := r.patchMachineAnnotations(ctx, machine, map[string]string{
+				controlplanev1.EtcdDBSizeAnnotation:      strconv.FormatInt(status.DBSize, 10),
+				controlplanev1.EtcdDBSizeInUseAnnotation: strconv.FormatInt(status.DBSizeInUse, 10),
+			}); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		if !needsEtcdDefragmentation(maintenance, machine, status, now) {
+			continue
+		}
+
+		if status.Leader {
+			leader = machine
+		} else {
+			candidates = append(candidates, machine)
+		}
+	}
+
+	// The leader is defragmented last, as defragmenting it first would trigger a leader election.
+	if leader != nil {
+		candidates = append(candidates, leader)
+	}
+
+	if len(candidates) == 0 {
+		conditions.MarkTrue(rcp, controlplanev1.EtcdMaintenanceSucceededCondition)
+
+		return ctrl.Result{}, nil
+	}
+
+	machine := candidates[0]
+	status := statuses[machine.Name]
+
+	conditions.MarkFalse(rcp, controlplanev1.EtcdMaintenanceSucceededCondition,
+		controlplanev1.EtcdDefragmentationInProgressReason, clusterv1.ConditionSeverityInfo,
+		"Defragmenting the etcd member of machine %s", machine.Name)
+
+	if err := workloadCluster.DefragmentEtcdMember(ctx, machine); err != nil {
+		logger.Error(err, "Failed to defragment etcd member", "machine", machine.Name)
+		r.recorder.Eventf(rcp, corev1.EventTypeWarning, "EtcdDefragmentationFailed",
+			"Failed to defragment the etcd member of machine %s: %v", machine.Name, err)
+		conditions.MarkFalse(rcp, controlplanev1.EtcdMaintenanceSucceededCondition,
+			controlplanev1.EtcdDefragmentationFailedReason, clusterv1.ConditionSeverityWarning,
+			"Failed to defragment the etcd member of machine %s: %v", machine.Name, err)
+
+		return ctrl.Result{RequeueAfter: etcdMaintenanceRequeueAfter}, nil
+	}
+
+	r.recorder.Eventf(rcp, corev1.EventTypeNormal, "EtcdMemberDefragmented",
+		"Defragmented the etcd member of machine %s", machine.Name)
+
+	if err := r.patchMachineAnnotations(ctx, machine, map[string]string{
+		controlplanev1.EtcdDefragmentedAnnotation: now.UTC().Format(time.RFC3339),
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if maintenance.DisarmNoSpaceAlarm && slices.Contains(status.Alarms, etcd.AlarmNoSpace) {
+		if err := workloadCluster.DisarmEtcdMemberAlarm(ctx, machine, etcd.AlarmNoSpace); err != nil {
+			logger.Error(err, "Failed to disarm etcd member alarm", "machine", machine.Name)
+			conditions.MarkFalse(rcp, controlplanev1.EtcdMaintenanceSucceededCondition,
+				controlplanev1.EtcdAlarmDisarmFailedReason, clusterv1.ConditionSeverityWarning,
+				"Failed to disarm the NOSPACE alarm of the etcd member of machine %s: %v", machine.Name, err)
+
+			return ctrl.Result{RequeueAfter: etcdMaintenanceRequeueAfter}, nil
+		}
+
+		r.recorder.Eventf(rcp, corev1.EventTypeNormal, "EtcdAlarmDisarmed",
+			"Disarmed the NOSPACE alarm of the etcd member of machine %s", machine.Name)
+	}
+
+	return ctrl.Result{RequeueAfter: etcdMaintenanceRequeueAfter}, nil
+}
+
+// needsEtcdDefragmentation returns whether the etcd member of a Machine must be defragmented: when it raised
+// a NOSPACE alarm, when its fragmentation reached the threshold, or when the defragmentation interval elapsed
+// since its last defragmentation or the creation of the Machine.
+func needsEtcdDefragmentation(
+	maintenance *controlplanev1.EtcdMaintenance,
+	machine *clusterv1.Machine,
+	status *rke2.EtcdDatabaseStatus,
+	now time.Time,
+) bool {
+	lastDefragmentation := machine.CreationTimestamp.Time
+	if value, ok := machine.Annotations[controlplanev1.EtcdDefragmentedAnnotation]; ok {
+		if defragmented, err := time.Parse(time.RFC3339, value); err == nil {
+			if now.Sub(defragmented) < minEtcdDefragmentationInterval {
+				return false
+			}
+
+			lastDefragmentation = defragmented
+		}
+	}
+
+	if slices.Contains(status.Alarms, etcd.AlarmNoSpace) {
+		return true
+	}
+
+	if maintenance.DefragmentationInterval != nil && !now.Before(lastDefragmentation.Add(maintenance.DefragmentationInterval.Duration)) {
+		return true
+	}
+
+	if maintenance.FragmentationThresholdPercent != nil && status.DBSize > 0 {
+		minDBSize := defaultEtcdMaintenanceMinDBSize
+		if maintenance.MinDBSize != nil {
+			minDBSize = *maintenance.MinDBSize
+		}
+
+		fragmented := (status.DBSize - status.DBSizeInUse) * 100 / status.DBSize
+
+		return status.DBSize >= minDBSize.Value() && fragmented >= int64(*maintenance.FragmentationThresholdPercent)
+	}
+
+	return false
+}
+
+// etcdDBSizeChanged returns whether a database size differs enough from the size recorded in an annotation
+// of a Machine to be recorded again.
+func etcdDBSizeChanged(machine *clusterv1.Machine, annotation string, size int64) bool {
+	recorded, err := strconv.ParseInt(machine.Annotations[annotation], 10, 64)
+	if err != nil {
+		return true
+	}
+
+	diff := size - recorded
+	if diff < 0 {
+		diff = -diff
+	}
+
+	return diff >= etcdDBSizeRecordingThreshold
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/etcd"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Etcd maintenance", func() {
+	var (
+		now     time.Time
+		machine *clusterv1.Machine
+		status  *rke2.EtcdDatabaseStatus
+	)
+
+	BeforeEach(func() {
+		now = time.Now()
+		machine = &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "machine",
+				CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour)),
+				Annotations:       map[string]string{},
+			},
+		}
+		status = &rke2.EtcdDatabaseStatus{
+			DBSize:      200 * 1024 * 1024,
+			DBSizeInUse: 150 * 1024 * 1024,
+			Alarms:      []etcd.AlarmType{},
+		}
+	})
+
+	It("should not defragment without a trigger", func() {
+		Expect(needsEtcdDefragmentation(&controlplanev1.EtcdMaintenance{}, machine, status, now)).To(BeFalse())
+	})
+
+	It("should defragment a member with a NOSPACE alarm", func() {
+		status.Alarms = []etcd.AlarmType{etcd.AlarmNoSpace}
+
+		Expect(needsEtcdDefragmentation(&controlplanev1.EtcdMaintenance{}, machine, status, now)).To(BeTrue())
+	})
+
+	It("should defragment a member once the interval elapsed since the creation of the machine", func() {
+		maintenance := &controlplanev1.EtcdMaintenance{
+			DefragmentationInterval: &metav1.Duration{Duration: 24 * time.Hour},
+		}
+
+		Expect(needsEtcdDefragmentation(maintenance, machine, status, now)).To(BeTrue())
+
+		machine.CreationTimestamp = metav1.NewTime(now.Add(-12 * time.Hour))
+		Expect(needsEtcdDefragmentation(maintenance, machine, status, now)).To(BeFalse())
+	})
+
+	It("should defragment a member once the interval elapsed since its last defragmentation", func() {
+		maintenance := &controlplanev1.EtcdMaintenance{
+			DefragmentationInterval: &metav1.Duration{Duration: 24 * time.Hour},
+		}
+
+		machine.Annotations[controlplanev1.EtcdDefragmentedAnnotation] = now.Add(-12 * time.Hour).UTC().Format(time.RFC3339)
+		Expect(needsEtcdDefragmentation(maintenance, machine, status, now)).To(BeFalse())
+
+		machine.Annotations[controlplanev1.EtcdDefragmentedAnnotation] = now.Add(-25 * time.Hour).UTC().Format(time.RFC3339)
+		Expect(needsEtcdDefragmentation(maintenance, machine, status, now)).To(BeTrue())
+	})
+
+	It("should defragment a member reaching the fragmentation threshold above the minimum database size", func() {
+		maintenance := &controlplanev1.EtcdMaintenance{
+			FragmentationThresholdPercent: ptr.To[int32](25),
+		}
+
+		Expect(needsEtcdDefragmentation(maintenance, machine, status, now)).To(BeTrue())
+
+		maintenance.FragmentationThresholdPercent = ptr.To[int32](30)
+		Expect(needsEtcdDefragmentation(maintenance, machine, status, now)).To(BeFalse())
+
+		maintenance.FragmentationThresholdPercent = ptr.To[int32](25)
+		maintenance.MinDBSize = ptr.To(resource.MustParse("1Gi"))
+		Expect(needsEtcdDefragmentation(maintenance, machine, status, now)).To(BeFalse())
+	})
+
+	It("should not defragment a member defragmented less than an hour ago", func() {
+		status.Alarms = []etcd.AlarmType{etcd.AlarmNoSpace}
+		machine.Annotations[controlplanev1.EtcdDefragmentedAnnotation] = now.Add(-30 * time.Minute).UTC().Format(time.RFC3339)
+
+		Expect(needsEtcdDefragmentation(&controlplanev1.EtcdMaintenance{}, machine, status, now)).To(BeFalse())
+	})
+
+	It("should record the database size when it changed enough", func() {
+		Expect(etcdDBSizeChanged(machine, controlplanev1.EtcdDBSizeAnnotation, status.DBSize)).To(BeTrue())
+
+		machine.Annotations[controlplanev1.EtcdDBSizeAnnotation] = "209715200"
+		Expect(etcdDBSizeChanged(machine, controlplanev1.EtcdDBSizeAnnotation, status.DBSize+1024)).To(BeFalse())
+		Expect(etcdDBSizeChanged(machine, controlplanev1.EtcdDBSizeAnnotation, status.DBSize-2*1024*1024)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Etcd maintenance of a member with a NOSPACE alarm", func() {
+	var (
+		cluster  *clusterv1.Cluster
+		rcp      *controlplanev1.RKE2ControlPlane
+		workload *fakeWorkloadCluster
+		m        *fakeManagementCluster
+		r        *RKE2ControlPlaneReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "nospace"}}
+		rcp = &controlplanev1.RKE2ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "nospace"},
+			Spec: controlplanev1.RKE2ControlPlaneSpec{
+				Replicas: ptr.To[int32](3),
+				ServerConfig: controlplanev1.RKE2ServerConfig{Etcd: controlplanev1.EtcdConfig{
+					Maintenance: &controlplanev1.EtcdMaintenance{DisarmNoSpaceAlarm: true},
+				}},
+			},
+			Status: controlplanev1.RKE2ControlPlaneStatus{Initialized: true},
+		}
+
+		objects := []client.Object{}
+		workload = &fakeWorkloadCluster{etcdDatabaseStatuses: map[string]*rke2.EtcdDatabaseStatus{}}
+		for i := range 3 {
+			machine := newTestMachine(fmt.Sprintf("machine-%d", i), "nospace", time.Now().Add(time.Duration(i)*time.Minute))
+			conditions.MarkTrue(machine, controlplanev1.MachineAgentHealthyCondition)
+			conditions.MarkTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition)
+			objects = append(objects, machine)
+			workload.etcdDatabaseStatuses[machine.Name] = &rke2.EtcdDatabaseStatus{
+				Leader: i == 0,
+				DBSize: 100 * 1024 * 1024,
+				Alarms: []etcd.AlarmType{},
+			}
+		}
+
+		// The member of machine-1 ran out of space, which is its only issue.
+		conditions.MarkFalse(objects[1].(*clusterv1.Machine), controlplanev1.MachineEtcdMemberHealthyCondition,
+			controlplanev1.EtcdMemberNoSpaceReason, clusterv1.ConditionSeverityWarning, "Etcd member raised a NOSPACE alarm")
+		workload.etcdDatabaseStatuses["machine-1"].Alarms = []etcd.AlarmType{etcd.AlarmNoSpace}
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).WithStatusSubresource(objects...).Build()
+		m = &fakeManagementCluster{Client: c, workload: workload}
+		r = &RKE2ControlPlaneReconciler{Client: c, recorder: record.NewFakeRecorder(32)}
+	})
+
+	It("should pass the preflight checks", func() {
+		Expect(r.preflightChecks(ctx, newTestControlPlane(m, cluster, rcp))).To(Equal(ctrl.Result{}))
+	})
+
+	It("should defragment the member and disarm its alarm", func() {
+		result, err := r.reconcileEtcdMaintenance(ctx, newTestControlPlane(m, cluster, rcp))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: etcdMaintenanceRequeueAfter}))
+		Expect(workload.defragmentedMembers).To(Equal([]string{"machine-1"}))
+		Expect(workload.disarmedAlarms).To(Equal([]string{"machine-1/NOSPACE"}))
+
+		machine := &clusterv1.Machine{}
+		Expect(m.Get(ctx, client.ObjectKey{Namespace: "nospace", Name: "machine-1"}, machine)).To(Succeed())
+		Expect(machine.Annotations).To(HaveKey(controlplanev1.EtcdDefragmentedAnnotation))
+	})
+
+	It("should wait for a member with another issue to recover", func() {
+		machine := &clusterv1.Machine{}
+		Expect(m.Get(ctx, client.ObjectKey{Namespace: "nospace", Name: "machine-2"}, machine)).To(Succeed())
+		conditions.MarkFalse(machine, controlplanev1.MachineEtcdMemberHealthyCondition,
+			controlplanev1.EtcdMemberUnhealthyReason, clusterv1.ConditionSeverityError, "Etcd member reports errors")
+		Expect(m.Status().Update(ctx, machine)).To(Succeed())
+
+		result, err := r.reconcileEtcdMaintenance(ctx, newTestControlPlane(m, cluster, rcp))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(workload.defragmentedMembers).To(BeEmpty())
+	})
+})
@@ -0,0 +1,124 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
+)
+
+// reconcileEtcdMembers removes the etcd members left behind by Machines which were deleted without their member
+// being removed, e.g. crashed or force-deleted Machines, so that the list of members is in sync with the Machines.
+// The members are removed one at a time, and only if the remaining healthy members keep the quorum.
+func (r *RKE2ControlPlaneReconciler) reconcileEtcdMembers(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileEtcdMembers")
+	defer tracing.End(span, &reterr)
+
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+
+	if !controlPlane.UsesEmbeddedEtcd() || !rcp.Status.Initialized {
+		return ctrl.Result{}, nil
+	}
+
+	// The member of a Machine still provisioning can not be told apart from an orphaned member until
+	// the Machine has a Node.
+	nodeNames := sets.New[string]()
+
+	for _, machine := range controlPlane.Machines {
+		if machine.Status.NodeRef == nil {
+			return ctrl.Result{}, nil
+		}
+
+		nodeNames.Insert(machine.Status.NodeRef.Name)
+	}
+
+	workloadCluster, err := controlPlane.GetWorkloadCluster(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	members, err := workloadCluster.EtcdMembers(ctx)
+	if err != nil {
+		logger.Error(err, "Failed to list etcd members")
+
+		return ctrl.Result{}, nil
+	}
+
+	orphans := []string{}
+
+	for _, member := range members {
+		// Members which have not started yet have no name.
+		if member != "" && !nodeNames.Has(member) {
+			orphans = append(orphans, member)
+		}
+	}
+
+	if len(orphans) == 0 || len(members) <= 1 {
+		return ctrl.Result{}, nil
+	}
+
+	slices.Sort(orphans)
+
+	// The orphaned members are assumed to be unhealthy, so the quorum of the remaining members must be held by
+	// the healthy members of the Machines.
+	healthyMembers := 0
+
+	for _, machine := range controlPlane.Machines {
+		if slices.Contains(members, machine.Status.NodeRef.Name) &&
+			etcdMemberHoldsQuorum(machine) {
+			healthyMembers++
+		}
+	}
+
+	if quorum := (len(members)-1)/2 + 1; healthyMembers < quorum {
+		logger.Info("Not removing orphaned etcd member, the remaining healthy members would not keep the quorum",
+			"member", orphans[0], "healthyMembers", healthyMembers, "quorum", quorum)
+		r.recorder.Eventf(rcp, corev1.EventTypeWarning, "EtcdMemberRemovalBlocked",
+			"Orphaned etcd member %s is not removed, only %d healthy members would remain for a quorum of %d",
+			orphans[0], healthyMembers, quorum)
+
+		return ctrl.Result{RequeueAfter: DefaultRequeueTime}, nil
+	}
+
+	logger.Info("Removing orphaned etcd member", "member", orphans[0])
+
+	removed, err := workloadCluster.RemoveOrphanedEtcdMember(ctx, orphans[0])
+	if err != nil {
+		r.recorder.Eventf(rcp, corev1.EventTypeWarning, "EtcdMemberRemovalFailed",
+			"Failed to remove orphaned etcd member %s: %v", orphans[0], err)
+
+		return ctrl.Result{}, err
+	}
+
+	if removed {
+		r.recorder.Eventf(rcp, corev1.EventTypeNormal, "EtcdMemberRemoved",
+			"Removed orphaned etcd member %s", orphans[0])
+	}
+
+	return ctrl.Result{RequeueAfter: DefaultRequeueTime}, nil
+}
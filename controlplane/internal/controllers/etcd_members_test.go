@@ -0,0 +1,137 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Orphaned etcd members", func() {
+	var (
+		cluster  *clusterv1.Cluster
+		rcp      *controlplanev1.RKE2ControlPlane
+		machines []*clusterv1.Machine
+		workload *fakeWorkloadCluster
+		r        *RKE2ControlPlaneReconciler
+		recorder *record.FakeRecorder
+	)
+
+	BeforeEach(func() {
+		cluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "orphans"}}
+		rcp = &controlplanev1.RKE2ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "orphans"},
+			Spec:       controlplanev1.RKE2ControlPlaneSpec{Replicas: ptr.To[int32](3)},
+			Status:     controlplanev1.RKE2ControlPlaneStatus{Initialized: true},
+		}
+
+		machines = nil
+		for i := range 3 {
+			machine := newTestMachine(fmt.Sprintf("machine-%d", i), "orphans", time.Now().Add(time.Duration(i)*time.Minute))
+			conditions.MarkTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition)
+			machines = append(machines, machine)
+		}
+
+		workload = &fakeWorkloadCluster{etcdMembers: []string{"machine-0", "machine-1", "machine-2", "orphan"}}
+		recorder = record.NewFakeRecorder(32)
+	})
+
+	reconcileEtcdMembers := func() (ctrl.Result, error) {
+		objects := []client.Object{}
+		for _, machine := range machines {
+			objects = append(objects, machine)
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).WithStatusSubresource(objects...).Build()
+		m := &fakeManagementCluster{Client: c, workload: workload}
+		r = &RKE2ControlPlaneReconciler{Client: c, recorder: recorder}
+
+		return r.reconcileEtcdMembers(ctx, newTestControlPlane(m, cluster, rcp))
+	}
+
+	It("should remove the member without a machine", func() {
+		workload.orphanRemoved = true
+
+		result, err := reconcileEtcdMembers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: DefaultRequeueTime}))
+		Expect(workload.removedOrphans).To(Equal([]string{"orphan"}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("EtcdMemberRemoved")))
+	})
+
+	It("should not remove the member when the remaining healthy members would not keep the quorum", func() {
+		for _, machine := range machines[1:] {
+			conditions.MarkFalse(machine, controlplanev1.MachineEtcdMemberHealthyCondition,
+				controlplanev1.EtcdMemberUnhealthyReason, clusterv1.ConditionSeverityError, "Etcd member reports errors")
+		}
+
+		result, err := reconcileEtcdMembers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: DefaultRequeueTime}))
+		Expect(workload.removedOrphans).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring("EtcdMemberRemovalBlocked")))
+	})
+
+	It("should count a member with only a NOSPACE alarm towards the quorum", func() {
+		conditions.MarkFalse(machines[1], controlplanev1.MachineEtcdMemberHealthyCondition,
+			controlplanev1.EtcdMemberNoSpaceReason, clusterv1.ConditionSeverityWarning, "Etcd member raised a NOSPACE alarm")
+		conditions.MarkFalse(machines[2], controlplanev1.MachineEtcdMemberHealthyCondition,
+			controlplanev1.EtcdMemberUnhealthyReason, clusterv1.ConditionSeverityError, "Etcd member reports errors")
+
+		_, err := reconcileEtcdMembers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(workload.removedOrphans).To(Equal([]string{"orphan"}))
+	})
+
+	It("should wait for RKE2 to remove the member of a Node which still exists", func() {
+		// The Node of the member is annotated for RKE2 to remove the member, which takes a few reconciliations.
+		result, err := reconcileEtcdMembers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: DefaultRequeueTime}))
+		Expect(workload.removedOrphans).To(Equal([]string{"orphan"}))
+		Expect(recorder.Events).ToNot(Receive())
+
+		workload.orphanRemoved = true
+
+		_, err = reconcileEtcdMembers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(workload.removedOrphans).To(Equal([]string{"orphan", "orphan"}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("EtcdMemberRemoved")))
+	})
+
+	It("should not remove any member while a machine has no Node", func() {
+		machines[2].Status.NodeRef = nil
+
+		result, err := reconcileEtcdMembers()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(workload.removedOrphans).To(BeEmpty())
+	})
+})
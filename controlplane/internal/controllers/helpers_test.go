@@ -0,0 +1,292 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/etcd"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeManagementCluster is a management cluster backed by a client, returning a fake workload cluster.
+type fakeManagementCluster struct {
+	client.Client
+
+	workload    *fakeWorkloadCluster
+	workloadErr error
+}
+
+func (f *fakeManagementCluster) GetMachinesForCluster(
+	ctx context.Context,
+	cluster client.ObjectKey,
+	filters ...collections.Func,
+) (collections.Machines, error) {
+	machines := &clusterv1.MachineList{}
+	if err := f.List(ctx, machines, client.InNamespace(cluster.Namespace)); err != nil {
+		return nil, err
+	}
+
+	return collections.FromMachineList(machines).Filter(filters...), nil
+}
+
+func (f *fakeManagementCluster) GetWorkloadCluster(context.Context, client.ObjectKey) (rke2.WorkloadCluster, error) {
+	if f.workloadErr != nil {
+		return nil, f.workloadErr
+	}
+
+	return f.workload, nil
+}
+
+// fakeWorkloadCluster implements the operations of the workload cluster the tests rely on, calling any other
+// operation panics.
+type fakeWorkloadCluster struct {
+	rke2.WorkloadCluster
+
+	etcdMembers         []string
+	forwardedLeadership []string
+	removedOrphans      []string
+	orphanRemoved       bool
+
+	etcdSnapshotFiles      []controlplanev1.EtcdSnapshotFile
+	etcdSnapshotFilesCalls int
+	etcdSnapshotJobs       map[string][]string
+	etcdSnapshotPhase      controlplanev1.EtcdSnapshotPhase
+
+	etcdRestoreJobs   map[string][]string
+	etcdRestoreDone   bool
+	etcdRestoreErr    error
+	cleanedUpJobNames []string
+
+	certificateRotationJobs  map[string]string
+	certificateRotationFiles map[string][]byte
+	certificateRotationDone  bool
+	certificateRotationErr   error
+
+	secretsEncryptionRuns []string
+	secretsEncryptionDone bool
+	secretsEncryptionErr  error
+
+	inPlaceUpgrades       []string
+	inPlaceUpgradeDone    bool
+	inPlaceUpgradePlan    bool
+	inPlaceUpgradePlanErr error
+
+	etcdDatabaseStatuses map[string]*rke2.EtcdDatabaseStatus
+	defragmentedMembers  []string
+	disarmedAlarms       []string
+}
+
+func (f *fakeWorkloadCluster) InitWorkload(context.Context, *rke2.ControlPlane) error {
+	return nil
+}
+
+func (f *fakeWorkloadCluster) EtcdMembers(context.Context) ([]string, error) {
+	return f.etcdMembers, nil
+}
+
+func (f *fakeWorkloadCluster) ForwardEtcdLeadership(_ context.Context, machine, leaderCandidate *clusterv1.Machine) error {
+	f.forwardedLeadership = []string{machine.Name, leaderCandidate.Name}
+
+	return nil
+}
+
+func (f *fakeWorkloadCluster) RemoveOrphanedEtcdMember(_ context.Context, nodeName string) (bool, error) {
+	f.removedOrphans = append(f.removedOrphans, nodeName)
+
+	return f.orphanRemoved, nil
+}
+
+func (f *fakeWorkloadCluster) EtcdSnapshotFiles(context.Context) ([]controlplanev1.EtcdSnapshotFile, error) {
+	f.etcdSnapshotFilesCalls++
+
+	return f.etcdSnapshotFiles, nil
+}
+
+func (f *fakeWorkloadCluster) SaveEtcdSnapshot(
+	_ context.Context,
+	machine *clusterv1.Machine,
+	jobName, snapshotName, image string,
+) (controlplanev1.EtcdSnapshotPhase, error) {
+	if f.etcdSnapshotJobs == nil {
+		f.etcdSnapshotJobs = map[string][]string{}
+	}
+
+	f.etcdSnapshotJobs[jobName] = []string{machine.Name, snapshotName, image}
+
+	return f.etcdSnapshotPhase, nil
+}
+
+func (f *fakeWorkloadCluster) CleanupEtcdSnapshot(_ context.Context, jobName string) error {
+	f.cleanedUpJobNames = append(f.cleanedUpJobNames, jobName)
+
+	return nil
+}
+
+func (f *fakeWorkloadCluster) ResetEtcdFromSnapshot(
+	_ context.Context,
+	machine *clusterv1.Machine,
+	jobName, snapshotPath, image string,
+) (bool, error) {
+	if f.etcdRestoreJobs == nil {
+		f.etcdRestoreJobs = map[string][]string{}
+	}
+
+	f.etcdRestoreJobs[jobName] = []string{machine.Name, snapshotPath, image}
+
+	return f.etcdRestoreDone, f.etcdRestoreErr
+}
+
+func (f *fakeWorkloadCluster) CleanupEtcdRestore(_ context.Context, jobName string) error {
+	f.cleanedUpJobNames = append(f.cleanedUpJobNames, jobName)
+
+	return nil
+}
+
+func (f *fakeWorkloadCluster) RotateCertificates(_ context.Context, machine *clusterv1.Machine, jobName, _ string) (bool, error) {
+	if f.certificateRotationJobs == nil {
+		f.certificateRotationJobs = map[string]string{}
+	}
+
+	f.certificateRotationJobs[jobName] = machine.Name
+
+	return f.certificateRotationDone, f.certificateRotationErr
+}
+
+func (f *fakeWorkloadCluster) RotateCertificateAuthorities(
+	ctx context.Context,
+	machine *clusterv1.Machine,
+	jobName, image string,
+	files map[string][]byte,
+) (bool, error) {
+	f.certificateRotationFiles = files
+
+	return f.RotateCertificates(ctx, machine, jobName, image)
+}
+
+func (f *fakeWorkloadCluster) CleanupCertificateRotation(_ context.Context, jobName string) error {
+	f.cleanedUpJobNames = append(f.cleanedUpJobNames, jobName)
+
+	return nil
+}
+
+func (f *fakeWorkloadCluster) RunSecretsEncryptionStage(
+	_ context.Context,
+	machine *clusterv1.Machine,
+	_, _ string,
+	stage controlplanev1.SecretsEncryptionRotationStage,
+) (bool, error) {
+	f.secretsEncryptionRuns = append(f.secretsEncryptionRuns, machine.Name+"/"+string(stage))
+
+	return f.secretsEncryptionDone, f.secretsEncryptionErr
+}
+
+func (f *fakeWorkloadCluster) RestartServer(_ context.Context, machine *clusterv1.Machine, _, _ string) (bool, error) {
+	f.secretsEncryptionRuns = append(f.secretsEncryptionRuns, machine.Name+"/restart")
+
+	return f.secretsEncryptionDone, f.secretsEncryptionErr
+}
+
+func (f *fakeWorkloadCluster) CleanupSecretsEncryptionRotation(_ context.Context, jobName string) error {
+	f.cleanedUpJobNames = append(f.cleanedUpJobNames, jobName)
+
+	return nil
+}
+
+func (f *fakeWorkloadCluster) EnsureInPlaceUpgradePlan(context.Context, *controlplanev1.RKE2ControlPlane) error {
+	if f.inPlaceUpgradePlanErr != nil {
+		return f.inPlaceUpgradePlanErr
+	}
+
+	f.inPlaceUpgradePlan = true
+
+	return nil
+}
+
+func (f *fakeWorkloadCluster) DeleteInPlaceUpgradePlan(context.Context, *controlplanev1.RKE2ControlPlane) error {
+	f.inPlaceUpgradePlan = false
+
+	return nil
+}
+
+func (f *fakeWorkloadCluster) UpgradeMachineInPlace(_ context.Context, machine *clusterv1.Machine, version string) (bool, error) {
+	f.inPlaceUpgrades = append(f.inPlaceUpgrades, machine.Name+"/"+version)
+
+	return f.inPlaceUpgradeDone, nil
+}
+
+func (f *fakeWorkloadCluster) EtcdDatabaseStatus(_ context.Context, machine *clusterv1.Machine) (*rke2.EtcdDatabaseStatus, error) {
+	return f.etcdDatabaseStatuses[machine.Name], nil
+}
+
+func (f *fakeWorkloadCluster) DefragmentEtcdMember(_ context.Context, machine *clusterv1.Machine) error {
+	f.defragmentedMembers = append(f.defragmentedMembers, machine.Name)
+
+	return nil
+}
+
+func (f *fakeWorkloadCluster) DisarmEtcdMemberAlarm(_ context.Context, machine *clusterv1.Machine, alarm etcd.AlarmType) error {
+	f.disarmedAlarms = append(f.disarmedAlarms, machine.Name+"/"+etcd.AlarmTypeName[alarm])
+
+	return nil
+}
+
+// newTestControlPlane returns the control plane of the given RKE2ControlPlane, with the Machines of its namespace.
+func newTestControlPlane(
+	m *fakeManagementCluster,
+	cluster *clusterv1.Cluster,
+	rcp *controlplanev1.RKE2ControlPlane,
+) *rke2.ControlPlane {
+	machines, err := m.GetMachinesForCluster(ctx, client.ObjectKeyFromObject(cluster))
+	Expect(err).ToNot(HaveOccurred())
+
+	controlPlane, err := rke2.NewControlPlane(ctx, m, m.Client, cluster, rcp, machines)
+	Expect(err).ToNot(HaveOccurred())
+
+	return controlPlane
+}
+
+// newTestMachine returns a ready control plane Machine with a Node, created at the given time.
+func newTestMachine(name, namespace string, created time.Time) *clusterv1.Machine {
+	return &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: clusterv1.MachineSpec{
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: "infrastructure.cluster.x-k8s.io/v1beta1",
+				Kind:       "GenericInfrastructureMachine",
+				Name:       name,
+				Namespace:  namespace,
+			},
+		},
+		Status: clusterv1.MachineStatus{
+			NodeRef:    &corev1.ObjectReference{Name: name},
+			Conditions: clusterv1.Conditions{{Type: clusterv1.ReadyCondition, Status: corev1.ConditionTrue}},
+		},
+	}
+}
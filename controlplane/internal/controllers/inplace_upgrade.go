@@ -0,0 +1,108 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
+)
+
+// upgradeControlPlaneInPlace upgrades the version of the outdated machines in place, one at a time, using the
+// system-upgrade-controller in the workload cluster. Once the Node of a machine runs the desired version, the
+// machine is annotated with it so that it is considered up to date.
+func (r *RKE2ControlPlaneReconciler) upgradeControlPlaneInPlace(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+	workloadCluster rke2.WorkloadCluster,
+	outdatedMachines collections.Machines,
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.upgradeControlPlaneInPlace")
+	defer tracing.End(span, &reterr)
+
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+	version := rcp.GetDesiredVersion()
+
+	conditions.MarkFalse(rcp,
+		controlplanev1.MachinesSpecUpToDateCondition,
+		controlplanev1.InPlaceUpgradeInProgressReason,
+		clusterv1.ConditionSeverityWarning,
+		"Upgrading %d replicas in place to version %s (%d replicas up to date)",
+		outdatedMachines.Len(),
+		version,
+		controlPlane.Machines.Len()-outdatedMachines.Len())
+
+	// The oldest outdated machine remains the one being upgraded until it is done, so that a single
+	// Node is upgraded at a time.
+	machine := outdatedMachines.Oldest()
+
+	// Only wait for the other machines to be healthy, as the one being upgraded restarts RKE2.
+	if result := r.preflightChecks(ctx, controlPlane, machine); !result.IsZero() {
+		return result, nil
+	}
+
+	if err := workloadCluster.EnsureInPlaceUpgradePlan(ctx, rcp); err != nil {
+		conditions.MarkFalse(rcp,
+			controlplanev1.MachinesSpecUpToDateCondition,
+			controlplanev1.InPlaceUpgradeFailedReason,
+			clusterv1.ConditionSeverityError,
+			"Failed to create the upgrade plan: %s", err.Error())
+
+		return ctrl.Result{}, fmt.Errorf("ensuring in-place upgrade plan: %w", err)
+	}
+
+	upgraded, err := workloadCluster.UpgradeMachineInPlace(ctx, machine, version)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("upgrading machine %s in place: %w", machine.Name, err)
+	}
+
+	if !upgraded {
+		logger.Info("Waiting for machine to be upgraded in place", "machine", machine.Name, "version", version)
+
+		return ctrl.Result{RequeueAfter: inPlaceUpgradeRequeueAfter}, nil
+	}
+
+	if outdatedMachines.Len() == 1 {
+		if err := workloadCluster.DeleteInPlaceUpgradePlan(ctx, rcp); err != nil {
+			return ctrl.Result{}, fmt.Errorf("deleting in-place upgrade plan: %w", err)
+		}
+	}
+
+	// RKE2 has been restarted, renewing the certificates about to expire, their expiry is read again.
+	if err := r.patchMachineAnnotations(ctx, machine,
+		map[string]string{controlplanev1.InPlaceUpgradedVersionAnnotation: version},
+		certificatesExpiryAnnotations...); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Machine upgraded in place", "machine", machine.Name, "version", version)
+	r.recorder.Eventf(rcp, corev1.EventTypeNormal, "InPlaceUpgradeSucceeded",
+		"Upgraded machine %s in place to version %s", machine.Name, version)
+
+	return ctrl.Result{Requeue: true}, nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("In-place upgrade", func() {
+	var (
+		cluster  *clusterv1.Cluster
+		rcp      *controlplanev1.RKE2ControlPlane
+		workload *fakeWorkloadCluster
+		m        *fakeManagementCluster
+		r        *RKE2ControlPlaneReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "inplace"}}
+		rcp = &controlplanev1.RKE2ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "inplace"},
+			Spec: controlplanev1.RKE2ControlPlaneSpec{
+				Version:         "v1.31.2+rke2r1",
+				RolloutStrategy: &controlplanev1.RolloutStrategy{Type: controlplanev1.InPlaceUpgradeStrategyType},
+			},
+			Status: controlplanev1.RKE2ControlPlaneStatus{Initialized: true},
+		}
+
+		objects := []client.Object{}
+		for i := range 2 {
+			machine := newTestMachine(fmt.Sprintf("machine-%d", i), "inplace", time.Now().Add(time.Duration(i)*time.Minute))
+			machine.Spec.Version = ptr.To("v1.30.6+rke2r1")
+			machine.Annotations = map[string]string{
+				controlplanev1.APIServerCertificateExpiryAnnotation: time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			}
+			conditions.MarkTrue(machine, controlplanev1.MachineAgentHealthyCondition)
+			conditions.MarkTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition)
+			objects = append(objects, machine)
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).WithStatusSubresource(objects...).Build()
+		workload = &fakeWorkloadCluster{}
+		m = &fakeManagementCluster{Client: c, workload: workload}
+		r = &RKE2ControlPlaneReconciler{Client: c, recorder: record.NewFakeRecorder(32)}
+	})
+
+	upgrade := func() (ctrl.Result, error) {
+		controlPlane := newTestControlPlane(m, cluster, rcp)
+
+		return r.upgradeControlPlane(ctx, cluster, rcp, controlPlane, controlPlane.MachinesNeedingRollout(ctx))
+	}
+
+	getMachine := func(name string) *clusterv1.Machine {
+		machine := &clusterv1.Machine{}
+		Expect(m.Get(ctx, client.ObjectKey{Namespace: "inplace", Name: name}, machine)).To(Succeed())
+
+		return machine
+	}
+
+	It("should upgrade the machines in place one at a time", func() {
+		for i, name := range []string{"machine-0", "machine-1"} {
+			result, err := upgrade()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(inPlaceUpgradeRequeueAfter))
+			Expect(workload.inPlaceUpgrades).To(Equal([]string{name + "/v1.31.2+rke2r1"}))
+			Expect(workload.inPlaceUpgradePlan).To(BeTrue())
+			Expect(conditions.GetReason(rcp, controlplanev1.MachinesSpecUpToDateCondition)).To(
+				Equal(controlplanev1.InPlaceUpgradeInProgressReason))
+			Expect(conditions.GetMessage(rcp, controlplanev1.MachinesSpecUpToDateCondition)).To(
+				ContainSubstring("(%d replicas up to date)", i))
+			Expect(getMachine(name).Annotations).ToNot(HaveKey(controlplanev1.InPlaceUpgradedVersionAnnotation))
+
+			workload.inPlaceUpgradeDone = true
+			result, err = upgrade()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue())
+
+			machine := getMachine(name)
+			Expect(machine.Annotations).To(HaveKeyWithValue(controlplanev1.InPlaceUpgradedVersionAnnotation, "v1.31.2+rke2r1"))
+			Expect(machine.Annotations).ToNot(HaveKey(controlplanev1.APIServerCertificateExpiryAnnotation))
+
+			workload.inPlaceUpgrades = nil
+			workload.inPlaceUpgradeDone = false
+		}
+
+		// The Plan is deleted once the last machine has been upgraded.
+		Expect(workload.inPlaceUpgradePlan).To(BeFalse())
+		Expect(getMachine("machine-1").Annotations).To(HaveKey(controlplanev1.InPlaceUpgradedVersionAnnotation))
+		Expect(newTestControlPlane(m, cluster, rcp).MachinesNeedingRollout(ctx).Len()).To(BeZero())
+	})
+
+	It("should keep the plan while other machines are outdated", func() {
+		workload.inPlaceUpgradeDone = true
+
+		_, err := upgrade()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(workload.inPlaceUpgrades).To(Equal([]string{"machine-0/v1.31.2+rke2r1"}))
+		Expect(workload.inPlaceUpgradePlan).To(BeTrue())
+		Expect(getMachine("machine-1").Annotations).ToNot(HaveKey(controlplanev1.InPlaceUpgradedVersionAnnotation))
+	})
+
+	It("should wait for the other machines to be healthy", func() {
+		machine := getMachine("machine-1")
+		conditions.MarkFalse(machine, controlplanev1.MachineAgentHealthyCondition, "Unhealthy", clusterv1.ConditionSeverityError, "")
+		Expect(m.Status().Update(ctx, machine)).To(Succeed())
+
+		result, err := upgrade()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(preflightFailedRequeueAfter))
+		Expect(workload.inPlaceUpgrades).To(BeEmpty())
+	})
+
+	It("should report a failure to create the plan", func() {
+		workload.inPlaceUpgradePlanErr = errors.New("system-upgrade-controller not installed")
+
+		_, err := upgrade()
+		Expect(err).To(HaveOccurred())
+		Expect(conditions.GetReason(rcp, controlplanev1.MachinesSpecUpToDateCondition)).To(
+			Equal(controlplanev1.InPlaceUpgradeFailedReason))
+		Expect(workload.inPlaceUpgrades).To(BeEmpty())
+	})
+})
@@ -31,6 +31,7 @@ import (
 
 	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
 )
 
 const (
@@ -39,7 +40,10 @@ const (
 
 // reconcileLifecycleHooks triggers the reconcile of all implemented lifecycle hooks.
 // **NOTE** keep the hooks in the expected lifecycle order.
-func (r *RKE2ControlPlaneReconciler) reconcileLifecycleHooks(ctx context.Context, controlPlane *rke2.ControlPlane) (ctrl.Result, error) {
+func (r *RKE2ControlPlaneReconciler) reconcileLifecycleHooks(ctx context.Context, controlPlane *rke2.ControlPlane) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileLifecycleHooks")
+	defer tracing.End(span, &reterr)
+
 	log := ctrl.LoggerFrom(ctx)
 
 	log.V(5).Info("Reconciling pre-drain hooks")
@@ -92,6 +96,12 @@ func (r *RKE2ControlPlaneReconciler) reconcilePreDrainHook(ctx context.Context,
 		cleanup = true
 	}
 
+	if cleanup && controlPlane.RCP.Spec.ControlPlaneEndpointManagement != nil {
+		log.V(5).Info("RKE2ControlPlane manages the control plane endpoint. Excluding deleting nodes from load balancers.")
+
+		cleanup = false
+	}
+
 	if cleanup {
 		if err := cleanupHookOnAllMachines(ctx, controlPlane, controlplanev1.PreDrainLoadbalancerExclusionAnnotation); err != nil {
 			return ctrl.Result{}, fmt.Errorf("cleaning up hook annotation %s on machines: %w", controlplanev1.PreDrainLoadbalancerExclusionAnnotation, err)
@@ -145,6 +155,20 @@ func (r *RKE2ControlPlaneReconciler) reconcilePreDrainHook(ctx context.Context,
 		return ctrl.Result{}, fmt.Errorf("applying label %s on machine %s node: %w", corev1.LabelNodeExcludeBalancers, deletingMachine.Name, err)
 	}
 
+	// Wait for kube-vip to leave the Node, so that the control plane endpoint address moves to another Node before draining
+	if controlPlane.RCP.Spec.ControlPlaneEndpointManagement != nil {
+		running, err := workloadCluster.IsKubeVIPRunningOnMachine(ctx, deletingMachine)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("checking kube-vip on machine %s node: %w", deletingMachine.Name, err)
+		}
+
+		if running {
+			log.Info("Waiting for kube-vip to stop on the Machine Node")
+
+			return ctrl.Result{RequeueAfter: deleteRequeueAfter}, nil
+		}
+	}
+
 	if err := r.removeHookAnnotationFromMachine(ctx, deletingMachine, controlplanev1.PreDrainLoadbalancerExclusionAnnotation); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -38,7 +38,9 @@ import (
 	"sigs.k8s.io/cluster-api/util/patch"
 
 	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/controlplane/internal/metrics"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
 )
 
 // reconcileUnhealthyMachines tries to remediate RKE2ControlPlane unhealthy machines
@@ -49,6 +51,9 @@ import (
 //
 //nolint:lll
 func (r *RKE2ControlPlaneReconciler) reconcileUnhealthyMachines(ctx context.Context, controlPlane *rke2.ControlPlane) (ret ctrl.Result, retErr error) { // nolint:gocyclo
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileUnhealthyMachines")
+	defer tracing.End(span, &retErr)
+
 	log := ctrl.LoggerFrom(ctx)
 	reconciliationTime := time.Now().UTC()
 
@@ -108,6 +113,8 @@ func (r *RKE2ControlPlaneReconciler) reconcileUnhealthyMachines(ctx context.Cont
 		return ctrl.Result{}, errors.New("failed to find a Machine to remediate within unhealthy Machines")
 	}
 
+	span.SetAttributes(tracing.MachineKey.String(machineToBeRemediated.Name))
+
 	// Returns if the machine is in the process of being deleted.
 	if !machineToBeRemediated.DeletionTimestamp.IsZero() {
 		return ctrl.Result{}, nil
@@ -323,6 +330,7 @@ func (r *RKE2ControlPlaneReconciler) reconcileUnhealthyMachines(ctx context.Cont
 	// Note: We intentionally log after Delete because we want this log line to show up only after DeletionTimestamp has been set.
 	// Also, setting DeletionTimestamp doesn't mean the Machine is actually deleted (deletion takes some time).
 	log.Info("Remediating unhealthy machine")
+	metrics.RecordRemediation(controlPlane.RCP)
 	conditions.MarkFalse(
 		machineToBeRemediated,
 		clusterv1.MachineOwnerRemediatedCondition,
@@ -638,7 +646,7 @@ func (r *RKE2ControlPlaneReconciler) canSafelyRemoveEtcdMember(ctx context.Conte
 		}
 
 		// Check member health as reported by machine's health conditions
-		if !conditions.IsTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition) {
+		if !etcdMemberHoldsQuorum(machine) {
 			targetUnhealthyMembers++
 
 			unhealthyMembers = append(unhealthyMembers, fmt.Sprintf("%s (%s)", etcdMember, machine.Name))
@@ -0,0 +1,251 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
+)
+
+// reconcileEtcdRestore restores etcd from the snapshot requested in the RKE2ControlPlane spec, if any.
+// The control plane is first scaled down to a single Machine, which then resets the etcd cluster from the snapshot
+// in place. Once this Machine is ready again the restore is completed, and the remaining replicas rejoin the cluster
+// through the regular scale up.
+func (r *RKE2ControlPlaneReconciler) reconcileEtcdRestore(ctx context.Context, controlPlane *rke2.ControlPlane) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileEtcdRestore")
+	defer tracing.End(span, &reterr)
+
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+
+	if rcp.Spec.Restore == nil {
+		// A failed restore is forgotten once removed from the spec, so that it can be requested again.
+		if rcp.Status.Restore != nil && rcp.Status.Restore.Phase == controlplanev1.EtcdRestorePhaseFailed {
+			rcp.Status.Restore = nil
+			conditions.Delete(rcp, controlplanev1.EtcdRestoredCondition)
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if rcp.Status.Restore == nil || rcp.Status.Restore.SnapshotName != rcp.Spec.Restore.SnapshotName {
+		logger.Info("Starting etcd restore", "snapshot", rcp.Spec.Restore.SnapshotName)
+		r.recorder.Eventf(rcp, corev1.EventTypeNormal, "EtcdRestoreStarted",
+			"Restoring etcd from snapshot %s", rcp.Spec.Restore.SnapshotName)
+
+		now := metav1.Now()
+		rcp.Status.Restore = &controlplanev1.EtcdRestoreStatus{
+			SnapshotName: rcp.Spec.Restore.SnapshotName,
+			Phase:        controlplanev1.EtcdRestorePhaseScalingDown,
+			StartTime:    &now,
+		}
+	}
+
+	switch rcp.Status.Restore.Phase {
+	case controlplanev1.EtcdRestorePhaseScalingDown:
+		return r.scaleDownForEtcdRestore(ctx, controlPlane)
+	case controlplanev1.EtcdRestorePhaseResetting:
+		return r.resetEtcdFromSnapshot(ctx, controlPlane)
+	case controlplanev1.EtcdRestorePhaseFailed:
+		// The other operations are blocked until the restore is retried or removed from the spec.
+		return ctrl.Result{RequeueAfter: etcdRestoreRequeueAfter}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// scaleDownForEtcdRestore deletes all the control plane Machines but the oldest one.
+func (r *RKE2ControlPlaneReconciler) scaleDownForEtcdRestore(ctx context.Context, controlPlane *rke2.ControlPlane) (ctrl.Result, error) {
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+
+	conditions.MarkFalse(rcp, controlplanev1.EtcdRestoredCondition, controlplanev1.EtcdRestoreScalingDownReason,
+		clusterv1.ConditionSeverityInfo, "Scaling down to a single Machine before restoring snapshot %s", rcp.Status.Restore.SnapshotName)
+
+	activeMachines := controlPlane.Machines.Filter(collections.ActiveMachines)
+	if activeMachines.Len() > 1 {
+		machineToKeep := activeMachines.Oldest()
+
+		for _, machine := range activeMachines {
+			if machine.Name == machineToKeep.Name {
+				continue
+			}
+
+			if err := r.deleteMachineForEtcdRestore(ctx, rcp, machine); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{RequeueAfter: deleteRequeueAfter}, nil
+	}
+
+	if controlPlane.HasDeletingMachine() {
+		logger.Info("Waiting for Machines to be deleted before restoring etcd",
+			"machines", controlPlane.DeletingMachines().Names())
+
+		return ctrl.Result{RequeueAfter: deleteRequeueAfter}, nil
+	}
+
+	if activeMachines.Len() == 0 {
+		logger.Info("Waiting for a control plane Machine to restore etcd on")
+
+		return ctrl.Result{RequeueAfter: etcdRestoreRequeueAfter}, nil
+	}
+
+	rcp.Status.Restore.Phase = controlplanev1.EtcdRestorePhaseResetting
+	rcp.Status.Restore.Machine = activeMachines.Oldest().Name
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// resetEtcdFromSnapshot resets the etcd cluster from the snapshot on the last control plane Machine, and waits
+// for it to be ready again.
+func (r *RKE2ControlPlaneReconciler) resetEtcdFromSnapshot(ctx context.Context, controlPlane *rke2.ControlPlane) (ctrl.Result, error) {
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+	restore := rcp.Status.Restore
+
+	conditions.MarkFalse(rcp, controlplanev1.EtcdRestoredCondition, controlplanev1.EtcdRestoreResettingReason,
+		clusterv1.ConditionSeverityInfo, "Resetting etcd from snapshot %s on Machine %s", restore.SnapshotName, restore.Machine)
+
+	machine, ok := controlPlane.Machines[restore.Machine]
+	if !ok || !machine.DeletionTimestamp.IsZero() {
+		logger.Info("Control plane Machine restoring etcd is gone, scaling down again", "machine", restore.Machine)
+
+		restore.Phase = controlplanev1.EtcdRestorePhaseScalingDown
+		restore.Machine = ""
+
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if restore.StartTime == nil {
+		now := metav1.Now()
+		restore.StartTime = &now
+	}
+
+	jobName, err := rotationJobName("rke2-etcd-restore",
+		restore.SnapshotName, machine.Name, strconv.FormatInt(restore.StartTime.Unix(), 10))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The API server of the workload cluster is not available while etcd is reset, errors reaching it are
+	// expected until RKE2 has been restarted.
+	workloadCluster, err := controlPlane.GetWorkloadCluster(ctx)
+	if err != nil {
+		logger.Info("Waiting for the workload cluster to restore etcd", "machine", machine.Name, "error", err.Error())
+
+		return ctrl.Result{RequeueAfter: etcdRestoreRequeueAfter}, nil
+	}
+
+	done, err := workloadCluster.ResetEtcdFromSnapshot(ctx, machine, jobName, restore.SnapshotName, rcp.Spec.Restore.Image)
+	if errors.Is(err, rke2.ErrEtcdRestoreFailed) {
+		logger.Error(err, "Failed to restore etcd", "machine", machine.Name, "snapshot", restore.SnapshotName)
+
+		restore.Phase = controlplanev1.EtcdRestorePhaseFailed
+
+		conditions.MarkFalse(rcp, controlplanev1.EtcdRestoredCondition, controlplanev1.EtcdRestoreFailedReason,
+			clusterv1.ConditionSeverityError, "Failed to restore etcd from snapshot %s on Machine %s, remove the restore "+
+				"or set a different snapshot to retry: %s", restore.SnapshotName, machine.Name, err.Error())
+		r.recorder.Eventf(rcp, corev1.EventTypeWarning, "FailedEtcdRestore",
+			"Failed to restore etcd from snapshot %s on Machine %s: %v", restore.SnapshotName, machine.Name, err)
+
+		return ctrl.Result{RequeueAfter: etcdRestoreRequeueAfter}, nil
+	}
+
+	if err != nil {
+		logger.Info("Waiting for the workload cluster to restore etcd", "machine", machine.Name, "error", err.Error())
+
+		return ctrl.Result{RequeueAfter: etcdRestoreRequeueAfter}, nil
+	}
+
+	if !done || machine.Status.NodeRef == nil || !conditions.IsTrue(machine, clusterv1.ReadyCondition) {
+		logger.Info("Waiting for control plane Machine to restore etcd", "machine", machine.Name)
+
+		return ctrl.Result{RequeueAfter: etcdRestoreRequeueAfter}, nil
+	}
+
+	if err := workloadCluster.CleanupEtcdRestore(ctx, jobName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Etcd restored from snapshot", "snapshot", restore.SnapshotName, "machine", machine.Name)
+	r.recorder.Eventf(rcp, corev1.EventTypeNormal, "EtcdRestoreCompleted",
+		"Restored etcd from snapshot %s on Machine %s", restore.SnapshotName, machine.Name)
+
+	now := metav1.Now()
+	restore.Phase = controlplanev1.EtcdRestorePhaseCompleted
+	restore.CompletionTime = &now
+
+	conditions.MarkTrue(rcp, controlplanev1.EtcdRestoredCondition)
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// deleteMachineForEtcdRestore deletes a control plane Machine being replaced during an etcd restore.
+// The etcd cluster membership is reset from the snapshot, so the Machine is deleted without removing its
+// etcd member nor draining its Node, as the workload cluster may not be reachable anymore.
+func (r *RKE2ControlPlaneReconciler) deleteMachineForEtcdRestore(
+	ctx context.Context,
+	rcp *controlplanev1.RKE2ControlPlane,
+	machine *clusterv1.Machine,
+) error {
+	logger := ctrl.LoggerFrom(ctx).WithValues("Machine", klog.KObj(machine))
+
+	machineOriginal := machine.DeepCopy()
+	delete(machine.Annotations, controlplanev1.PreTerminateHookCleanupAnnotation)
+	delete(machine.Annotations, controlplanev1.PreDrainLoadbalancerExclusionAnnotation)
+
+	if machine.Annotations == nil {
+		machine.Annotations = map[string]string{}
+	}
+
+	machine.Annotations[clusterv1.ExcludeNodeDrainingAnnotation] = trueString
+	machine.Annotations[clusterv1.ExcludeWaitForNodeVolumeDetachAnnotation] = trueString
+
+	if err := r.Patch(ctx, machine, client.MergeFrom(machineOriginal)); err != nil {
+		return fmt.Errorf("removing hooks from control plane Machine %s: %w", klog.KObj(machine), err)
+	}
+
+	logger.Info("Deleting control plane Machine to restore etcd")
+
+	if err := r.Delete(ctx, machine); err != nil && !apierrors.IsNotFound(err) {
+		r.recorder.Eventf(rcp, corev1.EventTypeWarning, "FailedEtcdRestore",
+			"Failed to delete control plane Machine %s to restore etcd: %v", machine.Name, err)
+
+		return fmt.Errorf("deleting control plane Machine %s: %w", klog.KObj(machine), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Etcd restore", func() {
+	var (
+		cluster  *clusterv1.Cluster
+		rcp      *controlplanev1.RKE2ControlPlane
+		workload *fakeWorkloadCluster
+		m        *fakeManagementCluster
+		r        *RKE2ControlPlaneReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "restore"}}
+		rcp = &controlplanev1.RKE2ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "restore"},
+			Spec: controlplanev1.RKE2ControlPlaneSpec{
+				Restore: &controlplanev1.EtcdRestore{SnapshotName: "etcd-snapshot", Image: "registry.example.com/busybox"},
+			},
+		}
+
+		objects := []client.Object{}
+		for i := range 3 {
+			objects = append(objects, newTestMachine(fmt.Sprintf("machine-%d", i), "restore", time.Now().Add(time.Duration(i)*time.Minute)))
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).WithStatusSubresource(objects...).Build()
+		workload = &fakeWorkloadCluster{}
+		m = &fakeManagementCluster{Client: c, workload: workload}
+		r = &RKE2ControlPlaneReconciler{Client: c, recorder: record.NewFakeRecorder(32)}
+	})
+
+	reconcile := func() (ctrl.Result, error) {
+		return r.reconcileEtcdRestore(ctx, newTestControlPlane(m, cluster, rcp))
+	}
+
+	It("should reset etcd in place on the oldest machine", func() {
+		result, err := reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(deleteRequeueAfter))
+		Expect(rcp.Status.Restore.Phase).To(Equal(controlplanev1.EtcdRestorePhaseScalingDown))
+		Expect(conditions.GetReason(rcp, controlplanev1.EtcdRestoredCondition)).To(Equal(controlplanev1.EtcdRestoreScalingDownReason))
+
+		machines := &clusterv1.MachineList{}
+		Expect(m.List(ctx, machines)).To(Succeed())
+		Expect(machines.Items).To(HaveLen(1))
+		Expect(machines.Items[0].Name).To(Equal("machine-0"))
+
+		_, err = reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rcp.Status.Restore.Phase).To(Equal(controlplanev1.EtcdRestorePhaseResetting))
+		Expect(rcp.Status.Restore.Machine).To(Equal("machine-0"))
+
+		// The workload cluster is not reachable while etcd is reset.
+		m.workloadErr = errors.New("connection refused")
+		result, err = reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(etcdRestoreRequeueAfter))
+		Expect(conditions.GetReason(rcp, controlplanev1.EtcdRestoredCondition)).To(Equal(controlplanev1.EtcdRestoreResettingReason))
+
+		m.workloadErr = nil
+		result, err = reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(etcdRestoreRequeueAfter))
+		Expect(workload.etcdRestoreJobs).To(HaveLen(1))
+
+		var jobName string
+		for name, args := range workload.etcdRestoreJobs {
+			jobName = name
+			Expect(args).To(Equal([]string{"machine-0", "etcd-snapshot", "registry.example.com/busybox"}))
+		}
+		Expect(jobName).To(HavePrefix("rke2-etcd-restore-"))
+
+		workload.etcdRestoreDone = true
+		_, err = reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(workload.etcdRestoreJobs).To(HaveLen(1))
+		Expect(workload.cleanedUpJobNames).To(ConsistOf(jobName))
+		Expect(rcp.Status.Restore.Phase).To(Equal(controlplanev1.EtcdRestorePhaseCompleted))
+		Expect(rcp.Status.Restore.CompletionTime).ToNot(BeNil())
+		Expect(conditions.IsTrue(rcp, controlplanev1.EtcdRestoredCondition)).To(BeTrue())
+
+		result, err = reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.IsZero()).To(BeTrue())
+	})
+
+	It("should block the control plane until a failed restore is removed", func() {
+		rcp.Status.Restore = &controlplanev1.EtcdRestoreStatus{
+			SnapshotName: "etcd-snapshot",
+			Phase:        controlplanev1.EtcdRestorePhaseResetting,
+			Machine:      "machine-0",
+			StartTime:    &metav1.Time{Time: time.Now()},
+		}
+		workload.etcdRestoreErr = fmt.Errorf("%w: job failed", rke2.ErrEtcdRestoreFailed)
+
+		_, err := reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rcp.Status.Restore.Phase).To(Equal(controlplanev1.EtcdRestorePhaseFailed))
+		Expect(conditions.GetReason(rcp, controlplanev1.EtcdRestoredCondition)).To(Equal(controlplanev1.EtcdRestoreFailedReason))
+
+		result, err := reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.IsZero()).To(BeFalse())
+
+		rcp.Spec.Restore = nil
+		result, err = reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(rcp.Status.Restore).To(BeNil())
+		Expect(conditions.Has(rcp, controlplanev1.EtcdRestoredCondition)).To(BeFalse())
+	})
+
+	It("should scale down again when the machine restoring etcd is gone", func() {
+		rcp.Status.Restore = &controlplanev1.EtcdRestoreStatus{
+			SnapshotName: "etcd-snapshot",
+			Phase:        controlplanev1.EtcdRestorePhaseResetting,
+			Machine:      "missing",
+		}
+
+		_, err := reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rcp.Status.Restore.Phase).To(Equal(controlplanev1.EtcdRestorePhaseScalingDown))
+		Expect(workload.etcdRestoreJobs).To(BeEmpty())
+	})
+})
@@ -23,9 +23,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -44,7 +42,6 @@ import (
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/controllers/clustercache"
-	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/certs"
@@ -55,11 +52,13 @@ import (
 
 	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/controlplane/internal/contract"
+	"github.com/rancher/cluster-api-provider-rke2/controlplane/internal/metrics"
 	"github.com/rancher/cluster-api-provider-rke2/controlplane/internal/util/ssa"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/kubeconfig"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/registration"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/secret"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
 	rke2util "github.com/rancher/cluster-api-provider-rke2/pkg/util"
 )
 
@@ -76,6 +75,12 @@ const (
 
 	// DefaultRequeueTime is the default requeue time for the controller.
 	DefaultRequeueTime = 20 * time.Second
+
+	// etcdSnapshotsRefreshInterval is how often the etcd snapshots reported in the status are listed again.
+	etcdSnapshotsRefreshInterval = 5 * time.Minute
+
+	// maxStatusEtcdSnapshots is the maximum number of etcd snapshots reported in the status.
+	maxStatusEtcdSnapshots = 20
 )
 
 // RKE2ControlPlaneReconciler reconciles a RKE2ControlPlane object.
@@ -89,6 +94,9 @@ type RKE2ControlPlaneReconciler struct {
 	// WatchFilterValue is the label value used to filter events prior to reconciliation.
 	WatchFilterValue string
 
+	// ClusterCache provides the connections to the workload clusters.
+	ClusterCache clustercache.ClusterCache
+
 	managementClusterUncached rke2.ManagementCluster
 	managementCluster         rke2.ManagementCluster
 	recorder                  record.EventRecorder
@@ -100,8 +108,10 @@ type RKE2ControlPlaneReconciler struct {
 //+kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=rke2controlplanes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=rke2controlplanes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=rke2controlplanes/finalizers,verbs=update
+//+kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=rke2etcdsnapshots,verbs=get;list;watch;create
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status;machinesets;machines;machines/status;machinepools;machinepools/status,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets;events;configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services;endpoints,verbs=get
 // +kubebuilder:rbac:groups="bootstrap.cluster.x-k8s.io",resources=rke2configs,verbs=get;list;watch;create;patch;delete
 // +kubebuilder:rbac:groups="infrastructure.cluster.x-k8s.io",resources=*,verbs=get;list;watch;create;patch;delete
 // +kubebuilder:rbac:groups="apiextensions.k8s.io",resources=customresourcedefinitions,verbs=get;list;watch
@@ -109,12 +119,18 @@ type RKE2ControlPlaneReconciler struct {
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 func (r *RKE2ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.Reconcile",
+		tracing.NamespaceKey.String(req.Namespace), tracing.ControlPlaneKey.String(req.Name))
+	defer tracing.End(span, &reterr)
+
 	logger := log.FromContext(ctx)
 	r.Log = logger
 	rcp := &controlplanev1.RKE2ControlPlane{}
 
 	if err := r.Get(ctx, req.NamespacedName, rcp); err != nil {
 		if apierrors.IsNotFound(err) {
+			metrics.Delete(req.Namespace, req.Name)
+
 			return ctrl.Result{}, err
 		}
 
@@ -136,6 +152,7 @@ func (r *RKE2ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 
 	logger = logger.WithValues("cluster", cluster.Name)
+	span.SetAttributes(tracing.ClusterKey.String(cluster.Name))
 
 	if annotations.IsPaused(cluster, rcp) {
 		logger.Info("Reconciliation is paused for this object")
@@ -178,6 +195,8 @@ func (r *RKE2ControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			}
 		}
 
+		metrics.RecordStatus(rcp)
+
 		// Always attempt to Patch the RKE2ControlPlane object and status after each reconciliation.
 		if err := patchRKE2ControlPlane(ctx, patchHelper, rcp); err != nil {
 			reterr = kerrors.NewAggregate([]error{reterr, err})
@@ -256,19 +275,25 @@ func patchRKE2ControlPlane(ctx context.Context, patchHelper *patch.Helper, rcp *
 			controlplanev1.ResizedCondition,
 			controlplanev1.MachinesReadyCondition,
 			controlplanev1.AvailableCondition,
+			controlplanev1.EtcdRestoredCondition,
+			controlplanev1.PreUpgradeSnapshotTakenCondition,
+			controlplanev1.CertificateAuthoritiesValidCondition,
+			controlplanev1.CertificatesRotatedCondition,
+			controlplanev1.CertificatesExpiringSoonCondition,
+			controlplanev1.SecretsEncryptionKeysRotatedCondition,
+			controlplanev1.ConfigDriftedCondition,
+			controlplanev1.EtcdMaintenanceSucceededCondition,
 		}},
 		patch.WithStatusObservedGeneration{},
 	)
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *RKE2ControlPlaneReconciler) SetupWithManager(
-	ctx context.Context, mgr ctrl.Manager, clientQPS float32,
-	clientBurst, clusterCacheConcurrency, concurrency int,
-) error {
+func (r *RKE2ControlPlaneReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, concurrency int) error {
 	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&controlplanev1.RKE2ControlPlane{}).
 		Owns(&clusterv1.Machine{}).
+		Owns(&controlplanev1.RKE2EtcdSnapshot{}).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: concurrency,
 		}).
@@ -290,43 +315,11 @@ func (r *RKE2ControlPlaneReconciler) SetupWithManager(
 	r.recorder = mgr.GetEventRecorderFor("rke2-control-plane-controller")
 	r.ssaCache = ssa.NewCache("rke2-control-plane")
 
-	// Set up a clusterCache to provide to controllers
-	// requiring a connection to a remote cluster
-	clusterCache, err := clustercache.SetupWithManager(ctx, mgr, clustercache.Options{
-		SecretClient: r.SecretCachingClient,
-		Cache: clustercache.CacheOptions{
-			Indexes: []clustercache.CacheOptionsIndex{clustercache.NodeProviderIDIndex},
-		},
-		Client: clustercache.ClientOptions{
-			QPS:       clientQPS,
-			Burst:     clientBurst,
-			UserAgent: remote.DefaultClusterAPIUserAgent("rke2-control-plane-controller"),
-			Cache: clustercache.ClientCacheOptions{
-				DisableFor: []client.Object{
-					// Don't cache ConfigMaps & Secrets.
-					&corev1.ConfigMap{},
-					&corev1.Secret{},
-					// Don't cache Pods & DaemonSets (we get/list them e.g. during drain).
-					&corev1.Pod{},
-					&appsv1.DaemonSet{},
-					// Don't cache PersistentVolumes and VolumeAttachments (we get/list them e.g. during wait for volumes to detach)
-					&storagev1.VolumeAttachment{},
-					&corev1.PersistentVolume{},
-				},
-			},
-		},
-	}, controller.Options{
-		MaxConcurrentReconciles: clusterCacheConcurrency,
-	})
-	if err != nil {
-		return errors.Wrap(err, "unable to create cluster cache tracker")
-	}
-
 	if r.managementCluster == nil {
 		r.managementCluster = &rke2.Management{
 			Client:              r.Client,
 			SecretCachingClient: r.SecretCachingClient,
-			ClusterCache:        clusterCache,
+			ClusterCache:        r.ClusterCache,
 		}
 	}
 
@@ -360,7 +353,10 @@ func (r *RKE2ControlPlaneReconciler) ClusterToRKE2ControlPlane(ctx context.Conte
 }
 
 // nolint:gocyclo
-func (r *RKE2ControlPlaneReconciler) updateStatus(ctx context.Context, rcp *controlplanev1.RKE2ControlPlane, cluster *clusterv1.Cluster) error {
+func (r *RKE2ControlPlaneReconciler) updateStatus(ctx context.Context, rcp *controlplanev1.RKE2ControlPlane, cluster *clusterv1.Cluster) (reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.updateStatus")
+	defer tracing.End(span, &reterr)
+
 	logger := log.FromContext(ctx)
 
 	if cluster == nil {
@@ -494,6 +490,8 @@ func (r *RKE2ControlPlaneReconciler) updateStatus(ctx context.Context, rcp *cont
 		rcp.Status.Initialized = true
 	}
 
+	r.refreshEtcdSnapshots(ctx, controlPlane, workloadCluster)
+
 	if len(ownedMachines) == 0 || len(readyMachines) == 0 {
 		logger.Info(fmt.Sprintf("No Control Plane Machines exist or are ready for RKE2ControlPlane %s/%s", rcp.Namespace, rcp.Name))
 
@@ -502,18 +500,9 @@ func (r *RKE2ControlPlaneReconciler) updateStatus(ctx context.Context, rcp *cont
 
 	availableCPMachines := readyMachines
 
-	registrationmethod, err := registration.NewRegistrationMethod(string(rcp.Spec.RegistrationMethod))
-	if err != nil {
-		logger.Error(err, "Failed to get node registration method")
-
-		return fmt.Errorf("getting node registration method: %w", err)
-	}
-
-	validIPAddresses, err := registrationmethod(cluster, rcp, availableCPMachines)
+	validIPAddresses, err := r.registrationAddresses(ctx, cluster, rcp, availableCPMachines)
 	if err != nil {
-		logger.Error(err, "Failed to get registration addresses")
-
-		return fmt.Errorf("getting registration addresses: %w", err)
+		return err
 	}
 
 	rcp.Status.AvailableServerIPs = validIPAddresses
@@ -527,7 +516,7 @@ func (r *RKE2ControlPlaneReconciler) updateStatus(ctx context.Context, rcp *cont
 
 	conditions.MarkTrue(rcp, controlplanev1.AvailableCondition)
 
-	lowestVersion := controlPlane.Machines.LowestVersion()
+	lowestVersion := controlPlane.LowestVersion()
 	if lowestVersion != nil {
 		controlPlane.RCP.Status.Version = lowestVersion
 	}
@@ -568,11 +557,87 @@ func (r *RKE2ControlPlaneReconciler) updateStatus(ctx context.Context, rcp *cont
 	return nil
 }
 
+// registrationAddresses returns the addresses the nodes register with, using the registration method of the control
+// plane. The registration methods relying on external sources, like DNS records or a webhook, may fail transiently:
+// the last known addresses are kept then, so that the nodes can still join the cluster.
+func (r *RKE2ControlPlaneReconciler) registrationAddresses(
+	ctx context.Context,
+	cluster *clusterv1.Cluster,
+	rcp *controlplanev1.RKE2ControlPlane,
+	availableCPMachines collections.Machines,
+) ([]string, error) {
+	logger := log.FromContext(ctx)
+
+	registrationmethod, err := registration.NewRegistrationMethod(string(rcp.Spec.RegistrationMethod), registration.WithClient(r.Client))
+	if err != nil {
+		logger.Error(err, "Failed to get node registration method")
+
+		return nil, fmt.Errorf("getting node registration method: %w", err)
+	}
+
+	validIPAddresses, err := registrationmethod(ctx, cluster, rcp, availableCPMachines)
+	if err != nil {
+		if len(rcp.Status.AvailableServerIPs) == 0 {
+			logger.Error(err, "Failed to get registration addresses")
+
+			return nil, fmt.Errorf("getting registration addresses: %w", err)
+		}
+
+		logger.Error(err, "Failed to get registration addresses, keeping the last known ones",
+			"addresses", rcp.Status.AvailableServerIPs)
+		r.recorder.Eventf(rcp, corev1.EventTypeWarning, "RegistrationAddressesStale",
+			"Failed to get registration addresses, keeping the last known ones: %v", err)
+
+		return rcp.Status.AvailableServerIPs, nil
+	}
+
+	return validIPAddresses, nil
+}
+
+// refreshEtcdSnapshots lists the etcd snapshots taken by RKE2 into the status, at most every
+// etcdSnapshotsRefreshInterval and keeping only the most recent ones, as the list grows with every periodic snapshot.
+func (r *RKE2ControlPlaneReconciler) refreshEtcdSnapshots(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+	workloadCluster rke2.WorkloadCluster,
+) {
+	rcp := controlPlane.RCP
+
+	if !controlPlane.UsesEmbeddedEtcd() {
+		rcp.Status.EtcdSnapshots = nil
+		rcp.Status.EtcdSnapshotsRefreshTime = nil
+
+		return
+	}
+
+	now := time.Now()
+	if rcp.Status.EtcdSnapshotsRefreshTime != nil && now.Sub(rcp.Status.EtcdSnapshotsRefreshTime.Time) < etcdSnapshotsRefreshInterval {
+		return
+	}
+
+	etcdSnapshots, err := workloadCluster.EtcdSnapshotFiles(ctx)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to list etcd snapshots")
+
+		return
+	}
+
+	if len(etcdSnapshots) > maxStatusEtcdSnapshots {
+		etcdSnapshots = etcdSnapshots[:maxStatusEtcdSnapshots]
+	}
+
+	rcp.Status.EtcdSnapshots = etcdSnapshots
+	rcp.Status.EtcdSnapshotsRefreshTime = &metav1.Time{Time: now}
+}
+
 func (r *RKE2ControlPlaneReconciler) reconcileNormal(
 	ctx context.Context,
 	cluster *clusterv1.Cluster,
 	rcp *controlplanev1.RKE2ControlPlane,
-) (ctrl.Result, error) {
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileNormal")
+	defer tracing.End(span, &reterr)
+
 	logger := log.FromContext(ctx)
 	logger.Info("Reconcile RKE2 Control Plane")
 
@@ -602,6 +667,8 @@ func (r *RKE2ControlPlaneReconciler) reconcileNormal(
 
 	conditions.MarkTrue(rcp, controlplanev1.CertificatesAvailableCondition)
 
+	r.reconcileCertificatesExpiry(ctx, rcp, certificates)
+
 	// If ControlPlaneEndpoint is not set, return early
 	if !cluster.Spec.ControlPlaneEndpoint.IsValid() {
 		logger.Info("Cluster does not yet have a ControlPlaneEndpoint defined")
@@ -661,6 +728,12 @@ func (r *RKE2ControlPlaneReconciler) reconcileNormal(
 		conditions.AddSourceRef(),
 		conditions.WithStepCounterIf(false))
 
+	// Restoring etcd from a snapshot takes precedence over any other operation, as the workload cluster
+	// may not be reachable until the restore has completed.
+	if result, err := r.reconcileEtcdRestore(ctx, controlPlane); err != nil || !result.IsZero() {
+		return result, err
+	}
+
 	// Updates conditions reporting the status of static pods and the status of the etcd cluster.
 	// NOTE: Conditions reporting RCP operation progress like e.g. Resized or SpecUpToDate are inlined with the rest of the execution.
 	if result, err := r.reconcileControlPlaneConditions(ctx, controlPlane); err != nil || !result.IsZero() {
@@ -673,15 +746,38 @@ func (r *RKE2ControlPlaneReconciler) reconcileNormal(
 		return result, err
 	}
 
+	// Remove the etcd members left behind by deleted machines, remediation relies on the list of members
+	// being in sync with the list of machines.
+	if result, err := r.reconcileEtcdMembers(ctx, controlPlane); err != nil || !result.IsZero() {
+		return result, err
+	}
+
 	// Reconcile unhealthy machines by triggering deletion and requeue if it is considered safe to remediate,
 	// otherwise continue with the other RCP operations.
 	if result, err := r.reconcileUnhealthyMachines(ctx, controlPlane); err != nil || !result.IsZero() {
 		return result, err
 	}
 
+	// Record the expiry of the serving certificates of the control plane Nodes, Machines whose certificates
+	// are about to expire are rolled out if requested in rolloutBefore.
+	r.reconcileMachineCertificatesExpiry(ctx, controlPlane)
+
+	// Rotate the certificates requested in the spec. The rollout of the machines after a certificate authorities
+	// rotation is handled below, along with the other rollouts.
+	if result, err := r.reconcileCertificateRotation(ctx, controlPlane); err != nil || !result.IsZero() {
+		return result, err
+	}
+
+	// Rotate the secrets encryption keys requested in the spec, once any rollout has completed.
+	if result, err := r.reconcileSecretsEncryptionRotation(ctx, controlPlane); err != nil || !result.IsZero() {
+		return result, err
+	}
+
 	// Control plane machines rollout due to configuration changes (e.g. upgrades) takes precedence over other operations.
 	needRollout := controlPlane.MachinesNeedingRollout(ctx)
 
+	metrics.RecordRollout(controlPlane.RCP, len(needRollout))
+
 	switch {
 	case len(needRollout) > 0:
 		logger.Info("Rolling out Control Plane machines", "needRollout", needRollout.Names())
@@ -699,7 +795,7 @@ func (r *RKE2ControlPlaneReconciler) reconcileNormal(
 		// NOTE: we are checking the condition already exists in order to avoid to set this condition at the first
 		// reconciliation/before a rolling upgrade actually starts.
 		if conditions.Has(controlPlane.RCP, controlplanev1.MachinesSpecUpToDateCondition) {
-			conditions.MarkTrue(controlPlane.RCP, controlplanev1.MachinesSpecUpToDateCondition)
+			markRolloutCompleted(controlPlane.RCP)
 		}
 	}
 
@@ -733,6 +829,16 @@ func (r *RKE2ControlPlaneReconciler) reconcileNormal(
 		return r.scaleDownControlPlane(ctx, cluster, rcp, controlPlane, collections.Machines{})
 	}
 
+	// Maintain the etcd members once the control plane is stable.
+	if result, err := r.reconcileEtcdMaintenance(ctx, controlPlane); err != nil || !result.IsZero() {
+		return result, err
+	}
+
+	// Nothing triggers a reconciliation when a scheduled rollout is due, requeue for it.
+	if next := controlPlane.NextScheduledRollout(); next != nil {
+		return ctrl.Result{RequeueAfter: time.Until(*next)}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -740,6 +846,9 @@ func (r *RKE2ControlPlaneReconciler) reconcileDelete(ctx context.Context,
 	cluster *clusterv1.Cluster,
 	rcp *controlplanev1.RKE2ControlPlane,
 ) (res ctrl.Result, err error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileDelete")
+	defer tracing.End(span, &err)
+
 	logger := log.FromContext(ctx)
 
 	// Gets all machines, not just control plane machines.
@@ -855,7 +964,10 @@ func (r *RKE2ControlPlaneReconciler) reconcileKubeconfig(
 	clusterName client.ObjectKey,
 	endpoint clusterv1.APIEndpoint,
 	rcp *controlplanev1.RKE2ControlPlane,
-) (ctrl.Result, error) {
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileKubeconfig")
+	defer tracing.End(span, &reterr)
+
 	logger := ctrl.LoggerFrom(ctx)
 	if endpoint.IsZero() {
 		logger.V(5).Info("API Endpoint not yet known")
@@ -918,6 +1030,9 @@ func (r *RKE2ControlPlaneReconciler) reconcileKubeconfig(
 func (r *RKE2ControlPlaneReconciler) reconcileControlPlaneConditions(
 	ctx context.Context, controlPlane *rke2.ControlPlane,
 ) (res ctrl.Result, retErr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileControlPlaneConditions")
+	defer tracing.End(span, &retErr)
+
 	logger := log.FromContext(ctx)
 
 	readyCPMachines := controlPlane.Machines.Filter(collections.IsReady())
@@ -967,7 +1082,21 @@ func (r *RKE2ControlPlaneReconciler) reconcileControlPlaneConditions(
 
 	// Update conditions status
 	workloadCluster.UpdateAgentConditions(controlPlane)
-	workloadCluster.UpdateEtcdConditions(controlPlane)
+	workloadCluster.UpdateEtcdConditions(ctx, controlPlane)
+	workloadCluster.UpdateConfigDriftConditions(ctx, controlPlane)
+
+	if etcdStatus := controlPlane.RCP.Status.Etcd; etcdStatus != nil {
+		healthyMembers := 0
+
+		for _, member := range etcdStatus.Members {
+			if machine, ok := controlPlane.Machines[member.MachineName]; ok &&
+				conditions.IsTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition) {
+				healthyMembers++
+			}
+		}
+
+		metrics.RecordEtcdMembers(controlPlane.RCP, healthyMembers)
+	}
 
 	// Patch nodes metadata
 	if err := workloadCluster.UpdateNodeMetadata(ctx, controlPlane); err != nil {
@@ -980,13 +1109,29 @@ func (r *RKE2ControlPlaneReconciler) reconcileControlPlaneConditions(
 	return ctrl.Result{}, nil
 }
 
+// markRolloutCompleted marks the machines of a RKE2ControlPlane up to date, and records the duration of the rollout,
+// rolling or in place, which completed.
+func markRolloutCompleted(rcp *controlplanev1.RKE2ControlPlane) {
+	if rollout := conditions.Get(rcp, controlplanev1.MachinesSpecUpToDateCondition); rollout != nil &&
+		rollout.Status == corev1.ConditionFalse &&
+		(rollout.Reason == controlplanev1.RollingUpdateInProgressReason ||
+			rollout.Reason == controlplanev1.InPlaceUpgradeInProgressReason) {
+		metrics.ObserveRolloutDuration(rcp, time.Since(rollout.LastTransitionTime.Time))
+	}
+
+	conditions.MarkTrue(rcp, controlplanev1.MachinesSpecUpToDateCondition)
+}
+
 func (r *RKE2ControlPlaneReconciler) upgradeControlPlane(
 	ctx context.Context,
 	cluster *clusterv1.Cluster,
 	rcp *controlplanev1.RKE2ControlPlane,
 	controlPlane *rke2.ControlPlane,
 	machinesRequireUpgrade collections.Machines,
-) (ctrl.Result, error) {
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.upgradeControlPlane")
+	defer tracing.End(span, &reterr)
+
 	logger := controlPlane.Logger()
 
 	// If the cluster is not yet initialized, there is no way to connect to the workload cluster and fetch information
@@ -1008,36 +1153,60 @@ func (r *RKE2ControlPlaneReconciler) upgradeControlPlane(
 		return ctrl.Result{}, err
 	}
 
+	if result, err := r.reconcilePreUpgradeSnapshot(ctx, controlPlane); err != nil || !result.IsZero() {
+		return result, err
+	}
+
 	switch rcp.Spec.RolloutStrategy.Type {
 	case controlplanev1.RollingUpdateStrategyType:
-		// RolloutStrategy is currently defaulted and validated to be RollingUpdate.
-		// Defaulted to 1 if not specified
-		maxSurge := intstr.FromInt(1)
-		if rcp.Spec.RolloutStrategy.RollingUpdate != nil && rcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge != nil {
-			maxSurge = *rcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge
+		return r.rollingUpdateControlPlane(ctx, cluster, rcp, controlPlane, machinesRequireUpgrade)
+	case controlplanev1.InPlaceUpgradeStrategyType:
+		if outdated := controlPlane.MachinesWithOutdatedVersion(ctx); outdated.Len() > 0 {
+			return r.upgradeControlPlaneInPlace(ctx, controlPlane, workloadCluster, outdated)
 		}
 
-		maxNodes := *rcp.Spec.Replicas + rke2util.SafeInt32(maxSurge.IntValue())
-		if rke2util.SafeInt32(controlPlane.Machines.Len()) < maxNodes {
-			// scaleUpControlPlane ensures that we don't continue scaling up while waiting for Machines to have NodeRefs
-			return r.scaleUpControlPlane(ctx, cluster, rcp, controlPlane)
-		}
-
-		return r.scaleDownControlPlane(ctx, cluster, rcp, controlPlane, machinesRequireUpgrade)
+		// Changes other than the version can not be applied in place, the machines are replaced instead.
+		return r.rollingUpdateControlPlane(ctx, cluster, rcp, controlPlane, machinesRequireUpgrade)
 	default:
 		err := fmt.Errorf("unknown rollout strategy type %q", rcp.Spec.RolloutStrategy.Type)
-		logger.Error(err, "RolloutStrategy type is not set to RollingUpdateStrategyType, unable to determine the strategy for rolling out machines")
+		logger.Error(err, "RolloutStrategy type is not supported, unable to determine the strategy for rolling out machines")
 
 		return ctrl.Result{}, nil
 	}
 }
 
+// rollingUpdateControlPlane replaces the machines requiring an upgrade by new ones, honoring the maximum surge.
+func (r *RKE2ControlPlaneReconciler) rollingUpdateControlPlane(
+	ctx context.Context,
+	cluster *clusterv1.Cluster,
+	rcp *controlplanev1.RKE2ControlPlane,
+	controlPlane *rke2.ControlPlane,
+	machinesRequireUpgrade collections.Machines,
+) (ctrl.Result, error) {
+	// Defaulted to 1 if not specified
+	maxSurge := intstr.FromInt(1)
+	if rcp.Spec.RolloutStrategy.RollingUpdate != nil && rcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge != nil {
+		maxSurge = *rcp.Spec.RolloutStrategy.RollingUpdate.MaxSurge
+	}
+
+	maxNodes := *rcp.Spec.Replicas + rke2util.SafeInt32(maxSurge.IntValue())
+	if rke2util.SafeInt32(controlPlane.Machines.Len()) < maxNodes {
+		// scaleUpControlPlane ensures that we don't continue scaling up while waiting for Machines to have NodeRefs
+		return r.scaleUpControlPlane(ctx, cluster, rcp, controlPlane)
+	}
+
+	return r.scaleDownControlPlane(ctx, cluster, rcp, controlPlane, machinesRequireUpgrade)
+}
+
 // syncMachines updates Machines, InfrastructureMachines and Rke2Configs to propagate in-place mutable fields from RKE2ControlPlane.
 // Note: For InfrastructureMachines and Rke2Configs it also drops ownership of "metadata.labels" and
 // "metadata.annotations" from "manager" so that "rke2controlplane" can own these fields and can work with SSA.
 // Otherwise, fields would be co-owned by our "old" "manager" and "rke2controlplane" and then we would not be
 // able to e.g. drop labels and annotations.
-func (r *RKE2ControlPlaneReconciler) syncMachines(ctx context.Context, controlPlane *rke2.ControlPlane) error {
+func (r *RKE2ControlPlaneReconciler) syncMachines(ctx context.Context, controlPlane *rke2.ControlPlane) (reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.syncMachines")
+	defer tracing.End(span, &reterr)
+
 	patchHelpers := map[string]*patch.Helper{}
 
 	for machineName := range controlPlane.Machines {
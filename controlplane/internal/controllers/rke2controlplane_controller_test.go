@@ -9,6 +9,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -20,12 +21,16 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/certs"
 	"sigs.k8s.io/cluster-api/util/collections"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/kubeconfig"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 var _ = Describe("Rotate kubeconfig cert", func() {
@@ -393,6 +398,103 @@ var _ = Describe("Reconcile control plane conditions", func() {
 })
 
 // generateCertAndKey generates a self-signed certificate and private key.
+var _ = Describe("Refresh etcd snapshots", func() {
+	var (
+		rcp          *controlplanev1.RKE2ControlPlane
+		controlPlane *rke2.ControlPlane
+		workload     *fakeWorkloadCluster
+		r            *RKE2ControlPlaneReconciler
+	)
+
+	BeforeEach(func() {
+		rcp = &controlplanev1.RKE2ControlPlane{}
+		controlPlane = &rke2.ControlPlane{RCP: rcp}
+		workload = &fakeWorkloadCluster{}
+		r = &RKE2ControlPlaneReconciler{}
+
+		for i := range maxStatusEtcdSnapshots + 5 {
+			workload.etcdSnapshotFiles = append(workload.etcdSnapshotFiles,
+				controlplanev1.EtcdSnapshotFile{Name: fmt.Sprintf("etcd-snapshot-%d", i)})
+		}
+	})
+
+	It("should report the most recent snapshots, at most every refresh interval", func() {
+		r.refreshEtcdSnapshots(ctx, controlPlane, workload)
+		Expect(rcp.Status.EtcdSnapshots).To(HaveLen(maxStatusEtcdSnapshots))
+		Expect(rcp.Status.EtcdSnapshots[0].Name).To(Equal("etcd-snapshot-0"))
+		Expect(rcp.Status.EtcdSnapshotsRefreshTime).ToNot(BeNil())
+
+		r.refreshEtcdSnapshots(ctx, controlPlane, workload)
+		Expect(workload.etcdSnapshotFilesCalls).To(Equal(1))
+
+		rcp.Status.EtcdSnapshotsRefreshTime = &metav1.Time{Time: time.Now().Add(-etcdSnapshotsRefreshInterval)}
+		r.refreshEtcdSnapshots(ctx, controlPlane, workload)
+		Expect(workload.etcdSnapshotFilesCalls).To(Equal(2))
+	})
+
+	It("should not list snapshots with an external datastore", func() {
+		rcp.Spec.ServerConfig.ExternalDatastoreSecret = &corev1.ObjectReference{Name: "datastore"}
+		rcp.Status.EtcdSnapshots = []controlplanev1.EtcdSnapshotFile{{Name: "etcd-snapshot"}}
+
+		r.refreshEtcdSnapshots(ctx, controlPlane, workload)
+		Expect(workload.etcdSnapshotFilesCalls).To(BeZero())
+		Expect(rcp.Status.EtcdSnapshots).To(BeNil())
+	})
+})
+
+var _ = Describe("Registration addresses", func() {
+	var (
+		cluster  *clusterv1.Cluster
+		rcp      *controlplanev1.RKE2ControlPlane
+		recorder *record.FakeRecorder
+		r        *RKE2ControlPlaneReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "registration"}}
+		rcp = &controlplanev1.RKE2ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "registration"},
+			Spec: controlplanev1.RKE2ControlPlaneSpec{
+				RegistrationMethod:     controlplanev1.RegistrationMethodService,
+				RegistrationServiceRef: &corev1.ObjectReference{Name: "registration"},
+			},
+		}
+		recorder = record.NewFakeRecorder(32)
+		r = &RKE2ControlPlaneReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+			recorder: recorder,
+		}
+	})
+
+	It("should fail without last known addresses", func() {
+		_, err := r.registrationAddresses(ctx, cluster, rcp, collections.Machines{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep the last known addresses when they can not be read", func() {
+		rcp.Status.AvailableServerIPs = []string{"10.0.0.1"}
+
+		addresses, err := r.registrationAddresses(ctx, cluster, rcp, collections.Machines{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(addresses).To(Equal([]string{"10.0.0.1"}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("RegistrationAddressesStale")))
+	})
+
+	It("should update the addresses once they can be read again", func() {
+		rcp.Status.AvailableServerIPs = []string{"10.0.0.1"}
+		Expect(r.Create(ctx, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "registration", Namespace: "registration"},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "10.0.0.2"}},
+			}},
+		})).To(Succeed())
+
+		addresses, err := r.registrationAddresses(ctx, cluster, rcp, collections.Machines{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(addresses).To(Equal([]string{"10.0.0.2"}))
+	})
+})
+
 func generateCertAndKey(expiryDate time.Time) ([]byte, []byte, error) {
 	priv, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
@@ -452,3 +554,45 @@ users:
 
 	return nil
 }
+
+var _ = Describe("Rollout completion", func() {
+	// rolloutsObserved returns the number of rollout durations observed for a RKE2ControlPlane.
+	rolloutsObserved := func(rcp *controlplanev1.RKE2ControlPlane) uint64 {
+		families, err := ctrlmetrics.Registry.Gather()
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, family := range families {
+			if family.GetName() != "caprke2_controlplane_rollout_duration_seconds" {
+				continue
+			}
+
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "name" && label.GetValue() == rcp.Name {
+						return metric.GetHistogram().GetSampleCount()
+					}
+				}
+			}
+		}
+
+		return 0
+	}
+
+	DescribeTable("should record the duration of the completed rollouts",
+		func(reason string, observed uint64) {
+			rcp := &controlplanev1.RKE2ControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Name: "rollout-" + strings.ToLower(reason), Namespace: "rollout"},
+			}
+			conditions.MarkFalse(rcp, controlplanev1.MachinesSpecUpToDateCondition, reason,
+				clusterv1.ConditionSeverityWarning, "")
+
+			markRolloutCompleted(rcp)
+
+			Expect(conditions.IsTrue(rcp, controlplanev1.MachinesSpecUpToDateCondition)).To(BeTrue())
+			Expect(rolloutsObserved(rcp)).To(Equal(observed))
+		},
+		Entry("rolling update", controlplanev1.RollingUpdateInProgressReason, uint64(1)),
+		Entry("in-place upgrade", controlplanev1.InPlaceUpgradeInProgressReason, uint64(1)),
+		Entry("failed in-place upgrade", controlplanev1.InPlaceUpgradeFailedReason, uint64(0)),
+	)
+})
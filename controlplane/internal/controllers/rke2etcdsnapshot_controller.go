@@ -0,0 +1,304 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/clustercache"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+)
+
+// errSnapshotMachineNotFound is returned when the Machine to take the snapshot on does not exist.
+var errSnapshotMachineNotFound = errors.New("control plane machine not found")
+
+// RKE2EtcdSnapshotReconciler reconciles a RKE2EtcdSnapshot object.
+type RKE2EtcdSnapshotReconciler struct {
+	client.Client
+
+	SecretCachingClient client.Client
+
+	// WatchFilterValue is the label value used to filter events prior to reconciliation.
+	WatchFilterValue string
+
+	// ClusterCache provides the connections to the workload clusters.
+	ClusterCache clustercache.ClusterCache
+
+	managementCluster rke2.ManagementCluster
+	recorder          record.EventRecorder
+}
+
+//nolint:lll
+//+kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=rke2etcdsnapshots,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=rke2etcdsnapshots/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;machines,verbs=get;list;watch
+
+// Reconcile takes a one-off etcd snapshot on a control plane Machine and reports it in the RKE2EtcdSnapshot status.
+func (r *RKE2EtcdSnapshotReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, reterr error) {
+	logger := log.FromContext(ctx)
+
+	snapshot := &controlplanev1.RKE2EtcdSnapshot{}
+	if err := r.Get(ctx, req.NamespacedName, snapshot); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !snapshot.DeletionTimestamp.IsZero() ||
+		snapshot.Status.Phase == controlplanev1.EtcdSnapshotPhaseSucceeded ||
+		snapshot.Status.Phase == controlplanev1.EtcdSnapshotPhaseFailed {
+		return ctrl.Result{}, nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: snapshot.Namespace, Name: snapshot.Spec.ClusterName}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.Info("Cluster does not exist yet", "cluster", snapshot.Spec.ClusterName)
+
+			return ctrl.Result{RequeueAfter: preflightFailedRequeueAfter}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	logger = logger.WithValues("cluster", cluster.Name)
+	ctx = log.IntoContext(ctx, logger)
+
+	if annotations.IsPaused(cluster, snapshot) {
+		logger.Info("Reconciliation is paused for this object")
+
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(snapshot, r.Client)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to configure the patch helper")
+	}
+
+	defer func() {
+		if err := patchHelper.Patch(ctx, snapshot); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
+	if snapshot.Status.Phase == "" {
+		snapshot.Status.Phase = controlplanev1.EtcdSnapshotPhasePending
+	}
+
+	return r.reconcileSnapshot(ctx, cluster, snapshot)
+}
+
+func (r *RKE2EtcdSnapshotReconciler) reconcileSnapshot(
+	ctx context.Context,
+	cluster *clusterv1.Cluster,
+	snapshot *controlplanev1.RKE2EtcdSnapshot,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if !cluster.Status.ControlPlaneReady {
+		logger.Info("Waiting for the control plane to be ready before taking an etcd snapshot")
+
+		return ctrl.Result{RequeueAfter: preflightFailedRequeueAfter}, nil
+	}
+
+	machine, err := r.machineForSnapshot(ctx, cluster, snapshot)
+	if errors.Is(err, errSnapshotMachineNotFound) {
+		snapshot.Status.Phase = controlplanev1.EtcdSnapshotPhaseFailed
+		snapshot.Status.FailureMessage = err.Error()
+		r.recorder.Eventf(snapshot, corev1.EventTypeWarning, "EtcdSnapshotFailed", "Failed to take etcd snapshot: %v", err)
+
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if machine == nil {
+		logger.Info("Waiting for a ready control plane Machine to take the etcd snapshot on")
+
+		return ctrl.Result{RequeueAfter: preflightFailedRequeueAfter}, nil
+	}
+
+	snapshot.Status.MachineName = machine.Name
+	snapshot.Status.NodeName = machine.Status.NodeRef.Name
+
+	workloadCluster, err := r.managementCluster.GetWorkloadCluster(ctx, client.ObjectKeyFromObject(cluster))
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting workload cluster: %w", err)
+	}
+
+	jobName := etcdSnapshotJobName(snapshot)
+
+	phase, err := workloadCluster.SaveEtcdSnapshot(ctx, machine, jobName, snapshot.GetSnapshotName(), snapshot.Spec.Image)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("taking etcd snapshot on machine %s: %w", machine.Name, err)
+	}
+
+	if phase == controlplanev1.EtcdSnapshotPhaseFailed {
+		snapshot.Status.Phase = controlplanev1.EtcdSnapshotPhaseFailed
+		snapshot.Status.FailureMessage = fmt.Sprintf(
+			"etcd snapshot job %s/%s failed on node %s", metav1.NamespaceSystem, jobName, snapshot.Status.NodeName)
+		r.recorder.Eventf(snapshot, corev1.EventTypeWarning, "EtcdSnapshotFailed",
+			"Failed to take etcd snapshot on Machine %s", machine.Name)
+
+		// The Job is kept in the workload cluster so its logs can be inspected.
+		return ctrl.Result{}, nil
+	}
+
+	if phase != controlplanev1.EtcdSnapshotPhaseSucceeded {
+		snapshot.Status.Phase = controlplanev1.EtcdSnapshotPhaseRunning
+
+		return ctrl.Result{RequeueAfter: etcdSnapshotRequeueAfter}, nil
+	}
+
+	files, err := workloadCluster.EtcdSnapshotFiles(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("listing etcd snapshot files: %w", err)
+	}
+
+	if !setEtcdSnapshotFiles(snapshot, files) {
+		logger.Info("Waiting for RKE2 to report the etcd snapshot file")
+
+		return ctrl.Result{RequeueAfter: etcdSnapshotRequeueAfter}, nil
+	}
+
+	if err := workloadCluster.CleanupEtcdSnapshot(ctx, jobName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	snapshot.Status.Phase = controlplanev1.EtcdSnapshotPhaseSucceeded
+
+	logger.Info("Etcd snapshot taken", "snapshot", snapshot.Status.SnapshotName, "machine", machine.Name)
+	r.recorder.Eventf(snapshot, corev1.EventTypeNormal, "EtcdSnapshotTaken",
+		"Took etcd snapshot %s on Machine %s", snapshot.Status.SnapshotName, machine.Name)
+
+	return ctrl.Result{}, nil
+}
+
+// machineForSnapshot returns the control plane Machine to take the snapshot on, or nil if none is ready yet.
+func (r *RKE2EtcdSnapshotReconciler) machineForSnapshot(
+	ctx context.Context,
+	cluster *clusterv1.Cluster,
+	snapshot *controlplanev1.RKE2EtcdSnapshot,
+) (*clusterv1.Machine, error) {
+	machines, err := r.managementCluster.GetMachinesForCluster(ctx, client.ObjectKeyFromObject(cluster),
+		collections.ControlPlaneMachines(cluster.Name), collections.ActiveMachines)
+	if err != nil {
+		return nil, fmt.Errorf("getting control plane machines: %w", err)
+	}
+
+	machineName := snapshot.Spec.MachineName
+	if machineName == "" {
+		machineName = snapshot.Status.MachineName
+	}
+
+	if machineName == "" {
+		return machines.Filter(collections.IsReady(), collections.HasNode()).Oldest(), nil
+	}
+
+	for _, machine := range machines {
+		if machine.Name == machineName {
+			if machine.Status.NodeRef == nil {
+				return nil, nil
+			}
+
+			return machine, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", errSnapshotMachineNotFound, machineName)
+}
+
+// setEtcdSnapshotFiles sets the snapshot details from the snapshot files RKE2 created for it.
+// It returns false if the snapshot file has not been reported yet.
+func setEtcdSnapshotFiles(snapshot *controlplanev1.RKE2EtcdSnapshot, files []controlplanev1.EtcdSnapshotFile) bool {
+	// RKE2 names the snapshots <name>-<node>-<timestamp>.
+	prefix := fmt.Sprintf("%s-%s-", snapshot.GetSnapshotName(), snapshot.Status.NodeName)
+
+	found := false
+
+	// Files are sorted most recent first, so the first matching ones are the files of this snapshot.
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name, prefix) ||
+			(file.CreationTime != nil && file.CreationTime.Before(&snapshot.CreationTimestamp)) {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(file.Location, "s3://"):
+			if snapshot.Status.S3Location == "" {
+				snapshot.Status.S3Location = file.Location
+			}
+		case !found:
+			found = true
+			snapshot.Status.SnapshotName = file.Name
+			snapshot.Status.Location = file.Location
+			snapshot.Status.Size = file.Size
+			snapshot.Status.CompletionTime = file.CreationTime
+		}
+	}
+
+	return found
+}
+
+// etcdSnapshotJobName returns the name of the Job taking the snapshot in the workload cluster.
+func etcdSnapshotJobName(snapshot *controlplanev1.RKE2EtcdSnapshot) string {
+	return "rke2-etcd-snapshot-" + string(snapshot.UID)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RKE2EtcdSnapshotReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, concurrency int) error {
+	err := ctrl.NewControllerManagedBy(mgr).
+		For(&controlplanev1.RKE2EtcdSnapshot{}).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: concurrency,
+		}).
+		WithEventFilter(predicates.ResourceHasFilterLabel(mgr.GetScheme(), ctrl.LoggerFrom(ctx), r.WatchFilterValue)).
+		Complete(r)
+	if err != nil {
+		return errors.Wrap(err, "failed setting up with a controller manager")
+	}
+
+	r.recorder = mgr.GetEventRecorderFor("rke2-etcd-snapshot-controller")
+
+	if r.managementCluster == nil {
+		r.managementCluster = &rke2.Management{
+			Client:              r.Client,
+			SecretCachingClient: r.SecretCachingClient,
+			ClusterCache:        r.ClusterCache,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Etcd snapshot controller", func() {
+	var (
+		cluster  *clusterv1.Cluster
+		snapshot *controlplanev1.RKE2EtcdSnapshot
+		workload *fakeWorkloadCluster
+		c        client.Client
+		r        *RKE2EtcdSnapshotReconciler
+		created  time.Time
+	)
+
+	BeforeEach(func() {
+		created = time.Now().Add(-time.Hour).Truncate(time.Second)
+
+		cluster = &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "snapshot"},
+			Status:     clusterv1.ClusterStatus{ControlPlaneReady: true},
+		}
+		snapshot = &controlplanev1.RKE2EtcdSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "backup",
+				Namespace:         "snapshot",
+				UID:               types.UID("snapshot-uid"),
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: controlplanev1.RKE2EtcdSnapshotSpec{ClusterName: "test", Image: "registry.example.com/busybox"},
+		}
+	})
+
+	// build creates the client and the reconciler with the Cluster, the snapshot and the given Machines.
+	build := func(machines ...*clusterv1.Machine) {
+		objects := []client.Object{cluster, snapshot}
+		for _, machine := range machines {
+			machine.Labels = map[string]string{
+				clusterv1.ClusterNameLabel:         "test",
+				clusterv1.MachineControlPlaneLabel: "",
+			}
+			objects = append(objects, machine)
+		}
+
+		c = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(objects...).
+			WithStatusSubresource(objects...).
+			Build()
+		workload = &fakeWorkloadCluster{}
+		r = &RKE2EtcdSnapshotReconciler{
+			Client:            c,
+			managementCluster: &fakeManagementCluster{Client: c, workload: workload},
+			recorder:          record.NewFakeRecorder(32),
+		}
+	}
+
+	reconcile := func() ctrl.Result {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(snapshot)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(snapshot), snapshot)).To(Succeed())
+
+		return result
+	}
+
+	It("should take the snapshot on the oldest machine and report the snapshot file", func() {
+		build(newTestMachine("machine-1", "snapshot", created.Add(time.Minute)), newTestMachine("machine-0", "snapshot", created))
+
+		workload.etcdSnapshotPhase = controlplanev1.EtcdSnapshotPhaseRunning
+		result := reconcile()
+		Expect(result.RequeueAfter).To(Equal(etcdSnapshotRequeueAfter))
+		Expect(snapshot.Status.Phase).To(Equal(controlplanev1.EtcdSnapshotPhaseRunning))
+		Expect(snapshot.Status.MachineName).To(Equal("machine-0"))
+		Expect(snapshot.Status.NodeName).To(Equal("machine-0"))
+		Expect(workload.etcdSnapshotJobs).To(HaveKeyWithValue(
+			"rke2-etcd-snapshot-snapshot-uid", []string{"machine-0", "backup", "registry.example.com/busybox"}))
+
+		// The snapshot file is not reported by RKE2 yet, a file of an older snapshot is ignored.
+		workload.etcdSnapshotPhase = controlplanev1.EtcdSnapshotPhaseSucceeded
+		workload.etcdSnapshotFiles = []controlplanev1.EtcdSnapshotFile{{
+			Name:         "backup-machine-0-1000",
+			Location:     "file:///var/lib/rancher/rke2/server/db/snapshots/backup-machine-0-1000",
+			CreationTime: &metav1.Time{Time: created.Add(-time.Hour)},
+		}}
+		result = reconcile()
+		Expect(result.RequeueAfter).To(Equal(etcdSnapshotRequeueAfter))
+		Expect(snapshot.Status.Phase).To(Equal(controlplanev1.EtcdSnapshotPhaseRunning))
+		Expect(workload.cleanedUpJobNames).To(BeEmpty())
+
+		size := resource.MustParse("12Mi")
+		workload.etcdSnapshotFiles = append([]controlplanev1.EtcdSnapshotFile{
+			{
+				Name:         "backup-machine-0-2000",
+				Location:     "s3://bucket/backup-machine-0-2000",
+				CreationTime: &metav1.Time{Time: created.Add(time.Minute)},
+			},
+			{
+				Name:         "backup-machine-0-2000",
+				Location:     "file:///var/lib/rancher/rke2/server/db/snapshots/backup-machine-0-2000",
+				Size:         &size,
+				CreationTime: &metav1.Time{Time: created.Add(time.Minute)},
+			},
+		}, workload.etcdSnapshotFiles...)
+		result = reconcile()
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(snapshot.Status.Phase).To(Equal(controlplanev1.EtcdSnapshotPhaseSucceeded))
+		Expect(snapshot.Status.SnapshotName).To(Equal("backup-machine-0-2000"))
+		Expect(snapshot.Status.Location).To(Equal("file:///var/lib/rancher/rke2/server/db/snapshots/backup-machine-0-2000"))
+		Expect(snapshot.Status.S3Location).To(Equal("s3://bucket/backup-machine-0-2000"))
+		Expect(snapshot.Status.Size.Equal(size)).To(BeTrue())
+		Expect(snapshot.Status.CompletionTime).ToNot(BeNil())
+		Expect(workload.cleanedUpJobNames).To(ConsistOf("rke2-etcd-snapshot-snapshot-uid"))
+
+		// A succeeded snapshot is not taken again.
+		workload.etcdSnapshotJobs = nil
+		result = reconcile()
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(workload.etcdSnapshotJobs).To(BeEmpty())
+	})
+
+	It("should keep the failed job and report the failure", func() {
+		snapshot.Spec.MachineName = "machine-1"
+		build(newTestMachine("machine-0", "snapshot", created), newTestMachine("machine-1", "snapshot", created.Add(time.Minute)))
+
+		workload.etcdSnapshotPhase = controlplanev1.EtcdSnapshotPhaseFailed
+		result := reconcile()
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(snapshot.Status.Phase).To(Equal(controlplanev1.EtcdSnapshotPhaseFailed))
+		Expect(snapshot.Status.MachineName).To(Equal("machine-1"))
+		Expect(snapshot.Status.FailureMessage).To(ContainSubstring("rke2-etcd-snapshot-snapshot-uid"))
+		Expect(workload.cleanedUpJobNames).To(BeEmpty())
+
+		// A failed snapshot is not retried.
+		workload.etcdSnapshotJobs = nil
+		result = reconcile()
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(workload.etcdSnapshotJobs).To(BeEmpty())
+	})
+
+	It("should fail when the requested machine does not exist", func() {
+		snapshot.Spec.MachineName = "missing"
+		build(newTestMachine("machine-0", "snapshot", created))
+
+		result := reconcile()
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(snapshot.Status.Phase).To(Equal(controlplanev1.EtcdSnapshotPhaseFailed))
+		Expect(snapshot.Status.FailureMessage).To(ContainSubstring("missing"))
+		Expect(workload.etcdSnapshotJobs).To(BeEmpty())
+	})
+
+	It("should wait for a ready control plane machine", func() {
+		machine := newTestMachine("machine-0", "snapshot", created)
+		machine.Status.NodeRef = nil
+		build(machine)
+
+		result := reconcile()
+		Expect(result.RequeueAfter).To(Equal(preflightFailedRequeueAfter))
+		Expect(snapshot.Status.Phase).To(Equal(controlplanev1.EtcdSnapshotPhasePending))
+		Expect(workload.etcdSnapshotJobs).To(BeEmpty())
+	})
+
+	It("should wait for the control plane to be ready", func() {
+		cluster.Status.ControlPlaneReady = false
+		build(newTestMachine("machine-0", "snapshot", created))
+
+		result := reconcile()
+		Expect(result.RequeueAfter).To(Equal(preflightFailedRequeueAfter))
+		Expect(snapshot.Status.Phase).To(Equal(controlplanev1.EtcdSnapshotPhasePending))
+		Expect(workload.etcdSnapshotJobs).To(BeEmpty())
+	})
+
+	It("should not take a snapshot that is being deleted", func() {
+		snapshot.Finalizers = []string{"test"}
+		build(newTestMachine("machine-0", "snapshot", created))
+		Expect(c.Delete(ctx, snapshot)).To(Succeed())
+
+		result := reconcile()
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(snapshot.Status.Phase).To(BeEmpty())
+		Expect(workload.etcdSnapshotJobs).To(BeEmpty())
+	})
+})
@@ -47,6 +47,8 @@ import (
 	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/controlplane/internal/util/ssa"
 	rke2 "github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
+	rke2util "github.com/rancher/cluster-api-provider-rke2/pkg/util"
 )
 
 func (r *RKE2ControlPlaneReconciler) initializeControlPlane(
@@ -54,7 +56,10 @@ func (r *RKE2ControlPlaneReconciler) initializeControlPlane(
 	cluster *clusterv1.Cluster,
 	rcp *controlplanev1.RKE2ControlPlane,
 	controlPlane *rke2.ControlPlane,
-) (ctrl.Result, error) {
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.initializeControlPlane")
+	defer tracing.End(span, &reterr)
+
 	logger := controlPlane.Logger()
 
 	// Perform an uncached read of all the owned machines. This check is in place to make sure
@@ -99,7 +104,10 @@ func (r *RKE2ControlPlaneReconciler) scaleUpControlPlane(
 	cluster *clusterv1.Cluster,
 	rcp *controlplanev1.RKE2ControlPlane,
 	controlPlane *rke2.ControlPlane,
-) (ctrl.Result, error) {
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.scaleUpControlPlane")
+	defer tracing.End(span, &reterr)
+
 	logger := controlPlane.Logger()
 
 	// Run preflight checks to ensure that the control plane is stable before proceeding with a scale up/scale down operation; if not, wait.
@@ -136,7 +144,10 @@ func (r *RKE2ControlPlaneReconciler) scaleDownControlPlane(
 	rcp *controlplanev1.RKE2ControlPlane,
 	controlPlane *rke2.ControlPlane,
 	outdatedMachines collections.Machines,
-) (ctrl.Result, error) {
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.scaleDownControlPlane")
+	defer tracing.End(span, &reterr)
+
 	logger := controlPlane.Logger()
 
 	// Pick the Machine that we should scale down.
@@ -157,15 +168,42 @@ func (r *RKE2ControlPlaneReconciler) scaleDownControlPlane(
 		return ctrl.Result{}, errors.New("failed to pick control plane Machine to delete")
 	}
 
+	span.SetAttributes(tracing.MachineKey.String(machineToDelete.Name))
+
 	workloadCluster, err := controlPlane.GetWorkloadCluster(ctx)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("getting workload cluster: %w", err)
 	}
 
 	if controlPlane.UsesEmbeddedEtcd() {
+		// When rolling out without surge, the outdated machine is deleted before its replacement is created,
+		// so the remaining etcd members must be able to keep quorum on their own.
+		if rke2util.SafeInt32(controlPlane.Machines.Len()) <= *rcp.Spec.Replicas {
+			canSafelyRemove, err := r.canSafelyRemoveEtcdMember(ctx, controlPlane, machineToDelete)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+
+			if !canSafelyRemove {
+				logger.Info("Waiting for etcd to be able to keep quorum before deleting control plane Machine",
+					"machine", machineToDelete.Name)
+				r.recorder.Eventf(rcp, corev1.EventTypeWarning, "ScaleDownBlocked",
+					"Deleting control plane Machine %s would cause etcd to lose quorum", machineToDelete.Name)
+
+				return ctrl.Result{RequeueAfter: preflightFailedRequeueAfter}, nil
+			}
+		}
+
 		// If etcd leadership is on machine that is about to be deleted, move it to the newest member available.
 		if _, found := controlPlane.RCP.Annotations[controlplanev1.LegacyRKE2ControlPlane]; !found {
-			etcdLeaderCandidate := controlPlane.Machines.Newest()
+			etcdLeaderCandidate := controlPlane.Machines.
+				Filter(collections.ActiveMachines, collections.HasNode()).
+				Difference(collections.FromMachines(machineToDelete)).
+				Newest()
+			if etcdLeaderCandidate == nil {
+				return ctrl.Result{}, errors.New("failed to pick a control plane Machine to move etcd leadership to")
+			}
+
 			if err := workloadCluster.ForwardEtcdLeadership(ctx, machineToDelete, etcdLeaderCandidate); err != nil {
 				logger.Error(err, "Failed to move leadership to candidate machine", "candidate", etcdLeaderCandidate.Name)
 
@@ -202,6 +240,9 @@ func (r *RKE2ControlPlaneReconciler) preflightChecks(
 	controlPlane *rke2.ControlPlane,
 	excludeFor ...*clusterv1.Machine,
 ) ctrl.Result {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.preflightChecks")
+	defer tracing.End(span, nil)
+
 	logger := log.FromContext(ctx)
 
 	// If there is no RCP-owned control-plane machines, then control-plane has not been initialized yet,
@@ -238,6 +279,10 @@ loopmachines:
 		}
 
 		for _, condition := range allMachineHealthConditions {
+			if condition == controlplanev1.MachineEtcdMemberHealthyCondition && etcdMemberHoldsQuorum(machine) {
+				continue
+			}
+
 			if err := preflightCheckCondition("machine", machine, condition); err != nil {
 				machineErrors = append(machineErrors, err)
 			}
@@ -256,6 +301,13 @@ loopmachines:
 	return ctrl.Result{}
 }
 
+// etcdMemberHoldsQuorum returns whether the etcd member of a Machine is healthy, or only reports a NOSPACE alarm: such
+// a member still takes part in the quorum, and the etcd maintenance disarms the alarm once it is defragmented.
+func etcdMemberHoldsQuorum(machine *clusterv1.Machine) bool {
+	return conditions.IsTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition) ||
+		conditions.GetReason(machine, controlplanev1.MachineEtcdMemberHealthyCondition) == controlplanev1.EtcdMemberNoSpaceReason
+}
+
 func preflightCheckCondition(kind string, obj conditions.Getter, condition clusterv1.ConditionType) error {
 	c := conditions.Get(obj, condition)
 	if c == nil {
@@ -534,6 +586,11 @@ func (r *RKE2ControlPlaneReconciler) computeDesiredMachine(
 
 		annotations[controlplanev1.RKE2ServerConfigurationAnnotation] = string(serverConfig)
 		annotations[controlplanev1.PreTerminateHookCleanupAnnotation] = ""
+
+		// A new machine comes with fresh leaf certificates, and with the current certificate authorities.
+		for k, v := range certificateRotationAnnotations(rcp) {
+			annotations[k] = v
+		}
 	} else {
 		// Updating an existing machine
 		machineName = existingMachine.Name
@@ -546,6 +603,18 @@ func (r *RKE2ControlPlaneReconciler) computeDesiredMachine(
 		if serverConfig, ok := existingMachine.Annotations[controlplanev1.RKE2ServerConfigurationAnnotation]; ok {
 			annotations[controlplanev1.RKE2ServerConfigurationAnnotation] = serverConfig
 		}
+
+		// Keep track of the in-place operations performed on the Node of the machine.
+		for _, annotation := range []string{
+			controlplanev1.InPlaceUpgradedVersionAnnotation,
+			controlplanev1.LeafCertificatesRotationAnnotation,
+			controlplanev1.CertificateAuthoritiesRotationAnnotation,
+			controlplanev1.SecretsEncryptionRotationAnnotation,
+		} {
+			if value, ok := existingMachine.Annotations[annotation]; ok {
+				annotations[annotation] = value
+			}
+		}
 	}
 
 	// Construct the basic Machine.
@@ -0,0 +1,117 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Scale down without surge", func() {
+	var (
+		cluster  *clusterv1.Cluster
+		rcp      *controlplanev1.RKE2ControlPlane
+		machines []*clusterv1.Machine
+		workload *fakeWorkloadCluster
+		m        *fakeManagementCluster
+		r        *RKE2ControlPlaneReconciler
+		recorder *record.FakeRecorder
+	)
+
+	BeforeEach(func() {
+		cluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "scale"}}
+		rcp = &controlplanev1.RKE2ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "scale"},
+			Spec: controlplanev1.RKE2ControlPlaneSpec{
+				Replicas: ptr.To[int32](3),
+				RolloutStrategy: &controlplanev1.RolloutStrategy{
+					Type: controlplanev1.RollingUpdateStrategyType,
+					RollingUpdate: &controlplanev1.RollingUpdate{
+						MaxSurge: ptr.To(intstr.FromInt32(0)),
+					},
+				},
+			},
+		}
+
+		machines = nil
+		objects := []client.Object{}
+		for i := range 3 {
+			machine := newTestMachine(fmt.Sprintf("machine-%d", i), "scale", time.Now().Add(time.Duration(i)*time.Minute))
+			conditions.MarkTrue(machine, controlplanev1.MachineAgentHealthyCondition)
+			conditions.MarkTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition)
+			machines = append(machines, machine)
+			objects = append(objects, machine)
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).WithStatusSubresource(objects...).Build()
+		workload = &fakeWorkloadCluster{etcdMembers: []string{"machine-0", "machine-1", "machine-2"}}
+		m = &fakeManagementCluster{Client: c, workload: workload}
+		recorder = record.NewFakeRecorder(32)
+		r = &RKE2ControlPlaneReconciler{Client: c, recorder: recorder}
+	})
+
+	scaleDown := func(outdated string) (ctrl.Result, error) {
+		controlPlane := newTestControlPlane(m, cluster, rcp)
+
+		return r.scaleDownControlPlane(ctx, cluster, rcp, controlPlane, collections.FromMachines(controlPlane.Machines[outdated]))
+	}
+
+	It("should not delete the outdated machine when the remaining etcd members cannot keep quorum", func() {
+		// The members without a machine are considered unhealthy, the machines passing the preflight checks.
+		workload.etcdMembers = append(workload.etcdMembers, "stale-0", "stale-1")
+
+		result, err := scaleDown("machine-0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{RequeueAfter: preflightFailedRequeueAfter}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("ScaleDownBlocked")))
+		Expect(workload.forwardedLeadership).To(BeEmpty())
+		Expect(m.Get(ctx, client.ObjectKeyFromObject(machines[0]), &clusterv1.Machine{})).To(Succeed())
+	})
+
+	It("should move etcd leadership away from the outdated machine before deleting it", func() {
+		result, err := scaleDown("machine-0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{Requeue: true}))
+		Expect(workload.forwardedLeadership).To(Equal([]string{"machine-0", "machine-2"}))
+
+		err = m.Get(ctx, client.ObjectKeyFromObject(machines[0]), &clusterv1.Machine{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should not move etcd leadership to the outdated machine when it is the newest one", func() {
+		result, err := scaleDown("machine-2")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(ctrl.Result{Requeue: true}))
+		Expect(workload.forwardedLeadership).To(Equal([]string{"machine-2", "machine-1"}))
+	})
+})
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
+)
+
+// nextSecretsEncryptionRotationStage is the stage following each stage of a secrets encryption keys rotation,
+// the rotation has completed after the last one.
+var nextSecretsEncryptionRotationStage = map[controlplanev1.SecretsEncryptionRotationStage]controlplanev1.SecretsEncryptionRotationStage{
+	controlplanev1.SecretsEncryptionRotationStagePreparing: controlplanev1.SecretsEncryptionRotationStageRotating,
+	controlplanev1.SecretsEncryptionRotationStageRotating:  controlplanev1.SecretsEncryptionRotationStageReencrypting,
+}
+
+// reconcileSecretsEncryptionRotation rotates the secrets encryption keys requested in the RKE2ControlPlane spec, if any.
+// Each stage is run with `rke2 secrets-encrypt` on the Node of the oldest Machine, which is then restarted, before
+// RKE2 is restarted on the other control plane Nodes, one at a time.
+func (r *RKE2ControlPlaneReconciler) reconcileSecretsEncryptionRotation(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcileSecretsEncryptionRotation")
+	defer tracing.End(span, &reterr)
+
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+	rotation := rcp.Spec.SecretsEncryptionRotation
+
+	if rotation == nil {
+		return ctrl.Result{}, nil
+	}
+
+	if rcp.Status.SecretsEncryptionRotation == nil {
+		rcp.Status.SecretsEncryptionRotation = &controlplanev1.SecretsEncryptionRotationStatus{}
+	}
+
+	status := rcp.Status.SecretsEncryptionRotation
+
+	// The encryption keys of a cluster being initialized are brand new, there is nothing to rotate.
+	if !rcp.Status.Initialized {
+		status.Keys = rotation.Keys
+
+		return ctrl.Result{}, nil
+	}
+
+	if rotation.Keys == "" || rotation.Keys == status.Keys {
+		if conditions.Has(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition) {
+			conditions.MarkTrue(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition)
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if encryption := rcp.Spec.ServerConfig.SecretsEncryption; encryption != nil &&
+		encryption.Provider == controlplanev1.SecretsEncryptionProviderKMS {
+		conditions.MarkFalse(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition,
+			controlplanev1.SecretsEncryptionKeysRotationFailedReason, clusterv1.ConditionSeverityError,
+			"Rotating the secrets encryption keys is not supported with the kms provider")
+
+		return ctrl.Result{}, nil
+	}
+
+	// Machines joining the cluster during a rotation could miss a stage, wait for any rollout to complete.
+	if status.Stage == "" && controlPlane.MachinesNeedingRollout(ctx).Len() > 0 {
+		return ctrl.Result{}, nil
+	}
+
+	if status.Stage == "" {
+		logger.Info("Starting secrets encryption keys rotation")
+		r.recorder.Eventf(rcp, corev1.EventTypeNormal, "SecretsEncryptionKeysRotationStarted",
+			"Rotating the secrets encryption keys of the cluster")
+
+		status.Stage = controlplanev1.SecretsEncryptionRotationStagePreparing
+	}
+
+	conditions.MarkFalse(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition,
+		controlplanev1.SecretsEncryptionKeysRotationInProgressReason, clusterv1.ConditionSeverityInfo,
+		"Rotating the secrets encryption keys (%s)", status.Stage)
+
+	stageValue := secretsEncryptionRotationStageValue(rotation.Keys, status.Stage)
+	machines := controlPlane.Machines.Filter(collections.ActiveMachines, collections.HasNode())
+	pendingMachines := machines.Filter(func(machine *clusterv1.Machine) bool {
+		return machine.Annotations[controlplanev1.SecretsEncryptionRotationAnnotation] != stageValue
+	})
+
+	if pendingMachines.Len() == 0 {
+		next, ok := nextSecretsEncryptionRotationStage[status.Stage]
+		if ok {
+			status.Stage = next
+
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		status.Keys = rotation.Keys
+		status.Stage = ""
+		status.LastRotationTime = ptr.To(metav1.Now())
+
+		conditions.MarkTrue(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition)
+		r.recorder.Eventf(rcp, corev1.EventTypeNormal, "SecretsEncryptionKeysRotated",
+			"Rotated the secrets encryption keys of the cluster")
+
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	// The stage is run on the oldest Machine first, the other Machines only restart RKE2 to load the new
+	// encryption configuration once it has been run.
+	machine := pendingMachines.Oldest()
+	runStage := pendingMachines.Len() == machines.Len()
+
+	if !runStage {
+		if result := r.preflightChecks(ctx, controlPlane, machine); !result.IsZero() {
+			return result, nil
+		}
+	}
+
+	workloadCluster, err := controlPlane.GetWorkloadCluster(ctx)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("getting workload cluster: %w", err)
+	}
+
+	jobName, err := rotationJobName("rke2-secrets-encryption", rotation.Keys, string(status.Stage), machine.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var done bool
+	if runStage {
+		done, err = workloadCluster.RunSecretsEncryptionStage(ctx, machine, jobName, rotation.Image, status.Stage)
+	} else {
+		done, err = workloadCluster.RestartServer(ctx, machine, jobName, rotation.Image)
+	}
+
+	if errors.Is(err, rke2.ErrSecretsEncryptionRotationFailed) {
+		conditions.MarkFalse(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition,
+			controlplanev1.SecretsEncryptionKeysRotationFailedReason, clusterv1.ConditionSeverityError,
+			"Failed to rotate the secrets encryption keys (%s) on machine %s, set a new value to retry: %s", status.Stage, machine.Name, err.Error())
+		r.recorder.Eventf(rcp, corev1.EventTypeWarning, "SecretsEncryptionKeysRotationFailed",
+			"Failed to rotate the secrets encryption keys (%s) on machine %s: %v", status.Stage, machine.Name, err)
+
+		return ctrl.Result{RequeueAfter: preflightFailedRequeueAfter}, nil
+	}
+
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("rotating secrets encryption keys on machine %s: %w", machine.Name, err)
+	}
+
+	if !done {
+		logger.Info("Waiting for the secrets encryption keys rotation", "machine", machine.Name, "stage", status.Stage)
+
+		return ctrl.Result{RequeueAfter: secretsEncryptionRotationRequeueAfter}, nil
+	}
+
+	if err := workloadCluster.CleanupSecretsEncryptionRotation(ctx, jobName); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.patchMachineAnnotations(ctx, machine,
+		map[string]string{controlplanev1.SecretsEncryptionRotationAnnotation: stageValue}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Rotated secrets encryption keys", "machine", machine.Name, "stage", status.Stage)
+
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// secretsEncryptionRotationStageValue returns the value of the annotation of the Machines whose Node has gone through
+// the given stage of a secrets encryption keys rotation.
+func secretsEncryptionRotationStageValue(keys string, stage controlplanev1.SecretsEncryptionRotationStage) string {
+	return fmt.Sprintf("%s/%s", keys, stage)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Secrets encryption keys rotation", func() {
+	var (
+		cluster  *clusterv1.Cluster
+		rcp      *controlplanev1.RKE2ControlPlane
+		workload *fakeWorkloadCluster
+		m        *fakeManagementCluster
+		r        *RKE2ControlPlaneReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "secrets-encryption"}}
+		rcp = &controlplanev1.RKE2ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "secrets-encryption"},
+			Spec: controlplanev1.RKE2ControlPlaneSpec{
+				Version:                   "v1.31.2+rke2r1",
+				SecretsEncryptionRotation: &controlplanev1.SecretsEncryptionRotation{Keys: "1"},
+			},
+			Status: controlplanev1.RKE2ControlPlaneStatus{Initialized: true},
+		}
+
+		objects := []client.Object{}
+		for i := range 2 {
+			machine := newTestMachine(fmt.Sprintf("machine-%d", i), "secrets-encryption", time.Now().Add(time.Duration(i)*time.Minute))
+			machine.Spec.Version = ptr.To("v1.31.2+rke2r1")
+			conditions.MarkTrue(machine, controlplanev1.MachineAgentHealthyCondition)
+			conditions.MarkTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition)
+			objects = append(objects, machine)
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).WithStatusSubresource(objects...).Build()
+		workload = &fakeWorkloadCluster{}
+		m = &fakeManagementCluster{Client: c, workload: workload}
+		r = &RKE2ControlPlaneReconciler{Client: c, recorder: record.NewFakeRecorder(32)}
+	})
+
+	reconcile := func() (ctrl.Result, error) {
+		return r.reconcileSecretsEncryptionRotation(ctx, newTestControlPlane(m, cluster, rcp))
+	}
+
+	// complete reconciles the rotation until it does not request to be requeued anymore.
+	complete := func() {
+		for range 20 {
+			result, err := reconcile()
+			Expect(err).ToNot(HaveOccurred())
+
+			if !result.Requeue {
+				return
+			}
+		}
+
+		Fail("the secrets encryption keys rotation did not complete")
+	}
+
+	annotation := func(name string) string {
+		machine := &clusterv1.Machine{}
+		Expect(m.Get(ctx, client.ObjectKey{Namespace: "secrets-encryption", Name: name}, machine)).To(Succeed())
+
+		return machine.Annotations[controlplanev1.SecretsEncryptionRotationAnnotation]
+	}
+
+	It("should run each stage on the oldest machine, then restart RKE2 on the other machines", func() {
+		result, err := reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(secretsEncryptionRotationRequeueAfter))
+		Expect(rcp.Status.SecretsEncryptionRotation.Stage).To(Equal(controlplanev1.SecretsEncryptionRotationStagePreparing))
+		Expect(conditions.GetReason(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition)).To(
+			Equal(controlplanev1.SecretsEncryptionKeysRotationInProgressReason))
+		Expect(workload.secretsEncryptionRuns).To(Equal([]string{"machine-0/Preparing"}))
+		Expect(annotation("machine-0")).To(BeEmpty())
+
+		workload.secretsEncryptionRuns = nil
+		workload.secretsEncryptionDone = true
+		complete()
+
+		Expect(workload.secretsEncryptionRuns).To(Equal([]string{
+			"machine-0/Preparing", "machine-1/restart",
+			"machine-0/Rotating", "machine-1/restart",
+			"machine-0/Reencrypting", "machine-1/restart",
+		}))
+		Expect(workload.cleanedUpJobNames).To(HaveLen(6))
+		Expect(annotation("machine-0")).To(Equal("1/Reencrypting"))
+		Expect(annotation("machine-1")).To(Equal("1/Reencrypting"))
+
+		Expect(rcp.Status.SecretsEncryptionRotation.Keys).To(Equal("1"))
+		Expect(rcp.Status.SecretsEncryptionRotation.Stage).To(BeEmpty())
+		Expect(rcp.Status.SecretsEncryptionRotation.LastRotationTime).ToNot(BeNil())
+		Expect(conditions.IsTrue(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition)).To(BeTrue())
+	})
+
+	It("should wait for the other machines to be healthy before restarting RKE2", func() {
+		workload.secretsEncryptionDone = true
+		result, err := reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Requeue).To(BeTrue())
+
+		// RKE2 has been restarted on the oldest machine, which is not healthy yet.
+		machine := &clusterv1.Machine{}
+		Expect(m.Get(ctx, client.ObjectKey{Namespace: "secrets-encryption", Name: "machine-0"}, machine)).To(Succeed())
+		conditions.MarkFalse(machine, controlplanev1.MachineAgentHealthyCondition, "Unhealthy", clusterv1.ConditionSeverityError, "")
+		Expect(m.Status().Update(ctx, machine)).To(Succeed())
+
+		result, err = reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(preflightFailedRequeueAfter))
+		Expect(workload.secretsEncryptionRuns).To(Equal([]string{"machine-0/Preparing"}))
+	})
+
+	It("should run the failed stage again on all the machines when a new value is set", func() {
+		rcp.Status.SecretsEncryptionRotation = &controlplanev1.SecretsEncryptionRotationStatus{
+			Stage: controlplanev1.SecretsEncryptionRotationStageRotating,
+		}
+
+		// The stage has been run on the oldest machine, restarting RKE2 on the other machine fails.
+		machine := &clusterv1.Machine{}
+		Expect(m.Get(ctx, client.ObjectKey{Namespace: "secrets-encryption", Name: "machine-0"}, machine)).To(Succeed())
+		machine.Annotations = map[string]string{controlplanev1.SecretsEncryptionRotationAnnotation: "1/Rotating"}
+		Expect(m.Update(ctx, machine)).To(Succeed())
+
+		workload.secretsEncryptionErr = fmt.Errorf("%w: job failed", rke2.ErrSecretsEncryptionRotationFailed)
+		result, err := reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(preflightFailedRequeueAfter))
+		Expect(workload.secretsEncryptionRuns).To(Equal([]string{"machine-1/restart"}))
+		Expect(workload.cleanedUpJobNames).To(BeEmpty())
+		Expect(conditions.GetReason(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition)).To(
+			Equal(controlplanev1.SecretsEncryptionKeysRotationFailedReason))
+		Expect(conditions.GetMessage(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition)).To(
+			ContainSubstring("set a new value to retry"))
+		Expect(rcp.Status.SecretsEncryptionRotation.Stage).To(Equal(controlplanev1.SecretsEncryptionRotationStageRotating))
+
+		// The status keeps the failed stage, which is run again from the oldest machine with the new value.
+		rcp.Spec.SecretsEncryptionRotation.Keys = "2"
+		workload.secretsEncryptionRuns = nil
+		workload.secretsEncryptionErr = nil
+		workload.secretsEncryptionDone = true
+		complete()
+
+		Expect(workload.secretsEncryptionRuns).To(Equal([]string{
+			"machine-0/Rotating", "machine-1/restart",
+			"machine-0/Reencrypting", "machine-1/restart",
+		}))
+		Expect(annotation("machine-0")).To(Equal("2/Reencrypting"))
+		Expect(rcp.Status.SecretsEncryptionRotation.Keys).To(Equal("2"))
+		Expect(conditions.IsTrue(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition)).To(BeTrue())
+	})
+
+	It("should not rotate the keys with the kms provider", func() {
+		rcp.Spec.ServerConfig.SecretsEncryption = &controlplanev1.SecretsEncryption{
+			Provider: controlplanev1.SecretsEncryptionProviderKMS,
+		}
+
+		result, err := reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(conditions.GetReason(rcp, controlplanev1.SecretsEncryptionKeysRotatedCondition)).To(
+			Equal(controlplanev1.SecretsEncryptionKeysRotationFailedReason))
+		Expect(rcp.Status.SecretsEncryptionRotation.Stage).To(BeEmpty())
+		Expect(workload.secretsEncryptionRuns).To(BeEmpty())
+	})
+
+	It("should record the keys of a cluster being initialized without rotating them", func() {
+		rcp.Status.Initialized = false
+
+		result, err := reconcile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(rcp.Status.SecretsEncryptionRotation.Keys).To(Equal("1"))
+		Expect(workload.secretsEncryptionRuns).To(BeEmpty())
+	})
+})
@@ -0,0 +1,167 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
+)
+
+// maxPreUpgradeSnapshotAttempts is the number of etcd snapshots taken before an upgrade, failed snapshots being
+// taken again until then.
+const maxPreUpgradeSnapshotAttempts = 3
+
+// maxPreUpgradeSnapshotNameLength bounds the name of the RKE2EtcdSnapshot taken before an upgrade, which is also
+// the name of the snapshot RKE2 suffixes with the node name and a timestamp.
+const maxPreUpgradeSnapshotNameLength = 63
+
+// reconcilePreUpgradeSnapshot takes an etcd snapshot before rolling out a new version of the control plane,
+// if enabled in the rollout strategy. The rollout is blocked until the snapshot has been taken.
+func (r *RKE2ControlPlaneReconciler) reconcilePreUpgradeSnapshot(
+	ctx context.Context,
+	controlPlane *rke2.ControlPlane,
+) (res ctrl.Result, reterr error) {
+	ctx, span := tracing.Start(ctx, "RKE2ControlPlane.reconcilePreUpgradeSnapshot")
+	defer tracing.End(span, &reterr)
+
+	logger := controlPlane.Logger()
+	rcp := controlPlane.RCP
+
+	if rcp.Spec.RolloutStrategy == nil || !rcp.Spec.RolloutStrategy.PreUpgradeSnapshot || !controlPlane.UsesEmbeddedEtcd() {
+		return ctrl.Result{}, nil
+	}
+
+	// Only version upgrades require a snapshot, other configuration changes are rolled out right away.
+	if controlPlane.MachinesWithOutdatedVersion(ctx).Len() == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	version := rcp.GetDesiredVersion()
+
+	if rcp.Status.PreUpgradeSnapshot == nil || rcp.Status.PreUpgradeSnapshot.Version != version {
+		rcp.Status.PreUpgradeSnapshot = &controlplanev1.PreUpgradeSnapshotStatus{Version: version}
+	}
+
+	status := rcp.Status.PreUpgradeSnapshot
+	if status.SnapshotName != "" {
+		return ctrl.Result{}, nil
+	}
+
+	snapshot := &controlplanev1.RKE2EtcdSnapshot{}
+	snapshotKey := client.ObjectKey{Namespace: rcp.Namespace, Name: preUpgradeSnapshotName(rcp.Name, version)}
+
+	if err := r.Get(ctx, snapshotKey, snapshot); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("getting pre-upgrade etcd snapshot: %w", err)
+		}
+
+		snapshot = &controlplanev1.RKE2EtcdSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      snapshotKey.Name,
+				Namespace: snapshotKey.Namespace,
+				Labels: map[string]string{
+					clusterv1.ClusterNameLabel: controlPlane.Cluster.Name,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(rcp, controlplanev1.GroupVersion.WithKind(rke2ControlPlaneKind)),
+				},
+			},
+			Spec: controlplanev1.RKE2EtcdSnapshotSpec{
+				ClusterName: controlPlane.Cluster.Name,
+			},
+		}
+
+		logger.Info("Taking etcd snapshot before upgrading the control plane", "version", version, "snapshot", snapshot.Name)
+
+		if err := r.Create(ctx, snapshot); err != nil {
+			return ctrl.Result{}, fmt.Errorf("creating pre-upgrade etcd snapshot: %w", err)
+		}
+
+		status.Attempts++
+
+		r.recorder.Eventf(rcp, corev1.EventTypeNormal, "PreUpgradeSnapshotStarted",
+			"Taking etcd snapshot %s before upgrading to version %s", snapshot.Name, version)
+	}
+
+	status.Name = snapshot.Name
+
+	switch snapshot.Status.Phase {
+	case controlplanev1.EtcdSnapshotPhaseSucceeded:
+		status.SnapshotName = snapshot.Status.SnapshotName
+		conditions.MarkTrue(rcp, controlplanev1.PreUpgradeSnapshotTakenCondition)
+
+		r.recorder.Eventf(rcp, corev1.EventTypeNormal, "PreUpgradeSnapshotTaken",
+			"Took etcd snapshot %s before upgrading to version %s", snapshot.Status.SnapshotName, version)
+
+		return ctrl.Result{}, nil
+	case controlplanev1.EtcdSnapshotPhaseFailed:
+		// The failed snapshot is deleted so that a new one is taken, its Job being kept in the workload cluster.
+		if status.Attempts < maxPreUpgradeSnapshotAttempts {
+			logger.Info("Etcd snapshot failed, taking a new one", "snapshot", snapshot.Name, "attempts", status.Attempts)
+
+			if err := r.Delete(ctx, snapshot); err != nil && !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, fmt.Errorf("deleting failed pre-upgrade etcd snapshot: %w", err)
+			}
+
+			conditions.MarkFalse(rcp, controlplanev1.PreUpgradeSnapshotTakenCondition, controlplanev1.PreUpgradeSnapshotInProgressReason,
+				clusterv1.ConditionSeverityInfo, "Etcd snapshot %s failed, retrying: %s", snapshot.Name, snapshot.Status.FailureMessage)
+
+			return ctrl.Result{RequeueAfter: etcdSnapshotRequeueAfter}, nil
+		}
+
+		conditions.MarkFalse(rcp, controlplanev1.PreUpgradeSnapshotTakenCondition, controlplanev1.PreUpgradeSnapshotFailedReason,
+			clusterv1.ConditionSeverityError, "Etcd snapshot %s failed %d times, delete it to retry: %s",
+			snapshot.Name, status.Attempts, snapshot.Status.FailureMessage)
+
+		return ctrl.Result{RequeueAfter: preflightFailedRequeueAfter}, nil
+	default:
+		conditions.MarkFalse(rcp, controlplanev1.PreUpgradeSnapshotTakenCondition, controlplanev1.PreUpgradeSnapshotInProgressReason,
+			clusterv1.ConditionSeverityInfo, "Waiting for etcd snapshot %s before upgrading to version %s", snapshot.Name, version)
+
+		return ctrl.Result{RequeueAfter: etcdSnapshotRequeueAfter}, nil
+	}
+}
+
+// preUpgradeSnapshotName returns the name of the RKE2EtcdSnapshot taken before upgrading to the given version.
+// A name longer than maxPreUpgradeSnapshotNameLength is truncated and suffixed with a hash of the full name.
+func preUpgradeSnapshotName(rcpName, version string) string {
+	name := strings.ToLower(strings.NewReplacer("+", "-", ".", "-").Replace(fmt.Sprintf("%s-pre-upgrade-%s", rcpName, version)))
+	if len(name) <= maxPreUpgradeSnapshotNameLength {
+		return name
+	}
+
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(name))
+	suffix := fmt.Sprintf("-%08x", hasher.Sum32())
+
+	return strings.TrimRight(name[:maxPreUpgradeSnapshotNameLength-len(suffix)], "-") + suffix
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/rke2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Pre-upgrade snapshot", func() {
+	var (
+		cluster *clusterv1.Cluster
+		rcp     *controlplanev1.RKE2ControlPlane
+		machine *clusterv1.Machine
+		m       *fakeManagementCluster
+		r       *RKE2ControlPlaneReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "upgrade"}}
+		rcp = &controlplanev1.RKE2ControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "upgrade", UID: "rcp-uid"},
+			Spec: controlplanev1.RKE2ControlPlaneSpec{
+				Version: "v1.31.2+rke2r1",
+				RolloutStrategy: &controlplanev1.RolloutStrategy{
+					Type:               controlplanev1.RollingUpdateStrategyType,
+					PreUpgradeSnapshot: true,
+				},
+			},
+			Status: controlplanev1.RKE2ControlPlaneStatus{Initialized: true},
+		}
+
+		machine = newTestMachine("machine", "upgrade", time.Now())
+		machine.Spec.Version = ptr.To("v1.30.6+rke2r1")
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(machine).
+			WithStatusSubresource(&controlplanev1.RKE2EtcdSnapshot{}).
+			Build()
+		m = &fakeManagementCluster{Client: c, workload: &fakeWorkloadCluster{}}
+		r = &RKE2ControlPlaneReconciler{Client: c, recorder: record.NewFakeRecorder(32)}
+	})
+
+	upgrade := func() (*rke2.ControlPlane, error) {
+		controlPlane := newTestControlPlane(m, cluster, rcp)
+
+		result, err := r.upgradeControlPlane(ctx, cluster, rcp, controlPlane, controlPlane.Machines)
+		Expect(result.RequeueAfter).ToNot(BeZero())
+
+		return controlPlane, err
+	}
+
+	getSnapshot := func(version string) *controlplanev1.RKE2EtcdSnapshot {
+		snapshot := &controlplanev1.RKE2EtcdSnapshot{}
+		Expect(m.Get(ctx, client.ObjectKey{Namespace: "upgrade", Name: preUpgradeSnapshotName("test", version)}, snapshot)).To(Succeed())
+
+		return snapshot
+	}
+
+	setSnapshotPhase := func(snapshot *controlplanev1.RKE2EtcdSnapshot, phase controlplanev1.EtcdSnapshotPhase) {
+		snapshot.Status.Phase = phase
+		snapshot.Status.SnapshotName = snapshot.Name + "-machine-1700000000"
+		Expect(m.Status().Update(ctx, snapshot)).To(Succeed())
+	}
+
+	expectMachinesUnchanged := func() {
+		machines := &clusterv1.MachineList{}
+		Expect(m.List(ctx, machines)).To(Succeed())
+		Expect(machines.Items).To(HaveLen(1))
+		Expect(machines.Items[0].DeletionTimestamp).To(BeNil())
+	}
+
+	It("should name the snapshot after the version", func() {
+		Expect(preUpgradeSnapshotName("test", "v1.31.2+rke2r1")).To(Equal("test-pre-upgrade-v1-31-2-rke2r1"))
+		Expect(preUpgradeSnapshotName("Test", "v1.32.0+rke2r1")).To(Equal("test-pre-upgrade-v1-32-0-rke2r1"))
+	})
+
+	It("should bound the name of the snapshot of a control plane with a long name", func() {
+		longName := strings.Repeat("control-plane-", 4) + "test"
+
+		name := preUpgradeSnapshotName(longName, "v1.31.2+rke2r1")
+		Expect(name).To(HaveLen(maxPreUpgradeSnapshotNameLength))
+		Expect(name).To(HavePrefix(longName[:40]))
+		Expect(validation.IsDNS1123Label(name)).To(BeEmpty())
+		Expect(name).To(Equal(preUpgradeSnapshotName(longName, "v1.31.2+rke2r1")))
+		Expect(name).ToNot(Equal(preUpgradeSnapshotName(longName, "v1.32.0+rke2r1")))
+
+		rcp.Name = longName
+		_, err := upgrade()
+		Expect(err).ToNot(HaveOccurred())
+		expectMachinesUnchanged()
+
+		snapshot := &controlplanev1.RKE2EtcdSnapshot{}
+		Expect(m.Get(ctx, client.ObjectKey{Namespace: "upgrade", Name: name}, snapshot)).To(Succeed())
+		Expect(rcp.Status.PreUpgradeSnapshot.Name).To(Equal(name))
+	})
+
+	It("should block the upgrade until the snapshot is taken", func() {
+		_, err := upgrade()
+		Expect(err).ToNot(HaveOccurred())
+		expectMachinesUnchanged()
+
+		snapshot := getSnapshot("v1.31.2+rke2r1")
+		Expect(snapshot.Spec.ClusterName).To(Equal("test"))
+		Expect(metav1.IsControlledBy(snapshot, rcp)).To(BeTrue())
+		Expect(rcp.Status.PreUpgradeSnapshot).To(Equal(&controlplanev1.PreUpgradeSnapshotStatus{
+			Version: "v1.31.2+rke2r1", Name: snapshot.Name, Attempts: 1,
+		}))
+		Expect(conditions.GetReason(rcp, controlplanev1.PreUpgradeSnapshotTakenCondition)).
+			To(Equal(controlplanev1.PreUpgradeSnapshotInProgressReason))
+
+		setSnapshotPhase(snapshot, controlplanev1.EtcdSnapshotPhaseRunning)
+		_, err = upgrade()
+		Expect(err).ToNot(HaveOccurred())
+		expectMachinesUnchanged()
+
+		setSnapshotPhase(snapshot, controlplanev1.EtcdSnapshotPhaseSucceeded)
+		controlPlane := newTestControlPlane(m, cluster, rcp)
+		result, err := r.reconcilePreUpgradeSnapshot(ctx, controlPlane)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(rcp.Status.PreUpgradeSnapshot.SnapshotName).To(Equal(snapshot.Status.SnapshotName))
+		Expect(conditions.IsTrue(rcp, controlplanev1.PreUpgradeSnapshotTakenCondition)).To(BeTrue())
+
+		// A new version requires a new snapshot.
+		rcp.Spec.Version = "v1.32.0+rke2r1"
+		_, err = upgrade()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getSnapshot("v1.32.0+rke2r1").Name).To(Equal("test-pre-upgrade-v1-32-0-rke2r1"))
+		Expect(rcp.Status.PreUpgradeSnapshot.Version).To(Equal("v1.32.0+rke2r1"))
+		Expect(rcp.Status.PreUpgradeSnapshot.SnapshotName).To(BeEmpty())
+	})
+
+	It("should retry a failed snapshot, then keep blocking the upgrade", func() {
+		for attempt := int32(1); attempt <= maxPreUpgradeSnapshotAttempts; attempt++ {
+			_, err := upgrade()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rcp.Status.PreUpgradeSnapshot.Attempts).To(Equal(attempt))
+
+			setSnapshotPhase(getSnapshot("v1.31.2+rke2r1"), controlplanev1.EtcdSnapshotPhaseFailed)
+			_, err = upgrade()
+			Expect(err).ToNot(HaveOccurred())
+			expectMachinesUnchanged()
+
+			if attempt < maxPreUpgradeSnapshotAttempts {
+				snapshots := &controlplanev1.RKE2EtcdSnapshotList{}
+				Expect(m.List(ctx, snapshots)).To(Succeed())
+				Expect(snapshots.Items).To(BeEmpty())
+			}
+		}
+
+		Expect(conditions.GetReason(rcp, controlplanev1.PreUpgradeSnapshotTakenCondition)).
+			To(Equal(controlplanev1.PreUpgradeSnapshotFailedReason))
+
+		_, err := upgrade()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getSnapshot("v1.31.2+rke2r1").Status.Phase).To(Equal(controlplanev1.EtcdSnapshotPhaseFailed))
+		Expect(rcp.Status.PreUpgradeSnapshot.Attempts).To(Equal(int32(maxPreUpgradeSnapshotAttempts)))
+		expectMachinesUnchanged()
+	})
+
+	It("should not take a snapshot when only the configuration changed", func() {
+		machine.Spec.Version = ptr.To(rcp.Spec.Version)
+		Expect(m.Update(ctx, machine)).To(Succeed())
+
+		result, err := r.reconcilePreUpgradeSnapshot(ctx, newTestControlPlane(m, cluster, rcp))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.IsZero()).To(BeTrue())
+		Expect(rcp.Status.PreUpgradeSnapshot).To(BeNil())
+	})
+})
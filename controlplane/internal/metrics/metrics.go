@@ -0,0 +1,208 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics implements the Prometheus metrics of the RKE2 control plane controller.
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+var controlPlaneLabels = []string{"namespace", "name", "cluster"}
+
+var (
+	desiredReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_desired_replicas",
+		Help: "Number of desired replicas of the RKE2ControlPlane.",
+	}, controlPlaneLabels)
+
+	replicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_replicas",
+		Help: "Number of replicas of the RKE2ControlPlane.",
+	}, controlPlaneLabels)
+
+	readyReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_ready_replicas",
+		Help: "Number of ready replicas of the RKE2ControlPlane.",
+	}, controlPlaneLabels)
+
+	updatedReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_updated_replicas",
+		Help: "Number of replicas of the RKE2ControlPlane with an up-to-date spec.",
+	}, controlPlaneLabels)
+
+	unavailableReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_unavailable_replicas",
+		Help: "Number of unavailable replicas of the RKE2ControlPlane.",
+	}, controlPlaneLabels)
+
+	rolloutOutdatedMachines = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_rollout_outdated_machines",
+		Help: "Number of machines of the RKE2ControlPlane waiting to be rolled out.",
+	}, controlPlaneLabels)
+
+	rolloutDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "caprke2_controlplane_rollout_duration_seconds",
+		Help:    "Duration of the completed rollouts of the RKE2ControlPlane.",
+		Buckets: prometheus.ExponentialBuckets(60, 2, 10),
+	}, controlPlaneLabels)
+
+	remediations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "caprke2_controlplane_remediations_total",
+		Help: "Total number of machines of the RKE2ControlPlane deleted for remediation.",
+	}, controlPlaneLabels)
+
+	lastRemediationRetryCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_last_remediation_retry_count",
+		Help: "Number of retries of the last remediation of the RKE2ControlPlane.",
+	}, controlPlaneLabels)
+
+	etcdMembers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_etcd_members",
+		Help: "Number of etcd members of the RKE2ControlPlane machines.",
+	}, controlPlaneLabels)
+
+	etcdHealthyMembers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_etcd_healthy_members",
+		Help: "Number of healthy etcd members of the RKE2ControlPlane machines.",
+	}, controlPlaneLabels)
+
+	etcdMemberDBSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_etcd_member_db_size_bytes",
+		Help: "Size of the database of the etcd member of a RKE2ControlPlane machine.",
+	}, append(controlPlaneLabels, "machine"))
+
+	etcdMemberDBSizeInUse = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "caprke2_controlplane_etcd_member_db_size_in_use_bytes",
+		Help: "Size of the database in use of the etcd member of a RKE2ControlPlane machine.",
+	}, append(controlPlaneLabels, "machine"))
+)
+
+// vec is a metric vector labeled with a RKE2ControlPlane.
+type vec interface {
+	prometheus.Collector
+	DeletePartialMatch(labels prometheus.Labels) int
+}
+
+var vecs = []vec{
+	desiredReplicas,
+	replicas,
+	readyReplicas,
+	updatedReplicas,
+	unavailableReplicas,
+	rolloutOutdatedMachines,
+	rolloutDuration,
+	remediations,
+	lastRemediationRetryCount,
+	etcdMembers,
+	etcdHealthyMembers,
+	etcdMemberDBSize,
+	etcdMemberDBSizeInUse,
+}
+
+func init() {
+	for _, v := range vecs {
+		ctrlmetrics.Registry.MustRegister(v)
+	}
+}
+
+// RecordStatus records the metrics reported in the status of a RKE2ControlPlane.
+func RecordStatus(rcp *controlplanev1.RKE2ControlPlane) {
+	labels := labelValues(rcp)
+
+	if rcp.Spec.Replicas != nil {
+		desiredReplicas.WithLabelValues(labels...).Set(float64(*rcp.Spec.Replicas))
+	}
+
+	replicas.WithLabelValues(labels...).Set(float64(rcp.Status.Replicas))
+	readyReplicas.WithLabelValues(labels...).Set(float64(rcp.Status.ReadyReplicas))
+	updatedReplicas.WithLabelValues(labels...).Set(float64(rcp.Status.UpdatedReplicas))
+	unavailableReplicas.WithLabelValues(labels...).Set(float64(rcp.Status.UnavailableReplicas))
+
+	if rcp.Status.LastRemediation != nil {
+		lastRemediationRetryCount.WithLabelValues(labels...).Set(float64(rcp.Status.LastRemediation.RetryCount))
+	}
+
+	// The machines of the members change, the series of the previous machines are dropped.
+	etcdMemberDBSize.DeletePartialMatch(partialLabels(rcp))
+	etcdMemberDBSizeInUse.DeletePartialMatch(partialLabels(rcp))
+
+	if rcp.Status.Etcd != nil {
+		for _, member := range rcp.Status.Etcd.Members {
+			etcdMemberDBSize.WithLabelValues(append(labels, member.MachineName)...).Set(float64(member.DBSize))
+			etcdMemberDBSizeInUse.WithLabelValues(append(labels, member.MachineName)...).Set(float64(member.DBSizeInUse))
+		}
+	}
+}
+
+// RecordRollout records the number of machines of a RKE2ControlPlane waiting to be rolled out.
+func RecordRollout(rcp *controlplanev1.RKE2ControlPlane, outdatedMachines int) {
+	rolloutOutdatedMachines.WithLabelValues(labelValues(rcp)...).Set(float64(outdatedMachines))
+}
+
+// ObserveRolloutDuration records the duration of a completed rollout of a RKE2ControlPlane.
+func ObserveRolloutDuration(rcp *controlplanev1.RKE2ControlPlane, duration time.Duration) {
+	rolloutDuration.WithLabelValues(labelValues(rcp)...).Observe(duration.Seconds())
+}
+
+// RecordRemediation records the deletion of a machine of a RKE2ControlPlane for remediation.
+func RecordRemediation(rcp *controlplanev1.RKE2ControlPlane) {
+	remediations.WithLabelValues(labelValues(rcp)...).Inc()
+}
+
+// RecordEtcdMembers records the number of etcd members reported in the status of a RKE2ControlPlane, and how many
+// of them are healthy.
+func RecordEtcdMembers(rcp *controlplanev1.RKE2ControlPlane, healthy int) {
+	members := 0
+	if rcp.Status.Etcd != nil {
+		members = len(rcp.Status.Etcd.Members)
+	}
+
+	etcdMembers.WithLabelValues(labelValues(rcp)...).Set(float64(members))
+	etcdHealthyMembers.WithLabelValues(labelValues(rcp)...).Set(float64(healthy))
+}
+
+// Delete drops the metrics of a deleted RKE2ControlPlane.
+func Delete(namespace, name string) {
+	for _, v := range vecs {
+		v.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+	}
+}
+
+// labelValues returns the namespace and name of a RKE2ControlPlane, and the name of the Cluster owning it.
+func labelValues(rcp *controlplanev1.RKE2ControlPlane) []string {
+	cluster := rcp.Labels[clusterv1.ClusterNameLabel]
+
+	for _, ref := range rcp.OwnerReferences {
+		if ref.Kind == "Cluster" && strings.HasPrefix(ref.APIVersion, clusterv1.GroupVersion.Group+"/") {
+			cluster = ref.Name
+		}
+	}
+
+	return []string{rcp.Namespace, rcp.Name, cluster}
+}
+
+func partialLabels(rcp *controlplanev1.RKE2ControlPlane) prometheus.Labels {
+	return prometheus.Labels{"namespace": rcp.Namespace, "name": rcp.Name}
+}
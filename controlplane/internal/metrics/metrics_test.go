@@ -0,0 +1,78 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+func TestRecordStatus(t *testing.T) {
+	g := NewWithT(t)
+
+	rcp := &controlplanev1.RKE2ControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "rcp",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: clusterv1.GroupVersion.String(),
+				Kind:       "Cluster",
+				Name:       "cluster",
+			}},
+		},
+		Spec: controlplanev1.RKE2ControlPlaneSpec{Replicas: ptr.To[int32](3)},
+		Status: controlplanev1.RKE2ControlPlaneStatus{
+			Replicas:      3,
+			ReadyReplicas: 2,
+			Etcd: &controlplanev1.EtcdStatus{Members: []controlplanev1.EtcdMemberStatus{
+				{MachineName: "machine-1", DBSize: 2048, DBSizeInUse: 1024},
+				{MachineName: "machine-2", DBSize: 4096, DBSizeInUse: 1024},
+			}},
+		},
+	}
+
+	RecordStatus(rcp)
+	RecordRemediation(rcp)
+	RecordEtcdMembers(rcp, 1)
+
+	g.Expect(testutil.ToFloat64(desiredReplicas.WithLabelValues("default", "rcp", "cluster"))).To(Equal(3.0))
+	g.Expect(testutil.ToFloat64(readyReplicas.WithLabelValues("default", "rcp", "cluster"))).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(remediations.WithLabelValues("default", "rcp", "cluster"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(etcdMembers.WithLabelValues("default", "rcp", "cluster"))).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(etcdHealthyMembers.WithLabelValues("default", "rcp", "cluster"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(etcdMemberDBSize.WithLabelValues("default", "rcp", "cluster", "machine-2"))).
+		To(Equal(4096.0))
+
+	// The series of the machines which are not members anymore are dropped.
+	rcp.Status.Etcd.Members = rcp.Status.Etcd.Members[:1]
+	RecordStatus(rcp)
+	g.Expect(testutil.CollectAndCount(etcdMemberDBSize)).To(Equal(1))
+
+	Delete("default", "rcp")
+
+	for _, v := range vecs {
+		g.Expect(testutil.CollectAndCount(v)).To(BeZero())
+	}
+}
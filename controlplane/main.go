@@ -28,7 +28,9 @@ import (
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	"github.com/spf13/pflag"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -36,9 +38,12 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/clustercache"
+	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util/flags"
 
 	bootstrapv1alpha1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1alpha1"
@@ -47,6 +52,7 @@ import (
 	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/controlplane/internal/controllers"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/consts"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
 	"github.com/rancher/cluster-api-provider-rke2/version"
 )
 
@@ -70,6 +76,7 @@ var (
 	webhookPort                    int
 	webhookCertDir                 string
 	healthAddr                     string
+	tracingOptions                 = tracing.Options{}
 	managerOptions                 = flags.ManagerOptions{}
 )
 
@@ -131,6 +138,8 @@ func InitFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&healthAddr, "health-addr", ":9440",
 		"The address the health endpoint binds to.")
 
+	tracing.AddFlags(fs, &tracingOptions)
+
 	flags.AddManagerOptions(fs, &managerOptions)
 }
 
@@ -182,6 +191,8 @@ func main() {
 				DisableFor: []client.Object{
 					&corev1.ConfigMap{},
 					&corev1.Secret{},
+					&corev1.Service{},
+					&corev1.Endpoints{},
 				},
 			},
 		},
@@ -203,13 +214,25 @@ func main() {
 	// Setup the context that's going to be used in controllers and for the manager.
 	ctx := ctrl.SetupSignalHandler()
 
+	shutdownTracing, err := tracing.Setup(ctx, "rke2-control-plane-controller", tracingOptions)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	setupChecks(mgr)
 	setupWebhooks(mgr)
 	setupReconcilers(ctx, mgr)
 
 	setupLog.Info("Starting manager", "version", version.Get().String(), "concurrency", concurrencyNumber)
 
-	if err := mgr.Start(ctx); err != nil {
+	err = mgr.Start(ctx)
+
+	if tracingErr := shutdownTracing(); tracingErr != nil {
+		setupLog.Error(tracingErr, "unable to flush traces")
+	}
+
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -239,18 +262,59 @@ func setupReconcilers(ctx context.Context, mgr ctrl.Manager) {
 		os.Exit(1)
 	}
 
+	// Set up a clusterCache to provide to controllers
+	// requiring a connection to a remote cluster
+	clusterCache, err := clustercache.SetupWithManager(ctx, mgr, clustercache.Options{
+		SecretClient: secretCachingClient,
+		Cache: clustercache.CacheOptions{
+			Indexes: []clustercache.CacheOptionsIndex{clustercache.NodeProviderIDIndex},
+		},
+		Client: clustercache.ClientOptions{
+			QPS:       clusterCacheTrackerClientQPS,
+			Burst:     clusterCacheTrackerClientBurst,
+			UserAgent: remote.DefaultClusterAPIUserAgent("rke2-control-plane-controller"),
+			Cache: clustercache.ClientCacheOptions{
+				DisableFor: []client.Object{
+					// Don't cache ConfigMaps & Secrets.
+					&corev1.ConfigMap{},
+					&corev1.Secret{},
+					// Don't cache Pods & DaemonSets (we get/list them e.g. during drain).
+					&corev1.Pod{},
+					&appsv1.DaemonSet{},
+					// Don't cache PersistentVolumes and VolumeAttachments (we get/list them e.g. during wait for volumes to detach)
+					&storagev1.VolumeAttachment{},
+					&corev1.PersistentVolume{},
+				},
+			},
+		},
+	}, controller.Options{
+		MaxConcurrentReconciles: clusterCacheConcurrencyNumber,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to create cluster cache tracker")
+		os.Exit(1)
+	}
+
 	if err := (&controllers.RKE2ControlPlaneReconciler{
 		Client:              mgr.GetClient(),
 		Scheme:              mgr.GetScheme(),
 		WatchFilterValue:    watchFilterValue,
 		SecretCachingClient: secretCachingClient,
-	}).SetupWithManager(
-		ctx, mgr, clusterCacheTrackerClientQPS, clusterCacheTrackerClientBurst,
-		clusterCacheConcurrencyNumber, concurrencyNumber,
-	); err != nil {
+		ClusterCache:        clusterCache,
+	}).SetupWithManager(ctx, mgr, concurrencyNumber); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "RKE2ControlPlane")
 		os.Exit(1)
 	}
+
+	if err := (&controllers.RKE2EtcdSnapshotReconciler{
+		Client:              mgr.GetClient(),
+		WatchFilterValue:    watchFilterValue,
+		SecretCachingClient: secretCachingClient,
+		ClusterCache:        clusterCache,
+	}).SetupWithManager(ctx, mgr, concurrencyNumber); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RKE2EtcdSnapshot")
+		os.Exit(1)
+	}
 }
 
 func setupWebhooks(mgr ctrl.Manager) {
@@ -25,20 +25,28 @@ import (
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/rancher/cluster-api-provider-rke2/pkg/proxy"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
 )
 
+// endpointKey is the attribute of the endpoint of the etcd member of a span.
+const endpointKey = attribute.Key("etcd.endpoint")
+
 // GRPCDial is a function that creates a connection to a given endpoint.
 type GRPCDial func(ctx context.Context, addr string) (net.Conn, error)
 
 // etcd wraps the etcd client from etcd's clientv3 package.
 // This interface is implemented by both the clientv3 package and the backoff adapter that adds retries to the client.
 type etcd interface {
+	AlarmDisarm(ctx context.Context, m *clientv3.AlarmMember) (*clientv3.AlarmResponse, error)
 	AlarmList(ctx context.Context) (*clientv3.AlarmResponse, error)
 	Close() error
+	Defragment(ctx context.Context, endpoint string) (*clientv3.DefragmentResponse, error)
 	Endpoints() []string
 	MemberList(ctx context.Context) (*clientv3.MemberListResponse, error)
 	MemberRemove(ctx context.Context, id uint64) (*clientv3.MemberRemoveResponse, error)
@@ -83,6 +91,10 @@ const (
 // for read and write operations to etcd.
 const DefaultCallTimeout = 15 * time.Second
 
+// DefaultDefragmentTimeout represents the duration that the etcd client waits at most for the defragmentation
+// of a member, which blocks the member while its database is rewritten.
+const DefaultDefragmentTimeout = 5 * time.Minute
+
 // AlarmTypeName provides a text translation for AlarmType codes.
 var AlarmTypeName = map[AlarmType]string{
 	AlarmOK:      "NONE",
@@ -129,6 +141,33 @@ func pbMemberToMember(m *etcdserverpb.Member) *Member {
 	}
 }
 
+// MemberStatus represents the status of the etcd member a client is connected to.
+type MemberStatus struct {
+	// MemberID is the ID of the member.
+	MemberID uint64
+
+	// LeaderID is the ID of the leader of the cluster, as seen by the member.
+	LeaderID uint64
+
+	// DBSize is the size in bytes of the database of the member.
+	DBSize int64
+
+	// DBSizeInUse is the size in bytes of the database of the member actually in use.
+	DBSizeInUse int64
+
+	// RaftIndex is the raft index of the member.
+	RaftIndex uint64
+
+	// IsLearner is true when the member is a learner.
+	IsLearner bool
+
+	// Errors are the errors reported by the member, without the alarms of the cluster which etcd reports as errors.
+	Errors []string
+
+	// Alarms are the alarms raised by the member.
+	Alarms []AlarmType
+}
+
 // ClientConfiguration describes the configuration for an etcd client.
 type ClientConfiguration struct {
 	Endpoint    string
@@ -139,14 +178,18 @@ type ClientConfiguration struct {
 }
 
 // NewClient creates a new etcd client with the given configuration.
-func NewClient(ctx context.Context, config ClientConfiguration) (*Client, error) {
+func NewClient(ctx context.Context, config ClientConfiguration) (_ *Client, rerr error) {
+	ctx, span := tracing.Start(ctx, "etcd.NewClient", endpointKey.String(config.Endpoint))
+	defer tracing.End(span, &rerr)
+
 	dialer, err := proxy.NewDialer(config.Proxy)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create a dialer for etcd client")
 	}
 
-	// Use a specific context with a timeout for the etcd client
-	clientCtx, cancel := context.WithTimeout(context.Background(), config.DialTimeout)
+	// Use a specific context with a timeout for the etcd client, only carrying the span so that the proxied
+	// connections are traced.
+	clientCtx, cancel := context.WithTimeout(trace.ContextWithSpan(context.Background(), span), config.DialTimeout)
 	defer cancel()
 
 	c := clientv3.Config{
@@ -202,13 +245,21 @@ func newEtcdClient(ctx context.Context, etcdClient etcd, callTimeout time.Durati
 	}, nil
 }
 
+// startSpan starts the span of an operation on the etcd member the client is connected to.
+func (c *Client) startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "etcd."+operation, endpointKey.String(c.Endpoint))
+}
+
 // Close closes the etcd client.
 func (c *Client) Close() error {
 	return c.EtcdClient.Close()
 }
 
 // Members retrieves a list of etcd members.
-func (c *Client) Members(ctx context.Context) ([]*Member, error) {
+func (c *Client) Members(ctx context.Context) (_ []*Member, rerr error) {
+	ctx, span := c.startSpan(ctx, "MemberList")
+	defer tracing.End(span, &rerr)
+
 	ctx, cancel := context.WithTimeout(ctx, c.CallTimeout)
 	defer cancel()
 
@@ -242,7 +293,10 @@ func (c *Client) Members(ctx context.Context) ([]*Member, error) {
 }
 
 // MoveLeader moves the leader to the provided member ID.
-func (c *Client) MoveLeader(ctx context.Context, newLeaderID uint64) error {
+func (c *Client) MoveLeader(ctx context.Context, newLeaderID uint64) (rerr error) {
+	ctx, span := c.startSpan(ctx, "MoveLeader")
+	defer tracing.End(span, &rerr)
+
 	ctx, cancel := context.WithTimeout(ctx, c.CallTimeout)
 	defer cancel()
 
@@ -252,7 +306,10 @@ func (c *Client) MoveLeader(ctx context.Context, newLeaderID uint64) error {
 }
 
 // RemoveMember removes a given member.
-func (c *Client) RemoveMember(ctx context.Context, id uint64) error {
+func (c *Client) RemoveMember(ctx context.Context, id uint64) (rerr error) {
+	ctx, span := c.startSpan(ctx, "MemberRemove")
+	defer tracing.End(span, &rerr)
+
 	ctx, cancel := context.WithTimeout(ctx, c.CallTimeout)
 	defer cancel()
 
@@ -262,7 +319,10 @@ func (c *Client) RemoveMember(ctx context.Context, id uint64) error {
 }
 
 // UpdateMemberPeerURLs updates the list of peer URLs.
-func (c *Client) UpdateMemberPeerURLs(ctx context.Context, id uint64, peerURLs []string) ([]*Member, error) {
+func (c *Client) UpdateMemberPeerURLs(ctx context.Context, id uint64, peerURLs []string) (_ []*Member, rerr error) {
+	ctx, span := c.startSpan(ctx, "MemberUpdate")
+	defer tracing.End(span, &rerr)
+
 	ctx, cancel := context.WithTimeout(ctx, c.CallTimeout)
 	defer cancel()
 
@@ -280,7 +340,10 @@ func (c *Client) UpdateMemberPeerURLs(ctx context.Context, id uint64, peerURLs [
 }
 
 // Alarms retrieves all alarms on a cluster.
-func (c *Client) Alarms(ctx context.Context) ([]MemberAlarm, error) {
+func (c *Client) Alarms(ctx context.Context) (_ []MemberAlarm, rerr error) {
+	ctx, span := c.startSpan(ctx, "AlarmList")
+	defer tracing.End(span, &rerr)
+
 	ctx, cancel := context.WithTimeout(ctx, c.CallTimeout)
 	defer cancel()
 
@@ -299,3 +362,79 @@ func (c *Client) Alarms(ctx context.Context) ([]MemberAlarm, error) {
 
 	return memberAlarms, nil
 }
+
+// Status retrieves the status of the etcd member the client is connected to.
+func (c *Client) Status(ctx context.Context) (_ *MemberStatus, rerr error) {
+	ctx, span := c.startSpan(ctx, "Status")
+	defer tracing.End(span, &rerr)
+
+	ctx, cancel := context.WithTimeout(ctx, c.CallTimeout)
+	defer cancel()
+
+	status, err := c.EtcdClient.Status(ctx, c.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get etcd member status")
+	}
+
+	// Every member reports the alarms of the whole cluster among its errors, they are attributed to the members
+	// which raised them instead.
+	alarmResponse, err := c.EtcdClient.AlarmList(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get alarms for etcd cluster")
+	}
+
+	memberStatus := &MemberStatus{
+		MemberID:    status.Header.GetMemberId(),
+		LeaderID:    status.Leader,
+		DBSize:      status.DbSize,
+		DBSizeInUse: status.DbSizeInUse,
+		RaftIndex:   status.RaftIndex,
+		IsLearner:   status.IsLearner,
+	}
+
+	alarms := make(map[string]bool, len(alarmResponse.Alarms))
+	for _, a := range alarmResponse.Alarms {
+		alarms[a.String()] = true
+
+		if a.GetMemberID() == memberStatus.MemberID {
+			memberStatus.Alarms = append(memberStatus.Alarms, AlarmType(a.GetAlarm()))
+		}
+	}
+
+	for _, e := range status.Errors {
+		if !alarms[e] {
+			memberStatus.Errors = append(memberStatus.Errors, e)
+		}
+	}
+
+	return memberStatus, nil
+}
+
+// Defragment defragments the database of the etcd member the client is connected to.
+func (c *Client) Defragment(ctx context.Context) (rerr error) {
+	ctx, span := c.startSpan(ctx, "Defragment")
+	defer tracing.End(span, &rerr)
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultDefragmentTimeout)
+	defer cancel()
+
+	_, err := c.EtcdClient.Defragment(ctx, c.Endpoint)
+
+	return errors.Wrapf(err, "failed to defragment etcd member %s", c.Endpoint)
+}
+
+// AlarmDisarm disarms an alarm raised by a member.
+func (c *Client) AlarmDisarm(ctx context.Context, alarm MemberAlarm) (rerr error) {
+	ctx, span := c.startSpan(ctx, "AlarmDisarm")
+	defer tracing.End(span, &rerr)
+
+	ctx, cancel := context.WithTimeout(ctx, c.CallTimeout)
+	defer cancel()
+
+	_, err := c.EtcdClient.AlarmDisarm(ctx, &clientv3.AlarmMember{
+		MemberID: alarm.MemberID,
+		Alarm:    etcdserverpb.AlarmType(alarm.Type),
+	})
+
+	return errors.Wrapf(err, "failed to disarm %s alarm of member %x", AlarmTypeName[alarm.Type], alarm.MemberID)
+}
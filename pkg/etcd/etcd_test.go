@@ -101,3 +101,62 @@ func TestEtcdMembers_WithSuccess(t *testing.T) {
 	g.Expect(updatedMembers[0].PeerURLs).To(HaveLen(2))
 	g.Expect(updatedMembers[0].PeerURLs).To(Equal([]string{"https://1.2.3.4:2000", "https://4.5.6.7:2000"}))
 }
+
+func TestEtcdMaintenance(t *testing.T) {
+	g := NewWithT(t)
+
+	fakeEtcdClient := &etcdfake.FakeEtcdClient{
+		EtcdEndpoints: []string{"https://etcd-instance:2379"},
+		StatusResponse: &clientv3.StatusResponse{
+			Header:      &etcdserverpb.ResponseHeader{MemberId: 1234},
+			Leader:      5678,
+			DbSize:      200,
+			DbSizeInUse: 50,
+		},
+		AlarmResponse:      &clientv3.AlarmResponse{},
+		DefragmentResponse: &clientv3.DefragmentResponse{},
+	}
+
+	client, err := newEtcdClient(ctx, fakeEtcdClient, DefaultCallTimeout)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	status, err := client.Status(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status.MemberID).To(Equal(uint64(1234)))
+	g.Expect(status.LeaderID).To(Equal(uint64(5678)))
+	g.Expect(status.DBSize).To(Equal(int64(200)))
+	g.Expect(status.DBSizeInUse).To(Equal(int64(50)))
+	g.Expect(status.Alarms).To(BeEmpty())
+
+	// The alarms of the cluster are reported by every member among its errors, and only attributed to the member
+	// which raised them.
+	for _, memberID := range []uint64{1234, 5678} {
+		noSpace := &etcdserverpb.AlarmMember{MemberID: memberID, Alarm: etcdserverpb.AlarmType_NOSPACE}
+		fakeEtcdClient.AlarmResponse = &clientv3.AlarmResponse{Alarms: []*etcdserverpb.AlarmMember{noSpace}}
+		fakeEtcdClient.StatusResponse.Errors = []string{noSpace.String(), "etcdserver: no leader"}
+
+		status, err = client.Status(ctx)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(status.Errors).To(Equal([]string{"etcdserver: no leader"}))
+
+		if memberID == 1234 {
+			g.Expect(status.Alarms).To(Equal([]AlarmType{AlarmNoSpace}))
+		} else {
+			g.Expect(status.Alarms).To(BeEmpty())
+		}
+	}
+
+	g.Expect(client.Defragment(ctx)).To(Succeed())
+	g.Expect(fakeEtcdClient.DefragmentedEndpoint).To(Equal("https://etcd-instance:2379"))
+
+	g.Expect(client.AlarmDisarm(ctx, MemberAlarm{MemberID: 1234, Type: AlarmNoSpace})).To(Succeed())
+	g.Expect(fakeEtcdClient.DisarmedAlarm).To(Equal(&clientv3.AlarmMember{
+		MemberID: 1234,
+		Alarm:    etcdserverpb.AlarmType_NOSPACE,
+	}))
+
+	fakeEtcdClient.ErrorResponse = errors.New("something went wrong")
+
+	g.Expect(client.Defragment(ctx)).ToNot(Succeed())
+	g.Expect(client.AlarmDisarm(ctx, MemberAlarm{MemberID: 1234, Type: AlarmNoSpace})).ToNot(Succeed())
+}
@@ -86,6 +86,9 @@ func generateKubeconfig(ctx context.Context, c client.Client, clusterName client
 		return nil, errors.Wrap(err, "failed to generate a kubeconfig")
 	}
 
+	// The Cluster CA holds a bundle while its certificate authorities are rotated, trust all of them.
+	cfg.Clusters[clusterName.Name].CertificateAuthorityData = clusterCA.Data[secret.TLSCrtDataName]
+
 	out, err := clientcmd.Write(*cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to serialize config to yaml")
@@ -29,6 +29,8 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/transport/spdy"
+
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
 )
 
 const defaultTimeout = 10 * time.Second
@@ -88,8 +90,12 @@ func (d *Dialer) DialContextWithAddr(ctx context.Context, addr string) (net.Conn
 }
 
 // DialContext creates proxied port-forwarded connections.
-// ctx is currently unused, but fulfils the type signature used by GRPC.
-func (d *Dialer) DialContext(_ context.Context, _ string, addr string) (net.Conn, error) {
+// ctx only carries the span of the connection, but fulfils the type signature used by GRPC.
+func (d *Dialer) DialContext(ctx context.Context, _ string, addr string) (_ net.Conn, rerr error) {
+	_, span := tracing.Start(ctx, "proxy.Dial",
+		tracing.NamespaceKey.String(d.proxy.Namespace), tracing.ObjectKey.String(addr), tracing.KindKey.String(d.proxy.Kind))
+	defer tracing.End(span, &rerr)
+
 	req := d.clientset.CoreV1().RESTClient().
 		Post().
 		Resource(d.proxy.Kind).
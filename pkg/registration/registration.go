@@ -17,23 +17,78 @@ limitations under the License.
 package registration
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
 )
 
+const (
+	// webhookTimeout is the time given to the webhook of the "webhook" registration method to respond.
+	webhookTimeout = 10 * time.Second
+
+	// maxWebhookResponseSize is the maximum size of the responses of the webhook of the "webhook" registration method.
+	maxWebhookResponseSize = 1 << 20
+)
+
 // GetRegistrationAddresses is a function type that is used to provide different implementations of
 // getting the addresses just when registering a new node into a cluster.
-type GetRegistrationAddresses func(cluster *clusterv1.Cluster,
+type GetRegistrationAddresses func(ctx context.Context,
+	cluster *clusterv1.Cluster,
 	rcp *controlplanev1.RKE2ControlPlane,
 	cpMachines collections.Machines) ([]string, error)
 
+// Resolver looks up the DNS records of the "dns" registration method.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// Option configures the registration methods.
+type Option func(*options)
+
+type options struct {
+	client   client.Reader
+	resolver Resolver
+}
+
+// WithClient sets the client used to read the Service of the "service" registration method.
+func WithClient(c client.Reader) Option {
+	return func(o *options) {
+		o.client = c
+	}
+}
+
+// WithResolver sets the resolver used by the "dns" registration method, the default resolver is used otherwise.
+func WithResolver(resolver Resolver) Option {
+	return func(o *options) {
+		o.resolver = resolver
+	}
+}
+
 // NewRegistrationMethod returns the function for the registration addresses based on the passed method name.
-func NewRegistrationMethod(method string) (GetRegistrationAddresses, error) {
+func NewRegistrationMethod(method string, opts ...Option) (GetRegistrationAddresses, error) {
+	o := &options{resolver: net.DefaultResolver}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	switch method {
 	case "internal-first":
 		return registrationMethodWithFilter(filterInternalFirst), nil
@@ -45,17 +100,31 @@ func NewRegistrationMethod(method string) (GetRegistrationAddresses, error) {
 		return registrationMethodAddress, nil
 	case "control-plane-endpoint", "":
 		return registrationMethodControlPlaneEndpoint, nil
+	case "dns":
+		return registrationMethodDNS(o.resolver), nil
+	case "service":
+		if o.client == nil {
+			return nil, errors.New("registration method service requires a client")
+		}
+
+		return registrationMethodService(o.client), nil
+	case "webhook":
+		return registrationMethodWebhook, nil
 	default:
 		return nil, fmt.Errorf("unsupported registration method: %s", method)
 	}
 }
 
 func registrationMethodWithFilter(filter addressFilter) GetRegistrationAddresses {
-	return func(_ *clusterv1.Cluster, _ *controlplanev1.RKE2ControlPlane, availableMachines collections.Machines) ([]string, error) {
+	return func(_ context.Context,
+		_ *clusterv1.Cluster,
+		rcp *controlplanev1.RKE2ControlPlane,
+		availableMachines collections.Machines,
+	) ([]string, error) {
 		validIPAddresses := []string{}
 
 		for _, availableMachine := range availableMachines {
-			ip := filter(availableMachine)
+			ip := filter(availableMachine, rcp.Spec.RegistrationAddressFamily)
 			if ip != "" {
 				validIPAddresses = append(validIPAddresses, ip)
 			}
@@ -65,7 +134,11 @@ func registrationMethodWithFilter(filter addressFilter) GetRegistrationAddresses
 	}
 }
 
-func registrationMethodAddress(_ *clusterv1.Cluster, rcp *controlplanev1.RKE2ControlPlane, _ collections.Machines) ([]string, error) {
+func registrationMethodAddress(_ context.Context,
+	_ *clusterv1.Cluster,
+	rcp *controlplanev1.RKE2ControlPlane,
+	_ collections.Machines,
+) ([]string, error) {
 	validIPAddresses := []string{}
 
 	validIPAddresses = append(validIPAddresses, rcp.Spec.RegistrationAddress)
@@ -77,7 +150,8 @@ func registrationMethodAddress(_ *clusterv1.Cluster, rcp *controlplanev1.RKE2Con
 	return validIPAddresses, nil
 }
 
-func registrationMethodControlPlaneEndpoint(cluster *clusterv1.Cluster,
+func registrationMethodControlPlaneEndpoint(_ context.Context,
+	cluster *clusterv1.Cluster,
 	_ *controlplanev1.RKE2ControlPlane,
 	_ collections.Machines,
 ) ([]string, error) {
@@ -96,45 +170,282 @@ func registrationMethodControlPlaneEndpoint(cluster *clusterv1.Cluster,
 	return validAddresses, nil
 }
 
-type addressFilter func(machine *clusterv1.Machine) string
+// registrationMethodDNS resolves the registration address, the targets of its SRV records when it starts with an
+// underscore, like _rke2._tcp.example.com, and its addresses otherwise.
+func registrationMethodDNS(resolver Resolver) GetRegistrationAddresses {
+	return func(ctx context.Context,
+		_ *clusterv1.Cluster,
+		rcp *controlplanev1.RKE2ControlPlane,
+		_ collections.Machines,
+	) ([]string, error) {
+		name := rcp.Spec.RegistrationAddress
+		if name == "" {
+			return nil, errors.New("no registration address supplied")
+		}
 
-func filterInternalFirst(machine *clusterv1.Machine) string {
-	for _, address := range machine.Status.Addresses {
-		switch address.Type {
-		case clusterv1.MachineInternalIP:
-			if address.Address != "" {
-				return address.Address
+		if strings.HasPrefix(name, "_") {
+			_, records, err := resolver.LookupSRV(ctx, "", "", name)
+			if err != nil {
+				return nil, fmt.Errorf("looking up SRV records of %s: %w", name, err)
 			}
-		case clusterv1.MachineExternalIP:
-			if address.Address != "" {
-				return address.Address
+
+			targets := []string{}
+			for _, record := range records {
+				targets = append(targets, strings.TrimSuffix(record.Target, "."))
 			}
+
+			return targets, nil
+		}
+
+		addresses, err := resolver.LookupHost(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("looking up addresses of %s: %w", name, err)
 		}
-	}
 
-	return ""
+		return preferAddressFamily(addresses, rcp.Spec.RegistrationAddressFamily), nil
+	}
 }
 
-func filterInternalOnly(machine *clusterv1.Machine) string {
-	for _, address := range machine.Status.Addresses {
-		if address.Type == clusterv1.MachineInternalIP {
-			if address.Address != "" {
-				return address.Address
+// registrationMethodService reads the registration addresses from the load balancer ingress of the referenced
+// Service or, when it has none, from the addresses of its ready endpoints.
+func registrationMethodService(c client.Reader) GetRegistrationAddresses {
+	return func(ctx context.Context,
+		_ *clusterv1.Cluster,
+		rcp *controlplanev1.RKE2ControlPlane,
+		_ collections.Machines,
+	) ([]string, error) {
+		ref := rcp.Spec.RegistrationServiceRef
+		if ref == nil || ref.Name == "" {
+			return nil, errors.New("no registration service supplied")
+		}
+
+		key := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
+		if key.Namespace == "" {
+			key.Namespace = rcp.Namespace
+		}
+
+		service := &corev1.Service{}
+		if err := c.Get(ctx, key, service); err != nil {
+			return nil, fmt.Errorf("getting registration service %s: %w", key, err)
+		}
+
+		addresses := []string{}
+
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			switch {
+			case ingress.IP != "":
+				addresses = append(addresses, ingress.IP)
+			case ingress.Hostname != "":
+				addresses = append(addresses, ingress.Hostname)
+			}
+		}
+
+		if len(addresses) > 0 {
+			return preferAddressFamily(addresses, rcp.Spec.RegistrationAddressFamily), nil
+		}
+
+		endpoints := &corev1.Endpoints{}
+		if err := c.Get(ctx, key, endpoints); err != nil {
+			return nil, fmt.Errorf("getting registration service %s endpoints: %w", key, err)
+		}
+
+		for _, subset := range endpoints.Subsets {
+			for _, address := range subset.Addresses {
+				addresses = append(addresses, address.IP)
 			}
 		}
+
+		return preferAddressFamily(addresses, rcp.Spec.RegistrationAddressFamily), nil
 	}
+}
+
+// WebhookRequest is the body of the POST requests sent to the webhook of the "webhook" registration method.
+type WebhookRequest struct {
+	// Namespace is the namespace of the Cluster and of its control plane.
+	Namespace string `json:"namespace"`
+
+	// ClusterName is the name of the Cluster.
+	ClusterName string `json:"clusterName"`
+
+	// ControlPlaneName is the name of the RKE2ControlPlane.
+	ControlPlaneName string `json:"controlPlaneName"`
 
-	return ""
+	// AddressFamily is the IP family of the addresses preferred for registering nodes, if any.
+	AddressFamily corev1.IPFamily `json:"addressFamily,omitempty"`
+
+	// Machines are the ready control plane machines, sorted by name.
+	Machines []WebhookMachine `json:"machines"`
+}
+
+// WebhookMachine is a ready control plane machine sent to the webhook of the "webhook" registration method.
+type WebhookMachine struct {
+	// Name is the name of the Machine.
+	Name string `json:"name"`
+
+	// Addresses are the addresses of the Machine.
+	Addresses clusterv1.MachineAddresses `json:"addresses,omitempty"`
 }
 
-func filterExternalOnly(machine *clusterv1.Machine) string {
+// WebhookResponse is the body of the responses of the webhook of the "webhook" registration method.
+type WebhookResponse struct {
+	// Addresses are the registration addresses, IP addresses or hostnames.
+	Addresses []string `json:"addresses"`
+}
+
+// registrationMethodWebhook requests the registration addresses from the webhook of the control plane, so that
+// external address providers can supply them.
+func registrationMethodWebhook(ctx context.Context,
+	cluster *clusterv1.Cluster,
+	rcp *controlplanev1.RKE2ControlPlane,
+	availableMachines collections.Machines,
+) ([]string, error) {
+	webhook := rcp.Spec.RegistrationWebhook
+	if webhook == nil || webhook.URL == "" {
+		return nil, errors.New("no registration webhook supplied")
+	}
+
+	request := WebhookRequest{
+		Namespace:        rcp.Namespace,
+		ControlPlaneName: rcp.Name,
+		AddressFamily:    rcp.Spec.RegistrationAddressFamily,
+		Machines:         []WebhookMachine{},
+	}
+
+	if cluster != nil {
+		request.ClusterName = cluster.Name
+	}
+
+	for _, machine := range availableMachines {
+		request.Machines = append(request.Machines, WebhookMachine{Name: machine.Name, Addresses: machine.Status.Addresses})
+	}
+
+	slices.SortFunc(request.Machines, func(a, b WebhookMachine) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling registration webhook request: %w", err)
+	}
+
+	httpClient, err := webhookClient(webhook.CABundle)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating registration webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling registration webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registration webhook responded with status %s", resp.Status)
+	}
+
+	response := &WebhookResponse{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxWebhookResponseSize)).Decode(response); err != nil {
+		return nil, fmt.Errorf("decoding registration webhook response: %w", err)
+	}
+
+	return preferAddressFamily(response.Addresses, rcp.Spec.RegistrationAddressFamily), nil
+}
+
+// webhookClient returns the client calling the webhook of the "webhook" registration method, verifying its
+// certificate against the given CA bundle if not empty.
+func webhookClient(caBundle []byte) (*http.Client, error) {
+	if len(caBundle) == 0 {
+		return &http.Client{}, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return nil, errors.New("registration webhook CA bundle contains no valid certificate")
+	}
+
+	// The transport is not reused across reconciles, its connections are not kept alive.
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			DisableKeepAlives: true,
+		},
+	}, nil
+}
+
+type addressFilter func(machine *clusterv1.Machine, family corev1.IPFamily) string
+
+func filterInternalFirst(machine *clusterv1.Machine, family corev1.IPFamily) string {
+	return firstAddress(machine, family, clusterv1.MachineInternalIP, clusterv1.MachineExternalIP)
+}
+
+func filterInternalOnly(machine *clusterv1.Machine, family corev1.IPFamily) string {
+	return firstAddress(machine, family, clusterv1.MachineInternalIP)
+}
+
+func filterExternalOnly(machine *clusterv1.Machine, family corev1.IPFamily) string {
+	return firstAddress(machine, family, clusterv1.MachineExternalIP)
+}
+
+// firstAddress returns the first address of the machine of one of the address types, of the preferred IP family
+// if the machine has one.
+func firstAddress(machine *clusterv1.Machine, family corev1.IPFamily, types ...clusterv1.MachineAddressType) string {
+	addresses := []string{}
+
 	for _, address := range machine.Status.Addresses {
-		if address.Type == clusterv1.MachineExternalIP {
-			if address.Address != "" {
-				return address.Address
+		for _, addressType := range types {
+			if address.Type == addressType && address.Address != "" {
+				addresses = append(addresses, address.Address)
 			}
 		}
 	}
 
-	return ""
+	addresses = preferAddressFamily(addresses, family)
+	if len(addresses) == 0 {
+		return ""
+	}
+
+	return addresses[0]
+}
+
+// preferAddressFamily moves the addresses of the IP family first, keeping their order otherwise.
+func preferAddressFamily(addresses []string, family corev1.IPFamily) []string {
+	if family == "" {
+		return addresses
+	}
+
+	preferred := []string{}
+	others := []string{}
+
+	for _, address := range addresses {
+		if ipFamily(address) == family {
+			preferred = append(preferred, address)
+		} else {
+			others = append(others, address)
+		}
+	}
+
+	return append(preferred, others...)
+}
+
+func ipFamily(address string) corev1.IPFamily {
+	ip := net.ParseIP(address)
+
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return corev1.IPv4Protocol
+	default:
+		return corev1.IPv6Protocol
+	}
 }
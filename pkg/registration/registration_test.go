@@ -17,10 +17,18 @@ limitations under the License.
 package registration_test
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
 	"github.com/rancher/cluster-api-provider-rke2/pkg/registration"
@@ -57,6 +65,18 @@ func TestNewRegistrationMethod(t *testing.T) {
 			name:        "",
 			expectError: false,
 		},
+		{
+			name:        "dns",
+			expectError: false,
+		},
+		{
+			name:        "service",
+			expectError: true,
+		},
+		{
+			name:        "webhook",
+			expectError: false,
+		},
 		{
 			name:        "unknownmethod",
 			expectError: true,
@@ -121,7 +141,7 @@ func TestInternalFirstMethod(t *testing.T) {
 
 			col := collections.FromMachines(tc.machines...)
 
-			actualAddresses, err := regMethod(nil, tc.rcp, col)
+			actualAddresses, err := regMethod(context.Background(), nil, tc.rcp, col)
 			g.Expect(err).NotTo(HaveOccurred())
 
 			g.Expect(actualAddresses).To(HaveLen(len(tc.expectedAddresses)))
@@ -174,7 +194,7 @@ func TestInternalOnlyMethod(t *testing.T) {
 
 			col := collections.FromMachines(tc.machines...)
 
-			actualAddresses, err := regMethod(nil, tc.rcp, col)
+			actualAddresses, err := regMethod(context.Background(), nil, tc.rcp, col)
 			g.Expect(err).NotTo(HaveOccurred())
 
 			g.Expect(actualAddresses).To(HaveLen(len(tc.expectedAddresses)))
@@ -227,7 +247,7 @@ func TestExternalOnlyMethod(t *testing.T) {
 
 			col := collections.FromMachines(tc.machines...)
 
-			actualAddresses, err := regMethod(nil, tc.rcp, col)
+			actualAddresses, err := regMethod(context.Background(), nil, tc.rcp, col)
 			g.Expect(err).NotTo(HaveOccurred())
 
 			g.Expect(len(actualAddresses)).To(Equal(len(tc.expectedAddresses)))
@@ -276,7 +296,7 @@ func TestAddressMethod(t *testing.T) {
 
 			col := collections.FromMachines(tc.machines...)
 
-			actualAddresses, err := regMethod(nil, tc.rcp, col)
+			actualAddresses, err := regMethod(context.Background(), nil, tc.rcp, col)
 			g.Expect(err).NotTo(HaveOccurred())
 
 			expectedAddresses := []string{"100.100.100.100"}
@@ -332,7 +352,7 @@ func TestControlPlaneMethod(t *testing.T) {
 			rcp := createControlPlane(string(controlplanev1.RegistrationMethodControlPlaneEndpoint), "")
 			col := collections.FromMachines(machines...)
 
-			actualAddresses, err := regMethod(tc.cluster, rcp, col)
+			actualAddresses, err := regMethod(context.Background(), tc.cluster, rcp, col)
 			if tc.expectErr {
 				g.Expect(err).To(HaveOccurred())
 				return
@@ -347,6 +367,316 @@ func TestControlPlaneMethod(t *testing.T) {
 	}
 }
 
+func TestRegistrationAddressFamily(t *testing.T) {
+	testCases := []struct {
+		name              string
+		method            controlplanev1.RegistrationMethod
+		family            corev1.IPFamily
+		machines          []*clusterv1.Machine
+		expectedAddresses []string
+	}{
+		{
+			name:   "no family",
+			method: controlplanev1.RegistrationMethodInternalIPs,
+			machines: []*clusterv1.Machine{
+				createMachine("machine1", []string{"fd00::3", "10.0.0.3"}, nil),
+			},
+			expectedAddresses: []string{"fd00::3"},
+		},
+		{
+			name:   "prefer IPv4",
+			method: controlplanev1.RegistrationMethodInternalIPs,
+			family: corev1.IPv4Protocol,
+			machines: []*clusterv1.Machine{
+				createMachine("machine1", []string{"fd00::3", "10.0.0.3"}, nil),
+			},
+			expectedAddresses: []string{"10.0.0.3"},
+		},
+		{
+			name:   "prefer IPv6 with internal first",
+			method: controlplanev1.RegistrationMethodFavourInternalIPs,
+			family: corev1.IPv6Protocol,
+			machines: []*clusterv1.Machine{
+				createMachine("machine1", []string{"10.0.0.3"}, []string{"2001:db8::3"}),
+				createMachine("machine2", []string{"10.0.0.4"}, nil),
+			},
+			expectedAddresses: []string{"2001:db8::3", "10.0.0.4"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rcp := createControlPlane(string(tc.method), "")
+			rcp.Spec.RegistrationAddressFamily = tc.family
+
+			regMethod, err := registration.NewRegistrationMethod(string(tc.method))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			actualAddresses, err := regMethod(context.Background(), nil, rcp, collections.FromMachines(tc.machines...))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(actualAddresses).To(ConsistOf(tc.expectedAddresses))
+		})
+	}
+}
+
+type fakeResolver struct {
+	hosts map[string][]string
+	srvs  map[string][]*net.SRV
+}
+
+func (r *fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addresses, ok := r.hosts[host]; ok {
+		return addresses, nil
+	}
+
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupSRV(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+	if records, ok := r.srvs[name]; ok {
+		return name, records, nil
+	}
+
+	return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func TestDNSMethod(t *testing.T) {
+	resolver := &fakeResolver{
+		hosts: map[string][]string{"cp.example.com": {"10.0.0.3", "fd00::3"}},
+		srvs: map[string][]*net.SRV{"_rke2._tcp.example.com": {
+			{Target: "cp1.example.com.", Port: 9345},
+			{Target: "cp2.example.com.", Port: 9345},
+		}},
+	}
+
+	testCases := []struct {
+		name              string
+		address           string
+		family            corev1.IPFamily
+		expectErr         bool
+		expectedAddresses []string
+	}{
+		{
+			name:              "address records",
+			address:           "cp.example.com",
+			expectedAddresses: []string{"10.0.0.3", "fd00::3"},
+		},
+		{
+			name:              "address records preferring IPv6",
+			address:           "cp.example.com",
+			family:            corev1.IPv6Protocol,
+			expectedAddresses: []string{"fd00::3", "10.0.0.3"},
+		},
+		{
+			name:              "SRV records",
+			address:           "_rke2._tcp.example.com",
+			expectedAddresses: []string{"cp1.example.com", "cp2.example.com"},
+		},
+		{
+			name:      "unknown name",
+			address:   "unknown.example.com",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rcp := createControlPlane(string(controlplanev1.RegistrationMethodDNS), tc.address)
+			rcp.Spec.RegistrationAddressFamily = tc.family
+
+			regMethod, err := registration.NewRegistrationMethod(string(controlplanev1.RegistrationMethodDNS),
+				registration.WithResolver(resolver))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			actualAddresses, err := regMethod(context.Background(), nil, rcp, nil)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(actualAddresses).To(Equal(tc.expectedAddresses))
+		})
+	}
+}
+
+func TestServiceMethod(t *testing.T) {
+	loadBalancer := &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "load-balancer", Namespace: "default"},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+			{IP: "192.168.1.10"},
+			{Hostname: "lb.example.com"},
+		}}},
+	}
+	endpointsService := &corev1.Service{ObjectMeta: v1.ObjectMeta{Name: "endpoints", Namespace: "registration"}}
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: v1.ObjectMeta{Name: "endpoints", Namespace: "registration"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.3"}, {IP: "fd00::3"}},
+			NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.4"}},
+		}},
+	}
+
+	c := fake.NewClientBuilder().WithObjects(loadBalancer, endpointsService, endpoints).Build()
+
+	testCases := []struct {
+		name              string
+		ref               *corev1.ObjectReference
+		family            corev1.IPFamily
+		expectErr         bool
+		expectedAddresses []string
+	}{
+		{
+			name:              "load balancer ingress",
+			ref:               &corev1.ObjectReference{Name: "load-balancer"},
+			expectedAddresses: []string{"192.168.1.10", "lb.example.com"},
+		},
+		{
+			name:              "ready endpoints preferring IPv6",
+			ref:               &corev1.ObjectReference{Name: "endpoints", Namespace: "registration"},
+			family:            corev1.IPv6Protocol,
+			expectedAddresses: []string{"fd00::3", "10.0.0.3"},
+		},
+		{
+			name:      "missing service",
+			ref:       &corev1.ObjectReference{Name: "missing"},
+			expectErr: true,
+		},
+		{
+			name:      "no service",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rcp := createControlPlane(string(controlplanev1.RegistrationMethodService), "")
+			rcp.Spec.RegistrationServiceRef = tc.ref
+			rcp.Spec.RegistrationAddressFamily = tc.family
+
+			regMethod, err := registration.NewRegistrationMethod(string(controlplanev1.RegistrationMethodService),
+				registration.WithClient(c))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			actualAddresses, err := regMethod(context.Background(), nil, rcp, nil)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(actualAddresses).To(Equal(tc.expectedAddresses))
+		})
+	}
+}
+
+func TestWebhookMethod(t *testing.T) {
+	var received registration.WebhookRequest
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		if received.ClusterName == "unavailable" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(registration.WebhookResponse{Addresses: []string{"10.0.0.3", "fd00::3"}})
+	}))
+	defer server.Close()
+
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	testCases := []struct {
+		name              string
+		cluster           string
+		webhook           *controlplanev1.RegistrationWebhook
+		family            corev1.IPFamily
+		expectErr         bool
+		expectedAddresses []string
+	}{
+		{
+			name:              "addresses of the webhook",
+			cluster:           "test",
+			webhook:           &controlplanev1.RegistrationWebhook{URL: server.URL, CABundle: caBundle},
+			expectedAddresses: []string{"10.0.0.3", "fd00::3"},
+		},
+		{
+			name:              "addresses of the webhook preferring IPv6",
+			cluster:           "test",
+			webhook:           &controlplanev1.RegistrationWebhook{URL: server.URL, CABundle: caBundle},
+			family:            corev1.IPv6Protocol,
+			expectedAddresses: []string{"fd00::3", "10.0.0.3"},
+		},
+		{
+			name:      "webhook not trusted",
+			cluster:   "test",
+			webhook:   &controlplanev1.RegistrationWebhook{URL: server.URL},
+			expectErr: true,
+		},
+		{
+			name:      "webhook failing",
+			cluster:   "unavailable",
+			webhook:   &controlplanev1.RegistrationWebhook{URL: server.URL, CABundle: caBundle},
+			expectErr: true,
+		},
+		{
+			name:      "no webhook",
+			cluster:   "test",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rcp := createControlPlane(string(controlplanev1.RegistrationMethodWebhook), "")
+			rcp.Spec.RegistrationWebhook = tc.webhook
+			rcp.Spec.RegistrationAddressFamily = tc.family
+
+			machines := collections.FromMachines(
+				createMachine("machine-2", []string{"10.0.0.2"}, nil),
+				createMachine("machine-1", []string{"10.0.0.1"}, nil),
+			)
+
+			regMethod, err := registration.NewRegistrationMethod(string(controlplanev1.RegistrationMethodWebhook))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			actualAddresses, err := regMethod(context.Background(), createCluster(tc.cluster, "", 0), rcp, machines)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(actualAddresses).To(Equal(tc.expectedAddresses))
+			g.Expect(received.Namespace).To(Equal("default"))
+			g.Expect(received.ControlPlaneName).To(Equal("test"))
+			g.Expect(received.AddressFamily).To(Equal(tc.family))
+			g.Expect(received.Machines).To(HaveLen(2))
+			g.Expect(received.Machines[0].Name).To(Equal("machine-1"))
+			g.Expect(received.Machines[0].Addresses).To(Equal(clusterv1.MachineAddresses{
+				{Type: clusterv1.MachineInternalIP, Address: "10.0.0.1"},
+			}))
+		})
+	}
+}
+
 func createControlPlane(registrationMethod, registrationAddress string) *controlplanev1.RKE2ControlPlane {
 	return &controlplanev1.RKE2ControlPlane{
 		ObjectMeta: v1.ObjectMeta{
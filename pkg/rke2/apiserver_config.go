@@ -0,0 +1,330 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	kubeyaml "sigs.k8s.io/yaml"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/consts"
+)
+
+const (
+	// DefaultRKE2AdmissionConfigLocation is the location of the admission configuration of the Kube API Server.
+	DefaultRKE2AdmissionConfigLocation = "/etc/rancher/rke2/admission-config.yaml"
+
+	// DefaultRKE2AuditWebhookConfigLocation is the location of the kubeconfig of the webhook audit backend.
+	DefaultRKE2AuditWebhookConfigLocation = "/etc/rancher/rke2/audit-webhook-config.yaml"
+
+	// DefaultRKE2EncryptionConfigLocation is the location of the encryption configuration of the Kube API Server
+	// when the Secrets are encrypted with a KMS plugin.
+	DefaultRKE2EncryptionConfigLocation = "/etc/rancher/rke2/encryption-config.yaml"
+
+	// defaultAuditLogDir is the directory of the audit log, which RKE2 mounts in the Kube API Server.
+	defaultAuditLogDir = "/var/lib/rancher/rke2/server/logs"
+
+	// auditWebhookConfigKey is the key of the kubeconfig of the webhook audit backend in its Secret.
+	auditWebhookConfigKey = "webhook-config.yaml"
+
+	// admissionPluginConfigKey is the key of the configuration of an admission plugin in its ConfigMap.
+	admissionPluginConfigKey = "configuration.yaml"
+
+	// defaultAdmissionPlugins are the admission plugins RKE2 enables besides the default ones of the Kube API Server.
+	defaultAdmissionPlugins = "NodeRestriction"
+)
+
+// admissionConfiguration is the apiserver.config.k8s.io/v1 AdmissionConfiguration of the Kube API Server.
+type admissionConfiguration struct {
+	APIVersion string                  `json:"apiVersion"`
+	Kind       string                  `json:"kind"`
+	Plugins    []admissionPluginConfig `json:"plugins"`
+}
+
+type admissionPluginConfig struct {
+	Name          string          `json:"name"`
+	Configuration json.RawMessage `json:"configuration"`
+}
+
+// podSecurityConfiguration is the pod-security.admission.config.k8s.io/v1 PodSecurityConfiguration of the PodSecurity plugin.
+type podSecurityConfiguration struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Defaults   struct {
+		Enforce        string `json:"enforce,omitempty"`
+		EnforceVersion string `json:"enforce-version,omitempty"`
+		Audit          string `json:"audit,omitempty"`
+		AuditVersion   string `json:"audit-version,omitempty"`
+		Warn           string `json:"warn,omitempty"`
+		WarnVersion    string `json:"warn-version,omitempty"`
+	} `json:"defaults"`
+	Exemptions struct {
+		Usernames      []string `json:"usernames,omitempty"`
+		RuntimeClasses []string `json:"runtimeClasses,omitempty"`
+		Namespaces     []string `json:"namespaces,omitempty"`
+	} `json:"exemptions"`
+}
+
+// eventRateLimitConfiguration is the eventratelimit.admission.k8s.io/v1alpha1 Configuration of the EventRateLimit plugin.
+type eventRateLimitConfiguration struct {
+	APIVersion string                          `json:"apiVersion"`
+	Kind       string                          `json:"kind"`
+	Limits     []controlplanev1.EventRateLimit `json:"limits"`
+}
+
+// encryptionConfiguration is the apiserver.config.k8s.io/v1 EncryptionConfiguration of the Kube API Server.
+type encryptionConfiguration struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Resources  []encryptionResource `json:"resources"`
+}
+
+type encryptionResource struct {
+	Resources []string             `json:"resources"`
+	Providers []encryptionProvider `json:"providers"`
+}
+
+type encryptionProvider struct {
+	KMS      *kmsProvider `json:"kms,omitempty"`
+	Identity *struct{}    `json:"identity,omitempty"`
+}
+
+type kmsProvider struct {
+	APIVersion string `json:"apiVersion"`
+	Name       string `json:"name"`
+	Endpoint   string `json:"endpoint"`
+	Timeout    string `json:"timeout,omitempty"`
+}
+
+// newAuditLogConfig returns the Kube API Server arguments, extra mounts and files configuring its audit log backends.
+func newAuditLogConfig(
+	ctx context.Context,
+	cl client.Client,
+	auditLog *controlplanev1.AuditLogConfig,
+) (args []string, mounts []string, files []bootstrapv1.File, err error) {
+	if auditLog == nil {
+		return nil, nil, nil, nil
+	}
+
+	if auditLog.Path != "" {
+		args = append(args, "audit-log-path="+auditLog.Path)
+
+		if dir := filepath.Dir(auditLog.Path); auditLog.Path != "-" && dir != defaultAuditLogDir {
+			mounts = append(mounts, fmt.Sprintf("%s:%s", dir, dir))
+		}
+	}
+
+	if auditLog.MaxAge != nil {
+		args = append(args, fmt.Sprintf("audit-log-maxage=%d", *auditLog.MaxAge))
+	}
+
+	if auditLog.MaxBackups != nil {
+		args = append(args, fmt.Sprintf("audit-log-maxbackup=%d", *auditLog.MaxBackups))
+	}
+
+	if auditLog.MaxSize != nil {
+		args = append(args, fmt.Sprintf("audit-log-maxsize=%d", *auditLog.MaxSize))
+	}
+
+	if webhook := auditLog.Webhook; webhook != nil {
+		webhookSecret := &corev1.Secret{}
+		if err := cl.Get(ctx, types.NamespacedName{
+			Name:      webhook.ConfigSecret.Name,
+			Namespace: webhook.ConfigSecret.Namespace,
+		}, webhookSecret); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get audit webhook config secret: %w", err)
+		}
+
+		webhookConfig, ok := webhookSecret.Data[auditWebhookConfigKey]
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("audit webhook config secret is missing %s key", auditWebhookConfigKey)
+		}
+
+		args = append(args, "audit-webhook-config-file="+DefaultRKE2AuditWebhookConfigLocation)
+		mounts = append(mounts, fmt.Sprintf("%s:%s:ro", DefaultRKE2AuditWebhookConfigLocation, DefaultRKE2AuditWebhookConfigLocation))
+
+		if webhook.Mode != "" {
+			args = append(args, "audit-webhook-mode="+string(webhook.Mode))
+		}
+
+		if webhook.InitialBackoff != nil {
+			args = append(args, "audit-webhook-initial-backoff="+webhook.InitialBackoff.Duration.String())
+		}
+
+		files = append(files, bootstrapv1.File{
+			Path:        DefaultRKE2AuditWebhookConfigLocation,
+			Content:     string(webhookConfig),
+			Owner:       consts.DefaultFileOwner,
+			Permissions: "0600",
+		})
+	}
+
+	return args, mounts, files, nil
+}
+
+// newAdmissionConfig returns the Kube API Server arguments, extra mounts and files configuring its admission plugins.
+func newAdmissionConfig(
+	ctx context.Context,
+	cl client.Client,
+	admission *controlplanev1.AdmissionConfiguration,
+) (args []string, mounts []string, files []bootstrapv1.File, err error) {
+	if admission == nil {
+		return nil, nil, nil, nil
+	}
+
+	podSecurity := podSecurityConfiguration{
+		APIVersion: "pod-security.admission.config.k8s.io/v1",
+		Kind:       "PodSecurityConfiguration",
+	}
+
+	if admission.PodSecurity != nil {
+		podSecurity.Defaults.Enforce = string(admission.PodSecurity.Enforce)
+		podSecurity.Defaults.Audit = string(admission.PodSecurity.Audit)
+		podSecurity.Defaults.Warn = string(admission.PodSecurity.Warn)
+		podSecurity.Defaults.EnforceVersion = admission.PodSecurity.Version
+		podSecurity.Defaults.AuditVersion = admission.PodSecurity.Version
+		podSecurity.Defaults.WarnVersion = admission.PodSecurity.Version
+		podSecurity.Exemptions.Usernames = admission.PodSecurity.ExemptUsernames
+		podSecurity.Exemptions.RuntimeClasses = admission.PodSecurity.ExemptRuntimeClasses
+		podSecurity.Exemptions.Namespaces = admission.PodSecurity.ExemptNamespaces
+	}
+
+	podSecurityJSON, err := json.Marshal(podSecurity)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to marshal PodSecurity configuration")
+	}
+
+	config := admissionConfiguration{
+		APIVersion: "apiserver.config.k8s.io/v1",
+		Kind:       "AdmissionConfiguration",
+		Plugins:    []admissionPluginConfig{{Name: "PodSecurity", Configuration: podSecurityJSON}},
+	}
+	enabledPlugins := []string{defaultAdmissionPlugins}
+
+	if admission.EventRateLimit != nil {
+		eventRateLimitJSON, err := json.Marshal(eventRateLimitConfiguration{
+			APIVersion: "eventratelimit.admission.k8s.io/v1alpha1",
+			Kind:       "Configuration",
+			Limits:     admission.EventRateLimit.Limits,
+		})
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "failed to marshal EventRateLimit configuration")
+		}
+
+		config.Plugins = append(config.Plugins, admissionPluginConfig{Name: "EventRateLimit", Configuration: eventRateLimitJSON})
+		enabledPlugins = append(enabledPlugins, "EventRateLimit")
+	}
+
+	for _, plugin := range admission.Plugins {
+		enabledPlugins = append(enabledPlugins, plugin.Name)
+
+		if plugin.ConfigMap == nil {
+			continue
+		}
+
+		pluginConfigMap := &corev1.ConfigMap{}
+		if err := cl.Get(ctx, types.NamespacedName{
+			Name:      plugin.ConfigMap.Name,
+			Namespace: plugin.ConfigMap.Namespace,
+		}, pluginConfigMap); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get admission plugin %s config map: %w", plugin.Name, err)
+		}
+
+		pluginConfig, ok := pluginConfigMap.Data[admissionPluginConfigKey]
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("admission plugin %s config map is missing %s key", plugin.Name, admissionPluginConfigKey)
+		}
+
+		pluginConfigJSON, err := kubeyaml.YAMLToJSON([]byte(pluginConfig))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("admission plugin %s config map has an invalid %s: %w", plugin.Name, admissionPluginConfigKey, err)
+		}
+
+		config.Plugins = append(config.Plugins, admissionPluginConfig{Name: plugin.Name, Configuration: pluginConfigJSON})
+	}
+
+	configYAML, err := kubeyaml.Marshal(config)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to marshal admission configuration")
+	}
+
+	args = append(args,
+		"admission-control-config-file="+DefaultRKE2AdmissionConfigLocation,
+		"enable-admission-plugins="+strings.Join(enabledPlugins, ","),
+	)
+	mounts = append(mounts, fmt.Sprintf("%s:%s:ro", DefaultRKE2AdmissionConfigLocation, DefaultRKE2AdmissionConfigLocation))
+	files = append(files, bootstrapv1.File{
+		Path:        DefaultRKE2AdmissionConfigLocation,
+		Content:     string(configYAML),
+		Owner:       consts.DefaultFileOwner,
+		Permissions: consts.DefaultFileMode,
+	})
+
+	return args, mounts, files, nil
+}
+
+// newKMSEncryptionConfig returns the Kube API Server arguments, extra mounts and files encrypting the Secrets
+// with a KMS v2 plugin. The Secrets written before remain readable through the identity provider.
+func newKMSEncryptionConfig(kms *controlplanev1.KMSPlugin) (args []string, mounts []string, files []bootstrapv1.File, err error) {
+	provider := &kmsProvider{
+		APIVersion: "v2",
+		Name:       kms.Name,
+		Endpoint:   "unix://" + kms.SocketPath,
+	}
+
+	if kms.Timeout != nil {
+		provider.Timeout = kms.Timeout.Duration.String()
+	}
+
+	configYAML, err := kubeyaml.Marshal(encryptionConfiguration{
+		APIVersion: "apiserver.config.k8s.io/v1",
+		Kind:       "EncryptionConfiguration",
+		Resources: []encryptionResource{{
+			Resources: []string{"secrets"},
+			Providers: []encryptionProvider{{KMS: provider}, {Identity: &struct{}{}}},
+		}},
+	})
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to marshal encryption configuration")
+	}
+
+	socketDir := filepath.Dir(kms.SocketPath)
+
+	args = append(args, "encryption-provider-config="+DefaultRKE2EncryptionConfigLocation)
+	mounts = append(mounts,
+		fmt.Sprintf("%s:%s:ro", DefaultRKE2EncryptionConfigLocation, DefaultRKE2EncryptionConfigLocation),
+		fmt.Sprintf("%s:%s", socketDir, socketDir),
+	)
+	files = append(files, bootstrapv1.File{
+		Path:        DefaultRKE2EncryptionConfigLocation,
+		Content:     string(configYAML),
+		Owner:       consts.DefaultFileOwner,
+		Permissions: "0600",
+	})
+
+	return args, mounts, files, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+func TestNewAuditLogConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	cl := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "audit-webhook"},
+		Data:       map[string][]byte{auditWebhookConfigKey: []byte("apiVersion: v1\nkind: Config\n")},
+	}).Build()
+
+	args, mounts, files, err := newAuditLogConfig(context.Background(), cl, &controlplanev1.AuditLogConfig{
+		Path:   "/var/log/kubernetes/audit.log",
+		MaxAge: ptr.To(int32(30)),
+		Webhook: &controlplanev1.AuditWebhookConfig{
+			ConfigSecret:   corev1.ObjectReference{Namespace: "default", Name: "audit-webhook"},
+			Mode:           controlplanev1.AuditWebhookModeBatch,
+			InitialBackoff: &metav1.Duration{Duration: 10 * time.Second},
+		},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(args).To(Equal([]string{
+		"audit-log-path=/var/log/kubernetes/audit.log",
+		"audit-log-maxage=30",
+		"audit-webhook-config-file=" + DefaultRKE2AuditWebhookConfigLocation,
+		"audit-webhook-mode=batch",
+		"audit-webhook-initial-backoff=10s",
+	}))
+	g.Expect(mounts).To(Equal([]string{
+		"/var/log/kubernetes:/var/log/kubernetes",
+		DefaultRKE2AuditWebhookConfigLocation + ":" + DefaultRKE2AuditWebhookConfigLocation + ":ro",
+	}))
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(files[0].Path).To(Equal(DefaultRKE2AuditWebhookConfigLocation))
+	g.Expect(files[0].Permissions).To(Equal("0600"))
+
+	_, _, _, err = newAuditLogConfig(context.Background(), fake.NewClientBuilder().Build(), &controlplanev1.AuditLogConfig{
+		Webhook: &controlplanev1.AuditWebhookConfig{ConfigSecret: corev1.ObjectReference{Namespace: "default", Name: "missing"}},
+	})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestNewAdmissionConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	cl := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "image-policy"},
+		Data: map[string]string{admissionPluginConfigKey: `imagePolicy:
+  kubeConfigFile: /etc/rancher/rke2/image-policy.kubeconfig
+  defaultAllow: false
+`},
+	}).Build()
+
+	args, mounts, files, err := newAdmissionConfig(context.Background(), cl, &controlplanev1.AdmissionConfiguration{
+		PodSecurity: &controlplanev1.PodSecurityAdmission{
+			Enforce:          controlplanev1.PodSecurityLevelRestricted,
+			Version:          "latest",
+			ExemptNamespaces: []string{"kube-system"},
+		},
+		EventRateLimit: &controlplanev1.EventRateLimitAdmission{
+			Limits: []controlplanev1.EventRateLimit{{Type: controlplanev1.EventRateLimitTypeServer, QPS: 50, Burst: 100}},
+		},
+		Plugins: []controlplanev1.AdmissionPlugin{
+			{Name: "AlwaysPullImages"},
+			{Name: "ImagePolicyWebhook", ConfigMap: &corev1.ObjectReference{Namespace: "default", Name: "image-policy"}},
+		},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(args).To(Equal([]string{
+		"admission-control-config-file=" + DefaultRKE2AdmissionConfigLocation,
+		"enable-admission-plugins=NodeRestriction,EventRateLimit,AlwaysPullImages,ImagePolicyWebhook",
+	}))
+	g.Expect(mounts).To(Equal([]string{DefaultRKE2AdmissionConfigLocation + ":" + DefaultRKE2AdmissionConfigLocation + ":ro"}))
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(files[0].Content).To(ContainSubstring("kind: AdmissionConfiguration"))
+	g.Expect(files[0].Content).To(ContainSubstring("enforce: restricted"))
+	g.Expect(files[0].Content).To(ContainSubstring("- kube-system"))
+	g.Expect(files[0].Content).To(ContainSubstring("qps: 50"))
+	g.Expect(files[0].Content).To(ContainSubstring("defaultAllow: false"))
+	g.Expect(files[0].Content).ToNot(ContainSubstring("AlwaysPullImages"))
+
+	args, _, _, err = newAdmissionConfig(context.Background(), cl, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(args).To(BeEmpty())
+}
+
+func TestNewKMSEncryptionConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	args, mounts, files, err := newKMSEncryptionConfig(&controlplanev1.KMSPlugin{
+		Name:       "vault",
+		SocketPath: "/var/run/kmsplugin/socket.sock",
+		Timeout:    &metav1.Duration{Duration: 5 * time.Second},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(args).To(Equal([]string{"encryption-provider-config=" + DefaultRKE2EncryptionConfigLocation}))
+	g.Expect(mounts).To(Equal([]string{
+		DefaultRKE2EncryptionConfigLocation + ":" + DefaultRKE2EncryptionConfigLocation + ":ro",
+		"/var/run/kmsplugin:/var/run/kmsplugin",
+	}))
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(files[0].Permissions).To(Equal("0600"))
+	g.Expect(files[0].Content).To(Equal(`apiVersion: apiserver.config.k8s.io/v1
+kind: EncryptionConfiguration
+resources:
+- providers:
+  - kms:
+      apiVersion: v2
+      endpoint: unix:///var/run/kmsplugin/socket.sock
+      name: vault
+      timeout: 5s
+  - identity: {}
+  resources:
+  - secrets
+`))
+}
+
+func TestNewRKE2ServerConfigSecretsEncryption(t *testing.T) {
+	g := NewWithT(t)
+
+	opts := ServerConfigOpts{
+		Ctx:    context.Background(),
+		Client: fake.NewClientBuilder().Build(),
+		ServerConfig: controlplanev1.RKE2ServerConfig{
+			SecretsEncryption: &controlplanev1.SecretsEncryption{Provider: controlplanev1.SecretsEncryptionProviderSecretbox},
+		},
+	}
+
+	config, _, err := newRKE2ServerConfig(opts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(config.SecretsEncryption).To(Equal(ptr.To(true)))
+	g.Expect(config.SecretsEncryptionProvider).To(Equal("secretbox"))
+
+	opts.ServerConfig.SecretsEncryption = &controlplanev1.SecretsEncryption{
+		Provider: controlplanev1.SecretsEncryptionProviderKMS,
+		KMS:      &controlplanev1.KMSPlugin{Name: "vault", SocketPath: "/var/run/kmsplugin/socket.sock"},
+	}
+
+	config, files, err := newRKE2ServerConfig(opts)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(config.SecretsEncryption).To(Equal(ptr.To(false)))
+	g.Expect(config.SecretsEncryptionProvider).To(BeEmpty())
+	g.Expect(config.KubeAPIServerArgs).To(ContainElement("encryption-provider-config=" + DefaultRKE2EncryptionConfigLocation))
+	g.Expect(files).To(ContainElement(HaveField("Path", DefaultRKE2EncryptionConfigLocation)))
+}
@@ -19,11 +19,13 @@ package rke2
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -137,6 +139,8 @@ type ServerConfig struct {
 	DatastoreCAFile                   string   `yaml:"datastore-cafile,omitempty"`
 	DatastoreCertFile                 string   `yaml:"datastore-certfile,omitempty"`
 	DatastoreKeyFile                  string   `yaml:"datastore-keyfile,omitempty"`
+	SecretsEncryption                 *bool    `yaml:"secrets-encryption,omitempty"`
+	SecretsEncryptionProvider         string   `yaml:"secrets-encryption-provider,omitempty"`
 
 	// We don't expose these fields in the API
 	ClusterCIDR string `yaml:"cluster-cidr,omitempty"`
@@ -165,9 +169,11 @@ type ServerConfigOpts struct {
 	ServerURL            string
 	ServerConfig         controlplanev1.RKE2ServerConfig
 	AgentConfig          bootstrapv1.RKE2AgentConfig
-	Ctx                  context.Context
-	Client               client.Client
-	Version              string
+	// ControlPlaneEndpointManagement deploys kube-vip to announce the control plane endpoint, when set.
+	ControlPlaneEndpointManagement *controlplanev1.ControlPlaneEndpointManagement
+	Ctx                            context.Context
+	Client                         client.Client
+	Version                        string
 }
 
 func newRKE2ServerConfig(opts ServerConfigOpts) (*ServerConfig, []bootstrapv1.File, error) { // nolint:gocyclo
@@ -345,6 +351,19 @@ func newRKE2ServerConfig(opts ServerConfigOpts) (*ServerConfig, []bootstrapv1.Fi
 	rke2ServerConfig.ServiceNodePortRange = opts.ServerConfig.ServiceNodePortRange
 	rke2ServerConfig.TLSSan = append(opts.ServerConfig.TLSSan, opts.ControlPlaneEndpoint)
 
+	if management := opts.ControlPlaneEndpointManagement; management != nil {
+		kubeVIPManifest, err := newKubeVIPManifest(management)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if management.Address != opts.ControlPlaneEndpoint {
+			rke2ServerConfig.TLSSan = append(rke2ServerConfig.TLSSan, management.Address)
+		}
+
+		files = append(files, kubeVIPManifest)
+	}
+
 	if opts.ServerConfig.KubeAPIServer != nil {
 		rke2ServerConfig.KubeAPIServerArgs = opts.ServerConfig.KubeAPIServer.ExtraArgs
 		rke2ServerConfig.KubeAPIserverImage = opts.ServerConfig.KubeAPIServer.OverrideImage
@@ -352,6 +371,42 @@ func newRKE2ServerConfig(opts ServerConfigOpts) (*ServerConfig, []bootstrapv1.Fi
 		rke2ServerConfig.KubeAPIserverExtraEnv = componentMapToSlice(extraEnv, opts.ServerConfig.KubeAPIServer.ExtraEnv)
 	}
 
+	auditLogArgs, auditLogMounts, auditLogFiles, err := newAuditLogConfig(opts.Ctx, opts.Client, opts.ServerConfig.AuditLog)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	admissionArgs, admissionMounts, admissionFiles, err := newAdmissionConfig(opts.Ctx, opts.Client, opts.ServerConfig.AdmissionConfiguration)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(auditLogArgs)+len(admissionArgs) > 0 {
+		rke2ServerConfig.KubeAPIServerArgs = slices.Concat(rke2ServerConfig.KubeAPIServerArgs, auditLogArgs, admissionArgs)
+		rke2ServerConfig.KubeAPIserverExtraMounts = slices.Concat(rke2ServerConfig.KubeAPIserverExtraMounts, auditLogMounts, admissionMounts)
+		files = slices.Concat(files, auditLogFiles, admissionFiles)
+	}
+
+	if secretsEncryption := opts.ServerConfig.SecretsEncryption; secretsEncryption != nil {
+		switch {
+		case secretsEncryption.Provider == controlplanev1.SecretsEncryptionProviderKMS && secretsEncryption.KMS != nil:
+			// RKE2 secrets encryption is replaced by the encryption configuration of the KMS plugin.
+			encryptionArgs, encryptionMounts, encryptionFiles, err := newKMSEncryptionConfig(secretsEncryption.KMS)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			rke2ServerConfig.SecretsEncryption = ptr.To(false)
+			rke2ServerConfig.KubeAPIServerArgs = slices.Concat(rke2ServerConfig.KubeAPIServerArgs, encryptionArgs)
+			rke2ServerConfig.KubeAPIserverExtraMounts = slices.Concat(rke2ServerConfig.KubeAPIserverExtraMounts, encryptionMounts)
+			files = slices.Concat(files, encryptionFiles)
+		case secretsEncryption.Provider == controlplanev1.SecretsEncryptionProviderSecretbox:
+			// The provider is only rendered when it is not the RKE2 default, as older RKE2 versions do not support it.
+			rke2ServerConfig.SecretsEncryption = ptr.To(true)
+			rke2ServerConfig.SecretsEncryptionProvider = string(secretsEncryption.Provider)
+		}
+	}
+
 	if opts.ServerConfig.KubeScheduler != nil {
 		rke2ServerConfig.KubeSchedulerArgs = opts.ServerConfig.KubeScheduler.ExtraArgs
 		rke2ServerConfig.KubeSchedulerImage = opts.ServerConfig.KubeScheduler.OverrideImage
@@ -563,8 +618,18 @@ func newRKE2AgentConfig(opts AgentConfigOpts) (*rke2AgentConfig, []bootstrapv1.F
 	}
 
 	rke2AgentConfig.KubeletPath = opts.AgentConfig.KubeletPath
+
+	// The extra arguments come after the configuration file, as kubelet flags take precedence over it.
+	kubeletConfigArgs, kubeletConfigFiles, err := newKubeletConfig(opts.AgentConfig.KubeletConfiguration)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rke2AgentConfig.KubeletArgs = kubeletConfigArgs
+	files = append(files, kubeletConfigFiles...)
+
 	if opts.AgentConfig.Kubelet != nil {
-		rke2AgentConfig.KubeletArgs = opts.AgentConfig.Kubelet.ExtraArgs
+		rke2AgentConfig.KubeletArgs = append(rke2AgentConfig.KubeletArgs, opts.AgentConfig.Kubelet.ExtraArgs...)
 	}
 
 	rke2AgentConfig.LbServerPort = opts.AgentConfig.LoadBalancerPort
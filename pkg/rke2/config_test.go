@@ -271,6 +271,7 @@ var _ = Describe("RKE2ServerConfig", func() {
 		Expect(files[6].Content).To(Equal("test_cloud_config"))
 		Expect(files[6].Owner).To(Equal(consts.DefaultFileOwner))
 		Expect(files[6].Permissions).To(Equal(consts.DefaultFileMode))
+
 	})
 })
 
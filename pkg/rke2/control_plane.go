@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -308,9 +309,64 @@ func (c *ControlPlane) MachinesNeedingRollout(ctx context.Context) collections.M
 	return machines.AnyFilter(
 		// Machines that do not match with RCP config.
 		collections.Not(matchesRCPConfiguration(ctx, c.InfraResources, c.Rke2Configs, c.RCP)),
+		// Machines not running with the rotated certificate authorities.
+		collections.Not(matchesCertificateAuthoritiesRotation(c.RCP)),
+		// Machines whose certificates are about to expire.
+		collections.Not(matchesCertificatesExpiry(c.RCP, c.reconciliationTime.Time)),
+		// Machines created before the rollout after time, once it has passed.
+		collections.ShouldRolloutAfter(&c.reconciliationTime, c.RCP.Spec.RolloutAfter),
+		// Machines older than the max age.
+		collections.Not(matchesMaxAge(c.RCP, c.reconciliationTime.Time)),
+		// Machines whose Node configuration has drifted.
+		collections.Not(matchesConfigDrift(c.RCP)),
 	)
 }
 
+// NextScheduledRollout returns the next time a machine is due for rollout according to the RCP rolloutAfter
+// and rolloutBefore.maxAge, or nil if no rollout is scheduled.
+func (c *ControlPlane) NextScheduledRollout() *time.Time {
+	var next *time.Time
+
+	schedule := func(t time.Time) {
+		if t.After(c.reconciliationTime.Time) && (next == nil || t.Before(*next)) {
+			next = &t
+		}
+	}
+
+	if c.RCP.Spec.RolloutAfter != nil {
+		schedule(c.RCP.Spec.RolloutAfter.Time)
+	}
+
+	if c.RCP.Spec.RolloutBefore != nil && c.RCP.Spec.RolloutBefore.MaxAge != nil {
+		for _, machine := range c.Machines.Filter(collections.Not(collections.HasDeletionTimestamp)) {
+			schedule(machine.CreationTimestamp.Add(c.RCP.Spec.RolloutBefore.MaxAge.Duration))
+		}
+	}
+
+	return next
+}
+
+// MachinesWithOutdatedVersion returns the machines not running the desired version of the control plane.
+func (c *ControlPlane) MachinesWithOutdatedVersion(ctx context.Context) collections.Machines {
+	return c.Machines.Filter(
+		collections.Not(collections.HasDeletionTimestamp),
+		collections.Not(matchesKubernetesOrRKE2Version(ctx, c.RCP.GetDesiredVersion())),
+	)
+}
+
+// LowestVersion returns the lowest version of the control plane machines, taking into account in-place upgrades.
+func (c *ControlPlane) LowestVersion() *string {
+	machines := collections.New()
+
+	for _, machine := range c.Machines {
+		m := machine.DeepCopy()
+		m.Spec.Version = machineVersion(machine)
+		machines.Insert(m)
+	}
+
+	return machines.LowestVersion()
+}
+
 // UpToDateMachines returns the machines that are up-to-date with the control
 // plane's configuration and therefore do not require rollout.
 func (c *ControlPlane) UpToDateMachines(ctx context.Context) collections.Machines {
@@ -412,6 +468,7 @@ func (c *ControlPlane) PatchMachines(ctx context.Context) error {
 				controlplanev1.MachineAgentHealthyCondition,
 				controlplanev1.MachineEtcdMemberHealthyCondition,
 				controlplanev1.NodeMetadataUpToDate,
+				controlplanev1.ConfigDriftedCondition,
 			}}); err != nil {
 				if machine.Status.NodeRef != nil {
 					_ = machine.Status.NodeRef.Name
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+func TestScheduledRollouts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newMachine := func(name string, age time.Duration) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec: clusterv1.MachineSpec{Version: ptr.To("v1.31.2+rke2r1")},
+		}
+	}
+
+	newControlPlane := func(spec controlplanev1.RKE2ControlPlaneSpec) *ControlPlane {
+		spec.Version = "v1.31.2+rke2r1"
+
+		return &ControlPlane{
+			RCP: &controlplanev1.RKE2ControlPlane{Spec: spec},
+			Machines: collections.FromMachines(
+				newMachine("old", 40*24*time.Hour),
+				newMachine("new", 10*24*time.Hour),
+			),
+			reconciliationTime: metav1.NewTime(now),
+		}
+	}
+
+	t.Run("nothing is scheduled by default", func(t *testing.T) {
+		g := NewWithT(t)
+		cp := newControlPlane(controlplanev1.RKE2ControlPlaneSpec{})
+
+		g.Expect(cp.NextScheduledRollout()).To(BeNil())
+		g.Expect(cp.MachinesNeedingRollout(ctx)).To(BeEmpty())
+	})
+
+	t.Run("machines older than the max age are rolled out", func(t *testing.T) {
+		g := NewWithT(t)
+		cp := newControlPlane(controlplanev1.RKE2ControlPlaneSpec{
+			RolloutBefore: &controlplanev1.RolloutBefore{MaxAge: &metav1.Duration{Duration: 30 * 24 * time.Hour}},
+		})
+
+		g.Expect(cp.MachinesNeedingRollout(ctx).Names()).To(ConsistOf("old"))
+		g.Expect(cp.NextScheduledRollout()).To(HaveValue(Equal(now.Add(20 * 24 * time.Hour))))
+	})
+
+	t.Run("machines created before rollout after are rolled out once it has passed", func(t *testing.T) {
+		g := NewWithT(t)
+		rolloutAfter := metav1.NewTime(now.Add(24 * time.Hour))
+		cp := newControlPlane(controlplanev1.RKE2ControlPlaneSpec{RolloutAfter: &rolloutAfter})
+
+		g.Expect(cp.MachinesNeedingRollout(ctx)).To(BeEmpty())
+		g.Expect(cp.NextScheduledRollout()).To(HaveValue(Equal(rolloutAfter.Time)))
+
+		cp.reconciliationTime = metav1.NewTime(now.Add(48 * time.Hour))
+		g.Expect(cp.MachinesNeedingRollout(ctx).Names()).To(ConsistOf("old", "new"))
+		g.Expect(cp.NextScheduledRollout()).To(BeNil())
+	})
+
+	t.Run("the earliest scheduled rollout is the next one", func(t *testing.T) {
+		g := NewWithT(t)
+		rolloutAfter := metav1.NewTime(now.Add(30 * 24 * time.Hour))
+		cp := newControlPlane(controlplanev1.RKE2ControlPlaneSpec{
+			RolloutAfter:  &rolloutAfter,
+			RolloutBefore: &controlplanev1.RolloutBefore{MaxAge: &metav1.Duration{Duration: 30 * 24 * time.Hour}},
+		})
+
+		g.Expect(cp.MachinesNeedingRollout(ctx).Names()).To(ConsistOf("old"))
+		g.Expect(cp.NextScheduledRollout()).To(HaveValue(Equal(now.Add(20 * 24 * time.Hour))))
+	})
+}
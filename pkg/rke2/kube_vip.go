@@ -0,0 +1,242 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/consts"
+)
+
+const (
+	// DefaultKubeVIPManifestLocation is the location of the kube-vip manifest, in the manifests directory
+	// RKE2 deploys to the cluster.
+	DefaultKubeVIPManifestLocation = "/var/lib/rancher/rke2/server/manifests/kube-vip.yaml"
+
+	// DefaultKubeVIPImage is the kube-vip image used when none is specified.
+	DefaultKubeVIPImage = "ghcr.io/kube-vip/kube-vip:v0.8.9"
+
+	// KubeVIPLabel is the label of the kube-vip pods.
+	KubeVIPLabel = "app.kubernetes.io/name"
+
+	// KubeVIPLabelValue is the value of the label of the kube-vip pods.
+	KubeVIPLabelValue = "kube-vip"
+
+	// kubeVIPPort is the port of the Kubernetes API server announced by kube-vip.
+	kubeVIPPort = 6443
+)
+
+// kubeVIPManifestTemplate deploys kube-vip on the control plane nodes. kube-vip reaches the local Kubernetes API
+// server, as the service network may not be available yet, and does not run on nodes excluded from load balancers,
+// so that the address moves off the nodes being deleted.
+var kubeVIPManifestTemplate = template.Must(template.New("kube-vip").Parse(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kube-vip
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: system:kube-vip-role
+rules:
+- apiGroups: [""]
+  resources: ["services/status"]
+  verbs: ["update"]
+- apiGroups: [""]
+  resources: ["services", "endpoints"]
+  verbs: ["list", "get", "watch", "update"]
+- apiGroups: [""]
+  resources: ["nodes"]
+  verbs: ["list", "get", "watch", "update", "patch"]
+- apiGroups: ["coordination.k8s.io"]
+  resources: ["leases"]
+  verbs: ["list", "get", "watch", "update", "create"]
+- apiGroups: ["discovery.k8s.io"]
+  resources: ["endpointslices"]
+  verbs: ["list", "get", "watch", "update"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: system:kube-vip-binding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: system:kube-vip-role
+subjects:
+- kind: ServiceAccount
+  name: kube-vip
+  namespace: kube-system
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: kube-vip
+  namespace: kube-system
+  labels:
+    {{ .Label }}: {{ .LabelValue }}
+spec:
+  selector:
+    matchLabels:
+      {{ .Label }}: {{ .LabelValue }}
+  template:
+    metadata:
+      labels:
+        {{ .Label }}: {{ .LabelValue }}
+    spec:
+      affinity:
+        nodeAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+            - matchExpressions:
+              - key: node-role.kubernetes.io/control-plane
+                operator: Exists
+              - key: node.kubernetes.io/exclude-from-external-load-balancers
+                operator: DoesNotExist
+      containers:
+      - name: kube-vip
+        image: {{ .Image }}
+        args:
+        - manager
+        env:
+        - name: KUBERNETES_SERVICE_HOST
+          value: 127.0.0.1
+        - name: KUBERNETES_SERVICE_PORT
+          value: "{{ .Port }}"
+{{- range .Env }}
+        - name: {{ .Name }}
+          value: "{{ .Value }}"
+{{- end }}
+        securityContext:
+          capabilities:
+            add:
+            - NET_ADMIN
+            - NET_RAW
+      hostNetwork: true
+      serviceAccountName: kube-vip
+      tolerations:
+      - effect: NoSchedule
+        operator: Exists
+      - effect: NoExecute
+        operator: Exists
+`))
+
+type kubeVIPEnv struct {
+	Name  string
+	Value string
+}
+
+// newKubeVIPManifest returns the manifest deploying kube-vip to announce the control plane endpoint address.
+func newKubeVIPManifest(management *controlplanev1.ControlPlaneEndpointManagement) (bootstrapv1.File, error) {
+	image := management.Image
+	if image == "" {
+		image = DefaultKubeVIPImage
+	}
+
+	env := []kubeVIPEnv{
+		{Name: "address", Value: management.Address},
+		{Name: "port", Value: fmt.Sprint(kubeVIPPort)},
+		{Name: "cp_enable", Value: "true"},
+		{Name: "cp_namespace", Value: "kube-system"},
+		{Name: "svc_enable", Value: "false"},
+	}
+
+	if management.Interface != "" {
+		env = append(env, kubeVIPEnv{Name: "vip_interface", Value: management.Interface})
+	}
+
+	switch management.Mode {
+	case controlplanev1.KubeVIPModeBGP:
+		if management.BGP == nil {
+			return bootstrapv1.File{}, fmt.Errorf("bgp configuration is required in %s mode", management.Mode)
+		}
+
+		peers := []string{}
+		for _, peer := range management.BGP.Peers {
+			peers = append(peers, fmt.Sprintf("%s:%d::false", peer.Address, peer.AS))
+		}
+
+		env = append(env,
+			kubeVIPEnv{Name: "bgp_enable", Value: "true"},
+			kubeVIPEnv{Name: "bgp_routerinterface", Value: management.Interface},
+			kubeVIPEnv{Name: "bgp_as", Value: fmt.Sprint(management.BGP.AS)},
+			kubeVIPEnv{Name: "bgp_peers", Value: strings.Join(peers, ",")},
+		)
+	default:
+		env = append(env,
+			kubeVIPEnv{Name: "vip_arp", Value: "true"},
+			kubeVIPEnv{Name: "vip_leaderelection", Value: "true"},
+			kubeVIPEnv{Name: "vip_leaseduration", Value: "5"},
+			kubeVIPEnv{Name: "vip_renewdeadline", Value: "3"},
+			kubeVIPEnv{Name: "vip_retryperiod", Value: "1"},
+		)
+	}
+
+	var manifest bytes.Buffer
+	if err := kubeVIPManifestTemplate.Execute(&manifest, map[string]interface{}{
+		"Label":      KubeVIPLabel,
+		"LabelValue": KubeVIPLabelValue,
+		"Image":      image,
+		"Port":       kubeVIPPort,
+		"Env":        env,
+	}); err != nil {
+		return bootstrapv1.File{}, fmt.Errorf("rendering kube-vip manifest: %w", err)
+	}
+
+	return bootstrapv1.File{
+		Path:        DefaultKubeVIPManifestLocation,
+		Content:     manifest.String(),
+		Owner:       consts.DefaultFileOwner,
+		Permissions: consts.DefaultFileMode,
+	}, nil
+}
+
+// IsKubeVIPRunningOnMachine returns whether a kube-vip pod is still running on the Node of the Machine, and so may
+// still hold the control plane endpoint address.
+func (w *Workload) IsKubeVIPRunningOnMachine(ctx context.Context, machine *clusterv1.Machine) (bool, error) {
+	if machine == nil || machine.Status.NodeRef == nil {
+		return false, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := w.List(ctx, pods,
+		ctrlclient.InNamespace(metav1.NamespaceSystem),
+		ctrlclient.MatchingLabels{KubeVIPLabel: KubeVIPLabelValue},
+	); err != nil {
+		return false, fmt.Errorf("listing kube-vip pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == machine.Status.NodeRef.Name && pod.DeletionTimestamp == nil && pod.Status.Phase == corev1.PodRunning {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
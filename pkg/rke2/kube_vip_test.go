@@ -0,0 +1,109 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+func TestNewKubeVIPManifest(t *testing.T) {
+	g := NewWithT(t)
+
+	file, err := newKubeVIPManifest(&controlplanev1.ControlPlaneEndpointManagement{
+		Address:   "192.168.1.100",
+		Interface: "eth0",
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(file.Path).To(Equal(DefaultKubeVIPManifestLocation))
+	g.Expect(file.Content).To(ContainSubstring("image: " + DefaultKubeVIPImage))
+	g.Expect(file.Content).To(ContainSubstring("- name: address\n          value: \"192.168.1.100\""))
+	g.Expect(file.Content).To(ContainSubstring("- name: vip_interface\n          value: \"eth0\""))
+	g.Expect(file.Content).To(ContainSubstring("- name: vip_arp\n          value: \"true\""))
+	g.Expect(file.Content).ToNot(ContainSubstring("bgp_enable"))
+
+	file, err = newKubeVIPManifest(&controlplanev1.ControlPlaneEndpointManagement{
+		Address:   "192.168.1.100",
+		Mode:      controlplanev1.KubeVIPModeBGP,
+		Interface: "eth0",
+		Image:     "registry.example.com/kube-vip:v0.8.9",
+		BGP: &controlplanev1.KubeVIPBGP{
+			AS: 65000,
+			Peers: []controlplanev1.KubeVIPBGPPeer{
+				{Address: "192.168.1.1", AS: 65001},
+				{Address: "192.168.1.2", AS: 65001},
+			},
+		},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(file.Content).To(ContainSubstring("image: registry.example.com/kube-vip:v0.8.9"))
+	g.Expect(file.Content).To(ContainSubstring("- name: bgp_as\n          value: \"65000\""))
+	g.Expect(file.Content).To(ContainSubstring("- name: bgp_peers\n          value: \"192.168.1.1:65001::false,192.168.1.2:65001::false\""))
+	g.Expect(file.Content).ToNot(ContainSubstring("vip_arp"))
+
+	_, err = newKubeVIPManifest(&controlplanev1.ControlPlaneEndpointManagement{
+		Address: "192.168.1.100",
+		Mode:    controlplanev1.KubeVIPModeBGP,
+	})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestIsKubeVIPRunningOnMachine(t *testing.T) {
+	g := NewWithT(t)
+
+	newPod := func(name, nodeName string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: metav1.NamespaceSystem,
+				Labels:    map[string]string{KubeVIPLabel: KubeVIPLabelValue},
+			},
+			Spec:   corev1.PodSpec{NodeName: nodeName},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	w := &Workload{
+		Client: fake.NewClientBuilder().WithObjects(
+			newPod("kube-vip-running", "running", corev1.PodRunning),
+			newPod("kube-vip-stopped", "stopped", corev1.PodSucceeded),
+		).Build(),
+	}
+
+	newMachine := func(nodeName string) *clusterv1.Machine {
+		return &clusterv1.Machine{Status: clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: nodeName}}}
+	}
+
+	running, err := w.IsKubeVIPRunningOnMachine(ctx, newMachine("running"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(running).To(BeTrue())
+
+	running, err = w.IsKubeVIPRunningOnMachine(ctx, newMachine("stopped"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(running).To(BeFalse())
+
+	running, err = w.IsKubeVIPRunningOnMachine(ctx, &clusterv1.Machine{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(running).To(BeFalse())
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"github.com/pkg/errors"
+	kubeyaml "sigs.k8s.io/yaml"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/consts"
+)
+
+// DefaultRKE2KubeletConfigLocation is the location of the kubelet configuration file.
+const DefaultRKE2KubeletConfigLocation = "/etc/rancher/rke2/kubelet-config.yaml"
+
+// kubeletConfiguration is the kubelet.config.k8s.io/v1beta1 KubeletConfiguration of the kubelet,
+// whose fields are named after the kubelet configuration ones.
+type kubeletConfiguration struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	bootstrapv1.KubeletConfiguration
+}
+
+// newKubeletConfig returns the kubelet arguments and files configuring the kubelet with a configuration file.
+func newKubeletConfig(config *bootstrapv1.KubeletConfiguration) (args []string, files []bootstrapv1.File, err error) {
+	if config == nil {
+		return nil, nil, nil
+	}
+
+	configYAML, err := kubeyaml.Marshal(kubeletConfiguration{
+		APIVersion:           "kubelet.config.k8s.io/v1beta1",
+		Kind:                 "KubeletConfiguration",
+		KubeletConfiguration: *config,
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal kubelet configuration")
+	}
+
+	args = append(args, "config="+DefaultRKE2KubeletConfigLocation)
+	files = append(files, bootstrapv1.File{
+		Path:        DefaultRKE2KubeletConfigLocation,
+		Content:     string(configYAML),
+		Owner:       consts.DefaultFileOwner,
+		Permissions: consts.DefaultFileMode,
+	})
+
+	return args, files, nil
+}
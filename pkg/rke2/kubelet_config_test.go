@@ -0,0 +1,80 @@
+/*
+Copyright 2024 SUSE LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+)
+
+func TestNewKubeletConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	args, files, err := newKubeletConfig(&bootstrapv1.KubeletConfiguration{
+		MaxPods:                 ptr.To(int32(250)),
+		EvictionHard:            map[string]string{"memory.available": "300Mi"},
+		SystemReserved:          map[string]string{"cpu": "500m"},
+		TopologyManagerPolicy:   "single-numa-node",
+		CPUManagerPolicy:        bootstrapv1.StaticCPUManagerPolicy,
+		ShutdownGracePeriod:     &metav1.Duration{Duration: time.Minute},
+		EvictionSoftGracePeriod: map[string]string{},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(args).To(Equal([]string{"config=" + DefaultRKE2KubeletConfigLocation}))
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(files[0].Path).To(Equal(DefaultRKE2KubeletConfigLocation))
+	g.Expect(files[0].Content).To(Equal(`apiVersion: kubelet.config.k8s.io/v1beta1
+cpuManagerPolicy: static
+evictionHard:
+  memory.available: 300Mi
+kind: KubeletConfiguration
+maxPods: 250
+shutdownGracePeriod: 1m0s
+systemReserved:
+  cpu: 500m
+topologyManagerPolicy: single-numa-node
+`))
+
+	args, files, err = newKubeletConfig(nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(args).To(BeEmpty())
+	g.Expect(files).To(BeEmpty())
+}
+
+func TestNewRKE2AgentConfigKubeletConfiguration(t *testing.T) {
+	g := NewWithT(t)
+
+	config, files, err := newRKE2AgentConfig(AgentConfigOpts{
+		Ctx:    context.Background(),
+		Client: fake.NewClientBuilder().Build(),
+		AgentConfig: bootstrapv1.RKE2AgentConfig{
+			KubeletConfiguration: &bootstrapv1.KubeletConfiguration{MaxPods: ptr.To(int32(250))},
+			Kubelet:              &bootstrapv1.ComponentConfig{ExtraArgs: []string{"max-pods=300"}},
+		},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(config.KubeletArgs).To(Equal([]string{"config=" + DefaultRKE2KubeletConfigLocation, "max-pods=300"}))
+	g.Expect(files).To(ContainElement(HaveField("Path", DefaultRKE2KubeletConfigLocation)))
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured" //nolint: gci,goimports
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/log" //nolint: gci,goimports
 
 	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
@@ -132,14 +134,14 @@ func matchServerConfig(ctx context.Context, rcp *controlplanev1.RKE2ControlPlane
 		machineServerConfig = &controlplanev1.RKE2ServerConfig{}
 	}
 
-	var rcpServerConfig *controlplanev1.RKE2ServerConfig
-	if reflect.DeepEqual(rcp.Spec.ServerConfig, controlplanev1.RKE2ServerConfig{}) {
-		// If the ServerConfig is empty, initialize a new RKE2ServerConfig
-		rcpServerConfig = &controlplanev1.RKE2ServerConfig{}
-	} else {
-		// Otherwise, take the address of the existing ServerConfig
-		rcpServerConfig = &rcp.Spec.ServerConfig
-	}
+	rcpServerConfig := rcp.Spec.ServerConfig.DeepCopy()
+
+	// The etcd maintenance and member health thresholds are applied to the running etcd members, they do not
+	// require a rollout.
+	machineServerConfig.Etcd.Maintenance = nil
+	rcpServerConfig.Etcd.Maintenance = nil
+	machineServerConfig.Etcd.MemberHealth = nil
+	rcpServerConfig.Etcd.MemberHealth = nil
 
 	// Compare and return
 	match := reflect.DeepEqual(machineServerConfig, rcpServerConfig)
@@ -214,6 +216,7 @@ func matchesTemplateClonedFrom(ctx context.Context,
 }
 
 // matchesKubernetesVersion returns a filter to find all machines that match a given Kubernetes or RKE2 version.
+// Machines upgraded in place are matched on the version their Node was upgraded to.
 func matchesKubernetesOrRKE2Version(ctx context.Context, rke2Version string) func(*clusterv1.Machine) bool {
 	return func(machine *clusterv1.Machine) bool {
 		logger := log.FromContext(ctx)
@@ -225,17 +228,18 @@ func matchesKubernetesOrRKE2Version(ctx context.Context, rke2Version string) fun
 
 		logger = logger.WithValues("Machine", machine.Name)
 
-		if machine.Spec.Version == nil {
+		version := machineVersion(machine)
+		if version == nil {
 			logger.V(5).Info("Machine is missing k8s version. Needs rollout.")
 
 			return false
 		}
 
-		if bsutil.IsRKE2Version(*machine.Spec.Version) {
-			match := bsutil.CompareVersions(*machine.Spec.Version, rke2Version)
+		if bsutil.IsRKE2Version(*version) {
+			match := bsutil.CompareVersions(*version, rke2Version)
 			if !match {
 				logger.V(5).Info(fmt.Sprintf("Machine RKE2 version '%s' does not match desired version '%s'. Needs rollout.",
-					*machine.Spec.Version,
+					*version,
 					rke2Version),
 				)
 			}
@@ -248,10 +252,10 @@ func matchesKubernetesOrRKE2Version(ctx context.Context, rke2Version string) fun
 			return true
 		}
 
-		match := bsutil.CompareVersions(*machine.Spec.Version, rcpKubeVersion)
+		match := bsutil.CompareVersions(*version, rcpKubeVersion)
 		if !match {
 			logger.V(5).Info(fmt.Sprintf("Machine k8s version '%s' does not match desired version '%s'. Needs rollout.",
-				*machine.Spec.Version,
+				*version,
 				rcpKubeVersion),
 			)
 		}
@@ -259,3 +263,99 @@ func matchesKubernetesOrRKE2Version(ctx context.Context, rke2Version string) fun
 		return match
 	}
 }
+
+// matchesCertificateAuthoritiesRotation returns a filter to find all machines running with the certificate authorities
+// being rolled out, if any.
+func matchesCertificateAuthoritiesRotation(rcp *controlplanev1.RKE2ControlPlane) collections.Func {
+	return func(machine *clusterv1.Machine) bool {
+		if machine == nil || rcp.Spec.CertificateRotation == nil || rcp.Status.CertificateRotation == nil ||
+			rcp.Status.CertificateRotation.CertificateAuthoritiesPhase != controlplanev1.CertificateAuthoritiesRotationPhaseRollingOut {
+			return true
+		}
+
+		return machine.Annotations[controlplanev1.CertificateAuthoritiesRotationAnnotation] ==
+			rcp.Spec.CertificateRotation.CertificateAuthorities
+	}
+}
+
+// matchesCertificatesExpiry returns a filter to find all machines whose Node serving certificates do not expire
+// within the days configured in the RCP rolloutBefore, if any.
+func matchesCertificatesExpiry(rcp *controlplanev1.RKE2ControlPlane, reconciliationTime time.Time) collections.Func {
+	return func(machine *clusterv1.Machine) bool {
+		if machine == nil || rcp.Spec.RolloutBefore == nil || rcp.Spec.RolloutBefore.CertificatesExpiryDays == nil {
+			return true
+		}
+
+		expiry := MachineCertificatesExpiry(machine)
+		if expiry == nil {
+			return true
+		}
+
+		days := time.Duration(*rcp.Spec.RolloutBefore.CertificatesExpiryDays) * 24 * time.Hour
+
+		return expiry.After(reconciliationTime.Add(days))
+	}
+}
+
+// matchesMaxAge returns a filter to find all machines younger than the max age configured in the RCP
+// rolloutBefore, if any.
+func matchesMaxAge(rcp *controlplanev1.RKE2ControlPlane, reconciliationTime time.Time) collections.Func {
+	return func(machine *clusterv1.Machine) bool {
+		if machine == nil || rcp.Spec.RolloutBefore == nil || rcp.Spec.RolloutBefore.MaxAge == nil {
+			return true
+		}
+
+		return machine.CreationTimestamp.Add(rcp.Spec.RolloutBefore.MaxAge.Duration).After(reconciliationTime)
+	}
+}
+
+// matchesConfigDrift returns a filter to find all machines whose Node configuration has not drifted from their
+// specification, when rolling out drifted machines is requested in the RCP rolloutBefore.
+func matchesConfigDrift(rcp *controlplanev1.RKE2ControlPlane) collections.Func {
+	return func(machine *clusterv1.Machine) bool {
+		if machine == nil || rcp.Spec.RolloutBefore == nil || !rcp.Spec.RolloutBefore.ConfigDrifted {
+			return true
+		}
+
+		return !conditions.IsTrue(machine, controlplanev1.ConfigDriftedCondition)
+	}
+}
+
+// MachineCertificatesExpiry returns the earliest expiry date of the serving certificates of the Node of a Machine,
+// as recorded in its annotations, or nil if unknown. The Cluster API certificates expiry annotation, which may be
+// set by users, is taken into account as well.
+func MachineCertificatesExpiry(machine *clusterv1.Machine) *time.Time {
+	var earliest *time.Time
+
+	for _, annotation := range []string{
+		controlplanev1.APIServerCertificateExpiryAnnotation,
+		controlplanev1.EtcdCertificateExpiryAnnotation,
+		controlplanev1.KubeletCertificateExpiryAnnotation,
+		clusterv1.MachineCertificatesExpiryDateAnnotation,
+	} {
+		value, ok := machine.Annotations[annotation]
+		if !ok {
+			continue
+		}
+
+		expiry, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			continue
+		}
+
+		if earliest == nil || expiry.Before(*earliest) {
+			earliest = &expiry
+		}
+	}
+
+	return earliest
+}
+
+// machineVersion returns the version of a Machine, taking into account in-place upgrades of its Node.
+func machineVersion(machine *clusterv1.Machine) *string {
+	if version := machine.Annotations[controlplanev1.InPlaceUpgradedVersionAnnotation]; version != "" {
+		return &version
+	}
+
+	return machine.Spec.Version
+}
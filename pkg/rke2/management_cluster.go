@@ -39,6 +39,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/collections"
 
 	"github.com/rancher/cluster-api-provider-rke2/pkg/secret"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/tracing"
 )
 
 const (
@@ -113,7 +114,12 @@ const (
 
 // GetWorkloadCluster builds a cluster object.
 // The cluster comes with an etcd client generator to connect to any etcd pod living on a managed machine.
-func (m *Management) GetWorkloadCluster(ctx context.Context, clusterKey ctrlclient.ObjectKey) (WorkloadCluster, error) {
+// The calls to the API server of the workload cluster are traced.
+func (m *Management) GetWorkloadCluster(ctx context.Context, clusterKey ctrlclient.ObjectKey) (_ WorkloadCluster, rerr error) {
+	ctx, span := tracing.Start(ctx, "GetWorkloadCluster",
+		tracing.NamespaceKey.String(clusterKey.Namespace), tracing.ClusterKey.String(clusterKey.Name))
+	defer tracing.End(span, &rerr)
+
 	restConfig, err := remote.RESTConfig(ctx, RKE2ControlPlaneControllerName, m.Client, clusterKey)
 	if err != nil {
 		return nil, err
@@ -126,7 +132,7 @@ func (m *Management) GetWorkloadCluster(ctx context.Context, clusterKey ctrlclie
 		return nil, &RemoteClusterConnectionError{Name: clusterKey.String(), Err: err}
 	}
 
-	return m.NewWorkload(ctx, c, restConfig, clusterKey)
+	return m.NewWorkload(ctx, tracing.WrapClient(c, "workload"), restConfig, clusterKey)
 }
 
 func (m *Management) getEtcdCAKeyPair(ctx context.Context, cl ctrlclient.Reader, clusterKey ctrlclient.ObjectKey) (*certs.KeyPair, error) {
@@ -62,7 +62,8 @@ type WorkloadCluster interface {
 
 	ClusterStatus(ctx context.Context) ClusterStatus
 	UpdateAgentConditions(controlPlane *ControlPlane)
-	UpdateEtcdConditions(controlPlane *ControlPlane)
+	UpdateEtcdConditions(ctx context.Context, controlPlane *ControlPlane)
+	UpdateConfigDriftConditions(ctx context.Context, controlPlane *ControlPlane)
 	// Upgrade related tasks.
 
 	//	AllowBootstrapTokensToGetNodes(ctx context.Context) error
@@ -72,9 +73,42 @@ type WorkloadCluster interface {
 	IsEtcdMemberSafelyRemovedForMachine(ctx context.Context, machine *clusterv1.Machine) (bool, error)
 	ForwardEtcdLeadership(ctx context.Context, machine *clusterv1.Machine, leaderCandidate *clusterv1.Machine) error
 	EtcdMembers(ctx context.Context) ([]string, error)
+	RemoveOrphanedEtcdMember(ctx context.Context, nodeName string) (bool, error)
+
+	// Etcd maintenance tasks.
+	EtcdDatabaseStatus(ctx context.Context, machine *clusterv1.Machine) (*EtcdDatabaseStatus, error)
+	DefragmentEtcdMember(ctx context.Context, machine *clusterv1.Machine) error
+	DisarmEtcdMemberAlarm(ctx context.Context, machine *clusterv1.Machine, alarm etcd.AlarmType) error
+
+	// Etcd snapshot tasks.
+	SaveEtcdSnapshot(ctx context.Context, machine *clusterv1.Machine, jobName, snapshotName, image string) (controlplanev1.EtcdSnapshotPhase, error)
+	CleanupEtcdSnapshot(ctx context.Context, jobName string) error
+	EtcdSnapshotFiles(ctx context.Context) ([]controlplanev1.EtcdSnapshotFile, error)
+
+	// Etcd restore tasks.
+	ResetEtcdFromSnapshot(ctx context.Context, machine *clusterv1.Machine, jobName, snapshotPath, image string) (bool, error)
+	CleanupEtcdRestore(ctx context.Context, jobName string) error
+
+	// Certificate rotation tasks.
+	RotateCertificates(ctx context.Context, machine *clusterv1.Machine, jobName, image string) (bool, error)
+	RotateCertificateAuthorities(ctx context.Context, machine *clusterv1.Machine, jobName, image string, files map[string][]byte) (bool, error)
+	CleanupCertificateRotation(ctx context.Context, jobName string) error
+	CertificatesExpiry(ctx context.Context, machine *clusterv1.Machine, embeddedEtcd bool) (map[string]time.Time, error)
+
+	// Secrets encryption rotation tasks.
+	RunSecretsEncryptionStage(ctx context.Context, machine *clusterv1.Machine, jobName, image string,
+		stage controlplanev1.SecretsEncryptionRotationStage) (bool, error)
+	RestartServer(ctx context.Context, machine *clusterv1.Machine, jobName, image string) (bool, error)
+	CleanupSecretsEncryptionRotation(ctx context.Context, jobName string) error
+
+	// In-place upgrade tasks.
+	EnsureInPlaceUpgradePlan(ctx context.Context, rcp *controlplanev1.RKE2ControlPlane) error
+	DeleteInPlaceUpgradePlan(ctx context.Context, rcp *controlplanev1.RKE2ControlPlane) error
+	UpgradeMachineInPlace(ctx context.Context, machine *clusterv1.Machine, version string) (bool, error)
 
 	// Common tasks.
 	ApplyLabelOnNode(ctx context.Context, machine *clusterv1.Machine, label, value string) error
+	IsKubeVIPRunningOnMachine(ctx context.Context, machine *clusterv1.Machine) (bool, error)
 }
 
 // Workload defines operations on workload clusters.
@@ -84,6 +118,9 @@ type Workload struct {
 	Nodes               map[string]*corev1.Node
 	nodePatchHelpers    map[string]*patch.Helper
 	etcdClientGenerator etcd.ClientFor
+
+	// servingCertificateProber returns the serving certificate of a port of a pod in the kube-system namespace.
+	servingCertificateProber func(ctx context.Context, podName string, port int) (*x509.Certificate, error)
 }
 
 // NewWorkload is creating a new ClusterWorkload instance.
@@ -102,6 +139,8 @@ func (m *Management) NewWorkload(
 	restConfig = rest.CopyConfig(restConfig)
 	restConfig.Timeout = remoteEtcdTimeout
 
+	workload.servingCertificateProber = newServingCertificateProber(restConfig)
+
 	// Retrieves the etcd CA key Pair
 	etcdKeyPair, err := m.getEtcdCAKeyPair(ctx, m.SecretCachingClient, clusterKey)
 	if ctrlclient.IgnoreNotFound(err) != nil {
@@ -475,8 +514,8 @@ func (w *Workload) ApplyLabelOnNode(ctx context.Context, machine *clusterv1.Mach
 // UpdateEtcdConditions is responsible for updating machine conditions reflecting the status of all the etcd members.
 // This operation is best effort, in the sense that in case of problems in retrieving member status, it sets
 // the condition to Unknown state without returning any error.
-func (w *Workload) UpdateEtcdConditions(controlPlane *ControlPlane) {
-	w.updateManagedEtcdConditions(controlPlane)
+func (w *Workload) UpdateEtcdConditions(ctx context.Context, controlPlane *ControlPlane) {
+	w.updateManagedEtcdConditions(ctx, controlPlane)
 }
 
 type aggregateFromMachinesToRCPInput struct {
@@ -603,9 +642,12 @@ func aggregateFromMachinesToRCP(input aggregateFromMachinesToRCPInput) {
 	}
 }
 
-func (w *Workload) updateManagedEtcdConditions(controlPlane *ControlPlane) {
+func (w *Workload) updateManagedEtcdConditions(ctx context.Context, controlPlane *ControlPlane) {
 	// NOTE: This methods uses control plane nodes only to get in contact with etcd but then it relies on etcd
 	// as ultimate source of truth for the list of members and for their health.
+	members := []controlplanev1.EtcdMemberStatus{}
+	memberMachines := map[string]*clusterv1.Machine{}
+
 	for k := range w.Nodes {
 		node := w.Nodes[k]
 
@@ -635,6 +677,47 @@ func (w *Workload) updateManagedEtcdConditions(controlPlane *ControlPlane) {
 			continue
 		}
 
+		// Without etcd certificates, or with an external datastore, the members can not be inspected.
+		if w.etcdClientGenerator == nil || !controlPlane.UsesEmbeddedEtcd() {
+			conditions.MarkTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition)
+
+			continue
+		}
+
+		member, err := w.etcdMemberStatus(ctx, node.Name)
+		if err != nil {
+			conditions.MarkUnknown(machine, controlplanev1.MachineEtcdMemberHealthyCondition,
+				controlplanev1.EtcdMemberInspectionFailedReason, "Failed to read the etcd member status: %v", err)
+
+			continue
+		}
+
+		member.MachineName = machine.Name
+		members = append(members, *member)
+		memberMachines[machine.Name] = machine
+	}
+
+	if w.etcdClientGenerator == nil || !controlPlane.UsesEmbeddedEtcd() {
+		controlPlane.RCP.Status.Etcd = nil
+
+		return
+	}
+
+	setEtcdRaftIndexLag(members)
+
+	for i := range members {
+		machine := memberMachines[members[i].MachineName]
+
+		reason, message, severity := etcdMemberUnhealthy(&members[i], controlPlane.RCP.Spec.ServerConfig.Etcd.MemberHealth)
+		if message != "" {
+			conditions.MarkFalse(machine, controlplanev1.MachineEtcdMemberHealthyCondition,
+				reason, severity, "%s", message)
+
+			continue
+		}
+
 		conditions.MarkTrue(machine, controlplanev1.MachineEtcdMemberHealthyCondition)
 	}
+
+	updateEtcdStatus(controlPlane.RCP, members, time.Now())
 }
@@ -0,0 +1,297 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/proxy"
+)
+
+const (
+	// DefaultCertificateRotationImage is the image used to run the certificate rotation Jobs when none is specified.
+	DefaultCertificateRotationImage = DefaultEtcdSnapshotImage
+
+	// certificateRotationJobLabel is the label set on the certificate rotation Jobs, its value is the kind of rotation.
+	certificateRotationJobLabel = "rke2.controlplane.cluster.x-k8s.io/certificate-rotation"
+
+	// certificateAuthoritiesMountPath is where the new certificate authorities are mounted in the rotation Job.
+	certificateAuthoritiesMountPath = "/certificate-authorities"
+
+	// leafCertificatesRotationScript rotates the leaf certificates with RKE2 stopped, and always restarts it.
+	leafCertificatesRotationScript = `export ` + hostPathEnv + `; systemctl stop rke2-server; rke2 certificate rotate; rc=$?; ` +
+		`systemctl start rke2-server; exit $rc`
+
+	// certificateAuthoritiesRotationScript copies the current certificate authorities of the node, overrides them
+	// with the mounted ones and installs them with rke2 certificate rotate-ca.
+	certificateAuthoritiesRotationScript = `set -e
+tls=/host/var/lib/rancher/rke2/server/tls
+dir="$tls-rotation"
+rm -rf "$dir"
+mkdir -p "$dir/etcd"
+cp "$tls"/*-ca.crt "$tls"/*-ca.key "$tls"/service.key "$dir"/
+cp "$tls"/etcd/*-ca.crt "$tls"/etcd/*-ca.key "$dir"/etcd/
+cp -RL ` + certificateAuthoritiesMountPath + `/. "$dir"/
+exec chroot /host /bin/sh -c '` + hostPathEnv + ` exec rke2 certificate rotate-ca --path=/var/lib/rancher/rke2/server/tls-rotation'`
+)
+
+// servingCertificatePorts are the ports serving the certificates whose expiry is recorded on the control plane
+// Machines, by annotation.
+var servingCertificatePorts = map[string]int{
+	controlplanev1.APIServerCertificateExpiryAnnotation: 6443,
+	controlplanev1.EtcdCertificateExpiryAnnotation:      2379,
+	controlplanev1.KubeletCertificateExpiryAnnotation:   10250,
+}
+
+// ErrCertificateRotationFailed is returned when a certificate rotation Job has failed. The Job is kept for troubleshooting.
+var ErrCertificateRotationFailed = errors.New("certificate rotation failed")
+
+// RotateCertificates runs a Job rotating the RKE2 leaf certificates on the Node of the given Machine, unless it
+// already exists, and returns true once the rotation has completed.
+func (w *Workload) RotateCertificates(ctx context.Context, machine *clusterv1.Machine, jobName, image string) (bool, error) {
+	if machine == nil {
+		return false, errors.New("machine is nil")
+	}
+
+	if machine.Status.NodeRef == nil {
+		return false, fmt.Errorf("machine %s has no node ref", machine.Name)
+	}
+
+	if image == "" {
+		image = DefaultCertificateRotationImage
+	}
+
+	job := newHostJob(jobName, machine.Status.NodeRef.Name, image, "certificate-rotation",
+		map[string]string{certificateRotationJobLabel: "leaf"},
+		[]string{"chroot", "/host", "/bin/sh", "-c", leafCertificatesRotationScript})
+
+	return w.runCertificateRotationJob(ctx, job)
+}
+
+// RotateCertificateAuthorities runs a Job installing the given certificate authorities with `rke2 certificate rotate-ca`
+// on the Node of the given Machine, unless it already exists, and returns true once the rotation has completed.
+// The files are keyed by their path relative to the RKE2 server TLS directory, e.g. "server-ca.crt" or "etcd/peer-ca.key".
+func (w *Workload) RotateCertificateAuthorities(
+	ctx context.Context,
+	machine *clusterv1.Machine,
+	jobName, image string,
+	files map[string][]byte,
+) (bool, error) {
+	if machine == nil {
+		return false, errors.New("machine is nil")
+	}
+
+	if machine.Status.NodeRef == nil {
+		return false, fmt.Errorf("machine %s has no node ref", machine.Name)
+	}
+
+	if image == "" {
+		image = DefaultCertificateRotationImage
+	}
+
+	// Secret keys can not contain slashes, the files are mapped back to their path when mounted.
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: metav1.NamespaceSystem,
+		},
+		Data: map[string][]byte{},
+	}
+	items := []corev1.KeyToPath{}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		key := strings.ReplaceAll(path, "/", "-")
+		secret.Data[key] = files[path]
+		items = append(items, corev1.KeyToPath{Key: key, Path: path})
+	}
+
+	if err := w.Create(ctx, secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return false, fmt.Errorf("creating certificate authorities secret %s: %w", jobName, err)
+	}
+
+	job := newHostJob(jobName, machine.Status.NodeRef.Name, image, "certificate-rotation",
+		map[string]string{certificateRotationJobLabel: "certificate-authorities"},
+		[]string{"/bin/sh", "-c", certificateAuthoritiesRotationScript})
+
+	podSpec := &job.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "certificate-authorities",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: jobName, Items: items},
+		},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name: "certificate-authorities", MountPath: certificateAuthoritiesMountPath, ReadOnly: true,
+	})
+
+	return w.runCertificateRotationJob(ctx, job)
+}
+
+// CleanupCertificateRotation deletes the Job, and the Secret if any, used to rotate certificates.
+func (w *Workload) CleanupCertificateRotation(ctx context.Context, jobName string) error {
+	if err := w.deleteHostJob(ctx, jobName); err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: metav1.NamespaceSystem,
+		},
+	}
+
+	if err := w.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting certificate authorities secret %s: %w", jobName, err)
+	}
+
+	return nil
+}
+
+func (w *Workload) runCertificateRotationJob(ctx context.Context, job *batchv1.Job) (bool, error) {
+	finished, succeeded, err := w.runHostJob(ctx, job)
+	if err != nil {
+		return false, err
+	}
+
+	if finished && !succeeded {
+		return false, fmt.Errorf("%w: job %s/%s failed", ErrCertificateRotationFailed, job.Namespace, job.Name)
+	}
+
+	if !finished {
+		log.FromContext(ctx).V(3).Info("Waiting for certificate rotation job", "job", ctrlclient.ObjectKeyFromObject(job))
+	}
+
+	return finished, nil
+}
+
+// CertificatesExpiry returns the expiry date of the kube-apiserver, kubelet and, with an embedded etcd, etcd serving
+// certificates of the Node of the given Machine, keyed by the Machine annotation recording them.
+// The certificates are read from TLS connections port-forwarded through the kube-apiserver pod of the Node,
+// which runs in the host network.
+func (w *Workload) CertificatesExpiry(
+	ctx context.Context,
+	machine *clusterv1.Machine,
+	embeddedEtcd bool,
+) (map[string]time.Time, error) {
+	if machine == nil {
+		return nil, errors.New("machine is nil")
+	}
+
+	if machine.Status.NodeRef == nil {
+		return nil, fmt.Errorf("machine %s has no node ref", machine.Name)
+	}
+
+	if w.servingCertificateProber == nil {
+		return nil, errors.New("workload cluster can not be probed for serving certificates")
+	}
+
+	podName := "kube-apiserver-" + machine.Status.NodeRef.Name
+	expiries := map[string]time.Time{}
+
+	for annotation, port := range servingCertificatePorts {
+		if annotation == controlplanev1.EtcdCertificateExpiryAnnotation && !embeddedEtcd {
+			continue
+		}
+
+		certificate, err := w.servingCertificateProber(ctx, podName, port)
+		if err != nil {
+			return nil, fmt.Errorf("getting serving certificate of port %d of pod %s: %w", port, podName, err)
+		}
+
+		expiries[annotation] = certificate.NotAfter
+	}
+
+	return expiries, nil
+}
+
+// newServingCertificateProber returns a function reading the serving certificate of a port of a pod in
+// the kube-system namespace, through the API server port-forwarding.
+func newServingCertificateProber(restConfig *rest.Config) func(ctx context.Context, podName string, port int) (*x509.Certificate, error) {
+	return func(ctx context.Context, podName string, port int) (*x509.Certificate, error) {
+		dialer, err := proxy.NewDialer(proxy.Proxy{
+			Kind:       "pods",
+			Namespace:  metav1.NamespaceSystem,
+			KubeConfig: rest.CopyConfig(restConfig),
+			Port:       port,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating dialer: %w", err)
+		}
+
+		conn, err := dialer.DialContext(ctx, "tcp", podName)
+		if err != nil {
+			return nil, fmt.Errorf("dialing: %w", err)
+		}
+
+		defer conn.Close()
+
+		var certificate *x509.Certificate
+
+		tlsConn := tls.Client(conn, &tls.Config{
+			// Only the expiry of the serving certificate is read, nothing is sent over the connection.
+			InsecureSkipVerify: true, //nolint:gosec
+			MinVersion:         tls.VersionTLS12,
+			VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 {
+					return errors.New("no serving certificate")
+				}
+
+				parsed, err := x509.ParseCertificate(rawCerts[0])
+				if err != nil {
+					return err
+				}
+
+				certificate = parsed
+
+				return nil
+			},
+		})
+
+		// The handshake fails when the server requires a client certificate, as etcd does, but the serving
+		// certificate has been received by then.
+		if err := tlsConn.HandshakeContext(ctx); err != nil && certificate == nil {
+			return nil, fmt.Errorf("TLS handshake: %w", err)
+		}
+
+		return certificate, nil
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+func TestRotateCertificateAuthorities(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node1"},
+		},
+	}
+
+	w := &Workload{Client: fake.NewClientBuilder().Build()}
+
+	done, err := w.RotateCertificateAuthorities(ctx, machine, "rke2-ca-rotation", "", map[string][]byte{
+		"server-ca.crt":    []byte("cert"),
+		"etcd/peer-ca.key": []byte("key"),
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(done).To(BeFalse())
+
+	secret := &corev1.Secret{}
+	g.Expect(w.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "rke2-ca-rotation"}, secret)).To(Succeed())
+	g.Expect(secret.Data).To(HaveKeyWithValue("etcd-peer-ca.key", []byte("key")))
+
+	job := &batchv1.Job{}
+	g.Expect(w.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "rke2-ca-rotation"}, job)).To(Succeed())
+	g.Expect(job.Spec.Template.Spec.NodeName).To(Equal("node1"))
+	g.Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal(DefaultCertificateRotationImage))
+	g.Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("VolumeSource.Secret.Items",
+		ContainElement(corev1.KeyToPath{Key: "etcd-peer-ca.key", Path: "etcd/peer-ca.key"}))))
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	g.Expect(w.Status().Update(ctx, job)).To(Succeed())
+
+	_, err = w.RotateCertificateAuthorities(ctx, machine, "rke2-ca-rotation", "", nil)
+	g.Expect(err).To(MatchError(ErrCertificateRotationFailed))
+
+	g.Expect(w.CleanupCertificateRotation(ctx, "rke2-ca-rotation")).To(Succeed())
+	g.Expect(w.Get(ctx, client.ObjectKeyFromObject(secret), secret)).ToNot(Succeed())
+	g.Expect(w.Get(ctx, client.ObjectKeyFromObject(job), job)).ToNot(Succeed())
+}
+
+func TestMatchesCertificateAuthoritiesRotation(t *testing.T) {
+	g := NewWithT(t)
+
+	rcp := &controlplanev1.RKE2ControlPlane{
+		Spec: controlplanev1.RKE2ControlPlaneSpec{
+			CertificateRotation: &controlplanev1.CertificateRotation{CertificateAuthorities: "2"},
+		},
+		Status: controlplanev1.RKE2ControlPlaneStatus{
+			CertificateRotation: &controlplanev1.CertificateRotationStatus{
+				CertificateAuthorities:      "1",
+				CertificateAuthoritiesPhase: controlplanev1.CertificateAuthoritiesRotationPhaseRotating,
+			},
+		},
+	}
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "machine",
+			Annotations: map[string]string{controlplanev1.CertificateAuthoritiesRotationAnnotation: "1"},
+		},
+	}
+
+	g.Expect(matchesCertificateAuthoritiesRotation(rcp)(machine)).To(BeTrue())
+
+	rcp.Status.CertificateRotation.CertificateAuthoritiesPhase = controlplanev1.CertificateAuthoritiesRotationPhaseRollingOut
+	g.Expect(matchesCertificateAuthoritiesRotation(rcp)(machine)).To(BeFalse())
+
+	machine.Annotations[controlplanev1.CertificateAuthoritiesRotationAnnotation] = "2"
+	g.Expect(matchesCertificateAuthoritiesRotation(rcp)(machine)).To(BeTrue())
+}
+
+func TestCertificatesExpiry(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node1"},
+		},
+	}
+
+	notAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	probed := map[int]string{}
+
+	w := &Workload{
+		servingCertificateProber: func(_ context.Context, podName string, port int) (*x509.Certificate, error) {
+			probed[port] = podName
+
+			return &x509.Certificate{NotAfter: notAfter.Add(time.Duration(port) * time.Hour)}, nil
+		},
+	}
+
+	expiries, err := w.CertificatesExpiry(ctx, machine, false)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(expiries).To(HaveLen(2))
+	g.Expect(expiries).To(HaveKeyWithValue(controlplanev1.APIServerCertificateExpiryAnnotation, notAfter.Add(6443*time.Hour)))
+	g.Expect(expiries).To(HaveKeyWithValue(controlplanev1.KubeletCertificateExpiryAnnotation, notAfter.Add(10250*time.Hour)))
+	g.Expect(probed).To(HaveKeyWithValue(6443, "kube-apiserver-node1"))
+
+	expiries, err = w.CertificatesExpiry(ctx, machine, true)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(expiries).To(HaveKeyWithValue(controlplanev1.EtcdCertificateExpiryAnnotation, notAfter.Add(2379*time.Hour)))
+}
+
+func TestMatchesCertificatesExpiry(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rcp := &controlplanev1.RKE2ControlPlane{}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "machine",
+			Annotations: map[string]string{
+				controlplanev1.APIServerCertificateExpiryAnnotation: "2030-03-01T00:00:00Z",
+				controlplanev1.KubeletCertificateExpiryAnnotation:   "2030-01-20T00:00:00Z",
+			},
+		},
+	}
+
+	g.Expect(matchesCertificatesExpiry(rcp, now)(machine)).To(BeTrue())
+
+	rcp.Spec.RolloutBefore = &controlplanev1.RolloutBefore{CertificatesExpiryDays: ptr.To(int32(30))}
+	g.Expect(matchesCertificatesExpiry(rcp, now)(machine)).To(BeFalse())
+
+	rcp.Spec.RolloutBefore.CertificatesExpiryDays = ptr.To(int32(7))
+	g.Expect(matchesCertificatesExpiry(rcp, now)(machine)).To(BeTrue())
+
+	machine.Annotations[clusterv1.MachineCertificatesExpiryDateAnnotation] = "2030-01-05T00:00:00Z"
+	g.Expect(matchesCertificatesExpiry(rcp, now)(machine)).To(BeFalse())
+}
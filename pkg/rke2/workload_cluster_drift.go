@@ -0,0 +1,432 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+const (
+	// nodeArgsAnnotation is the annotation RKE2 sets on its Node with the arguments it was started with, including
+	// the ones read from its configuration file. The values of sensitive arguments are redacted.
+	nodeArgsAnnotation = "rke2.io/node-args"
+
+	// staticPodTierLabel is the label RKE2 sets on the static pods of the control plane components.
+	staticPodTierLabel = "tier"
+)
+
+// staticPodComponents are the control plane components run by RKE2 as static pods whose arguments are inspected.
+// The etcd arguments are written to a configuration file rather than passed on the command line.
+var staticPodComponents = []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler"}
+
+// expectedNodeConfig is the RKE2 configuration expected on the Node of a Machine. It only holds the arguments
+// rendered from the user specification, arguments added by RKE2 or rendered from referenced objects are ignored.
+type expectedNodeConfig struct {
+	// values are the arguments with a single value, which are only compared when set.
+	values map[string]string
+	// booleans are the boolean arguments, which are always compared.
+	booleans map[string]bool
+	// lists are the arguments whose values are compared as a set.
+	lists map[string][]string
+	// componentArgs are the extra arguments of the components, keyed by RKE2 argument. Only the component arguments
+	// listed are compared, by name.
+	componentArgs map[string][]string
+	// staticPods are the extra arguments and image of the components run as static pods, keyed by component.
+	staticPods map[string]*bootstrapv1.ComponentConfig
+}
+
+// UpdateConfigDriftConditions is responsible for updating the ConfigDrifted condition of the control plane Machines,
+// comparing the configuration the Machines were created with to the configuration reported by their Node and
+// static pods, and aggregating it on the RKE2ControlPlane. This operation is best effort, in the sense that in case
+// of problems in retrieving the configuration of a Node, it sets the condition to Unknown state without returning
+// any error.
+func (w *Workload) UpdateConfigDriftConditions(ctx context.Context, controlPlane *ControlPlane) {
+	machines := controlPlane.Machines.Filter(collections.ActiveMachines, collections.HasNode())
+
+	pods := &corev1.PodList{}
+	if err := w.List(ctx, pods,
+		ctrlclient.InNamespace(metav1.NamespaceSystem),
+		ctrlclient.MatchingLabels{staticPodTierLabel: "control-plane"},
+	); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list control plane static pods")
+
+		for _, machine := range machines {
+			conditions.MarkUnknown(machine, controlplanev1.ConfigDriftedCondition,
+				controlplanev1.ConfigDriftInspectionFailedReason, "Failed to list control plane static pods")
+		}
+
+		return
+	}
+
+	staticPods := map[string]*corev1.Pod{}
+	for i := range pods.Items {
+		staticPods[pods.Items[i].Name] = &pods.Items[i]
+	}
+
+	drifted := []string{}
+
+	for _, machine := range machines.SortedByCreationTimestamp() {
+		node, found := w.Nodes[machine.Status.NodeRef.Name]
+		if !found {
+			// Missing nodes are reported by the agent conditions.
+			continue
+		}
+
+		expected, err := machineExpectedNodeConfig(machine, controlPlane.Rke2Configs[machine.Name])
+		if err != nil {
+			conditions.MarkUnknown(machine, controlplanev1.ConfigDriftedCondition,
+				controlplanev1.ConfigDriftInspectionFailedReason, "%s", err.Error())
+
+			continue
+		}
+
+		drifts, err := nodeConfigDrifts(expected, node, staticPods)
+		if err != nil {
+			conditions.MarkUnknown(machine, controlplanev1.ConfigDriftedCondition,
+				controlplanev1.ConfigDriftInspectionFailedReason, "%s", err.Error())
+
+			continue
+		}
+
+		if len(drifts) == 0 {
+			conditions.MarkFalseWithNegativePolarity(machine, controlplanev1.ConfigDriftedCondition)
+
+			continue
+		}
+
+		drifted = append(drifted, machine.Name)
+
+		conditions.MarkTrueWithNegativePolarity(machine, controlplanev1.ConfigDriftedCondition,
+			controlplanev1.MachineConfigDriftedReason, clusterv1.ConditionSeverityWarning,
+			"Node %s configuration has drifted: %s", node.Name, strings.Join(drifts, "; "))
+	}
+
+	if len(drifted) == 0 {
+		conditions.MarkFalseWithNegativePolarity(controlPlane.RCP, controlplanev1.ConfigDriftedCondition)
+
+		return
+	}
+
+	conditions.MarkTrueWithNegativePolarity(controlPlane.RCP, controlplanev1.ConfigDriftedCondition,
+		controlplanev1.MachineConfigDriftedReason, clusterv1.ConditionSeverityWarning,
+		"Node configuration of machines %s has drifted from their specification", strings.Join(drifted, ", "))
+}
+
+// machineExpectedNodeConfig returns the RKE2 configuration expected on the Node of a Machine, from the server
+// configuration recorded in its annotations and the agent configuration of its RKE2Config.
+func machineExpectedNodeConfig(machine *clusterv1.Machine, rke2Config *bootstrapv1.RKE2Config) (*expectedNodeConfig, error) {
+	serverConfigValue, ok := machine.Annotations[controlplanev1.RKE2ServerConfigurationAnnotation]
+	if !ok {
+		return nil, fmt.Errorf("machine is missing the %s annotation", controlplanev1.RKE2ServerConfigurationAnnotation)
+	}
+
+	serverConfig := &controlplanev1.RKE2ServerConfig{}
+	if err := json.Unmarshal([]byte(serverConfigValue), serverConfig); err != nil {
+		return nil, fmt.Errorf("unmarshalling the %s annotation: %w", controlplanev1.RKE2ServerConfigurationAnnotation, err)
+	}
+
+	if rke2Config == nil {
+		return nil, fmt.Errorf("RKE2Config of machine %s not found", machine.Name)
+	}
+
+	return newExpectedNodeConfig(serverConfig, &rke2Config.Spec.AgentConfig), nil
+}
+
+// newExpectedNodeConfig returns the RKE2 configuration rendered from a server and agent configuration.
+func newExpectedNodeConfig(
+	serverConfig *controlplanev1.RKE2ServerConfig,
+	agentConfig *bootstrapv1.RKE2AgentConfig,
+) *expectedNodeConfig {
+	expected := &expectedNodeConfig{
+		values: map[string]string{},
+		booleans: map[string]bool{
+			"embedded-registry":        serverConfig.EmbeddedRegistry,
+			"etcd-expose-metrics":      serverConfig.Etcd.ExposeMetrics,
+			"disable-kube-proxy":       false,
+			"disable-scheduler":        false,
+			"disable-cloud-controller": false,
+			"selinux":                  agentConfig.EnableContainerdSElinux,
+			"protect-kernel-defaults":  agentConfig.ProtectKernelDefaults,
+		},
+		lists:         map[string][]string{},
+		componentArgs: map[string][]string{},
+		staticPods:    map[string]*bootstrapv1.ComponentConfig{},
+	}
+
+	for name, value := range map[string]string{
+		"cluster-dns":             serverConfig.ClusterDNS,
+		"cluster-domain":          serverConfig.ClusterDomain,
+		"service-node-port-range": serverConfig.ServiceNodePortRange,
+		"cloud-provider-name":     serverConfig.CloudProviderName,
+		"data-dir":                agentConfig.DataDir,
+		"kubelet-path":            agentConfig.KubeletPath,
+		"profile":                 string(agentConfig.CISProfile),
+		"runtime-image":           agentConfig.RuntimeImage,
+		"snapshotter":             agentConfig.Snapshotter,
+	} {
+		if value != "" {
+			expected.values[name] = value
+		}
+	}
+
+	switch {
+	case serverConfig.CNIMultusEnable:
+		expected.lists["cni"] = []string{"multus", string(serverConfig.CNI)}
+	case serverConfig.CNI != "":
+		expected.lists["cni"] = []string{string(serverConfig.CNI)}
+	}
+
+	expected.lists["disable"] = []string{}
+	for _, plugin := range serverConfig.DisableComponents.PluginComponents {
+		expected.lists["disable"] = append(expected.lists["disable"], string(plugin))
+	}
+
+	for _, component := range serverConfig.DisableComponents.KubernetesComponents {
+		switch component {
+		case controlplanev1.KubeProxy:
+			expected.booleans["disable-kube-proxy"] = true
+		case controlplanev1.Scheduler:
+			expected.booleans["disable-scheduler"] = true
+		case controlplanev1.CloudController:
+			expected.booleans["disable-cloud-controller"] = true
+		}
+	}
+
+	for name, component := range map[string]*bootstrapv1.ComponentConfig{
+		"kube-apiserver":          serverConfig.KubeAPIServer,
+		"kube-controller-manager": serverConfig.KubeControllerManager,
+		"kube-scheduler":          serverConfig.KubeScheduler,
+		"etcd":                    serverConfig.Etcd.CustomConfig,
+		"kube-proxy":              agentConfig.KubeProxy,
+		"kubelet":                 agentConfig.Kubelet,
+	} {
+		if component == nil {
+			continue
+		}
+
+		expected.componentArgs[name+"-arg"] = component.ExtraArgs
+
+		if slices.Contains(staticPodComponents, name) && !(name == "kube-scheduler" && expected.booleans["disable-scheduler"]) {
+			expected.staticPods[name] = component
+		}
+	}
+
+	if agentConfig.KubeletConfiguration != nil {
+		expected.componentArgs["kubelet-arg"] = append([]string{"config=" + DefaultRKE2KubeletConfigLocation},
+			expected.componentArgs["kubelet-arg"]...)
+	}
+
+	return expected
+}
+
+// nodeConfigDrifts returns the differences between the expected RKE2 configuration and the configuration reported
+// by a Node and its static pods. Nodes without the RKE2 arguments annotation, and components whose static pod is
+// not found, are not compared.
+func nodeConfigDrifts(expected *expectedNodeConfig, node *corev1.Node, staticPods map[string]*corev1.Pod) ([]string, error) {
+	drifts := []string{}
+
+	if value, ok := node.Annotations[nodeArgsAnnotation]; ok {
+		args, err := parseNodeArgs(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the %s annotation of node %s: %w", nodeArgsAnnotation, node.Name, err)
+		}
+
+		drifts = append(drifts, nodeArgsDrifts(expected, args)...)
+	}
+
+	for _, component := range staticPodComponents {
+		config, ok := expected.staticPods[component]
+		if !ok {
+			continue
+		}
+
+		pod, ok := staticPods[component+"-"+node.Name]
+		if !ok {
+			continue
+		}
+
+		drifts = append(drifts, staticPodDrifts(component, config, pod)...)
+	}
+
+	return drifts, nil
+}
+
+// nodeArgsDrifts returns the differences between the expected RKE2 configuration and the RKE2 arguments of a Node.
+func nodeArgsDrifts(expected *expectedNodeConfig, args map[string][]string) []string {
+	drifts := []string{}
+
+	for _, name := range slices.Sorted(maps.Keys(expected.values)) {
+		if actual := lastValue(args[name]); actual != expected.values[name] {
+			drifts = append(drifts, fmt.Sprintf("%s is %q instead of %q", name, actual, expected.values[name]))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(expected.booleans)) {
+		if actual := lastValue(args[name]) == "true"; actual != expected.booleans[name] {
+			drifts = append(drifts, fmt.Sprintf("%s is %t instead of %t", name, actual, expected.booleans[name]))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(expected.lists)) {
+		actual := []string{}
+		for _, value := range args[name] {
+			actual = append(actual, strings.Split(value, ",")...)
+		}
+
+		slices.Sort(actual)
+
+		want := slices.Clone(expected.lists[name])
+		slices.Sort(want)
+
+		if !slices.Equal(slices.Compact(actual), slices.Compact(want)) {
+			drifts = append(drifts, fmt.Sprintf("%s is %q instead of %q", name, strings.Join(actual, ","), strings.Join(want, ",")))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(expected.componentArgs)) {
+		drifts = append(drifts, componentArgsDrifts(name, expected.componentArgs[name], args[name])...)
+	}
+
+	return drifts
+}
+
+// staticPodDrifts returns the differences between the expected configuration of a component and its static pod.
+func staticPodDrifts(component string, config *bootstrapv1.ComponentConfig, pod *corev1.Pod) []string {
+	var container *corev1.Container
+
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == component {
+			container = &pod.Spec.Containers[i]
+		}
+	}
+
+	if container == nil {
+		return nil
+	}
+
+	drifts := []string{}
+
+	if config.OverrideImage != "" && container.Image != config.OverrideImage {
+		drifts = append(drifts, fmt.Sprintf("%s image is %q instead of %q", component, container.Image, config.OverrideImage))
+	}
+
+	args := []string{}
+
+	for _, arg := range slices.Concat(container.Command, container.Args) {
+		if strings.HasPrefix(arg, "--") {
+			args = append(args, arg)
+		}
+	}
+
+	return append(drifts, componentArgsDrifts(component, config.ExtraArgs, args)...)
+}
+
+// componentArgsDrifts returns the differences between the expected arguments of a component and its actual
+// arguments. Arguments are compared by name, the last occurrence of an argument taking precedence.
+func componentArgsDrifts(name string, expected, actual []string) []string {
+	want := parseComponentArgs(expected)
+	got := parseComponentArgs(actual)
+	drifts := []string{}
+
+	for _, arg := range slices.Sorted(maps.Keys(want)) {
+		value, ok := got[arg]
+
+		switch {
+		case !ok:
+			drifts = append(drifts, fmt.Sprintf("%s %s is missing", name, arg))
+		case value != want[arg]:
+			drifts = append(drifts, fmt.Sprintf("%s %s is %q instead of %q", name, arg, value, want[arg]))
+		}
+	}
+
+	return drifts
+}
+
+// parseComponentArgs returns the values of a list of component arguments, in the "name=value" or "--name=value"
+// format, keyed by name.
+func parseComponentArgs(args []string) map[string]string {
+	values := map[string]string{}
+
+	for _, arg := range args {
+		name, value, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		values[name] = value
+	}
+
+	return values
+}
+
+// parseNodeArgs parses the RKE2 arguments annotation of a Node, a JSON list of arguments where values are either
+// part of the argument, e.g. "--cni=calico", or the next element, e.g. "--cni", "calico". Arguments without a
+// value are booleans.
+func parseNodeArgs(value string) (map[string][]string, error) {
+	list := []string{}
+	if err := json.Unmarshal([]byte(value), &list); err != nil {
+		return nil, err
+	}
+
+	args := map[string][]string{}
+
+	for i := 0; i < len(list); i++ {
+		name, ok := strings.CutPrefix(list[i], "--")
+		if !ok {
+			// Subcommands, e.g. "server".
+			continue
+		}
+
+		if name, value, found := strings.Cut(name, "="); found {
+			args[name] = append(args[name], value)
+
+			continue
+		}
+
+		if i+1 < len(list) && !strings.HasPrefix(list[i+1], "--") {
+			args[name] = append(args[name], list[i+1])
+			i++
+
+			continue
+		}
+
+		args[name] = append(args[name], "true")
+	}
+
+	return args, nil
+}
+
+func lastValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[len(values)-1]
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	bootstrapv1 "github.com/rancher/cluster-api-provider-rke2/bootstrap/api/v1beta1"
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+func TestParseNodeArgs(t *testing.T) {
+	g := NewWithT(t)
+
+	args, err := parseNodeArgs(`["server","--cni","calico","--disable-kube-proxy","--kube-apiserver-arg=v=2",` +
+		`"--kube-apiserver-arg","audit-log-maxage=30","--token","********","--selinux"]`)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(args).To(Equal(map[string][]string{
+		"cni":                {"calico"},
+		"disable-kube-proxy": {"true"},
+		"kube-apiserver-arg": {"v=2", "audit-log-maxage=30"},
+		"token":              {"********"},
+		"selinux":            {"true"},
+	}))
+
+	_, err = parseNodeArgs("server")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestUpdateConfigDriftConditions(t *testing.T) {
+	g := NewWithT(t)
+
+	serverConfig := controlplanev1.RKE2ServerConfig{
+		CNI:        controlplanev1.Calico,
+		ClusterDNS: "10.43.0.10",
+		KubeAPIServer: &bootstrapv1.ComponentConfig{
+			ExtraArgs:     []string{"audit-log-maxage=30"},
+			OverrideImage: "registry.example.com/kube-apiserver:v1.31.1",
+		},
+		DisableComponents: controlplanev1.DisableComponents{
+			PluginComponents: []controlplanev1.DisabledPluginComponent{controlplanev1.IngressNginx},
+		},
+	}
+	serverConfigValue, err := json.Marshal(serverConfig)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	newMachine := func(name string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{controlplanev1.RKE2ServerConfigurationAnnotation: string(serverConfigValue)},
+			},
+			Status: clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: name}},
+		}
+	}
+
+	newNode := func(name, nodeArgs string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{nodeArgsAnnotation: nodeArgs},
+		}}
+	}
+
+	newAPIServerPod := func(nodeName string, args ...string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kube-apiserver-" + nodeName,
+				Namespace: metav1.NamespaceSystem,
+				Labels:    map[string]string{staticPodTierLabel: "control-plane"},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:    "kube-apiserver",
+				Image:   "registry.example.com/kube-apiserver:v1.31.1",
+				Command: []string{"kube-apiserver"},
+				Args:    args,
+			}}},
+		}
+	}
+
+	kubeletConfig := &bootstrapv1.RKE2Config{Spec: bootstrapv1.RKE2ConfigSpec{AgentConfig: bootstrapv1.RKE2AgentConfig{
+		Kubelet: &bootstrapv1.ComponentConfig{ExtraArgs: []string{"max-pods=250"}},
+	}}}
+
+	upToDate := newMachine("up-to-date")
+	drifted := newMachine("drifted")
+	unknown := newMachine("unknown")
+	delete(unknown.Annotations, controlplanev1.RKE2ServerConfigurationAnnotation)
+
+	w := &Workload{
+		Client: fake.NewClientBuilder().WithObjects(
+			newAPIServerPod("up-to-date", "--audit-log-maxage=30", "--v=2"),
+			newAPIServerPod("drifted", "--audit-log-maxage=7"),
+		).Build(),
+		Nodes: map[string]*corev1.Node{
+			"up-to-date": newNode("up-to-date", `["server","--cni","calico","--cluster-dns","10.43.0.10",`+
+				`"--disable","rke2-ingress-nginx","--kube-apiserver-arg","audit-log-maxage=30","--kubelet-arg","max-pods=250"]`),
+			"drifted": newNode("drifted", `["server","--cni","calico","--cluster-dns","10.43.0.10",`+
+				`"--disable","rke2-ingress-nginx,rke2-metrics-server","--kube-apiserver-arg","audit-log-maxage=30",`+
+				`"--kubelet-arg","max-pods=110","--disable-kube-proxy","true"]`),
+			"unknown": newNode("unknown", `[]`),
+		},
+	}
+
+	controlPlane := &ControlPlane{
+		RCP:      &controlplanev1.RKE2ControlPlane{},
+		Machines: collections.FromMachines(upToDate, drifted, unknown),
+		Rke2Configs: map[string]*bootstrapv1.RKE2Config{
+			"up-to-date": kubeletConfig,
+			"drifted":    kubeletConfig,
+			"unknown":    kubeletConfig,
+		},
+	}
+
+	w.UpdateConfigDriftConditions(ctx, controlPlane)
+
+	g.Expect(conditions.IsFalse(upToDate, controlplanev1.ConfigDriftedCondition)).To(BeTrue())
+	g.Expect(conditions.IsUnknown(unknown, controlplanev1.ConfigDriftedCondition)).To(BeTrue())
+
+	g.Expect(conditions.IsTrue(drifted, controlplanev1.ConfigDriftedCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(drifted, controlplanev1.ConfigDriftedCondition)).To(Equal(controlplanev1.MachineConfigDriftedReason))
+	message := conditions.GetMessage(drifted, controlplanev1.ConfigDriftedCondition)
+	g.Expect(message).To(ContainSubstring(`disable is "rke2-ingress-nginx,rke2-metrics-server" instead of "rke2-ingress-nginx"`))
+	g.Expect(message).To(ContainSubstring("disable-kube-proxy is true instead of false"))
+	g.Expect(message).To(ContainSubstring(`kubelet-arg max-pods is "110" instead of "250"`))
+	g.Expect(message).To(ContainSubstring(`kube-apiserver audit-log-maxage is "7" instead of "30"`))
+	g.Expect(message).ToNot(ContainSubstring("cni"))
+
+	g.Expect(conditions.IsTrue(controlPlane.RCP, controlplanev1.ConfigDriftedCondition)).To(BeTrue())
+	g.Expect(conditions.GetMessage(controlPlane.RCP, controlplanev1.ConfigDriftedCondition)).To(ContainSubstring("drifted"))
+}
+
+func TestMatchesConfigDrift(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &clusterv1.Machine{}
+	conditions.MarkTrueWithNegativePolarity(machine, controlplanev1.ConfigDriftedCondition,
+		controlplanev1.MachineConfigDriftedReason, clusterv1.ConditionSeverityWarning, "")
+
+	rcp := &controlplanev1.RKE2ControlPlane{}
+	g.Expect(matchesConfigDrift(rcp)(machine)).To(BeTrue())
+
+	rcp.Spec.RolloutBefore = &controlplanev1.RolloutBefore{ConfigDrifted: true}
+	g.Expect(matchesConfigDrift(rcp)(machine)).To(BeFalse())
+
+	conditions.MarkFalseWithNegativePolarity(machine, controlplanev1.ConfigDriftedCondition)
+	g.Expect(matchesConfigDrift(rcp)(machine)).To(BeTrue())
+}
@@ -118,6 +118,61 @@ func (w *Workload) isMemberRemovedForNode(ctx context.Context, name string) (boo
 	return false, nil
 }
 
+// RemoveOrphanedEtcdMember removes the etcd member of a Node which has no Machine anymore, and returns whether
+// the member is removed. When the Node still exists, the removal is delegated to RKE2 with the
+// EtcdNodeRemoveAnnotation and the member is removed once RKE2 acknowledged it; otherwise the member is removed
+// through the etcd API.
+func (w *Workload) RemoveOrphanedEtcdMember(ctx context.Context, nodeName string) (bool, error) {
+	// Return early for clusters without an etcd certificate secret
+	if w.etcdClientGenerator == nil {
+		return false, nil
+	}
+
+	nodes, err := w.getControlPlaneNodes(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list control plane nodes")
+	}
+
+	nodeNames := make([]string, 0, len(nodes.Items))
+
+	for _, node := range nodes.Items {
+		if node.Name == nodeName {
+			if _, requested := node.Annotations[EtcdNodeRemoveAnnotation]; requested {
+				return w.isMemberRemovedForNode(ctx, nodeName)
+			}
+
+			return false, w.removeMemberForNode(ctx, nodeName)
+		}
+
+		nodeNames = append(nodeNames, node.Name)
+	}
+
+	etcdClient, err := w.etcdClientGenerator.ForLeader(ctx, nodeNames)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create etcd client")
+	}
+	defer etcdClient.Close()
+
+	members, err := etcdClient.Members(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list etcd members using etcd client")
+	}
+
+	for _, member := range members {
+		if member.Name == "" || etcdutil.NodeNameFromMember(member) != nodeName {
+			continue
+		}
+
+		log.FromContext(ctx).Info("Removing orphaned etcd member", "member", member.Name)
+
+		if err := etcdClient.RemoveMember(ctx, member.ID); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
 // ForwardEtcdLeadership forwards etcd leadership to the first follower.
 func (w *Workload) ForwardEtcdLeadership(ctx context.Context, machine *clusterv1.Machine, leaderCandidate *clusterv1.Machine) error {
 	if machine == nil || machine.Status.NodeRef == nil {
@@ -0,0 +1,149 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/etcd"
+)
+
+// etcdStatusRefreshInterval is the minimum interval between two refreshes of the etcd status of the RKE2ControlPlane,
+// as each refresh of its volatile fields triggers a new reconciliation.
+const etcdStatusRefreshInterval = time.Minute
+
+// etcdMemberStatus reads the status of the etcd member of a Node, and measures the time it took to be read.
+func (w *Workload) etcdMemberStatus(ctx context.Context, nodeName string) (*controlplanev1.EtcdMemberStatus, error) {
+	etcdClient, err := w.etcdClientGenerator.ForFirstAvailableNode(ctx, []string{nodeName})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create etcd client")
+	}
+	defer etcdClient.Close()
+
+	start := time.Now()
+
+	status, err := etcdClient.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alarms := make([]string, 0, len(status.Alarms))
+	for _, alarm := range status.Alarms {
+		alarms = append(alarms, etcd.AlarmTypeName[alarm])
+	}
+
+	return &controlplanev1.EtcdMemberStatus{
+		NodeName:      nodeName,
+		ID:            fmt.Sprintf("%x", status.MemberID),
+		LeaderID:      fmt.Sprintf("%x", status.LeaderID),
+		IsLeader:      status.MemberID == status.LeaderID,
+		IsLearner:     status.IsLearner,
+		DBSize:        status.DBSize,
+		DBSizeInUse:   status.DBSizeInUse,
+		RaftIndex:     int64(status.RaftIndex), //nolint:gosec
+		RoundTripTime: metav1.Duration{Duration: time.Since(start).Round(time.Microsecond)},
+		Errors:        status.Errors,
+		Alarms:        alarms,
+	}, nil
+}
+
+// setEtcdRaftIndexLag sets the raft index lag of the etcd members against the leader, when the status of the leader
+// could be read. As the statuses are not read at the same time, a member appearing ahead of the leader has no lag.
+func setEtcdRaftIndexLag(members []controlplanev1.EtcdMemberStatus) {
+	leader := slices.IndexFunc(members, func(member controlplanev1.EtcdMemberStatus) bool {
+		return member.IsLeader
+	})
+	if leader < 0 {
+		return
+	}
+
+	for i := range members {
+		lag := max(members[leader].RaftIndex-members[i].RaftIndex, 0)
+		members[i].RaftIndexLag = &lag
+	}
+}
+
+// etcdMemberUnhealthy returns why an etcd member is unhealthy, as a condition reason and message, and the severity of
+// the issue, or an empty message when the member is healthy. A NOSPACE alarm is only reported when the member has no
+// other issue, with its own reason, as it does not prevent the member from taking part in the quorum.
+func etcdMemberUnhealthy(
+	member *controlplanev1.EtcdMemberStatus,
+	health *controlplanev1.EtcdMemberHealth,
+) (string, string, clusterv1.ConditionSeverity) {
+	if len(member.Errors) > 0 {
+		return controlplanev1.EtcdMemberUnhealthyReason,
+			"Etcd member reports errors: " + strings.Join(member.Errors, ", "), clusterv1.ConditionSeverityError
+	}
+
+	if slices.Contains(member.Alarms, etcd.AlarmTypeName[etcd.AlarmCorrupt]) {
+		return controlplanev1.EtcdMemberUnhealthyReason,
+			"Etcd member raised a CORRUPT alarm", clusterv1.ConditionSeverityError
+	}
+
+	if member.IsLearner {
+		return controlplanev1.EtcdMemberUnhealthyReason, "Etcd member is a learner", clusterv1.ConditionSeverityInfo
+	}
+
+	if health != nil && health.MaxRaftIndexLag != nil && member.RaftIndexLag != nil &&
+		*member.RaftIndexLag > *health.MaxRaftIndexLag {
+		return controlplanev1.EtcdMemberUnhealthyReason, fmt.Sprintf("Etcd member is %d raft entries behind the leader, more than %d",
+			*member.RaftIndexLag, *health.MaxRaftIndexLag), clusterv1.ConditionSeverityWarning
+	}
+
+	if health != nil && health.MaxRoundTripTime != nil && member.RoundTripTime.Duration > health.MaxRoundTripTime.Duration {
+		return controlplanev1.EtcdMemberUnhealthyReason, fmt.Sprintf("Etcd member status took %s to be read, more than %s",
+			member.RoundTripTime.Duration, health.MaxRoundTripTime.Duration), clusterv1.ConditionSeverityWarning
+	}
+
+	if slices.Contains(member.Alarms, etcd.AlarmTypeName[etcd.AlarmNoSpace]) {
+		return controlplanev1.EtcdMemberNoSpaceReason,
+			"Etcd member raised a NOSPACE alarm", clusterv1.ConditionSeverityWarning
+	}
+
+	return "", "", ""
+}
+
+// updateEtcdStatus records the status of the etcd members in the RKE2ControlPlane, when the control plane Machines
+// changed or the last refresh is older than etcdStatusRefreshInterval.
+func updateEtcdStatus(rcp *controlplanev1.RKE2ControlPlane, members []controlplanev1.EtcdMemberStatus, now time.Time) {
+	slices.SortFunc(members, func(a, b controlplanev1.EtcdMemberStatus) int {
+		return strings.Compare(a.MachineName, b.MachineName)
+	})
+
+	if current := rcp.Status.Etcd; current != nil && current.LastUpdateTime != nil &&
+		now.Sub(current.LastUpdateTime.Time) < etcdStatusRefreshInterval &&
+		slices.EqualFunc(current.Members, members, func(a, b controlplanev1.EtcdMemberStatus) bool {
+			return a.MachineName == b.MachineName
+		}) {
+		return
+	}
+
+	rcp.Status.Etcd = &controlplanev1.EtcdStatus{
+		LastUpdateTime: &metav1.Time{Time: now},
+		Members:        members,
+	}
+}
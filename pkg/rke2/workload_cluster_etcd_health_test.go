@@ -0,0 +1,202 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	"github.com/rancher/cluster-api-provider-rke2/pkg/etcd"
+	etcdfake "github.com/rancher/cluster-api-provider-rke2/pkg/etcd/fake"
+)
+
+func TestUpdateEtcdConditions(t *testing.T) {
+	g := NewWithT(t)
+
+	newMachine := func(name string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: name}},
+		}
+	}
+
+	statuses := map[string]*clientv3.StatusResponse{
+		"leader": {Header: &pb.ResponseHeader{MemberId: 1}, Leader: 1, RaftIndex: 5000, DbSize: 2048, DbSizeInUse: 1024},
+		"behind": {Header: &pb.ResponseHeader{MemberId: 2}, Leader: 1, RaftIndex: 3000},
+		"learner": {
+			Header: &pb.ResponseHeader{MemberId: 3}, Leader: 1, RaftIndex: 5000, IsLearner: true,
+		},
+		"failing": {
+			Header: &pb.ResponseHeader{MemberId: 4}, Leader: 1, RaftIndex: 5000, Errors: []string{"etcdserver: no leader"},
+		},
+		"nospace": {Header: &pb.ResponseHeader{MemberId: 5}, Leader: 1, RaftIndex: 5000},
+	}
+
+	// Every member reports the NOSPACE alarm of the nospace member among its errors.
+	noSpace := &pb.AlarmMember{MemberID: 5, Alarm: pb.AlarmType_NOSPACE}
+	for _, status := range statuses {
+		status.Errors = append(status.Errors, noSpace.String())
+	}
+
+	machines := collections.New()
+	nodes := map[string]*corev1.Node{}
+
+	for name := range statuses {
+		machines.Insert(newMachine(name))
+		nodes[name] = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+
+	machines.Insert(newMachine("unreachable"))
+	nodes["unreachable"] = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unreachable"}}
+
+	w := &Workload{
+		Nodes: nodes,
+		etcdClientGenerator: &fakeEtcdClientGenerator{
+			forNodesClientFunc: func(nodeNames []string) (*etcd.Client, error) {
+				status, ok := statuses[nodeNames[0]]
+				if !ok {
+					return nil, errors.New("no etcd member")
+				}
+
+				return &etcd.Client{
+					Endpoint:    "https://" + nodeNames[0] + ":2379",
+					CallTimeout: etcd.DefaultCallTimeout,
+					EtcdClient: &etcdfake.FakeEtcdClient{
+						StatusResponse: status,
+						AlarmResponse:  &clientv3.AlarmResponse{Alarms: []*pb.AlarmMember{noSpace}},
+					},
+				}, nil
+			},
+		},
+	}
+
+	controlPlane := &ControlPlane{
+		RCP: &controlplanev1.RKE2ControlPlane{Spec: controlplanev1.RKE2ControlPlaneSpec{
+			ServerConfig: controlplanev1.RKE2ServerConfig{Etcd: controlplanev1.EtcdConfig{
+				MemberHealth: &controlplanev1.EtcdMemberHealth{MaxRaftIndexLag: ptr.To[int64](1000)},
+			}},
+		}},
+		Machines: machines,
+	}
+
+	w.UpdateEtcdConditions(ctx, controlPlane)
+
+	g.Expect(conditions.IsTrue(machines["leader"], controlplanev1.MachineEtcdMemberHealthyCondition)).To(BeTrue())
+	g.Expect(conditions.IsUnknown(machines["unreachable"], controlplanev1.MachineEtcdMemberHealthyCondition)).To(BeTrue())
+
+	for name, message := range map[string]string{
+		"behind":  "2000 raft entries behind the leader",
+		"learner": "is a learner",
+		"failing": "reports errors: etcdserver: no leader",
+	} {
+		g.Expect(conditions.IsFalse(machines[name], controlplanev1.MachineEtcdMemberHealthyCondition)).To(BeTrue(), name)
+		g.Expect(conditions.GetReason(machines[name], controlplanev1.MachineEtcdMemberHealthyCondition)).
+			To(Equal(controlplanev1.EtcdMemberUnhealthyReason))
+		g.Expect(conditions.GetMessage(machines[name], controlplanev1.MachineEtcdMemberHealthyCondition)).
+			To(ContainSubstring(message))
+	}
+
+	g.Expect(conditions.IsFalse(machines["nospace"], controlplanev1.MachineEtcdMemberHealthyCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(machines["nospace"], controlplanev1.MachineEtcdMemberHealthyCondition)).
+		To(Equal(controlplanev1.EtcdMemberNoSpaceReason))
+
+	etcdStatus := controlPlane.RCP.Status.Etcd
+	g.Expect(etcdStatus).ToNot(BeNil())
+	g.Expect(etcdStatus.LastUpdateTime).ToNot(BeNil())
+	g.Expect(etcdStatus.Members).To(HaveLen(5))
+	g.Expect(etcdStatus.Members[0].MachineName).To(Equal("behind"))
+	g.Expect(etcdStatus.Members[0].RaftIndexLag).To(Equal(ptr.To[int64](2000)))
+	g.Expect(etcdStatus.Members[2].MachineName).To(Equal("leader"))
+	g.Expect(etcdStatus.Members[2].ID).To(Equal("1"))
+	g.Expect(etcdStatus.Members[2].IsLeader).To(BeTrue())
+	g.Expect(etcdStatus.Members[2].DBSize).To(Equal(int64(2048)))
+	g.Expect(etcdStatus.Members[2].RaftIndexLag).To(Equal(ptr.To[int64](0)))
+	g.Expect(etcdStatus.Members[2].Errors).To(BeEmpty())
+	g.Expect(etcdStatus.Members[4].MachineName).To(Equal("nospace"))
+	g.Expect(etcdStatus.Members[4].Alarms).To(Equal([]string{"NOSPACE"}))
+
+	// The volatile fields are not refreshed until the refresh interval elapsed.
+	statuses["behind"].RaftIndex = 5000
+	w.UpdateEtcdConditions(ctx, controlPlane)
+	g.Expect(conditions.IsTrue(machines["behind"], controlplanev1.MachineEtcdMemberHealthyCondition)).To(BeTrue())
+	g.Expect(controlPlane.RCP.Status.Etcd.Members[0].RaftIndexLag).To(Equal(ptr.To[int64](2000)))
+
+	controlPlane.RCP.Status.Etcd.LastUpdateTime = &metav1.Time{Time: time.Now().Add(-etcdStatusRefreshInterval)}
+	w.UpdateEtcdConditions(ctx, controlPlane)
+	g.Expect(controlPlane.RCP.Status.Etcd.Members[0].RaftIndexLag).To(Equal(ptr.To[int64](0)))
+
+	// Without embedded etcd, the members are not inspected.
+	controlPlane.RCP.Spec.ServerConfig.ExternalDatastoreSecret = &corev1.ObjectReference{Name: "datastore"}
+	w.UpdateEtcdConditions(ctx, controlPlane)
+	g.Expect(conditions.IsTrue(machines["failing"], controlplanev1.MachineEtcdMemberHealthyCondition)).To(BeTrue())
+	g.Expect(controlPlane.RCP.Status.Etcd).To(BeNil())
+}
+
+func TestEtcdMemberUnhealthy(t *testing.T) {
+	g := NewWithT(t)
+
+	member := &controlplanev1.EtcdMemberStatus{
+		RaftIndexLag:  ptr.To[int64](10),
+		RoundTripTime: metav1.Duration{Duration: 800 * time.Millisecond},
+	}
+
+	_, message, _ := etcdMemberUnhealthy(member, nil)
+	g.Expect(message).To(BeEmpty())
+
+	_, message, severity := etcdMemberUnhealthy(member, &controlplanev1.EtcdMemberHealth{
+		MaxRoundTripTime: &metav1.Duration{Duration: 500 * time.Millisecond},
+	})
+	g.Expect(message).To(Equal("Etcd member status took 800ms to be read, more than 500ms"))
+	g.Expect(severity).To(Equal(clusterv1.ConditionSeverityWarning))
+
+	_, message, _ = etcdMemberUnhealthy(member, &controlplanev1.EtcdMemberHealth{
+		MaxRaftIndexLag:  ptr.To[int64](10),
+		MaxRoundTripTime: &metav1.Duration{Duration: time.Second},
+	})
+	g.Expect(message).To(BeEmpty())
+
+	// A NOSPACE alarm has its own reason, unless the member has other issues.
+	member.Alarms = []string{"NOSPACE"}
+	reason, message, severity := etcdMemberUnhealthy(member, nil)
+	g.Expect(reason).To(Equal(controlplanev1.EtcdMemberNoSpaceReason))
+	g.Expect(message).To(Equal("Etcd member raised a NOSPACE alarm"))
+	g.Expect(severity).To(Equal(clusterv1.ConditionSeverityWarning))
+
+	member.IsLearner = true
+	reason, _, _ = etcdMemberUnhealthy(member, nil)
+	g.Expect(reason).To(Equal(controlplanev1.EtcdMemberUnhealthyReason))
+
+	member.IsLearner = false
+	member.Alarms = []string{"CORRUPT"}
+	reason, message, severity = etcdMemberUnhealthy(member, nil)
+	g.Expect(reason).To(Equal(controlplanev1.EtcdMemberUnhealthyReason))
+	g.Expect(message).To(Equal("Etcd member raised a CORRUPT alarm"))
+	g.Expect(severity).To(Equal(clusterv1.ConditionSeverityError))
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	"github.com/rancher/cluster-api-provider-rke2/pkg/etcd"
+)
+
+// EtcdDatabaseStatus contains the database status of the etcd member of a control plane Machine.
+type EtcdDatabaseStatus struct {
+	// Leader is true when the member is the leader of the etcd cluster.
+	Leader bool
+
+	// DBSize is the size in bytes of the database of the member.
+	DBSize int64
+
+	// DBSizeInUse is the size in bytes of the database of the member actually in use.
+	DBSizeInUse int64
+
+	// Alarms are the alarms raised by the member.
+	Alarms []etcd.AlarmType
+}
+
+// EtcdDatabaseStatus returns the database status of the etcd member of the Node of a Machine.
+func (w *Workload) EtcdDatabaseStatus(ctx context.Context, machine *clusterv1.Machine) (*EtcdDatabaseStatus, error) {
+	etcdClient, err := w.etcdClientForMachine(ctx, machine)
+	if err != nil {
+		return nil, err
+	}
+	defer etcdClient.Close()
+
+	status, err := etcdClient.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EtcdDatabaseStatus{
+		Leader:      status.MemberID == status.LeaderID,
+		DBSize:      status.DBSize,
+		DBSizeInUse: status.DBSizeInUse,
+		Alarms:      append([]etcd.AlarmType{}, status.Alarms...),
+	}, nil
+}
+
+// DefragmentEtcdMember defragments the database of the etcd member of the Node of a Machine. The member does not
+// serve requests while its database is defragmented.
+func (w *Workload) DefragmentEtcdMember(ctx context.Context, machine *clusterv1.Machine) error {
+	etcdClient, err := w.etcdClientForMachine(ctx, machine)
+	if err != nil {
+		return err
+	}
+	defer etcdClient.Close()
+
+	log.FromContext(ctx).Info("Defragmenting etcd member", "node", machine.Status.NodeRef.Name)
+
+	return etcdClient.Defragment(ctx)
+}
+
+// DisarmEtcdMemberAlarm disarms an alarm raised by the etcd member of the Node of a Machine, if any.
+func (w *Workload) DisarmEtcdMemberAlarm(ctx context.Context, machine *clusterv1.Machine, alarm etcd.AlarmType) error {
+	etcdClient, err := w.etcdClientForMachine(ctx, machine)
+	if err != nil {
+		return err
+	}
+	defer etcdClient.Close()
+
+	status, err := etcdClient.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Disarming etcd member alarm", "node", machine.Status.NodeRef.Name, "alarm", etcd.AlarmTypeName[alarm])
+
+	return etcdClient.AlarmDisarm(ctx, etcd.MemberAlarm{MemberID: status.MemberID, Type: alarm})
+}
+
+// etcdClientForMachine returns an etcd client connected to the etcd member of the Node of a Machine.
+func (w *Workload) etcdClientForMachine(ctx context.Context, machine *clusterv1.Machine) (*etcd.Client, error) {
+	if machine == nil || machine.Status.NodeRef == nil {
+		return nil, errors.New("machine has no node")
+	}
+
+	if w.etcdClientGenerator == nil {
+		return nil, errors.New("cluster does not provide etcd certificates")
+	}
+
+	etcdClient, err := w.etcdClientGenerator.ForFirstAvailableNode(ctx, []string{machine.Status.NodeRef.Name})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create etcd client")
+	}
+
+	return etcdClient, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// DefaultEtcdRestoreImage is the image used to run the etcd restore Jobs when none is specified.
+	DefaultEtcdRestoreImage = DefaultEtcdSnapshotImage
+
+	// etcdRestoreJobLabel is the label set on the etcd restore Jobs.
+	etcdRestoreJobLabel = "rke2.controlplane.cluster.x-k8s.io/etcd-restore"
+
+	// etcdRestoreStateDir is the directory of the host the result of the etcd restores is recorded in.
+	etcdRestoreStateDir = "/var/lib/rancher/rke2/server/etcd-restore"
+
+	// etcdRestoreScript resets etcd from the snapshot passed as $0 in a transient systemd unit named after the Job,
+	// passed as $1, so that it survives RKE2 being stopped. The workload cluster is restored to the content of the
+	// snapshot, which does not contain the Job: its result is recorded on the host, so that the Job created again
+	// once the cluster is back reports it instead of resetting etcd a second time.
+	etcdRestoreScript = `export ` + hostPathEnv + `
+state="` + etcdRestoreStateDir + `/$1"
+while systemctl is-active -q "$1"; do sleep 5; done
+if [ -f "$state.succeeded" ]; then exit 0; fi
+if [ -f "$state.failed" ]; then cat "$state.failed"; exit 1; fi
+mkdir -p ` + etcdRestoreStateDir + `
+systemd-run --wait --collect --unit="$1" /bin/sh -c '
+systemctl stop rke2-server
+if rke2 server --cluster-reset --cluster-reset-restore-path="$0" >"$1.log" 2>&1; then
+  touch "$1.succeeded"
+else
+  cp "$1.log" "$1.failed"
+fi
+systemctl start rke2-server' "$0" "$state"
+test -f "$state.succeeded"`
+)
+
+// ErrEtcdRestoreFailed is returned when an etcd restore Job has failed. The Job is kept for troubleshooting.
+var ErrEtcdRestoreFailed = errors.New("etcd restore failed")
+
+// ResetEtcdFromSnapshot runs a Job resetting the etcd cluster from the given snapshot on the Node of the given
+// Machine, unless it already exists, and returns true once etcd has been reset and RKE2 restarted.
+// The API server is not available while etcd is reset, callers should expect errors until it is back.
+func (w *Workload) ResetEtcdFromSnapshot(
+	ctx context.Context,
+	machine *clusterv1.Machine,
+	jobName, snapshotPath, image string,
+) (bool, error) {
+	if machine == nil {
+		return false, errors.New("machine is nil")
+	}
+
+	if machine.Status.NodeRef == nil {
+		return false, fmt.Errorf("machine %s has no node ref", machine.Name)
+	}
+
+	if image == "" {
+		image = DefaultEtcdRestoreImage
+	}
+
+	job := newHostJob(jobName, machine.Status.NodeRef.Name, image, "etcd-restore",
+		map[string]string{etcdRestoreJobLabel: "true"},
+		[]string{"chroot", "/host", "/bin/sh", "-c", etcdRestoreScript, snapshotPath, jobName})
+
+	finished, succeeded, err := w.runHostJob(ctx, job)
+	if err != nil {
+		return false, err
+	}
+
+	if finished && !succeeded {
+		return false, fmt.Errorf("%w: job %s/%s failed", ErrEtcdRestoreFailed, job.Namespace, job.Name)
+	}
+
+	if !finished {
+		log.FromContext(ctx).V(3).Info("Waiting for etcd restore job", "job", ctrlclient.ObjectKeyFromObject(job))
+	}
+
+	return finished, nil
+}
+
+// CleanupEtcdRestore deletes the Job used to reset etcd from a snapshot.
+func (w *Workload) CleanupEtcdRestore(ctx context.Context, jobName string) error {
+	return w.deleteHostJob(ctx, jobName)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+func TestResetEtcdFromSnapshot(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node1"},
+		},
+	}
+
+	w := &Workload{Client: fake.NewClientBuilder().Build()}
+
+	done, err := w.ResetEtcdFromSnapshot(ctx, machine, "rke2-etcd-restore", "/snapshots/etcd-snapshot", "")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(done).To(BeFalse())
+
+	job := &batchv1.Job{}
+	g.Expect(w.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "rke2-etcd-restore"}, job)).To(Succeed())
+	g.Expect(job.Spec.Template.Spec.NodeName).To(Equal("node1"))
+	g.Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal(DefaultEtcdRestoreImage))
+	g.Expect(job.Spec.Template.Spec.Containers[0].Command).To(HaveExactElements(
+		"chroot", "/host", "/bin/sh", "-c",
+		And(
+			ContainSubstring("systemd-run --wait --collect"),
+			ContainSubstring(`rke2 server --cluster-reset --cluster-reset-restore-path="$0"`),
+			ContainSubstring("systemctl start rke2-server"),
+		),
+		"/snapshots/etcd-snapshot", "rke2-etcd-restore",
+	))
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	g.Expect(w.Status().Update(ctx, job)).To(Succeed())
+
+	done, err = w.ResetEtcdFromSnapshot(ctx, machine, "rke2-etcd-restore", "/snapshots/etcd-snapshot", "")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(done).To(BeTrue())
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	g.Expect(w.Status().Update(ctx, job)).To(Succeed())
+
+	_, err = w.ResetEtcdFromSnapshot(ctx, machine, "rke2-etcd-restore", "/snapshots/etcd-snapshot", "")
+	g.Expect(err).To(MatchError(ErrEtcdRestoreFailed))
+
+	g.Expect(w.CleanupEtcdRestore(ctx, "rke2-etcd-restore")).To(Succeed())
+	g.Expect(w.Get(ctx, client.ObjectKeyFromObject(job), job)).ToNot(Succeed())
+
+	_, err = w.ResetEtcdFromSnapshot(ctx, &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "pending"}},
+		"rke2-etcd-restore", "/snapshots/etcd-snapshot", "")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+const (
+	// DefaultEtcdSnapshotImage is the image used to run the etcd snapshot Jobs when none is specified.
+	DefaultEtcdSnapshotImage = "registry.suse.com/bci/bci-busybox:latest"
+
+	// etcdSnapshotJobLabel is the label set on the etcd snapshot Jobs. The snapshot name is not used as its value,
+	// as it can be longer than a label value.
+	etcdSnapshotJobLabel = "rke2.controlplane.cluster.x-k8s.io/etcd-snapshot"
+
+	// etcdSnapshotScript runs the snapshot from the host filesystem, the snapshot name is passed as $0.
+	etcdSnapshotScript = hostPathEnv + ` exec rke2 etcd-snapshot save --name "$0"`
+)
+
+// etcdSnapshotFileGVK is the GroupVersionKind of the ETCDSnapshotFile objects RKE2 creates for each etcd snapshot.
+var etcdSnapshotFileGVK = schema.GroupVersionKind{Group: "k3s.cattle.io", Version: "v1", Kind: "ETCDSnapshotFileList"}
+
+// SaveEtcdSnapshot starts a Job taking an etcd snapshot on the Node of the given Machine, unless it already exists,
+// and returns the phase of the snapshot.
+func (w *Workload) SaveEtcdSnapshot(
+	ctx context.Context,
+	machine *clusterv1.Machine,
+	jobName, snapshotName, image string,
+) (controlplanev1.EtcdSnapshotPhase, error) {
+	if machine == nil {
+		return "", errors.New("machine is nil")
+	}
+
+	if machine.Status.NodeRef == nil {
+		return "", fmt.Errorf("machine %s has no node ref", machine.Name)
+	}
+
+	nodeName := machine.Status.NodeRef.Name
+
+	job := &batchv1.Job{}
+
+	err := w.Get(ctx, ctrlclient.ObjectKey{Namespace: metav1.NamespaceSystem, Name: jobName}, job)
+	if err == nil {
+		return etcdSnapshotJobPhase(job), nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("getting etcd snapshot job %s: %w", jobName, err)
+	}
+
+	members, err := w.EtcdMembers(ctx)
+	if err != nil {
+		return "", fmt.Errorf("listing etcd members: %w", err)
+	}
+
+	if len(members) > 0 && !slices.Contains(members, nodeName) {
+		return "", fmt.Errorf("node %s is not an etcd member", nodeName)
+	}
+
+	if image == "" {
+		image = DefaultEtcdSnapshotImage
+	}
+
+	job = newEtcdSnapshotJob(jobName, nodeName, snapshotName, image)
+	if err := w.Create(ctx, job); err != nil {
+		return "", fmt.Errorf("creating etcd snapshot job %s: %w", jobName, err)
+	}
+
+	log.FromContext(ctx).Info("Started etcd snapshot", "job", jobName, "node", nodeName, "snapshot", snapshotName)
+
+	return controlplanev1.EtcdSnapshotPhaseRunning, nil
+}
+
+// CleanupEtcdSnapshot deletes the Job used to take an etcd snapshot.
+func (w *Workload) CleanupEtcdSnapshot(ctx context.Context, jobName string) error {
+	return w.deleteHostJob(ctx, jobName)
+}
+
+// EtcdSnapshotFiles returns the etcd snapshots reported by the ETCDSnapshotFile objects, most recent first.
+func (w *Workload) EtcdSnapshotFiles(ctx context.Context) ([]controlplanev1.EtcdSnapshotFile, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(etcdSnapshotFileGVK)
+
+	if err := w.List(ctx, list); err != nil {
+		if meta.IsNoMatchError(err) {
+			// The CRD is only installed once RKE2 has taken a first snapshot.
+			return []controlplanev1.EtcdSnapshotFile{}, nil
+		}
+
+		return nil, fmt.Errorf("listing etcd snapshot files: %w", err)
+	}
+
+	files := make([]controlplanev1.EtcdSnapshotFile, 0, len(list.Items))
+	for _, item := range list.Items {
+		files = append(files, etcdSnapshotFileFromUnstructured(item.Object))
+	}
+
+	slices.SortStableFunc(files, func(a, b controlplanev1.EtcdSnapshotFile) int {
+		switch {
+		case a.CreationTime == nil && b.CreationTime == nil:
+			return strings.Compare(a.Name, b.Name)
+		case a.CreationTime == nil:
+			return 1
+		case b.CreationTime == nil:
+			return -1
+		}
+
+		return b.CreationTime.Compare(a.CreationTime.Time)
+	})
+
+	return files, nil
+}
+
+func newEtcdSnapshotJob(jobName, nodeName, snapshotName, image string) *batchv1.Job {
+	return newHostJob(jobName, nodeName, image, "etcd-snapshot",
+		map[string]string{etcdSnapshotJobLabel: "true"},
+		[]string{"chroot", "/host", "/bin/sh", "-c", etcdSnapshotScript, snapshotName})
+}
+
+func etcdSnapshotJobPhase(job *batchv1.Job) controlplanev1.EtcdSnapshotPhase {
+	finished, succeeded := hostJobResult(job)
+
+	switch {
+	case !finished:
+		return controlplanev1.EtcdSnapshotPhaseRunning
+	case succeeded:
+		return controlplanev1.EtcdSnapshotPhaseSucceeded
+	default:
+		return controlplanev1.EtcdSnapshotPhaseFailed
+	}
+}
+
+func etcdSnapshotFileFromUnstructured(obj map[string]interface{}) controlplanev1.EtcdSnapshotFile {
+	file := controlplanev1.EtcdSnapshotFile{}
+
+	file.Name, _, _ = unstructured.NestedString(obj, "spec", "snapshotName")
+	file.NodeName, _, _ = unstructured.NestedString(obj, "spec", "nodeName")
+	file.Location, _, _ = unstructured.NestedString(obj, "spec", "location")
+	file.ReadyToUse, _, _ = unstructured.NestedBool(obj, "status", "readyToUse")
+
+	if size, ok, _ := unstructured.NestedString(obj, "status", "size"); ok {
+		if quantity, err := resource.ParseQuantity(size); err == nil {
+			file.Size = &quantity
+		}
+	}
+
+	if creationTime, ok, _ := unstructured.NestedString(obj, "status", "creationTime"); ok {
+		if t, err := time.Parse(time.RFC3339, creationTime); err == nil {
+			file.CreationTime = &metav1.Time{Time: t}
+		}
+	}
+
+	return file
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+func newEtcdSnapshotFile(name, snapshotName, nodeName, location, size, creationTime string) *unstructured.Unstructured {
+	file := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"snapshotName": snapshotName,
+			"nodeName":     nodeName,
+			"location":     location,
+		},
+		"status": map[string]interface{}{
+			"size":         size,
+			"creationTime": creationTime,
+			"readyToUse":   true,
+		},
+	}}
+	file.SetAPIVersion("k3s.cattle.io/v1")
+	file.SetKind("ETCDSnapshotFile")
+	file.SetName(name)
+
+	return file
+}
+
+func TestEtcdSnapshotFiles(t *testing.T) {
+	g := NewWithT(t)
+
+	w := &Workload{
+		Client: fake.NewClientBuilder().WithObjects(
+			newEtcdSnapshotFile("local-old", "old-node1-1700000000", "node1",
+				"file:///var/lib/rancher/rke2/server/db/snapshots/old-node1-1700000000", "1Mi", "2023-11-14T22:13:20Z"),
+			newEtcdSnapshotFile("s3-new", "new-node1-1800000000", "s3",
+				"s3://bucket/new-node1-1800000000", "2Mi", "2027-01-15T08:00:00Z"),
+		).Build(),
+	}
+
+	files, err := w.EtcdSnapshotFiles(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(files).To(HaveLen(2))
+
+	g.Expect(files[0].Name).To(Equal("new-node1-1800000000"))
+	g.Expect(files[0].NodeName).To(Equal("s3"))
+	g.Expect(files[0].Location).To(Equal("s3://bucket/new-node1-1800000000"))
+	g.Expect(files[0].Size).To(Equal(ptrQuantity("2Mi")))
+	g.Expect(files[0].ReadyToUse).To(BeTrue())
+
+	g.Expect(files[1].Name).To(Equal("old-node1-1700000000"))
+	g.Expect(files[1].CreationTime.Unix()).To(Equal(int64(1700000000)))
+}
+
+func TestSaveEtcdSnapshot(t *testing.T) {
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node1"},
+		},
+	}
+
+	t.Run("creates the snapshot job on the machine node", func(t *testing.T) {
+		g := NewWithT(t)
+		w := &Workload{Client: fake.NewClientBuilder().Build()}
+
+		phase, err := w.SaveEtcdSnapshot(ctx, machine, "snapshot-job", "snapshot", "")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(phase).To(Equal(controlplanev1.EtcdSnapshotPhaseRunning))
+
+		job := &batchv1.Job{}
+		g.Expect(w.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "snapshot-job"}, job)).To(Succeed())
+		g.Expect(job.Spec.Template.Spec.NodeName).To(Equal("node1"))
+		g.Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal(DefaultEtcdSnapshotImage))
+		g.Expect(job.Spec.Template.Spec.Containers[0].Command).To(HaveExactElements(
+			"chroot", "/host", "/bin/sh", "-c", etcdSnapshotScript, "snapshot"))
+	})
+
+	t.Run("does not use a long snapshot name as a label value", func(t *testing.T) {
+		g := NewWithT(t)
+		w := &Workload{Client: fake.NewClientBuilder().Build()}
+		snapshotName := strings.Repeat("s", 128)
+
+		_, err := w.SaveEtcdSnapshot(ctx, machine, "snapshot-job", snapshotName, "")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		job := &batchv1.Job{}
+		g.Expect(w.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "snapshot-job"}, job)).To(Succeed())
+		g.Expect(job.Spec.Template.Spec.Containers[0].Command).To(ContainElement(snapshotName))
+
+		for key, value := range job.Labels {
+			g.Expect(validation.IsValidLabelValue(value)).To(BeEmpty(), key)
+		}
+	})
+
+	t.Run("reports the phase of an existing job", func(t *testing.T) {
+		g := NewWithT(t)
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: "snapshot-job"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+			},
+		}
+		w := &Workload{Client: fake.NewClientBuilder().WithObjects(job).Build()}
+
+		phase, err := w.SaveEtcdSnapshot(ctx, machine, "snapshot-job", "snapshot", "")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(phase).To(Equal(controlplanev1.EtcdSnapshotPhaseFailed))
+
+		g.Expect(w.CleanupEtcdSnapshot(ctx, "snapshot-job")).To(Succeed())
+		g.Expect(w.Get(ctx, client.ObjectKeyFromObject(job), job)).ToNot(Succeed())
+	})
+
+	t.Run("returns an error if the machine has no node", func(t *testing.T) {
+		g := NewWithT(t)
+		w := &Workload{Client: fake.NewClientBuilder().Build()}
+
+		_, err := w.SaveEtcdSnapshot(ctx, &clusterv1.Machine{}, "snapshot-job", "snapshot", "")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func ptrQuantity(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+
+	return &q
+}
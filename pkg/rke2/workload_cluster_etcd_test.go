@@ -34,6 +34,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	etcdfake "github.com/rancher/cluster-api-provider-rke2/pkg/etcd/fake"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -434,3 +435,86 @@ func nodeNamed(name string) corev1.Node {
 	}
 	return node
 }
+
+func TestRemoveOrphanedEtcdMember(t *testing.T) {
+	t.Run("removes the member of a deleted node through etcd", func(t *testing.T) {
+		g := NewWithT(t)
+		fakeEtcdClient := &etcdfake.FakeEtcdClient{
+			MemberListResponse: &clientv3.MemberListResponse{
+				Members: []*pb.Member{
+					{Name: "machine-node-1a2b3c4d", ID: uint64(101)},
+					{Name: "orphaned-node-5e6f7a8b", ID: uint64(102)},
+				},
+			},
+			AlarmResponse:        &clientv3.AlarmResponse{},
+			MemberRemoveResponse: &clientv3.MemberRemoveResponse{},
+		}
+
+		w := &Workload{
+			Client: &fakeClient{list: &corev1.NodeList{
+				Items: []corev1.Node{nodeNamed("machine-node")},
+			}},
+			etcdClientGenerator: &fakeEtcdClientGenerator{
+				forLeaderClient: &etcd.Client{EtcdClient: fakeEtcdClient},
+			},
+		}
+
+		removed, err := w.RemoveOrphanedEtcdMember(ctx, "orphaned-node")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(removed).To(BeTrue())
+		g.Expect(fakeEtcdClient.RemovedMember).To(BeEquivalentTo(102))
+	})
+
+	t.Run("annotates an existing node for RKE2 to remove its member", func(t *testing.T) {
+		g := NewWithT(t)
+		node := nodeNamed("orphaned-node")
+		node.Labels = map[string]string{labelNodeRoleControlPlane: "true"}
+		k8sClient := fake.NewClientBuilder().WithObjects(&node).Build()
+
+		patchHelper, err := patch.NewHelper(&node, k8sClient)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		w := &Workload{
+			Client:              k8sClient,
+			etcdClientGenerator: &fakeEtcdClientGenerator{},
+			nodePatchHelpers:    map[string]*patch.Helper{node.Name: patchHelper},
+		}
+
+		removed, err := w.RemoveOrphanedEtcdMember(ctx, "orphaned-node")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(removed).To(BeFalse())
+
+		g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&node), &node)).To(Succeed())
+		g.Expect(node.Annotations).To(HaveKeyWithValue(EtcdNodeRemoveAnnotation, "true"))
+	})
+
+	t.Run("waits for RKE2 to remove the member of an existing node", func(t *testing.T) {
+		g := NewWithT(t)
+		node := nodeNamed("orphaned-node")
+		node.Annotations = map[string]string{EtcdNodeRemoveAnnotation: "true"}
+		k8sClient := &fakeClient{list: &corev1.NodeList{Items: []corev1.Node{node}}}
+
+		w := &Workload{
+			Client:              k8sClient,
+			etcdClientGenerator: &fakeEtcdClientGenerator{},
+		}
+
+		removed, err := w.RemoveOrphanedEtcdMember(ctx, "orphaned-node")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(removed).To(BeFalse())
+
+		k8sClient.list.(*corev1.NodeList).Items[0].Annotations[EtcdNodeRemovedNodeNameAnnotation] = "orphaned-node"
+
+		removed, err = w.RemoveOrphanedEtcdMember(ctx, "orphaned-node")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(removed).To(BeTrue())
+	})
+
+	t.Run("does nothing for clusters without etcd certificates", func(t *testing.T) {
+		g := NewWithT(t)
+
+		removed, err := (&Workload{}).RemoveOrphanedEtcdMember(ctx, "orphaned-node")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(removed).To(BeFalse())
+	})
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// hostPathEnv extends the PATH of the scripts run on the host filesystem with the RKE2 install locations.
+const hostPathEnv = `PATH="$PATH:/usr/local/bin:/opt/rke2/bin:/var/lib/rancher/rke2/bin"`
+
+// newHostJob returns a privileged Job running the given command once on a Node, with the host filesystem mounted on /host.
+func newHostJob(jobName, nodeName, image, containerName string, labels map[string]string, command []string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: metav1.NamespaceSystem,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To[int32](0),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					NodeName:      nodeName,
+					HostNetwork:   true,
+					RestartPolicy: corev1.RestartPolicyNever,
+					Tolerations: []corev1.Toleration{
+						{Operator: corev1.TolerationOpExists},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    containerName,
+							Image:   image,
+							Command: command,
+							SecurityContext: &corev1.SecurityContext{
+								Privileged: ptr.To(true),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "host", MountPath: "/host"},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "host",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{Path: "/"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// runHostJob creates the given Job unless it already exists, and returns whether it has finished and succeeded.
+func (w *Workload) runHostJob(ctx context.Context, job *batchv1.Job) (bool, bool, error) {
+	existing := &batchv1.Job{}
+
+	err := w.Get(ctx, ctrlclient.ObjectKeyFromObject(job), existing)
+	if err == nil {
+		finished, succeeded := hostJobResult(existing)
+
+		return finished, succeeded, nil
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return false, false, fmt.Errorf("getting job %s: %w", job.Name, err)
+	}
+
+	if err := w.Create(ctx, job); err != nil {
+		return false, false, fmt.Errorf("creating job %s: %w", job.Name, err)
+	}
+
+	return false, false, nil
+}
+
+// deleteHostJob deletes a Job run on a Node, along with its Pods.
+func (w *Workload) deleteHostJob(ctx context.Context, jobName string) error {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: metav1.NamespaceSystem,
+		},
+	}
+
+	if err := w.Delete(ctx, job, ctrlclient.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil &&
+		!apierrors.IsNotFound(err) {
+		return fmt.Errorf("deleting job %s: %w", jobName, err)
+	}
+
+	return nil
+}
+
+// hostJobResult returns whether a Job has finished, and whether it succeeded.
+func hostJobResult(job *batchv1.Job) (bool, bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, true
+		case batchv1.JobFailed:
+			return true, false
+		}
+	}
+
+	if job.Status.Succeeded > 0 {
+		return true, true
+	}
+
+	if job.Status.Failed > 0 {
+		return true, false
+	}
+
+	return false, false
+}
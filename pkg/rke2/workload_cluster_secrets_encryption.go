@@ -0,0 +1,134 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+const (
+	// DefaultSecretsEncryptionRotationImage is the image used to run the secrets encryption rotation Jobs when none
+	// is specified.
+	DefaultSecretsEncryptionRotationImage = DefaultEtcdSnapshotImage
+
+	// secretsEncryptionRotationJobLabel is the label set on the secrets encryption rotation Jobs, its value is
+	// the kind of Job.
+	secretsEncryptionRotationJobLabel = "rke2.controlplane.cluster.x-k8s.io/secrets-encryption-rotation"
+
+	// secretsEncryptionStageScript runs a `rke2 secrets-encrypt` command, waits for the node to report the expected
+	// rotation stage, and restarts RKE2 so that it loads the new encryption configuration.
+	secretsEncryptionStageScript = `set -e
+export ` + hostPathEnv + `
+rke2 secrets-encrypt %[1]s
+i=0
+until rke2 secrets-encrypt status | grep -q 'Current Rotation Stage: %[2]s$'; do
+  i=$((i+1))
+  if [ "$i" -gt 120 ]; then echo 'timed out waiting for stage %[2]s'; exit 1; fi
+  sleep 5
+done
+systemctl restart rke2-server`
+
+	// restartServerScript restarts RKE2 on a control plane node.
+	restartServerScript = `systemctl restart rke2-server`
+)
+
+// secretsEncryptionStageCommands are the `rke2 secrets-encrypt` command of each rotation stage, along with the
+// stage reported by RKE2 once it has completed.
+var secretsEncryptionStageCommands = map[controlplanev1.SecretsEncryptionRotationStage][2]string{
+	controlplanev1.SecretsEncryptionRotationStagePreparing:    {"prepare", "prepare"},
+	controlplanev1.SecretsEncryptionRotationStageRotating:     {"rotate", "rotate"},
+	controlplanev1.SecretsEncryptionRotationStageReencrypting: {"reencrypt", "reencrypt_finished"},
+}
+
+// ErrSecretsEncryptionRotationFailed is returned when a secrets encryption rotation Job has failed.
+// The Job is kept for troubleshooting.
+var ErrSecretsEncryptionRotationFailed = errors.New("secrets encryption rotation failed")
+
+// RunSecretsEncryptionStage runs a Job going through the given stage of a secrets encryption keys rotation on the
+// Node of the given Machine, then restarting RKE2, unless it already exists. It returns true once the stage has
+// completed on the Node.
+func (w *Workload) RunSecretsEncryptionStage(
+	ctx context.Context,
+	machine *clusterv1.Machine,
+	jobName, image string,
+	stage controlplanev1.SecretsEncryptionRotationStage,
+) (bool, error) {
+	commands, ok := secretsEncryptionStageCommands[stage]
+	if !ok {
+		return false, fmt.Errorf("unknown secrets encryption rotation stage %q", stage)
+	}
+
+	return w.runSecretsEncryptionJob(ctx, machine, jobName, image, "stage",
+		fmt.Sprintf(secretsEncryptionStageScript, commands[0], commands[1]))
+}
+
+// RestartServer runs a Job restarting RKE2 on the Node of the given Machine, unless it already exists, and returns
+// true once RKE2 has been restarted.
+func (w *Workload) RestartServer(ctx context.Context, machine *clusterv1.Machine, jobName, image string) (bool, error) {
+	return w.runSecretsEncryptionJob(ctx, machine, jobName, image, "restart", restartServerScript)
+}
+
+// CleanupSecretsEncryptionRotation deletes a Job used to rotate the secrets encryption keys.
+func (w *Workload) CleanupSecretsEncryptionRotation(ctx context.Context, jobName string) error {
+	return w.deleteHostJob(ctx, jobName)
+}
+
+func (w *Workload) runSecretsEncryptionJob(
+	ctx context.Context,
+	machine *clusterv1.Machine,
+	jobName, image, kind, script string,
+) (bool, error) {
+	if machine == nil {
+		return false, errors.New("machine is nil")
+	}
+
+	if machine.Status.NodeRef == nil {
+		return false, fmt.Errorf("machine %s has no node ref", machine.Name)
+	}
+
+	if image == "" {
+		image = DefaultSecretsEncryptionRotationImage
+	}
+
+	job := newHostJob(jobName, machine.Status.NodeRef.Name, image, "secrets-encryption-rotation",
+		map[string]string{secretsEncryptionRotationJobLabel: kind},
+		[]string{"chroot", "/host", "/bin/sh", "-c", script})
+
+	finished, succeeded, err := w.runHostJob(ctx, job)
+	if err != nil {
+		return false, err
+	}
+
+	if finished && !succeeded {
+		return false, fmt.Errorf("%w: job %s/%s failed", ErrSecretsEncryptionRotationFailed, job.Namespace, job.Name)
+	}
+
+	if !finished {
+		log.FromContext(ctx).V(3).Info("Waiting for secrets encryption rotation job", "job", ctrlclient.ObjectKeyFromObject(job))
+	}
+
+	return finished, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+func TestRunSecretsEncryptionStage(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node1"},
+		},
+	}
+
+	w := &Workload{Client: fake.NewClientBuilder().Build()}
+
+	done, err := w.RunSecretsEncryptionStage(ctx, machine, "rke2-secrets-encryption", "",
+		controlplanev1.SecretsEncryptionRotationStageReencrypting)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(done).To(BeFalse())
+
+	job := &batchv1.Job{}
+	g.Expect(w.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "rke2-secrets-encryption"}, job)).To(Succeed())
+	g.Expect(job.Spec.Template.Spec.NodeName).To(Equal("node1"))
+	g.Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal(DefaultSecretsEncryptionRotationImage))
+	g.Expect(job.Spec.Template.Spec.Containers[0].Command).To(ContainElement(And(
+		ContainSubstring("rke2 secrets-encrypt reencrypt\n"),
+		ContainSubstring("Current Rotation Stage: reencrypt_finished$"),
+		ContainSubstring("systemctl restart rke2-server"),
+	)))
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	g.Expect(w.Status().Update(ctx, job)).To(Succeed())
+
+	done, err = w.RunSecretsEncryptionStage(ctx, machine, "rke2-secrets-encryption", "",
+		controlplanev1.SecretsEncryptionRotationStageReencrypting)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(done).To(BeTrue())
+
+	g.Expect(w.CleanupSecretsEncryptionRotation(ctx, "rke2-secrets-encryption")).To(Succeed())
+	g.Expect(w.Get(ctx, client.ObjectKeyFromObject(job), job)).ToNot(Succeed())
+
+	_, err = w.RunSecretsEncryptionStage(ctx, machine, "rke2-secrets-encryption", "", "Unknown")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestRestartServer(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node2"},
+		},
+	}
+
+	w := &Workload{Client: fake.NewClientBuilder().Build()}
+
+	done, err := w.RestartServer(ctx, machine, "rke2-restart", "registry.example.com/busybox")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(done).To(BeFalse())
+
+	job := &batchv1.Job{}
+	g.Expect(w.Get(ctx, client.ObjectKey{Namespace: metav1.NamespaceSystem, Name: "rke2-restart"}, job)).To(Succeed())
+	g.Expect(job.Spec.Template.Spec.Containers[0].Image).To(Equal("registry.example.com/busybox"))
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	g.Expect(w.Status().Update(ctx, job)).To(Succeed())
+
+	_, err = w.RestartServer(ctx, machine, "rke2-restart", "")
+	g.Expect(err).To(MatchError(ErrSecretsEncryptionRotationFailed))
+
+	_, err = w.RestartServer(ctx, &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "pending"}}, "rke2-restart", "")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+	rke2util "github.com/rancher/cluster-api-provider-rke2/pkg/util"
+)
+
+const (
+	// DefaultInPlaceUpgradeNamespace is the namespace the system-upgrade-controller runs in when none is specified.
+	DefaultInPlaceUpgradeNamespace = "system-upgrade"
+
+	// DefaultInPlaceUpgradeServiceAccountName is the service account of the upgrade Jobs when none is specified.
+	DefaultInPlaceUpgradeServiceAccountName = "system-upgrade"
+
+	// DefaultInPlaceUpgradeImage is the image used to upgrade RKE2 on the nodes when none is specified.
+	DefaultInPlaceUpgradeImage = "rancher/rke2-upgrade"
+
+	// InPlaceUpgradeNodeLabel is set on the Node being upgraded in place, the upgrade Plan only selects Nodes with this label.
+	InPlaceUpgradeNodeLabel = "rke2.controlplane.cluster.x-k8s.io/in-place-upgrade"
+
+	// inPlaceUpgradePlanName is the name of the system-upgrade-controller Plan upgrading the control plane nodes.
+	inPlaceUpgradePlanName = "rke2-control-plane"
+)
+
+// upgradePlanGVK is the GroupVersionKind of the system-upgrade-controller Plan objects.
+var upgradePlanGVK = schema.GroupVersionKind{Group: "upgrade.cattle.io", Version: "v1", Kind: "Plan"}
+
+// EnsureInPlaceUpgradePlan creates or updates the system-upgrade-controller Plan upgrading the control plane
+// Nodes labeled for an in-place upgrade to the desired version of the RKE2ControlPlane.
+func (w *Workload) EnsureInPlaceUpgradePlan(ctx context.Context, rcp *controlplanev1.RKE2ControlPlane) error {
+	plan := newInPlaceUpgradePlan(rcp)
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(upgradePlanGVK)
+
+	err := w.Get(ctx, ctrlclient.ObjectKeyFromObject(plan), existing)
+
+	switch {
+	case meta.IsNoMatchError(err):
+		return errors.New("system-upgrade-controller Plan CRD is not installed in the workload cluster")
+	case apierrors.IsNotFound(err):
+		if err := w.Create(ctx, plan); err != nil {
+			return fmt.Errorf("creating upgrade plan %s: %w", plan.GetName(), err)
+		}
+
+		log.FromContext(ctx).Info("Created in-place upgrade plan", "plan", plan.GetName(), "version", rcp.GetDesiredVersion())
+
+		return nil
+	case err != nil:
+		return fmt.Errorf("getting upgrade plan %s: %w", plan.GetName(), err)
+	}
+
+	if err := unstructured.SetNestedField(existing.Object, plan.Object["spec"], "spec"); err != nil {
+		return fmt.Errorf("setting upgrade plan spec: %w", err)
+	}
+
+	if err := w.Update(ctx, existing); err != nil {
+		return fmt.Errorf("updating upgrade plan %s: %w", plan.GetName(), err)
+	}
+
+	return nil
+}
+
+// DeleteInPlaceUpgradePlan deletes the system-upgrade-controller Plan upgrading the control plane Nodes.
+func (w *Workload) DeleteInPlaceUpgradePlan(ctx context.Context, rcp *controlplanev1.RKE2ControlPlane) error {
+	plan := newInPlaceUpgradePlan(rcp)
+
+	if err := w.Delete(ctx, plan); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("deleting upgrade plan %s: %w", plan.GetName(), err)
+	}
+
+	return nil
+}
+
+// UpgradeMachineInPlace selects the Node of the given Machine for the in-place upgrade Plan, and returns true
+// once the Node runs the given version and is schedulable again. The Node is then unselected from the Plan.
+func (w *Workload) UpgradeMachineInPlace(ctx context.Context, machine *clusterv1.Machine, version string) (bool, error) {
+	if machine == nil {
+		return false, errors.New("machine is nil")
+	}
+
+	if machine.Status.NodeRef == nil {
+		return false, fmt.Errorf("machine %s has no node ref", machine.Name)
+	}
+
+	node := &corev1.Node{}
+	if err := w.Get(ctx, ctrlclient.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+		return false, fmt.Errorf("getting node %s: %w", machine.Status.NodeRef.Name, err)
+	}
+
+	_, selected := node.Labels[InPlaceUpgradeNodeLabel]
+	upgraded := nodeRunsVersion(node, version) && nodeIsReady(node) && !node.Spec.Unschedulable
+
+	if upgraded == !selected {
+		return upgraded, nil
+	}
+
+	patch := ctrlclient.MergeFrom(node.DeepCopy())
+
+	if upgraded {
+		delete(node.Labels, InPlaceUpgradeNodeLabel)
+	} else {
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+
+		node.Labels[InPlaceUpgradeNodeLabel] = "true"
+	}
+
+	if err := w.Patch(ctx, node, patch); err != nil {
+		return false, fmt.Errorf("patching node %s with %s label: %w", node.Name, InPlaceUpgradeNodeLabel, err)
+	}
+
+	if !upgraded {
+		log.FromContext(ctx).Info("Selected node for in-place upgrade", "node", node.Name, "version", version)
+	}
+
+	return upgraded, nil
+}
+
+func newInPlaceUpgradePlan(rcp *controlplanev1.RKE2ControlPlane) *unstructured.Unstructured {
+	namespace := DefaultInPlaceUpgradeNamespace
+	serviceAccountName := DefaultInPlaceUpgradeServiceAccountName
+	image := DefaultInPlaceUpgradeImage
+
+	if rcp.Spec.RolloutStrategy != nil && rcp.Spec.RolloutStrategy.InPlace != nil {
+		inPlace := rcp.Spec.RolloutStrategy.InPlace
+
+		if inPlace.Namespace != "" {
+			namespace = inPlace.Namespace
+		}
+
+		if inPlace.ServiceAccountName != "" {
+			serviceAccountName = inPlace.ServiceAccountName
+		}
+
+		if inPlace.Image != "" {
+			image = inPlace.Image
+		}
+	}
+
+	plan := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			// Nodes are upgraded one at a time, the controller only selects a single Node at once.
+			"concurrency": int64(1),
+			"cordon":      true,
+			"nodeSelector": map[string]interface{}{
+				"matchExpressions": []interface{}{
+					map[string]interface{}{
+						"key":      labelNodeRoleControlPlane,
+						"operator": "In",
+						"values":   []interface{}{"true"},
+					},
+					map[string]interface{}{
+						"key":      InPlaceUpgradeNodeLabel,
+						"operator": "In",
+						"values":   []interface{}{"true"},
+					},
+				},
+			},
+			"serviceAccountName": serviceAccountName,
+			"tolerations": []interface{}{
+				map[string]interface{}{"operator": string(corev1.TolerationOpExists)},
+			},
+			"upgrade": map[string]interface{}{
+				"image": image,
+			},
+			"version": rcp.GetDesiredVersion(),
+		},
+	}}
+	plan.SetGroupVersionKind(upgradePlanGVK)
+	plan.SetName(inPlaceUpgradePlanName)
+	plan.SetNamespace(namespace)
+
+	return plan
+}
+
+// nodeRunsVersion returns true if the kubelet of the Node reports the given RKE2 or Kubernetes version.
+func nodeRunsVersion(node *corev1.Node, version string) bool {
+	kubeletVersion := node.Status.NodeInfo.KubeletVersion
+	if kubeletVersion == "" || version == "" {
+		return false
+	}
+
+	if rke2util.IsRKE2Version(version) {
+		return rke2util.CompareVersions(kubeletVersion, version)
+	}
+
+	kubeVersion, err := rke2util.Rke2ToKubeVersion(kubeletVersion)
+	if err != nil {
+		return false
+	}
+
+	return rke2util.CompareVersions(kubeVersion, version)
+}
+
+func nodeIsReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rke2
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	controlplanev1 "github.com/rancher/cluster-api-provider-rke2/controlplane/api/v1beta1"
+)
+
+func TestEnsureInPlaceUpgradePlan(t *testing.T) {
+	g := NewWithT(t)
+
+	rcp := &controlplanev1.RKE2ControlPlane{
+		Spec: controlplanev1.RKE2ControlPlaneSpec{
+			Version: "v1.31.2+rke2r1",
+			RolloutStrategy: &controlplanev1.RolloutStrategy{
+				Type:    controlplanev1.InPlaceUpgradeStrategyType,
+				InPlace: &controlplanev1.InPlaceUpgrade{Image: "registry.example.com/rke2-upgrade"},
+			},
+		},
+	}
+
+	w := &Workload{Client: fake.NewClientBuilder().Build()}
+	g.Expect(w.EnsureInPlaceUpgradePlan(ctx, rcp)).To(Succeed())
+
+	rcp.Spec.Version = "v1.31.3+rke2r1"
+	g.Expect(w.EnsureInPlaceUpgradePlan(ctx, rcp)).To(Succeed())
+
+	plan := &unstructured.Unstructured{}
+	plan.SetGroupVersionKind(upgradePlanGVK)
+	g.Expect(w.Get(ctx, client.ObjectKey{Namespace: DefaultInPlaceUpgradeNamespace, Name: inPlaceUpgradePlanName}, plan)).To(Succeed())
+
+	version, _, _ := unstructured.NestedString(plan.Object, "spec", "version")
+	g.Expect(version).To(Equal("v1.31.3+rke2r1"))
+
+	image, _, _ := unstructured.NestedString(plan.Object, "spec", "upgrade", "image")
+	g.Expect(image).To(Equal("registry.example.com/rke2-upgrade"))
+
+	serviceAccountName, _, _ := unstructured.NestedString(plan.Object, "spec", "serviceAccountName")
+	g.Expect(serviceAccountName).To(Equal(DefaultInPlaceUpgradeServiceAccountName))
+
+	g.Expect(w.DeleteInPlaceUpgradePlan(ctx, rcp)).To(Succeed())
+	g.Expect(w.Get(ctx, client.ObjectKeyFromObject(plan), plan)).ToNot(Succeed())
+}
+
+func TestUpgradeMachineInPlace(t *testing.T) {
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: "node1"},
+		},
+	}
+
+	newNode := func(kubeletVersion string, labels map[string]string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: labels},
+			Status: corev1.NodeStatus{
+				NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: kubeletVersion},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	t.Run("selects the node of an outdated machine", func(t *testing.T) {
+		g := NewWithT(t)
+		w := &Workload{Client: fake.NewClientBuilder().WithObjects(newNode("v1.31.2+rke2r1", nil)).Build()}
+
+		upgraded, err := w.UpgradeMachineInPlace(ctx, machine, "v1.31.3+rke2r1")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(upgraded).To(BeFalse())
+
+		node := &corev1.Node{}
+		g.Expect(w.Get(ctx, client.ObjectKey{Name: "node1"}, node)).To(Succeed())
+		g.Expect(node.Labels).To(HaveKeyWithValue(InPlaceUpgradeNodeLabel, "true"))
+	})
+
+	t.Run("unselects the node once upgraded", func(t *testing.T) {
+		g := NewWithT(t)
+		w := &Workload{Client: fake.NewClientBuilder().WithObjects(
+			newNode("v1.31.3+rke2r1", map[string]string{InPlaceUpgradeNodeLabel: "true"}),
+		).Build()}
+
+		upgraded, err := w.UpgradeMachineInPlace(ctx, machine, "v1.31.3+rke2r1")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(upgraded).To(BeTrue())
+
+		node := &corev1.Node{}
+		g.Expect(w.Get(ctx, client.ObjectKey{Name: "node1"}, node)).To(Succeed())
+		g.Expect(node.Labels).ToNot(HaveKey(InPlaceUpgradeNodeLabel))
+	})
+
+	t.Run("waits for the node to be uncordoned", func(t *testing.T) {
+		g := NewWithT(t)
+		node := newNode("v1.31.3+rke2r1", map[string]string{InPlaceUpgradeNodeLabel: "true"})
+		node.Spec.Unschedulable = true
+		w := &Workload{Client: fake.NewClientBuilder().WithObjects(node).Build()}
+
+		upgraded, err := w.UpgradeMachineInPlace(ctx, machine, "v1.31.3+rke2r1")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(upgraded).To(BeFalse())
+	})
+}
+
+func TestMatchesKubernetesOrRKE2VersionInPlace(t *testing.T) {
+	g := NewWithT(t)
+
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "machine",
+			Annotations: map[string]string{
+				controlplanev1.InPlaceUpgradedVersionAnnotation: "v1.31.3+rke2r1",
+			},
+		},
+		Spec: clusterv1.MachineSpec{Version: ptr.To("v1.31.2+rke2r1")},
+	}
+
+	g.Expect(matchesKubernetesOrRKE2Version(ctx, "v1.31.3+rke2r1")(machine)).To(BeTrue())
+	g.Expect(matchesKubernetesOrRKE2Version(ctx, "v1.31.2+rke2r1")(machine)).To(BeFalse())
+}
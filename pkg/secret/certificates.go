@@ -22,6 +22,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"math/big"
 	"path/filepath"
@@ -497,6 +498,88 @@ func newSelfSignedCACert(key *rsa.PrivateKey) (*x509.Certificate, error) {
 	return c, errors.WithStack(err)
 }
 
+// NotAfter returns the expiry time of the certificate of a key pair.
+func NotAfter(keyPair *certs.KeyPair) (time.Time, error) {
+	if keyPair == nil {
+		return time.Time{}, errors.New("key pair is nil")
+	}
+
+	cert, err := certs.DecodeCertPEM(keyPair.Cert)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to decode certificate")
+	} else if cert == nil {
+		return time.Time{}, errors.New("certificate not found")
+	}
+
+	return cert.NotAfter, nil
+}
+
+// NewCrossSignedCertificateAuthority generates a new certificate authority, along with a certificate of the new
+// certificate authority issued by the given one. Clients trusting the given certificate authority can then verify
+// certificates issued by the new one, which allows to rotate it without disruption.
+func NewCrossSignedCertificateAuthority(issuer *certs.KeyPair) (*certs.KeyPair, []byte, error) {
+	issuerCert, err := certs.DecodeCertPEM(issuer.Cert)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode issuer certificate")
+	} else if issuerCert == nil {
+		return nil, nil, errors.New("issuer certificate not found")
+	}
+
+	issuerKey, err := certs.DecodePrivateKeyPEM(issuer.Key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode issuer private key")
+	} else if issuerKey == nil {
+		return nil, nil, errors.New("issuer private key not found")
+	}
+
+	key, err := certs.NewPrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate serial number")
+	}
+
+	now := time.Now().UTC()
+
+	tmpl := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: fmt.Sprintf("kubernetes@%d", now.Unix()),
+		},
+		NotBefore:             now.Add(time.Minute * -5),
+		NotAfter:              now.Add(TenYears),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		MaxPathLenZero:        true,
+		BasicConstraintsValid: true,
+		MaxPathLen:            0,
+		IsCA:                  true,
+	}
+
+	selfSigned, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, key.Public(), key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create self signed CA certificate")
+	}
+
+	// The cross-signed certificate is an intermediate of the issuer, it can not outlive it.
+	tmpl.SerialNumber = new(big.Int).Add(serialNumber, big.NewInt(1))
+	if issuerCert.NotAfter.Before(tmpl.NotAfter) {
+		tmpl.NotAfter = issuerCert.NotAfter
+	}
+
+	crossSigned, err := x509.CreateCertificate(rand.Reader, &tmpl, issuerCert, key.Public(), issuerKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create cross-signed CA certificate")
+	}
+
+	return &certs.KeyPair{
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: selfSigned}),
+		Key:  certs.EncodePrivateKeyPEM(key),
+	}, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: crossSigned}), nil
+}
+
 func generateServiceAccountKeys() (*certs.KeyPair, error) {
 	saCreds, err := certs.NewPrivateKey()
 	if err != nil {
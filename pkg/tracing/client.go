@@ -0,0 +1,202 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The attributes of the spans of the client calls.
+const (
+	// KindKey is the kind of the object of a client call.
+	KindKey = attribute.Key("k8s.object.kind")
+
+	// ObjectKey is the name of the object of a client call.
+	ObjectKey = attribute.Key("k8s.object.name")
+
+	// SubResourceKey is the subresource of the object of a client call, e.g. status.
+	SubResourceKey = attribute.Key("k8s.subresource.name")
+)
+
+// tracedClient is a client starting a span for each call to the API server.
+type tracedClient struct {
+	client.Client
+	name string
+}
+
+// WrapClient returns a client starting a span for each call to the API server, the spans being named after the
+// client, the verb and the kind of the object, e.g. "workload.Get Node".
+func WrapClient(c client.Client, name string) client.Client {
+	return &tracedClient{Client: c, name: name}
+}
+
+// Get implements client.Client.
+func (c *tracedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) (err error) {
+	ctx, span := c.start(ctx, "Get", obj, ObjectKey.String(key.Name))
+	defer End(span, &err)
+
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+// List implements client.Client.
+func (c *tracedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (err error) {
+	ctx, span := c.start(ctx, "List", list)
+	defer End(span, &err)
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+// Create implements client.Client.
+func (c *tracedClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) (err error) {
+	ctx, span := c.start(ctx, "Create", obj, ObjectKey.String(obj.GetName()))
+	defer End(span, &err)
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// Update implements client.Client.
+func (c *tracedClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) (err error) {
+	ctx, span := c.start(ctx, "Update", obj, ObjectKey.String(obj.GetName()))
+	defer End(span, &err)
+
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// Patch implements client.Client.
+func (c *tracedClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) (err error) {
+	ctx, span := c.start(ctx, "Patch", obj, ObjectKey.String(obj.GetName()))
+	defer End(span, &err)
+
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// Delete implements client.Client.
+func (c *tracedClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) (err error) {
+	ctx, span := c.start(ctx, "Delete", obj, ObjectKey.String(obj.GetName()))
+	defer End(span, &err)
+
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// DeleteAllOf implements client.Client.
+func (c *tracedClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) (err error) {
+	ctx, span := c.start(ctx, "DeleteAllOf", obj)
+	defer End(span, &err)
+
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+// Status implements client.Client.
+func (c *tracedClient) Status() client.SubResourceWriter {
+	return &tracedSubResourceClient{SubResourceWriter: c.Client.Status(), client: c, subResource: "status"}
+}
+
+// SubResource implements client.Client.
+func (c *tracedClient) SubResource(subResource string) client.SubResourceClient {
+	subResourceClient := c.Client.SubResource(subResource)
+
+	return &tracedSubResourceClient{
+		SubResourceReader: subResourceClient,
+		SubResourceWriter: subResourceClient,
+		client:            c,
+		subResource:       subResource,
+	}
+}
+
+func (c *tracedClient) start(
+	ctx context.Context,
+	verb string,
+	obj runtime.Object,
+	attributes ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, err := c.GroupVersionKindFor(obj); err == nil {
+		kind = gvk.Kind
+	}
+
+	return Start(ctx, c.name+"."+verb+" "+kind, append(attributes, KindKey.String(kind))...)
+}
+
+// tracedSubResourceClient is a client of a subresource starting a span for each call to the API server, the spans
+// being named after the subresource, e.g. "workload.Update Node/status".
+type tracedSubResourceClient struct {
+	client.SubResourceReader
+	client.SubResourceWriter
+
+	client      *tracedClient
+	subResource string
+}
+
+// Get implements client.SubResourceReader.
+func (c *tracedSubResourceClient) Get(
+	ctx context.Context,
+	obj, subResource client.Object,
+	opts ...client.SubResourceGetOption,
+) (err error) {
+	ctx, span := c.start(ctx, "Get", obj)
+	defer End(span, &err)
+
+	return c.SubResourceReader.Get(ctx, obj, subResource, opts...)
+}
+
+// Create implements client.SubResourceWriter.
+func (c *tracedSubResourceClient) Create(
+	ctx context.Context,
+	obj, subResource client.Object,
+	opts ...client.SubResourceCreateOption,
+) (err error) {
+	ctx, span := c.start(ctx, "Create", obj)
+	defer End(span, &err)
+
+	return c.SubResourceWriter.Create(ctx, obj, subResource, opts...)
+}
+
+// Update implements client.SubResourceWriter.
+func (c *tracedSubResourceClient) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) (err error) {
+	ctx, span := c.start(ctx, "Update", obj)
+	defer End(span, &err)
+
+	return c.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+// Patch implements client.SubResourceWriter.
+func (c *tracedSubResourceClient) Patch(
+	ctx context.Context,
+	obj client.Object,
+	patch client.Patch,
+	opts ...client.SubResourcePatchOption,
+) (err error) {
+	ctx, span := c.start(ctx, "Patch", obj)
+	defer End(span, &err)
+
+	return c.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *tracedSubResourceClient) start(ctx context.Context, verb string, obj client.Object) (context.Context, trace.Span) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, err := c.client.GroupVersionKindFor(obj); err == nil {
+		kind = gvk.Kind
+	}
+
+	return Start(ctx, c.client.name+"."+verb+" "+kind+"/"+c.subResource,
+		ObjectKey.String(obj.GetName()), KindKey.String(kind), SubResourceKey.String(c.subResource))
+}
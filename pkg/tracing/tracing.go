@@ -0,0 +1,140 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing implements the OpenTelemetry tracing of the RKE2 managers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rancher/cluster-api-provider-rke2/version"
+)
+
+// tracerName is the name of the tracer of the spans of the RKE2 managers.
+const tracerName = "github.com/rancher/cluster-api-provider-rke2"
+
+// shutdownTimeout is the time given to export the remaining spans on shutdown.
+const shutdownTimeout = 5 * time.Second
+
+// The attributes identifying the objects a span relates to.
+const (
+	// NamespaceKey is the namespace of the reconciled objects.
+	NamespaceKey = attribute.Key("k8s.namespace.name")
+
+	// ClusterKey is the name of the Cluster.
+	ClusterKey = attribute.Key("capi.cluster.name")
+
+	// MachineKey is the name of the Machine.
+	MachineKey = attribute.Key("capi.machine.name")
+
+	// ControlPlaneKey is the name of the RKE2ControlPlane.
+	ControlPlaneKey = attribute.Key("rke2.controlplane.name")
+
+	// ConfigKey is the name of the RKE2Config.
+	ConfigKey = attribute.Key("rke2.config.name")
+)
+
+// Options are the options of the tracing of a manager.
+type Options struct {
+	// Endpoint is the address of the OTLP gRPC collector the spans are exported to, tracing being disabled when empty.
+	Endpoint string
+
+	// Insecure disables the TLS of the connection to the collector.
+	Insecure bool
+
+	// SamplingRatio is the ratio of the traces which are sampled.
+	SamplingRatio float64
+}
+
+// AddFlags adds the flags configuring the tracing of a manager.
+func AddFlags(fs *pflag.FlagSet, options *Options) {
+	fs.StringVar(&options.Endpoint, "tracing-otlp-endpoint", "",
+		"Address of the OTLP gRPC collector the traces are exported to (e.g. otel-collector:4317). If unspecified, tracing is disabled.")
+
+	fs.BoolVar(&options.Insecure, "tracing-otlp-insecure", false,
+		"Disable TLS for the connection to the OTLP collector.")
+
+	fs.Float64Var(&options.SamplingRatio, "tracing-sampling-ratio", 1,
+		"Ratio of the traces which are sampled, between 0 and 1.")
+}
+
+// Setup configures the global tracer provider to export the spans of a manager to the collector, and returns
+// a function flushing the remaining spans on shutdown, within shutdownTimeout.
+func Setup(ctx context.Context, serviceName string, options Options) (func() error, error) {
+	if options.Endpoint == "" {
+		return func() error { return nil }, nil
+	}
+
+	if options.SamplingRatio < 0 || options.SamplingRatio > 1 {
+		return nil, fmt.Errorf("invalid tracing sampling ratio %v, must be between 0 and 1", options.SamplingRatio)
+	}
+
+	exporterOptions := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(options.Endpoint)}
+	if options.Insecure {
+		exporterOptions = append(exporterOptions, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, exporterOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SamplingRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+			attribute.String("service.version", version.Get().GitVersion),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		return provider.Shutdown(ctx)
+	}, nil
+}
+
+// Start starts a span, a child of the span of the context if any.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End ends a span, recording the error it returned if any.
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+
+	span.End()
+}
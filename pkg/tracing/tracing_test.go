@@ -0,0 +1,144 @@
+/*
+Copyright 2025 SUSE.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// collector is a stand-in for an OTLP collector, recording the spans it receives.
+type collector struct {
+	collectortracev1.UnimplementedTraceServiceServer
+
+	lock  sync.Mutex
+	spans []*tracev1.ResourceSpans
+}
+
+func (c *collector) Export(
+	_ context.Context,
+	request *collectortracev1.ExportTraceServiceRequest,
+) (*collectortracev1.ExportTraceServiceResponse, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.spans = append(c.spans, request.GetResourceSpans()...)
+
+	return &collectortracev1.ExportTraceServiceResponse{}, nil
+}
+
+func TestSetup(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	server := grpc.NewServer()
+	c := &collector{}
+	collectortracev1.RegisterTraceServiceServer(server, c)
+
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	shutdown, err := Setup(ctx, "test-controller", Options{Endpoint: listener.Addr().String(), Insecure: true, SamplingRatio: 1})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, span := Start(ctx, "reconcile", ClusterKey.String("cluster"))
+	err = errors.New("failed")
+	End(span, &err)
+
+	g.Expect(shutdown()).To(Succeed())
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	g.Expect(c.spans).To(HaveLen(1))
+	g.Expect(c.spans[0].GetResource().GetAttributes()).To(ContainElement(
+		HaveField("Value.GetStringValue()", "test-controller")))
+
+	spans := c.spans[0].GetScopeSpans()[0].GetSpans()
+	g.Expect(spans).To(HaveLen(1))
+	g.Expect(spans[0].GetName()).To(Equal("reconcile"))
+	g.Expect(spans[0].GetAttributes()[0].GetKey()).To(Equal(string(ClusterKey)))
+	g.Expect(spans[0].GetStatus().GetCode()).To(Equal(tracev1.Status_STATUS_CODE_ERROR))
+	g.Expect(spans[0].GetStatus().GetMessage()).To(Equal("failed"))
+}
+
+func TestSetupOptions(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// Tracing is disabled without endpoint.
+	shutdown, err := Setup(ctx, "test-controller", Options{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(shutdown()).To(Succeed())
+
+	_, err = Setup(ctx, "test-controller", Options{Endpoint: "127.0.0.1:4317", SamplingRatio: 2})
+	g.Expect(err).To(MatchError(ContainSubstring("invalid tracing sampling ratio")))
+}
+
+func TestWrapClient(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}
+	c := WrapClient(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(node).Build(), "workload")
+
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(node), &corev1.Node{})).To(Succeed())
+	g.Expect(c.List(ctx, &corev1.NodeList{})).To(Succeed())
+	g.Expect(c.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "missing"}})).ToNot(Succeed())
+	g.Expect(c.Status().Update(ctx, node)).To(Succeed())
+	// The fake client does not support reading the status subresource.
+	g.Expect(c.SubResource("status").Get(ctx, node, &corev1.Node{})).ToNot(Succeed())
+
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(5))
+	g.Expect(spans[0].Name()).To(Equal("workload.Get Node"))
+	g.Expect(spans[0].Attributes()).To(ContainElements(ObjectKey.String("node"), KindKey.String("Node")))
+	g.Expect(spans[1].Name()).To(Equal("workload.List NodeList"))
+	g.Expect(spans[2].Name()).To(Equal("workload.Delete Node"))
+	g.Expect(spans[2].Status().Code).To(Equal(codes.Error))
+	g.Expect(spans[3].Name()).To(Equal("workload.Update Node/status"))
+	g.Expect(spans[3].Attributes()).To(ContainElements(ObjectKey.String("node"), SubResourceKey.String("status")))
+	g.Expect(spans[4].Name()).To(Equal("workload.Get Node/status"))
+	g.Expect(spans[4].Status().Code).To(Equal(codes.Error))
+}